@@ -16,6 +16,11 @@ import (
 func main() {
 	configFlag := flag.String("config", "config.yml", "path to config")
 	versionFlag := flag.Bool("version", false, "print version and exit")
+	auditFlag := flag.Bool("audit", false, "compare the blob_kzg_commitments of the blocks in [start-slot, end-slot] with the blob store, report gaps and exit")
+	backfillFlag := flag.Bool("backfill", false, "index all blobs in [start-slot, end-slot] and exit, combined with -audit only the detected gaps are indexed")
+	startSlotFlag := flag.Uint64("start-slot", 0, "first slot of the audit / backfill range, defaults to the deneb fork slot")
+	endSlotFlag := flag.Uint64("end-slot", 0, "last slot of the audit / backfill range, defaults to the last indexed finalized slot")
+	concurrencyFlag := flag.Int("concurrency", 8, "number of slots that are audited / backfilled in parallel")
 	flag.Parse()
 	if *versionFlag {
 		fmt.Println(version.Version)
@@ -38,6 +43,54 @@ func main() {
 	if err != nil {
 		logrus.Fatal(err)
 	}
+
+	if *auditFlag || *backfillFlag {
+		if *concurrencyFlag < 1 {
+			logrus.Fatalf("invalid concurrency %v", *concurrencyFlag)
+		}
+		startSlot := *startSlotFlag
+		denebForkSlot := utils.Config.Chain.ClConfig.DenebForkEpoch * utils.Config.Chain.ClConfig.SlotsPerEpoch
+		if startSlot < denebForkSlot {
+			startSlot = denebForkSlot
+		}
+		// blobs before the retention window are pruned and no longer served by the node
+		if retentionStartSlot := exporter.BlobRetentionStartSlot(); startSlot < retentionStartSlot {
+			startSlot = retentionStartSlot
+		}
+		endSlot := *endSlotFlag
+		if endSlot == 0 {
+			status, err := blobIndexer.GetIndexerStatus()
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			endSlot = status.LastIndexedFinalizedSlot
+		}
+		if endSlot < startSlot {
+			logrus.Fatalf("end-slot < start-slot: %v < %v", endSlot, startSlot)
+		}
+
+		gaps := []exporter.BlobGap{{StartSlot: startSlot, EndSlot: endSlot}}
+		if *auditFlag {
+			gaps, err = blobIndexer.Audit(startSlot, endSlot, *concurrencyFlag)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			for _, gap := range gaps {
+				logrus.WithFields(logrus.Fields{"startSlot": gap.StartSlot, "endSlot": gap.EndSlot, "missingBlobs": gap.MissingBlobs}).Infof("found gap")
+			}
+		}
+		if *backfillFlag {
+			failed, err := blobIndexer.Backfill(gaps, *concurrencyFlag)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			if failed > 0 {
+				logrus.Fatalf("failed backfilling %v slots", failed)
+			}
+		}
+		return
+	}
+
 	go blobIndexer.Start()
 	utils.WaitForCtrlC()
 }
@@ -271,6 +271,8 @@ func main() {
 		apiV1Router.HandleFunc("/slot/{slot}/proposerslashings", handlers.ApiSlotProposerSlashings).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/slot/{slot}/voluntaryexits", handlers.ApiSlotVoluntaryExits).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/slot/{slot}/withdrawals", handlers.ApiSlotWithdrawals).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/slot/{slot}/blobs", handlers.ApiSlotBlobs).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/blobs/{versionedHash}", handlers.ApiBlob).Methods("GET", "OPTIONS")
 
 		// deprecated, use slot equivalents
 		apiV1Router.HandleFunc("/block/{slotOrHash}", handlers.ApiSlots).Methods("GET", "OPTIONS")
@@ -296,13 +298,21 @@ func main() {
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/deposits", handlers.ApiValidatorDeposits).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/attestationefficiency", handlers.ApiValidatorAttestationEfficiency).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/attestationeffectiveness", handlers.ApiValidatorAttestationEffectiveness).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/client", handlers.ApiValidatorClient).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/stats/{index}", handlers.ApiValidatorDailyStats).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/eth1/{address}", handlers.ApiValidatorByEth1Address).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/withdrawalCredentials/{withdrawalCredentialsOrEth1address}", handlers.ApiWithdrawalCredentialsValidators).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/validators", handlers.ApiValidatorsByTags).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validators/queue", handlers.ApiValidatorQueue).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validators/proposalLuck", handlers.ApiProposalLuck).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/graffitiwall", handlers.ApiGraffitiwall).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/chart/{chart}", handlers.ApiChart).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/charts/series", handlers.ApiChartSeries).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/charts/indicators", handlers.ApiChartSeriesIndicators).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/clients/diversity", handlers.ApiClientDiversity).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/relays/bids/competition", handlers.ApiRelayBidCompetition).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/relays/bids/builders", handlers.ApiRelayBuilderBidStats).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/search", handlers.ApiSearch).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/user/token", handlers.APIGetToken).Methods("POST", "OPTIONS")
 		apiV1Router.HandleFunc("/dashboard/data/allbalances", handlers.DashboardDataBalanceCombined).Methods("GET", "OPTIONS") // consensus & execution
 		apiV1Router.HandleFunc("/dashboard/data/balances", handlers.DashboardDataBalance).Methods("GET", "OPTIONS")            // new app versions
@@ -312,12 +322,17 @@ func main() {
 		apiV1Router.HandleFunc("/stats/{apiKey}/{machine}", handlers.ClientStatsPostOld).Methods("POST", "OPTIONS")
 		apiV1Router.HandleFunc("/stats/{apiKey}", handlers.ClientStatsPostOld).Methods("POST", "OPTIONS")
 		apiV1Router.HandleFunc("/client/metrics", handlers.ClientStatsPostNew).Methods("POST", "OPTIONS")
+		apiV1Router.HandleFunc("/client/metrics/prometheus", handlers.ClientStatsPostPrometheus).Methods("POST", "OPTIONS")
+		apiV1Router.HandleFunc("/client/metrics/otlp", handlers.ClientStatsPostOTLP).Methods("POST", "OPTIONS")
 		apiV1Router.HandleFunc("/app/dashboard", handlers.ApiDashboard).Methods("POST", "OPTIONS")
 		apiV1Router.HandleFunc("/rocketpool/stats", handlers.ApiRocketpoolStats).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/rocketpool/validator/{indexOrPubkey}", handlers.ApiRocketpoolValidators).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/ethstore/{day}", handlers.ApiEthStoreDay).Methods("GET", "OPTIONS")
 
 		apiV1Router.HandleFunc("/execution/gasnow", handlers.ApiEth1GasNowData).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/gasoracle", handlers.ApiEth1GasOracle).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/mempool", handlers.ApiEth1Mempool).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/execution/mempool/stats", handlers.ApiEth1MempoolStats).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/execution/block/{blockNumber}", handlers.ApiETH1ExecBlocks).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/execution/{addressIndexOrPubkey}/produced", handlers.ApiETH1AccountProducedBlocks).Methods("GET", "OPTIONS")
 
@@ -327,6 +342,7 @@ func main() {
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/widget", handlers.GetMobileWidgetStatsGet).Methods("GET")
 		apiV1Router.HandleFunc("/dashboard/widget", handlers.GetMobileWidgetStatsPost).Methods("POST")
 		apiV1Router.HandleFunc("/ens/lookup/{domain}", handlers.ResolveEnsDomain).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/broadcast/dryrun", handlers.ApiBroadcastDryRun).Methods("POST", "OPTIONS")
 		apiV1Router.Use(utils.CORSMiddleware)
 
 		apiV1AuthRouter := apiV1Router.PathPrefix("/user").Subrouter()
@@ -346,6 +362,11 @@ func main() {
 		apiV1AuthRouter.HandleFunc("/notifications/unsubscribe", handlers.UserNotificationsUnsubscribe).Methods("POST", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/notifications", handlers.UserNotificationsSubscribed).Methods("POST", "GET", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/stats", handlers.ClientStats).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/stats/query", handlers.ClientStatsQuery).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/stats/export", handlers.ClientStatsExport).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/stats/alerts", handlers.ClientStatsAlerts).Methods("GET", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/stats/alerts", handlers.ClientStatsAlertAdd).Methods("POST", "OPTIONS")
+		apiV1AuthRouter.HandleFunc("/stats/alerts/{id}", handlers.ClientStatsAlertDelete).Methods("DELETE", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/stats/{offset}/{limit}", handlers.ClientStats).Methods("GET", "OPTIONS")
 		apiV1AuthRouter.HandleFunc("/ethpool", handlers.RegisterEthpoolSubscription).Methods("POST", "OPTIONS")
 
@@ -356,7 +377,7 @@ func main() {
 		router.HandleFunc("/api/healthz-loadbalancer", handlers.ApiHealthzLoadbalancer).Methods("GET", "HEAD")
 
 		logrus.Infof("initializing prices")
-		price.Init(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Eth1ErigonEndpoint, utils.Config.Frontend.ClCurrency, utils.Config.Frontend.ElCurrency)
+		price.Init(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Eth1ErigonEndpoint, utils.Config.Frontend.ClCurrency, utils.Config.Frontend.ElCurrency, utils.Config.Prices)
 
 		logrus.Infof("prices initialized")
 		if !utils.Config.Frontend.Debug {
@@ -435,6 +456,7 @@ func main() {
 
 			router.HandleFunc("/vis", handlers.Vis).Methods("GET")
 			router.HandleFunc("/charts", handlers.Charts).Methods("GET")
+			router.HandleFunc("/charts/epochs/data", handlers.EpochChartData).Methods("GET")
 			router.HandleFunc("/charts/{chart}", handlers.Chart).Methods("GET")
 			router.HandleFunc("/charts/{chart}/data", handlers.GenericChartData).Methods("GET")
 			router.HandleFunc("/vis/blocks", handlers.VisBlocks).Methods("GET")
@@ -522,6 +544,9 @@ func main() {
 			router.HandleFunc("/rewards", handlers.ValidatorRewards).Methods("GET")
 			router.HandleFunc("/rewards/hist", handlers.RewardsHistoricalData).Methods("GET")
 			router.HandleFunc("/rewards/hist/download", handlers.DownloadRewardsHistoricalData).Methods("GET")
+			router.HandleFunc("/rewards/export", handlers.RewardsExport).Methods("GET")
+			router.HandleFunc("/rewards/export/{id}", handlers.RewardsExportStatus).Methods("GET")
+			router.HandleFunc("/rewards/export/{id}/download", handlers.DownloadRewardsExport).Methods("GET")
 
 			router.HandleFunc("/notifications/unsubscribe", handlers.UserNotificationsUnsubscribeByHash).Methods("GET")
 
@@ -576,6 +601,9 @@ func main() {
 			authRouter.HandleFunc("/ad_configuration/delete", handlers.AdConfigurationDeletePost).Methods("POST")
 			authRouter.HandleFunc("/explorer_configuration", handlers.ExplorerConfiguration).Methods("GET")
 			authRouter.HandleFunc("/explorer_configuration", handlers.ExplorerConfigurationPost).Methods("POST")
+			authRouter.HandleFunc("/validator_tag_rules", handlers.ValidatorTagRules).Methods("GET")
+			authRouter.HandleFunc("/validator_tag_rules", handlers.ValidatorTagRulesPost).Methods("POST")
+			authRouter.HandleFunc("/validator_tag_rules/delete", handlers.ValidatorTagRulesDeletePost).Methods("POST")
 
 			authRouter.HandleFunc("/notifications-center", handlers.UserNotificationsCenter).Methods("GET")
 			authRouter.HandleFunc("/notifications-center/removeall", handlers.RemoveAllValidatorsAndUnsubscribe).Methods("POST")
@@ -587,6 +615,9 @@ func main() {
 			authRouter.HandleFunc("/rewards/subscribe", handlers.RewardNotificationSubscribe).Methods("POST")
 			authRouter.HandleFunc("/rewards/unsubscribe", handlers.RewardNotificationUnsubscribe).Methods("POST")
 			authRouter.HandleFunc("/rewards/subscriptions/data", handlers.RewardGetUserSubscriptions).Methods("POST")
+			authRouter.HandleFunc("/reports", handlers.UserReports).Methods("GET")
+			authRouter.HandleFunc("/reports/{id}/download", handlers.UserReportDownload).Methods("GET")
+			authRouter.HandleFunc("/reports/schedules/{id}/delete", handlers.UserReportScheduleDelete).Methods("POST")
 			authRouter.HandleFunc("/webhooks", handlers.NotificationWebhookPage).Methods("GET")
 			authRouter.HandleFunc("/webhooks/add", handlers.UsersAddWebhook).Methods("POST")
 			authRouter.HandleFunc("/webhooks/{webhookID}/update", handlers.UsersEditWebhook).Methods("POST")
@@ -21,6 +21,7 @@ import (
 	"github.com/gobitfly/eth2-beaconchain-explorer/db"
 	"github.com/gobitfly/eth2-beaconchain-explorer/exporter"
 	"github.com/gobitfly/eth2-beaconchain-explorer/notify"
+	"github.com/gobitfly/eth2-beaconchain-explorer/price"
 	"github.com/gobitfly/eth2-beaconchain-explorer/rpc"
 	"github.com/gobitfly/eth2-beaconchain-explorer/services"
 	"github.com/gobitfly/eth2-beaconchain-explorer/types"
@@ -51,6 +52,8 @@ var opts = struct {
 	EndEpoch            uint64
 	StartDay            uint64
 	EndDay              uint64
+	StartDate           string
+	EndDate             string
 	Validator           uint64
 	StartBlock          uint64
 	EndBlock            uint64
@@ -77,12 +80,14 @@ func main() {
 	statsPartitionCommand := commands.StatsMigratorCommand{}
 
 	configPath := flag.String("config", "config/default.config.yml", "Path to the config file")
-	flag.StringVar(&opts.Command, "command", "", "command to run, available: updateAPIKey, applyDbSchema, initBigtableSchema, epoch-export, debug-rewards, debug-blocks, clear-bigtable, index-old-eth1-blocks, update-aggregation-bits, historic-prices-export, index-missing-blocks, export-epoch-missed-slots, migrate-last-attestation-slot-bigtable, export-genesis-validators, update-block-finalization-sequentially, nameValidatorsByRanges, export-stats-totals, export-sync-committee-periods, export-sync-committee-validator-stats, partition-validator-stats, migrate-app-purchases, disable-user-per-email, validate-firebase-tokens")
+	flag.StringVar(&opts.Command, "command", "", "command to run, available: updateAPIKey, applyDbSchema, initBigtableSchema, apply-machine-metrics-retention, epoch-export, debug-rewards, debug-blocks, clear-bigtable, index-old-eth1-blocks, update-aggregation-bits, historic-prices-export, historic-prices-backfill, index-missing-blocks, export-epoch-missed-slots, migrate-last-attestation-slot-bigtable, export-genesis-validators, update-block-finalization-sequentially, nameValidatorsByRanges, export-stats-totals, export-sync-committee-periods, export-sync-committee-validator-stats, partition-validator-stats, migrate-app-purchases, disable-user-per-email, validate-firebase-tokens")
 	flag.Uint64Var(&opts.StartEpoch, "start-epoch", 0, "start epoch")
 	flag.Uint64Var(&opts.EndEpoch, "end-epoch", 0, "end epoch")
 	flag.Uint64Var(&opts.User, "user", 0, "user id")
 	flag.Uint64Var(&opts.StartDay, "day-start", 0, "start day to debug")
 	flag.Uint64Var(&opts.EndDay, "day-end", 0, "end day to debug")
+	flag.StringVar(&opts.StartDate, "date-start", "", "start date (YYYY-MM-DD), defaults to the date of day-start")
+	flag.StringVar(&opts.EndDate, "date-end", "", "end date (YYYY-MM-DD), defaults to the date of day-end")
 	flag.Uint64Var(&opts.Validator, "validator", 0, "validator to check for")
 	flag.Int64Var(&opts.TargetVersion, "target-version", -2, "Db migration target version, use -2 to apply up to the latest version, -1 to apply only the next version or the specific versions")
 	flag.StringVar(&opts.Table, "table", "", "big table table")
@@ -234,6 +239,11 @@ func main() {
 			logrus.WithError(err).Fatal("error initializing bigtable schema")
 		}
 		logrus.Infof("bigtable schema initialization completed")
+	case "apply-machine-metrics-retention":
+		err := db.ApplyMachineMetricsRetention()
+		if err != nil {
+			logrus.WithError(err).Fatal("error applying machine metrics retention")
+		}
 	case "epoch-export":
 		logrus.Infof("exporting epochs %v - %v", opts.StartEpoch, opts.EndEpoch)
 		for epoch := opts.StartEpoch; epoch <= opts.EndEpoch; epoch++ {
@@ -318,6 +328,8 @@ func main() {
 		err = updateBlockFinalizationSequentially()
 	case "historic-prices-export":
 		exportHistoricPrices(opts.StartDay, opts.EndDay)
+	case "historic-prices-backfill":
+		err = backfillHistoricPrices(opts.StartDate, opts.EndDate, opts.StartDay, opts.EndDay, opts.DryRun)
 	case "index-missing-blocks":
 		indexMissingBlocks(opts.StartBlock, opts.EndBlock, bt, erigonClient)
 	case "migrate-last-attestation-slot-bigtable":
@@ -1687,6 +1699,35 @@ func exportHistoricPrices(dayStart uint64, dayEnd uint64) {
 	logrus.Info("historic price update run completed")
 }
 
+// backfillHistoricPrices writes the historic prices of the configured providers for the given date range, days are used if no dates are given
+func backfillHistoricPrices(startDate, endDate string, dayStart, dayEnd uint64, dryRun bool) error {
+	start := utils.DayToTime(int64(dayStart)).UTC().Truncate(utils.Day)
+	end := utils.DayToTime(int64(dayEnd)).UTC().Truncate(utils.Day)
+	var err error
+	if startDate != "" {
+		start, err = time.Parse("2006-01-02", startDate)
+		if err != nil {
+			return fmt.Errorf("error parsing date-start: %w", err)
+		}
+	}
+	if endDate != "" {
+		end, err = time.Parse("2006-01-02", endDate)
+		if err != nil {
+			return fmt.Errorf("error parsing date-end: %w", err)
+		}
+	}
+	if end.Before(start) {
+		return fmt.Errorf("end date %v is before start date %v", end.Format("2006-01-02"), start.Format("2006-01-02"))
+	}
+
+	providers, err := price.NewHistoricPriceProviders(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Prices)
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"start": start.Format("2006-01-02"), "end": end.Format("2006-01-02"), "providers": utils.Config.Prices.Historic.Providers, "dryRun": dryRun}).Infof("backfilling historic prices")
+	return services.BackfillHistoricPrices(providers, start, end, dryRun)
+}
+
 func exportStatsTotals(columns string, dayStart, dayEnd, concurrency uint64) {
 	start := time.Now()
 	exportToToday := false
@@ -66,6 +66,7 @@ func main() {
 	defer db.ReaderDb.Close()
 	defer db.WriterDb.Close()
 
+	logrus.WithFields(logrus.Fields{"clEndpoint": utils.Config.NodeJobsProcessor.ClEndpoint, "clEndpoints": utils.Config.NodeJobsProcessor.ClEndpoints, "elEndpoints": utils.Config.NodeJobsProcessor.ElEndpoints, "resubmitEpochs": utils.Config.NodeJobsProcessor.ResubmitEpochs}).Infof("starting node-jobs-processor")
 	nrp := NewNodeJobsProcessor(utils.Config.NodeJobsProcessor.ClEndpoint, utils.Config.NodeJobsProcessor.ElEndpoint)
 	go nrp.Run()
 
@@ -140,7 +140,7 @@ func main() {
 	}
 
 	logrus.Infof("initializing prices...")
-	price.Init(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Eth1ErigonEndpoint, utils.Config.Frontend.ClCurrency, utils.Config.Frontend.ElCurrency)
+	price.Init(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Eth1ErigonEndpoint, utils.Config.Frontend.ClCurrency, utils.Config.Frontend.ElCurrency, utils.Config.Prices)
 	logrus.Infof("...prices initialized")
 
 	wg.Wait()
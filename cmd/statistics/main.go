@@ -23,13 +23,20 @@ import (
 )
 
 type options struct {
-	configPath                string
-	statisticsDayToExport     int64
-	statisticsDaysToExport    string
-	statisticsValidatorToggle bool
-	statisticsChartToggle     bool
-	statisticsGraffitiToggle  bool
-	resetStatus               bool
+	configPath                 string
+	statisticsDayToExport      int64
+	statisticsDaysToExport     string
+	statisticsValidatorToggle  bool
+	statisticsChartToggle      bool
+	statisticsGraffitiToggle   bool
+	resetStatus                bool
+	statisticsColumns          string
+	resumeColumns              bool
+	statisticsIntraDayToggle   bool
+	chartIndicators            string
+	statisticsEpochChartToggle bool
+	statisticsClientsToggle    bool
+	machineMetricsRollupToggle bool
 }
 
 var opt = &options{}
@@ -41,7 +48,14 @@ func main() {
 	flag.BoolVar(&opt.statisticsValidatorToggle, "validators.enabled", false, "Toggle exporting validator statistics")
 	flag.BoolVar(&opt.statisticsChartToggle, "charts.enabled", false, "Toggle exporting chart series")
 	flag.BoolVar(&opt.statisticsGraffitiToggle, "graffiti.enabled", false, "Toggle exporting graffiti statistics")
+	flag.BoolVar(&opt.statisticsClientsToggle, "clients.enabled", false, "Toggle classifying the consensus client of proposed blocks and exporting client diversity statistics")
+	flag.BoolVar(&opt.machineMetricsRollupToggle, "machinemetrics.rollups.enabled", false, "Toggle computing the 5m and 1h rollups of machine metrics")
 	flag.BoolVar(&opt.resetStatus, "validators.reset", false, "Export stats independet if they have already been exported previously")
+	flag.StringVar(&opt.statisticsColumns, "validators.columns", "", fmt.Sprintf("Comma separated list of column groups to recompute for the already exported days given by statistics.day or statistics.days (%v)", strings.Join(db.ValidatorStatsColumnGroups, ", ")))
+	flag.BoolVar(&opt.statisticsEpochChartToggle, "charts.epochs.enabled", false, "Toggle exporting epoch-resolution chart series after each finalized epoch")
+	flag.StringVar(&opt.chartIndicators, "charts.indicators", "", "Comma separated list of chart series indicators defined in the config to backfill for the days given by statistics.day or statistics.days")
+	flag.BoolVar(&opt.statisticsIntraDayToggle, "validators.intraday", false, "Toggle aggregating validator statistics of the current day after each finalized epoch")
+	flag.BoolVar(&opt.resumeColumns, "validators.columns.resume", false, "Skip days whose column groups have already been recomputed instead of recomputing the whole range")
 
 	versionFlag := flag.Bool("version", false, "Show version and exit")
 	flag.Parse()
@@ -126,7 +140,7 @@ func main() {
 		logrus.Fatalf("error connecting to bigtable: %v", err)
 	}
 
-	price.Init(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Eth1ErigonEndpoint, utils.Config.Frontend.ClCurrency, utils.Config.Frontend.ElCurrency)
+	price.Init(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Eth1ErigonEndpoint, utils.Config.Frontend.ClCurrency, utils.Config.Frontend.ElCurrency, utils.Config.Prices)
 
 	if utils.Config.TieredCacheProvider != "redis" {
 		logrus.Fatalf("No cache provider set. Please set TierdCacheProvider (example redis)")
@@ -162,6 +176,16 @@ func main() {
 			utils.LogFatal(err, "error parsing last day of statisticsDaysToExport flag to uint", 0)
 		}
 
+		if opt.statisticsColumns != "" {
+			recomputeValidatorStatisticsColumns(firstDay, lastDay, rpcClient)
+			return
+		}
+
+		if opt.chartIndicators != "" {
+			backfillChartSeriesIndicators(firstDay, lastDay)
+			return
+		}
+
 		if opt.statisticsValidatorToggle {
 			logrus.Infof("exporting validator statistics for days %v-%v", firstDay, lastDay)
 			for d := firstDay; d <= lastDay; d++ {
@@ -206,6 +230,15 @@ func main() {
 
 		return
 	} else if opt.statisticsDayToExport >= 0 {
+		if opt.statisticsColumns != "" {
+			recomputeValidatorStatisticsColumns(uint64(opt.statisticsDayToExport), uint64(opt.statisticsDayToExport), rpcClient)
+			return
+		}
+
+		if opt.chartIndicators != "" {
+			backfillChartSeriesIndicators(uint64(opt.statisticsDayToExport), uint64(opt.statisticsDayToExport))
+			return
+		}
 
 		if opt.statisticsValidatorToggle {
 			if opt.resetStatus {
@@ -239,6 +272,17 @@ func main() {
 		return
 	}
 
+	if opt.statisticsColumns != "" {
+		logrus.Fatalf("validators.columns requires statistics.day or statistics.days")
+	}
+	if opt.chartIndicators != "" {
+		logrus.Fatalf("charts.indicators requires statistics.day or statistics.days")
+	}
+
+	if opt.machineMetricsRollupToggle {
+		go machineMetricsRollupLoop()
+	}
+
 	go statisticsLoop(rpcClient)
 
 	utils.WaitForCtrlC()
@@ -294,6 +338,14 @@ func statisticsLoop(client rpc.Client) {
 
 		}
 
+		if opt.statisticsIntraDayToggle && loopError == nil {
+			err := db.WriteValidatorIntraDayStatistics(client)
+			if err != nil {
+				utils.LogError(err, "error exporting intra-day validator statistics", 0)
+				loopError = err
+			}
+		}
+
 		if opt.statisticsChartToggle {
 			var lastExportedDayChart uint64
 			err := db.WriterDb.Get(&lastExportedDayChart, "select COALESCE(max(day), 0) from chart_series_status where status")
@@ -317,6 +369,22 @@ func statisticsLoop(client rpc.Client) {
 			}
 		}
 
+		if opt.statisticsEpochChartToggle {
+			err := db.WriteEpochChartSeries(client)
+			if err != nil {
+				utils.LogError(err, "error exporting epoch chart series", 0)
+				loopError = err
+			}
+		}
+
+		if opt.statisticsClientsToggle {
+			err := db.WriteClientDiversity()
+			if err != nil {
+				utils.LogError(err, "error exporting client diversity", 0)
+				loopError = err
+			}
+		}
+
 		if opt.statisticsGraffitiToggle {
 			graffitiStatsStatus := []struct {
 				Day    uint64
@@ -353,6 +421,23 @@ func statisticsLoop(client rpc.Client) {
 	}
 }
 
+// machineMetricsRollupLoop computes the machine metric rollups of all complete buckets every minute, coarser rollups are computed from the finer ones
+func machineMetricsRollupLoop() {
+	for {
+		for _, resolution := range db.MachineMetricsResolutions {
+			if resolution.Source == nil {
+				continue
+			}
+			err := db.BigtableClient.RollupMachineMetrics(resolution)
+			if err != nil {
+				utils.LogError(err, fmt.Sprintf("error computing machine metrics rollup %v", resolution.Name), 0)
+				break
+			}
+		}
+		time.Sleep(time.Minute)
+	}
+}
+
 func clearStatsStatusTable(day uint64) {
 	logrus.Infof("deleting validator_stats_status for day %v", day)
 	_, err := db.WriterDb.Exec("DELETE FROM validator_stats_status WHERE day = $1", day)
@@ -360,3 +445,30 @@ func clearStatsStatusTable(day uint64) {
 		logrus.Fatalf("error resetting status for day %v: %v", day, err)
 	}
 }
+
+func recomputeValidatorStatisticsColumns(firstDay, lastDay uint64, client rpc.Client) {
+	groups, err := db.ParseValidatorStatsColumnGroups(opt.statisticsColumns)
+	if err != nil {
+		logrus.Fatalf("error parsing validators.columns: %v", err)
+	}
+	logrus.Infof("recomputing column groups %v of validator statistics for days %v-%v", strings.Join(groups, ", "), firstDay, lastDay)
+	err = db.RecomputeValidatorStatisticsColumns(groups, firstDay, lastDay, client, opt.resumeColumns)
+	if err != nil {
+		utils.LogError(err, fmt.Errorf("error recomputing column groups for days %v-%v", firstDay, lastDay), 0)
+	}
+}
+
+func backfillChartSeriesIndicators(firstDay, lastDay uint64) {
+	indicators := strings.Split(opt.chartIndicators, ",")
+	for i := range indicators {
+		indicators[i] = strings.TrimSpace(indicators[i])
+	}
+	logrus.Infof("backfilling chart series indicators %v for days %v-%v", strings.Join(indicators, ", "), firstDay, lastDay)
+	for d := firstDay; d <= lastDay; d++ {
+		err := db.WriteCustomChartSeriesForDay(int64(d), indicators...)
+		if err != nil {
+			utils.LogError(err, fmt.Errorf("error backfilling chart series indicators for day %v", d), 0)
+			return
+		}
+	}
+}
@@ -36,6 +36,8 @@ const (
 	INCOME_DETAILS_COLUMN_FAMILY          = "id"
 	STATS_COLUMN_FAMILY                   = "stats"
 	MACHINE_METRICS_COLUMN_FAMILY         = "mm"
+	MACHINE_METRICS_5M_COLUMN_FAMILY      = "mm5m"
+	MACHINE_METRICS_1H_COLUMN_FAMILY      = "mm1h"
 	SERIES_FAMILY                         = "series"
 
 	SUM_COLUMN = "sum"
@@ -215,13 +217,7 @@ func (bigtable *Bigtable) SaveMachineMetric(process string, userID uint64, machi
 		return fmt.Errorf("rate limit, last metric insert was less than 1 min ago")
 	}
 
-	// for limiting machines per user, add the machine field to a redis set
-	// bucket period is 15mins
-	machineLimitKey := fmt.Sprintf("%s:%d", bigtable.reversePaddedUserID(userID), ts.Time().Minute()%15)
-	pipe := bigtable.redisCache.Pipeline()
-	pipe.SAdd(ctx, machineLimitKey, machine)
-	pipe.Expire(ctx, machineLimitKey, time.Minute*15)
-	_, err = pipe.Exec(ctx)
+	err = bigtable.addMachineMetricMachine(ctx, userID, machine, ts.Time())
 	if err != nil {
 		return err
 	}
@@ -239,6 +235,79 @@ func (bigtable *Bigtable) SaveMachineMetric(process string, userID uint64, machi
 	return nil
 }
 
+// MergeMachineMetric stores a partial machine metric of a push based exporter (remote-write, otlp) which splits a scrape into several requests.
+// All metrics of a machine and process pushed within a minute are merged in redis and written to the same cell,
+// fields missing in a push keep the value of the earlier pushes of the minute.
+func (bigtable *Bigtable) MergeMachineMetric(process string, userID uint64, machine string, metric proto.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	rowKeyData := fmt.Sprintf("u:%s:p:%s:m:%v", bigtable.reversePaddedUserID(userID), process, machine)
+	minute := time.Now().Truncate(time.Minute)
+	mergeKey := fmt.Sprintf("%s:merge:%d", rowKeyData, minute.Unix())
+
+	var data []byte
+	merge := func(tx *redis.Tx) error {
+		merged := proto.Clone(metric)
+		previous, err := tx.Get(ctx, mergeKey).Bytes()
+		if err != nil && err != redis.Nil {
+			return err
+		}
+		if err == nil {
+			merged = metric.ProtoReflect().New().Interface()
+			err = proto.Unmarshal(previous, merged)
+			if err != nil {
+				return fmt.Errorf("error decoding merged machine metric: %w", err)
+			}
+			proto.Merge(merged, metric)
+		}
+		data, err = proto.Marshal(merged)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, mergeKey, data, time.Minute*2)
+			return nil
+		})
+		return err
+	}
+	// pushes of the same machine are sent concurrently, retry if the merged metric changed in the meantime
+	var err error
+	for i := 0; i < 10; i++ {
+		err = bigtable.redisCache.Watch(ctx, merge, mergeKey)
+		if err != redis.TxFailedErr {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("error merging machine metric: %w", err)
+	}
+
+	err = bigtable.addMachineMetricMachine(ctx, userID, machine, minute)
+	if err != nil {
+		return err
+	}
+
+	// the merged metric of the minute replaces the cell written by the previous pushes of the minute
+	dataMut := gcp_bigtable.NewMutation()
+	dataMut.Set(MACHINE_METRICS_COLUMN_FAMILY, "v1", gcp_bigtable.Time(minute), data)
+	bigtable.machineMetricsQueuedWritesChan <- types.BulkMutation{
+		Key: rowKeyData,
+		Mut: dataMut,
+	}
+	return nil
+}
+
+// addMachineMetricMachine adds the machine to a redis set for limiting machines per user, the bucket period is 15mins
+func (bigtable *Bigtable) addMachineMetricMachine(ctx context.Context, userID uint64, machine string, ts time.Time) error {
+	machineLimitKey := fmt.Sprintf("%s:%d", bigtable.reversePaddedUserID(userID), ts.Minute()%15)
+	pipe := bigtable.redisCache.Pipeline()
+	pipe.SAdd(ctx, machineLimitKey, machine)
+	pipe.Expire(ctx, machineLimitKey, time.Minute*15)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
 func (bigtable Bigtable) getMachineMetricNamesMap(userID uint64, searchDepth int) (map[string]bool, error) {
 	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second*30))
 	defer cancel()
@@ -337,6 +406,30 @@ func (bigtable Bigtable) GetMachineMetricsNode(userID uint64, limit, offset int)
 	)
 }
 
+func (bigtable Bigtable) GetMachineMetricsExecution(userID uint64, limit, offset int) ([]*types.MachineMetricExecution, error) {
+
+	tmr := time.AfterFunc(REPORT_TIMEOUT, func() {
+		logger.WithFields(logrus.Fields{
+			"userId": userID,
+			"limit":  limit,
+			"offset": offset,
+		}).Warnf("%s call took longer than %v", utils.GetCurrentFuncName(), REPORT_TIMEOUT)
+	})
+	defer tmr.Stop()
+
+	return getMachineMetrics(bigtable, "executionnode", userID, limit, offset,
+		func(data []byte, machine string) *types.MachineMetricExecution {
+			obj := &types.MachineMetricExecution{}
+			err := proto.Unmarshal(data, obj)
+			if err != nil {
+				return nil
+			}
+			obj.Machine = &machine
+			return obj
+		},
+	)
+}
+
 func (bigtable Bigtable) GetMachineMetricsValidator(userID uint64, limit, offset int) ([]*types.MachineMetricValidator, error) {
 
 	tmr := time.AfterFunc(REPORT_TIMEOUT, func() {
@@ -385,7 +478,7 @@ func (bigtable Bigtable) GetMachineMetricsSystem(userID uint64, limit, offset in
 	)
 }
 
-func getMachineMetrics[T types.MachineMetricSystem | types.MachineMetricNode | types.MachineMetricValidator](bigtable Bigtable, process string, userID uint64, limit, offset int, marshler func(data []byte, machine string) *T) ([]*T, error) {
+func getMachineMetrics[T types.MachineMetricSystem | types.MachineMetricNode | types.MachineMetricValidator | types.MachineMetricExecution](bigtable Bigtable, process string, userID uint64, limit, offset int, marshler func(data []byte, machine string) *T) ([]*T, error) {
 	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second*30))
 	defer cancel()
 
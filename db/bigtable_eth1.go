@@ -943,6 +943,44 @@ func CalculateMevFromBlock(block *types.Eth1Block) *big.Int {
 	return mevReward
 }
 
+// CalculateProposerPaymentFromBlock returns the amount the fee recipient received in the block.
+// Value transfers of successful transactions are taken from the internal transactions (which include the top level call),
+// if the fee recipient is also the coinbase of the block the priority fees are added as well.
+func CalculateProposerPaymentFromBlock(block *types.Eth1Block, feeRecipient []byte) *big.Int {
+	recipient := common.BytesToAddress(feeRecipient)
+	payment := new(big.Int)
+
+	for _, tx := range block.GetTransactions() {
+		if tx.GetStatus() != 1 {
+			continue
+		}
+		if len(tx.GetItx()) == 0 {
+			if common.BytesToAddress(tx.GetTo()) == recipient && common.BytesToAddress(tx.GetFrom()) != recipient {
+				payment.Add(payment, new(big.Int).SetBytes(tx.GetValue()))
+			}
+			continue
+		}
+		for _, itx := range tx.GetItx() {
+			switch itx.GetType() {
+			case "call", "create", "suicide", "selfdestruct":
+			default:
+				// delegate and static calls do not transfer value
+				continue
+			}
+			if common.BytesToAddress(itx.GetTo()) == recipient && common.BytesToAddress(itx.GetFrom()) != recipient {
+				payment.Add(payment, new(big.Int).SetBytes(itx.GetValue()))
+			}
+		}
+	}
+
+	if common.BytesToAddress(block.GetCoinbase()) == recipient {
+		fees := CalculateTxFeesFromBlock(block)
+		burned := new(big.Int).Mul(new(big.Int).SetBytes(block.GetBaseFee()), new(big.Int).SetUint64(block.GetGasUsed()))
+		payment.Add(payment, fees.Sub(fees, burned))
+	}
+	return payment
+}
+
 func CalculateTxFeesFromBlock(block *types.Eth1Block) *big.Int {
 	txFees := new(big.Int)
 	for _, tx := range block.Transactions {
@@ -0,0 +1,398 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	gcp_bigtable "cloud.google.com/go/bigtable"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MachineMetricsResolution is a tier of machine metrics, the raw metrics are stored at most once per minute and rolled up into 5m and 1h tiers
+type MachineMetricsResolution struct {
+	Name   string
+	Family string
+	Step   time.Duration
+	// Source is the resolution the rollups are computed from, it is nil for the raw metrics
+	Source *MachineMetricsResolution
+}
+
+var machineMetricsResolution1m = &MachineMetricsResolution{Name: "1m", Family: MACHINE_METRICS_COLUMN_FAMILY, Step: time.Minute}
+var machineMetricsResolution5m = &MachineMetricsResolution{Name: "5m", Family: MACHINE_METRICS_5M_COLUMN_FAMILY, Step: time.Minute * 5, Source: machineMetricsResolution1m}
+var machineMetricsResolution1h = &MachineMetricsResolution{Name: "1h", Family: MACHINE_METRICS_1H_COLUMN_FAMILY, Step: time.Hour, Source: machineMetricsResolution5m}
+
+// MachineMetricsResolutions lists the machine metric tiers from the finest to the coarsest resolution
+var MachineMetricsResolutions = []*MachineMetricsResolution{machineMetricsResolution1m, machineMetricsResolution5m, machineMetricsResolution1h}
+
+// maximum number of data points per machine returned by an automatically chosen resolution
+const machineMetricsMaxAutoPoints = 1500
+
+// number of buckets rolled up per run, limits the amount of data read at once when catching up
+const machineMetricsMaxRollupBuckets = 288
+
+// Retention returns how long metrics of the resolution are kept
+func (resolution *MachineMetricsResolution) Retention() time.Duration {
+	days := uint64(0)
+	defaultDays := uint64(0)
+	switch resolution.Name {
+	case "1m":
+		days, defaultDays = utils.Config.MachineMetrics.Retention.RawDays, 31
+	case "5m":
+		days, defaultDays = utils.Config.MachineMetrics.Retention.FiveMinutesDays, 180
+	case "1h":
+		days, defaultDays = utils.Config.MachineMetrics.Retention.HourDays, 730
+	}
+	if days == 0 {
+		days = defaultDays
+	}
+	return time.Duration(days) * utils.Day
+}
+
+// GetMachineMetricsResolution returns the resolution with the given name (1m, 5m or 1h), auto picks the finest resolution
+// that covers the time range within its retention with at most 1500 data points
+func GetMachineMetricsResolution(name string, from, to time.Time) (*MachineMetricsResolution, error) {
+	if name == "" || name == "auto" {
+		for _, resolution := range MachineMetricsResolutions {
+			if to.Sub(from)/resolution.Step <= machineMetricsMaxAutoPoints && time.Since(from) <= resolution.Retention() {
+				return resolution, nil
+			}
+		}
+		return machineMetricsResolution1h, nil
+	}
+	for _, resolution := range MachineMetricsResolutions {
+		if resolution.Name == name {
+			return resolution, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid resolution %v", name)
+}
+
+func (bigtable Bigtable) GetMachineMetricsSystemRange(userID uint64, machine string, resolution *MachineMetricsResolution, from, to time.Time) ([]*types.MachineMetricSystem, error) {
+	return getMachineMetricsRange(bigtable, "system", userID, machine, resolution, from, to, func(data []byte, machine string) *types.MachineMetricSystem {
+		obj := &types.MachineMetricSystem{}
+		err := proto.Unmarshal(data, obj)
+		if err != nil {
+			return nil
+		}
+		obj.Machine = &machine
+		return obj
+	})
+}
+
+func (bigtable Bigtable) GetMachineMetricsNodeRange(userID uint64, machine string, resolution *MachineMetricsResolution, from, to time.Time) ([]*types.MachineMetricNode, error) {
+	return getMachineMetricsRange(bigtable, "beaconnode", userID, machine, resolution, from, to, func(data []byte, machine string) *types.MachineMetricNode {
+		obj := &types.MachineMetricNode{}
+		err := proto.Unmarshal(data, obj)
+		if err != nil {
+			return nil
+		}
+		obj.Machine = &machine
+		return obj
+	})
+}
+
+func (bigtable Bigtable) GetMachineMetricsValidatorRange(userID uint64, machine string, resolution *MachineMetricsResolution, from, to time.Time) ([]*types.MachineMetricValidator, error) {
+	return getMachineMetricsRange(bigtable, "validator", userID, machine, resolution, from, to, func(data []byte, machine string) *types.MachineMetricValidator {
+		obj := &types.MachineMetricValidator{}
+		err := proto.Unmarshal(data, obj)
+		if err != nil {
+			return nil
+		}
+		obj.Machine = &machine
+		return obj
+	})
+}
+
+func (bigtable Bigtable) GetMachineMetricsExecutionRange(userID uint64, machine string, resolution *MachineMetricsResolution, from, to time.Time) ([]*types.MachineMetricExecution, error) {
+	return getMachineMetricsRange(bigtable, "executionnode", userID, machine, resolution, from, to, func(data []byte, machine string) *types.MachineMetricExecution {
+		obj := &types.MachineMetricExecution{}
+		err := proto.Unmarshal(data, obj)
+		if err != nil {
+			return nil
+		}
+		obj.Machine = &machine
+		return obj
+	})
+}
+
+// getMachineMetricsRange returns the metrics of a process within [from, to) ordered by machine and time, an empty machine returns the metrics of all machines
+func getMachineMetricsRange[T types.MachineMetricSystem | types.MachineMetricNode | types.MachineMetricValidator | types.MachineMetricExecution](bigtable Bigtable, process string, userID uint64, machine string, resolution *MachineMetricsResolution, from, to time.Time, marshler func(data []byte, machine string) *T) ([]*T, error) {
+	tmr := time.AfterFunc(REPORT_TIMEOUT, func() {
+		logger.WithFields(logrus.Fields{
+			"userId":     userID,
+			"process":    process,
+			"machine":    machine,
+			"resolution": resolution.Name,
+			"from":       from,
+			"to":         to,
+		}).Warnf("%s call took longer than %v", utils.GetCurrentFuncName(), REPORT_TIMEOUT)
+	})
+	defer tmr.Stop()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second*30))
+	defer cancel()
+
+	var rowSet gcp_bigtable.RowSet = gcp_bigtable.PrefixRange(fmt.Sprintf("u:%s:p:%s:m:", bigtable.reversePaddedUserID(userID), process))
+	if machine != "" {
+		rowSet = gcp_bigtable.SingleRow(bigtable.GetMachineRowKey(userID, process, machine))
+	}
+
+	filter := gcp_bigtable.ChainFilters(
+		gcp_bigtable.FamilyFilter(resolution.Family),
+		gcp_bigtable.TimestampRangeFilter(from, to),
+	)
+
+	res := make([]*T, 0)
+	var decodeErr error
+	err := bigtable.tableMachineMetrics.ReadRows(ctx, rowSet, func(r gcp_bigtable.Row) bool {
+		success, _, machine, _ := machineMetricRowParts(r.Key())
+		if !success {
+			decodeErr = fmt.Errorf("invalid machine metrics row key %v", r.Key())
+			return false
+		}
+		// cells are returned newest first
+		cells := r[resolution.Family]
+		for i := len(cells) - 1; i >= 0; i-- {
+			obj := marshler(cells[i].Value, machine)
+			if obj == nil {
+				decodeErr = fmt.Errorf("error decoding machine metric of row %v at %v", r.Key(), cells[i].Timestamp.Time())
+				return false
+			}
+			res = append(res, obj)
+		}
+		return true
+	}, gcp_bigtable.RowFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+
+	return res, nil
+}
+
+// GetMachineMetricsForAlerts returns the raw metrics of the given row ranges since from ordered by time, keyed by row key
+func (bigtable Bigtable) GetMachineMetricsForAlerts(rowRanges gcp_bigtable.RowRangeList, from time.Time) (map[string][]proto.Message, error) {
+	tmr := time.AfterFunc(REPORT_TIMEOUT, func() {
+		logger.WithFields(logrus.Fields{
+			"rowRanges": len(rowRanges),
+			"from":      from,
+		}).Warnf("%s call took longer than %v", utils.GetCurrentFuncName(), REPORT_TIMEOUT)
+	})
+	defer tmr.Stop()
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(time.Second*200))
+	defer cancel()
+
+	filter := gcp_bigtable.ChainFilters(
+		gcp_bigtable.FamilyFilter(MACHINE_METRICS_COLUMN_FAMILY),
+		gcp_bigtable.TimestampRangeFilter(from, time.Now().Add(time.Minute)),
+	)
+
+	res := make(map[string][]proto.Message)
+	err := bigtable.tableMachineMetrics.ReadRows(ctx, rowRanges, func(r gcp_bigtable.Row) bool {
+		success, _, _, process := machineMetricRowParts(r.Key())
+		if !success {
+			return true
+		}
+		// cells are returned newest first
+		cells := r[MACHINE_METRICS_COLUMN_FAMILY]
+		metrics := make([]proto.Message, 0, len(cells))
+		for i := len(cells) - 1; i >= 0; i-- {
+			metric := utils.NewMachineMetric(process)
+			if metric == nil {
+				return true
+			}
+			err := proto.Unmarshal(cells[i].Value, metric)
+			if err != nil {
+				logger.Warnf("skipping invalid machine metric of row %v: %v", r.Key(), err)
+				continue
+			}
+			metrics = append(metrics, metric)
+		}
+		res[r.Key()] = metrics
+		return true
+	}, gcp_bigtable.RowFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// RollupMachineMetrics computes the rollups of a resolution for all complete buckets since the last run from the metrics of its source resolution
+func (bigtable *Bigtable) RollupMachineMetrics(resolution *MachineMetricsResolution) error {
+	if resolution.Source == nil {
+		return fmt.Errorf("resolution %v is not a rollup", resolution.Name)
+	}
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute*5)
+	defer cancel()
+
+	// raw metrics are written in batches, leave them some time to arrive before their bucket is closed
+	end := time.Now().Add(-time.Minute).Truncate(resolution.Step)
+	from := end.Add(-resolution.Step * 12)
+
+	lastEnd, exists, err := bigtable.getMachineMetricsRollupEnd(ctx, resolution)
+	if err != nil {
+		return err
+	}
+	if exists {
+		from = lastEnd
+	}
+	// rollups of rollups only cover the buckets their source resolution has completely rolled up so far
+	if resolution.Source.Source != nil {
+		sourceEnd, exists, err := bigtable.getMachineMetricsRollupEnd(ctx, resolution.Source)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+		if sourceEnd = sourceEnd.Truncate(resolution.Step); sourceEnd.Before(end) {
+			end = sourceEnd
+		}
+	}
+	if oldest := end.Add(-resolution.Source.Retention()).Truncate(resolution.Step); from.Before(oldest) {
+		from = oldest
+	}
+	if end.Sub(from) > resolution.Step*machineMetricsMaxRollupBuckets {
+		end = from.Add(resolution.Step * machineMetricsMaxRollupBuckets)
+	}
+	if !from.Before(end) {
+		return nil
+	}
+
+	filter := gcp_bigtable.ChainFilters(
+		gcp_bigtable.FamilyFilter(resolution.Source.Family),
+		gcp_bigtable.TimestampRangeFilter(from, end),
+	)
+
+	muts := types.NewBulkMutations(MAX_BATCH_MUTATIONS)
+	var rollupErr error
+	err = bigtable.tableMachineMetrics.ReadRows(ctx, gcp_bigtable.PrefixRange("u:"), func(r gcp_bigtable.Row) bool {
+		success, _, _, process := machineMetricRowParts(r.Key())
+		if !success {
+			return true
+		}
+
+		buckets := make(map[time.Time][]proto.Message)
+		for _, cell := range r[resolution.Source.Family] {
+			metric := utils.NewMachineMetric(process)
+			if metric == nil {
+				return true
+			}
+			err := proto.Unmarshal(cell.Value, metric)
+			if err != nil {
+				logger.Warnf("skipping invalid machine metric of row %v: %v", r.Key(), err)
+				continue
+			}
+			bucket := cell.Timestamp.Time().Truncate(resolution.Step)
+			buckets[bucket] = append(buckets[bucket], metric)
+		}
+
+		for bucket, metrics := range buckets {
+			// cells are returned newest first, rollups expect the metrics ordered by time
+			for i, j := 0, len(metrics)-1; i < j; i, j = i+1, j-1 {
+				metrics[i], metrics[j] = metrics[j], metrics[i]
+			}
+			rollup := utils.RollupMachineMetrics(metrics)
+			m := rollup.ProtoReflect()
+			m.Set(m.Descriptor().Fields().ByName("timestamp"), protoreflect.ValueOfUint64(uint64(bucket.UnixMilli())))
+			data, err := proto.Marshal(rollup)
+			if err != nil {
+				rollupErr = fmt.Errorf("error marshalling rollup of row %v: %w", r.Key(), err)
+				return false
+			}
+			mut := gcp_bigtable.NewMutation()
+			mut.Set(resolution.Family, "v1", gcp_bigtable.Time(bucket), data)
+			muts.Add(r.Key(), mut)
+		}
+		return true
+	}, gcp_bigtable.RowFilter(filter))
+	if err != nil {
+		return fmt.Errorf("error reading machine metrics for rollup %v: %w", resolution.Name, err)
+	}
+	if rollupErr != nil {
+		return rollupErr
+	}
+
+	err = bigtable.WriteBulk(muts, bigtable.tableMachineMetrics, DEFAULT_BATCH_INSERTS)
+	if err != nil {
+		return fmt.Errorf("error writing machine metric rollups %v: %w", resolution.Name, err)
+	}
+
+	err = bigtable.redisCache.Set(ctx, machineMetricsRollupStatusKey(bigtable.chainId, resolution), end.Unix(), 0).Err()
+	if err != nil {
+		return fmt.Errorf("error saving last rollup of resolution %v: %w", resolution.Name, err)
+	}
+
+	logger.WithFields(logrus.Fields{"resolution": resolution.Name, "from": from, "to": end, "rollups": len(muts.Keys), "duration": time.Since(start)}).Infof("rolled up machine metrics")
+	return nil
+}
+
+func machineMetricsRollupStatusKey(chainId string, resolution *MachineMetricsResolution) string {
+	return fmt.Sprintf("%s:machineMetricsRollup:%s", chainId, resolution.Name)
+}
+
+// getMachineMetricsRollupEnd returns the end of the last computed rollup of a resolution and whether it was computed before
+func (bigtable *Bigtable) getMachineMetricsRollupEnd(ctx context.Context, resolution *MachineMetricsResolution) (time.Time, bool, error) {
+	lastEnd, err := bigtable.redisCache.Get(ctx, machineMetricsRollupStatusKey(bigtable.chainId, resolution)).Result()
+	if err == redis.Nil {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("error retrieving last rollup of resolution %v: %w", resolution.Name, err)
+	}
+	ts, err := strconv.ParseInt(lastEnd, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("error parsing last rollup of resolution %v: %w", resolution.Name, err)
+	}
+	return time.Unix(ts, 0), true, nil
+}
+
+// ApplyMachineMetricsRetention creates missing machine metric column families and sets their gc policies to the configured retention of their tier
+func ApplyMachineMetricsRetention() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	admin, err := gcp_bigtable.NewAdminClient(ctx, utils.Config.Bigtable.Project, utils.Config.Bigtable.Instance)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	info, err := admin.TableInfo(ctx, "machine_metrics")
+	if err != nil {
+		return fmt.Errorf("error retrieving machine_metrics table info: %w", err)
+	}
+	existing := make(map[string]bool, len(info.FamilyInfos))
+	for _, family := range info.FamilyInfos {
+		existing[family.Name] = true
+	}
+
+	for _, resolution := range MachineMetricsResolutions {
+		if !existing[resolution.Family] {
+			err := admin.CreateColumnFamily(ctx, "machine_metrics", resolution.Family)
+			if err != nil {
+				return fmt.Errorf("error creating column family %v: %w", resolution.Family, err)
+			}
+		}
+		err := admin.SetGCPolicy(ctx, "machine_metrics", resolution.Family, gcp_bigtable.MaxAgeGCPolicy(resolution.Retention()))
+		if err != nil {
+			return fmt.Errorf("error setting gc policy of column family %v: %w", resolution.Family, err)
+		}
+		logger.Infof("set retention of machine metrics resolution %v to %v", resolution.Name, resolution.Retention())
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+var chartSeriesIndicatorNameRE = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// chartSeriesIndicatorParamRE matches the named parameters of the queries of config-defined indicators. They are bound positionally
+// instead of using sqlx named parameters, which would turn postgres casts (::) into named parameters.
+var chartSeriesIndicatorParamRE = regexp.MustCompile(`(^|[^:]):(day_start|first_epoch|last_epoch|first_slot|last_slot)\b`)
+
+// ChartSeriesResolutions are the resolutions chart_series indicators can be queried at
+var ChartSeriesResolutions = []string{"day", "week", "month"}
+
+// chartSeriesAggregations maps the supported aggregation functions to their sql expression
+var chartSeriesAggregations = map[string]string{
+	"sum":   "sum(value)",
+	"avg":   "avg(value)",
+	"min":   "min(value)",
+	"max":   "max(value)",
+	"first": "(array_agg(value ORDER BY time ASC))[1]",
+	"last":  "(array_agg(value ORDER BY time DESC))[1]",
+}
+
+// builtinChartSeriesIndicators are the indicators written by WriteConsensusChartSeriesForDay and WriteExecutionChartSeriesForDay
+var builtinChartSeriesIndicators = []types.ChartSeriesIndicator{
+	{Name: "STAKED_ETH", Description: "Eligible ether at the end of the day", Aggregation: "last"},
+	{Name: "AVG_VALIDATOR_BALANCE_ETH", Description: "Average validator balance", Aggregation: "avg"},
+	{Name: "AVG_PARTICIPATION_RATE", Description: "Average global participation rate", Aggregation: "avg"},
+	{Name: "AVG_STAKE_EFFECTIVENESS", Description: "Average stake effectiveness", Aggregation: "avg"},
+	{Name: "EL_VALID_DEPOSITS_ETH", Description: "Valid deposits on the execution layer", Aggregation: "sum"},
+	{Name: "EL_INVALID_DEPOSITS_ETH", Description: "Invalid deposits on the execution layer", Aggregation: "sum"},
+	{Name: "CL_DEPOSITS_ETH", Description: "Deposits processed on the consensus layer", Aggregation: "sum"},
+	{Name: "WITHDRAWALS_ETH", Description: "Withdrawals processed on the consensus layer", Aggregation: "sum"},
+	{Name: "PROPOSED_BLOCKS", Description: "Proposed blocks", Aggregation: "sum"},
+	{Name: "MISSED_BLOCKS", Description: "Missed blocks", Aggregation: "sum"},
+	{Name: "ORPHANED_BLOCKS", Description: "Orphaned blocks", Aggregation: "sum"},
+	{Name: "BURNED_FEES", Description: "Burned fees in wei", Aggregation: "sum"},
+	{Name: "BURNED_BLOB_FEES", Description: "Burned blob fees in wei", Aggregation: "sum"},
+	{Name: "NON_FAILED_TX_GAS_USAGE", Description: "Gas used by successful transactions", Aggregation: "sum"},
+	{Name: "BLOCK_COUNT", Description: "Execution blocks", Aggregation: "sum"},
+	{Name: "BLOB_COUNT", Description: "Blobs", Aggregation: "sum"},
+	{Name: "BLOCK_TIME_AVG", Description: "Average block time in seconds", Aggregation: "avg"},
+	{Name: "TOTAL_EMISSION", Description: "Total emission in wei", Aggregation: "last"},
+	{Name: "AVG_GASPRICE", Description: "Average gas price in wei", Aggregation: "avg"},
+	{Name: "AVG_GASUSED", Description: "Average gas used per block", Aggregation: "avg"},
+	{Name: "TOTAL_GASUSED", Description: "Total gas used", Aggregation: "sum"},
+	{Name: "TOTAL_BLOB_GASUSED", Description: "Total blob gas used", Aggregation: "sum"},
+	{Name: "AVG_GASLIMIT", Description: "Average gas limit", Aggregation: "avg"},
+	{Name: "AVG_BLOCK_UTIL", Description: "Average block utilization in percent", Aggregation: "avg"},
+	{Name: "MARKET_CAP", Description: "Market capitalization", Aggregation: "last"},
+	{Name: "TX_COUNT", Description: "Transactions", Aggregation: "sum"},
+}
+
+// ChartSeriesIndicators returns the built-in indicators followed by the valid indicators defined in the config
+func ChartSeriesIndicators() []types.ChartSeriesIndicator {
+	indicators := make([]types.ChartSeriesIndicator, 0, len(builtinChartSeriesIndicators)+len(utils.Config.ChartSeries.Indicators))
+	indicators = append(indicators, builtinChartSeriesIndicators...)
+	indicators = append(indicators, customChartSeriesIndicators()...)
+	return indicators
+}
+
+// GetChartSeriesIndicator returns the indicator with the given name
+func GetChartSeriesIndicator(name string) (types.ChartSeriesIndicator, bool) {
+	for _, indicator := range ChartSeriesIndicators() {
+		if indicator.Name == name {
+			return indicator, true
+		}
+	}
+	return types.ChartSeriesIndicator{}, false
+}
+
+// customChartSeriesIndicators returns the indicators defined in the config, invalid definitions are skipped
+func customChartSeriesIndicators() []types.ChartSeriesIndicator {
+	builtin := make(map[string]bool, len(builtinChartSeriesIndicators))
+	for _, indicator := range builtinChartSeriesIndicators {
+		builtin[indicator.Name] = true
+	}
+
+	indicators := make([]types.ChartSeriesIndicator, 0, len(utils.Config.ChartSeries.Indicators))
+	seen := make(map[string]bool)
+	for _, indicator := range utils.Config.ChartSeries.Indicators {
+		if err := validateChartSeriesIndicator(indicator); err != nil {
+			logger.WithField("indicator", indicator.Name).Warnf("skipping chart_series indicator: %v", err)
+			continue
+		}
+		if builtin[indicator.Name] || seen[indicator.Name] {
+			logger.WithField("indicator", indicator.Name).Warnf("skipping chart_series indicator: indicator is already defined")
+			continue
+		}
+		seen[indicator.Name] = true
+		indicator.Custom = true
+		indicators = append(indicators, indicator)
+	}
+	return indicators
+}
+
+func validateChartSeriesIndicator(indicator types.ChartSeriesIndicator) error {
+	if !chartSeriesIndicatorNameRE.MatchString(indicator.Name) {
+		return fmt.Errorf("invalid name, it must consist of upper case letters, digits and underscores")
+	}
+	if indicator.Query == "" {
+		return fmt.Errorf("no query defined")
+	}
+	if _, ok := chartSeriesAggregations[indicator.Aggregation]; !ok {
+		return fmt.Errorf("invalid aggregation %v", indicator.Aggregation)
+	}
+	return nil
+}
+
+// WriteCustomChartSeriesForDay writes the chart_series values of the config-defined indicators for the given day,
+// if names are given only those indicators are written (which is used to backfill new indicators).
+// A failing indicator is logged and skipped so it does not keep the other indicators from being written, the number of failed indicators is returned as error.
+func WriteCustomChartSeriesForDay(day int64, names ...string) error {
+	if day < 0 {
+		logger.Warnf("no custom charts for day < 0: %v", day)
+		return nil
+	}
+
+	indicators := customChartSeriesIndicators()
+	if len(names) > 0 {
+		selected := make(map[string]bool, len(names))
+		for _, name := range names {
+			selected[name] = true
+		}
+		filtered := make([]types.ChartSeriesIndicator, 0, len(names))
+		for _, indicator := range indicators {
+			if selected[indicator.Name] {
+				filtered = append(filtered, indicator)
+				delete(selected, indicator.Name)
+			}
+		}
+		for name := range selected {
+			return fmt.Errorf("unknown custom chart_series indicator %v", name)
+		}
+		indicators = filtered
+	}
+	if len(indicators) == 0 {
+		return nil
+	}
+
+	_, dateTrunc, firstSlot, lastSlot, firstEpoch, lastEpoch := consensusChartSeriesDayBounds(day)
+	logrus.WithFields(logrus.Fields{"day": day, "firstSlot": firstSlot, "lastSlot": lastSlot, "firstEpoch": firstEpoch, "lastEpoch": lastEpoch, "dateTrunc": dateTrunc, "indicators": len(indicators)}).Infof("exporting custom chart_series")
+
+	failed := 0
+	for _, indicator := range indicators {
+		query, args := bindChartSeriesIndicatorParams(indicator.Query, map[string]interface{}{
+			"day_start":   dateTrunc,
+			"first_epoch": firstEpoch,
+			"last_epoch":  lastEpoch,
+			"first_slot":  firstSlot,
+			"last_slot":   lastSlot,
+		}, dateTrunc, indicator.Name)
+		_, err := WriterDb.Exec(fmt.Sprintf(`
+			insert into chart_series (time, indicator, value)
+			select $1::timestamp, $2::text, coalesce((%s), 0)
+			on conflict (time, indicator) do update set value = excluded.value`, query), args...)
+		if err != nil {
+			utils.LogError(err, fmt.Errorf("error inserting custom indicator %v into chart_series", indicator.Name), 0, map[string]interface{}{"day": day})
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("error writing %v of %v custom chart_series indicators for day %v", failed, len(indicators), day)
+	}
+	return nil
+}
+
+// bindChartSeriesIndicatorParams replaces the named parameters used by an indicator query by positional parameters following args
+// and returns the query together with the values to bind, only used parameters are bound as postgres cannot infer the type of unused ones
+func bindChartSeriesIndicatorParams(query string, params map[string]interface{}, args ...interface{}) (string, []interface{}) {
+	positions := map[string]int{}
+	query = chartSeriesIndicatorParamRE.ReplaceAllStringFunc(query, func(match string) string {
+		groups := chartSeriesIndicatorParamRE.FindStringSubmatch(match)
+		if _, ok := positions[groups[2]]; !ok {
+			args = append(args, params[groups[2]])
+			positions[groups[2]] = len(args)
+		}
+		return fmt.Sprintf("%s$%d", groups[1], positions[groups[2]])
+	})
+	return query, args
+}
+
+// GetChartSeries returns the values of an indicator between from and to (inclusive) aggregated to the given resolution
+func GetChartSeries(indicator types.ChartSeriesIndicator, from, to time.Time, resolution, aggregation string) ([]*types.ChartSeriesPoint, error) {
+	if aggregation == "" {
+		aggregation = indicator.Aggregation
+	}
+	aggregationExpr, ok := chartSeriesAggregations[aggregation]
+	if !ok {
+		return nil, fmt.Errorf("invalid aggregation %v", aggregation)
+	}
+	validResolution := false
+	for _, r := range ChartSeriesResolutions {
+		validResolution = validResolution || r == resolution
+	}
+	if !validResolution {
+		return nil, fmt.Errorf("invalid resolution %v", resolution)
+	}
+
+	points := []*types.ChartSeriesPoint{}
+	err := ReaderDb.Select(&points, fmt.Sprintf(`
+		SELECT date_trunc($2, time) AS time, %s AS value
+		FROM chart_series
+		WHERE indicator = $1 AND time >= $3 AND time <= $4
+		GROUP BY 1
+		ORDER BY 1`, aggregationExpr), indicator.Name, resolution, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving chart_series of %v: %w", indicator.Name, err)
+	}
+	return points, nil
+}
+
+// ChartSeriesAggregations returns the names of the supported aggregation functions
+func ChartSeriesAggregations() []string {
+	aggregations := make([]string, 0, len(chartSeriesAggregations))
+	for aggregation := range chartSeriesAggregations {
+		aggregations = append(aggregations, aggregation)
+	}
+	sort.Strings(aggregations)
+	return aggregations
+}
@@ -0,0 +1,214 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/rpc"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const epochChartSeriesBatchSize = 100
+
+// EpochChartSeriesIndicators are the indicators stored per epoch in the chart_series_epochs table
+var EpochChartSeriesIndicators = []types.ChartSeriesIndicator{
+	{Name: "PARTICIPATION_RATE", Description: "Participation Rate", Aggregation: "avg"},
+	{Name: "ACTIVE_VALIDATORS", Description: "Active Validators", Aggregation: "avg"},
+	{Name: "STAKED", Description: "Staked", Aggregation: "avg"},
+	{Name: "MISSED_PROPOSALS", Description: "Missed Proposals", Aggregation: "avg"},
+	{Name: "SYNC_PARTICIPATION_RATE", Description: "Sync Committee Participation Rate", Aggregation: "avg"},
+	{Name: "AVG_INCLUSION_DISTANCE", Description: "Average Inclusion Distance", Aggregation: "avg"},
+}
+
+// epochChartSeriesRetention returns the number of epochs the epoch chart series are kept for
+func epochChartSeriesRetention() uint64 {
+	if utils.Config.ChartSeries.EpochRetention > 0 {
+		return utils.Config.ChartSeries.EpochRetention
+	}
+	return 30 * utils.EpochsPerDay()
+}
+
+// WriteEpochChartSeries writes the epoch chart series of all finalized epochs that have not been written yet and removes epochs that are outside of the retention window.
+// Attestations of an epoch can be included until the end of the next epoch, therefore the series lag one epoch behind the latest finalized epoch.
+func WriteEpochChartSeries(client rpc.Client) error {
+	exportStart := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("db_update_chart_series_epochs").Observe(time.Since(exportStart).Seconds())
+	}()
+
+	latestFinalizedEpoch, err := GetLatestFinalizedEpoch()
+	if err != nil {
+		return fmt.Errorf("error getting latest finalized epoch from db %w", err)
+	}
+	if latestFinalizedEpoch == 0 {
+		return nil
+	}
+	lastEpoch := latestFinalizedEpoch - 1
+
+	retention := epochChartSeriesRetention()
+	firstEpoch := uint64(0)
+	if lastEpoch >= retention {
+		firstEpoch = lastEpoch - retention + 1
+	}
+
+	var lastWrittenEpoch sql.NullInt64
+	err = WriterDb.Get(&lastWrittenEpoch, `SELECT MAX(epoch) FROM chart_series_epochs WHERE indicator = $1`, EpochChartSeriesIndicators[0].Name)
+	if err != nil {
+		return fmt.Errorf("error retrieving last epoch of chart_series_epochs: %w", err)
+	}
+	if lastWrittenEpoch.Valid && uint64(lastWrittenEpoch.Int64)+1 > firstEpoch {
+		firstEpoch = uint64(lastWrittenEpoch.Int64) + 1
+	}
+
+	for from := firstEpoch; from <= lastEpoch; from += epochChartSeriesBatchSize {
+		to := from + epochChartSeriesBatchSize - 1
+		if to > lastEpoch {
+			to = lastEpoch
+		}
+		err := writeEpochChartSeriesForEpochs(client, from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	if lastEpoch >= retention {
+		_, err = WriterDb.Exec(`DELETE FROM chart_series_epochs WHERE epoch <= $1`, lastEpoch-retention)
+		if err != nil {
+			return fmt.Errorf("error deleting chart_series_epochs older than epoch %v: %w", lastEpoch-retention, err)
+		}
+	}
+
+	if firstEpoch <= lastEpoch {
+		logger.WithFields(logrus.Fields{"firstEpoch": firstEpoch, "lastEpoch": lastEpoch}).Infof("chart_series_epochs export completed, took %v", time.Since(exportStart))
+	}
+	return nil
+}
+
+func writeEpochChartSeriesForEpochs(client rpc.Client, firstEpoch, lastEpoch uint64) error {
+	slotsPerEpoch := utils.Config.Chain.ClConfig.SlotsPerEpoch
+	values := make(map[string]map[uint64]float64, len(EpochChartSeriesIndicators))
+	for _, indicator := range EpochChartSeriesIndicators {
+		values[indicator.Name] = make(map[uint64]float64)
+	}
+
+	epochs := []struct {
+		Epoch                   uint64          `db:"epoch"`
+		ValidatorsCount         uint64          `db:"validatorscount"`
+		EligibleEther           sql.NullInt64   `db:"eligibleether"`
+		GlobalParticipationRate sql.NullFloat64 `db:"globalparticipationrate"`
+	}{}
+	err := WriterDb.Select(&epochs, `SELECT epoch, validatorscount, eligibleether, globalparticipationrate FROM epochs WHERE epoch >= $1 AND epoch <= $2 ORDER BY epoch`, firstEpoch, lastEpoch)
+	if err != nil {
+		return fmt.Errorf("error retrieving epochs %v-%v for chart_series_epochs: %w", firstEpoch, lastEpoch, err)
+	}
+	for _, e := range epochs {
+		if !e.EligibleEther.Valid || e.EligibleEther.Int64 == 0 || !e.GlobalParticipationRate.Valid {
+			participation, err := client.GetValidatorParticipation(e.Epoch)
+			if err != nil {
+				return fmt.Errorf("error retrieving validator participation of epoch %v: %w", e.Epoch, err)
+			}
+			e.EligibleEther = sql.NullInt64{Int64: int64(participation.EligibleEther), Valid: true}
+			e.GlobalParticipationRate = sql.NullFloat64{Float64: float64(participation.GlobalParticipationRate), Valid: true}
+		}
+		values["PARTICIPATION_RATE"][e.Epoch] = e.GlobalParticipationRate.Float64
+		values["ACTIVE_VALIDATORS"][e.Epoch] = float64(e.ValidatorsCount)
+		values["STAKED"][e.Epoch] = float64(e.EligibleEther.Int64) / 1e9
+	}
+
+	blocks := []struct {
+		Epoch                uint64          `db:"epoch"`
+		MissedProposals      uint64          `db:"missed_proposals"`
+		SyncParticipation    sql.NullFloat64 `db:"sync_participation"`
+		AvgInclusionDistance sql.NullFloat64 `db:"avg_inclusion_distance"`
+	}{}
+	err = WriterDb.Select(&blocks, `
+		SELECT
+			b.epoch,
+			COUNT(*) FILTER (WHERE b.status = '2') AS missed_proposals,
+			AVG(b.syncaggregate_participation) FILTER (WHERE b.status = '1') AS sync_participation,
+			(
+				SELECT AVG(ba.block_slot - ba.slot)
+				FROM blocks_attestations ba
+				INNER JOIN blocks ib ON ib.blockroot = ba.block_root AND ib.status = '1'
+				WHERE ba.block_slot >= b.epoch * $3 AND ba.block_slot < (b.epoch + 2) * $3 AND ba.slot >= b.epoch * $3 AND ba.slot < (b.epoch + 1) * $3
+			) AS avg_inclusion_distance
+		FROM blocks b
+		WHERE b.epoch >= $1 AND b.epoch <= $2
+		GROUP BY b.epoch`, firstEpoch, lastEpoch, slotsPerEpoch)
+	if err != nil {
+		return fmt.Errorf("error retrieving blocks of epochs %v-%v for chart_series_epochs: %w", firstEpoch, lastEpoch, err)
+	}
+	for _, b := range blocks {
+		values["MISSED_PROPOSALS"][b.Epoch] = float64(b.MissedProposals)
+		if b.Epoch >= utils.Config.Chain.ClConfig.AltairForkEpoch && b.SyncParticipation.Valid {
+			values["SYNC_PARTICIPATION_RATE"][b.Epoch] = b.SyncParticipation.Float64
+		}
+		if b.AvgInclusionDistance.Valid {
+			values["AVG_INCLUSION_DISTANCE"][b.Epoch] = b.AvgInclusionDistance.Float64
+		}
+	}
+
+	indicators := make([]string, 0, len(epochs)*len(EpochChartSeriesIndicators))
+	epochNumbers := make([]int64, 0, cap(indicators))
+	epochValues := make([]float64, 0, cap(indicators))
+	for indicator, byEpoch := range values {
+		for epoch, value := range byEpoch {
+			indicators = append(indicators, indicator)
+			epochNumbers = append(epochNumbers, int64(epoch))
+			epochValues = append(epochValues, value)
+		}
+	}
+
+	_, err = WriterDb.Exec(`
+		INSERT INTO chart_series_epochs (indicator, epoch, value)
+		SELECT * FROM unnest($1::text[], $2::int[], $3::float[])
+		ON CONFLICT (indicator, epoch) DO UPDATE SET value = excluded.value`,
+		pq.Array(indicators), pq.Array(epochNumbers), pq.Array(epochValues))
+	if err != nil {
+		return fmt.Errorf("error writing chart_series_epochs of epochs %v-%v: %w", firstEpoch, lastEpoch, err)
+	}
+	return nil
+}
+
+// GetEpochChartSeries returns the values of an epoch chart series indicator between fromEpoch and toEpoch (inclusive),
+// if the range holds more than maxPoints epochs, consecutive epochs are averaged
+func GetEpochChartSeries(indicator string, fromEpoch, toEpoch uint64, maxPoints uint64) ([]*types.ChartSeriesEpochPoint, error) {
+	if maxPoints == 0 {
+		maxPoints = 1
+	}
+	bucketSize := (toEpoch - fromEpoch + maxPoints) / maxPoints
+	if bucketSize == 0 {
+		bucketSize = 1
+	}
+
+	points := []*types.ChartSeriesEpochPoint{}
+	err := ReaderDb.Select(&points, `
+		SELECT MIN(epoch) AS epoch, AVG(value) AS value
+		FROM chart_series_epochs
+		WHERE indicator = $1 AND epoch >= $2 AND epoch <= $3
+		GROUP BY (epoch - $2) / $4
+		ORDER BY 1`, indicator, fromEpoch, toEpoch, bucketSize)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving chart_series_epochs of %v: %w", indicator, err)
+	}
+	return points, nil
+}
+
+// GetEpochChartSeriesRange returns the first and last epoch stored in the chart_series_epochs table
+func GetEpochChartSeriesRange() (uint64, uint64, error) {
+	r := struct {
+		First sql.NullInt64 `db:"first"`
+		Last  sql.NullInt64 `db:"last"`
+	}{}
+	err := ReaderDb.Get(&r, `SELECT MIN(epoch) AS first, MAX(epoch) AS last FROM chart_series_epochs`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error retrieving range of chart_series_epochs: %w", err)
+	}
+	return uint64(r.First.Int64), uint64(r.Last.Int64), nil
+}
@@ -0,0 +1,294 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const clientDiversityBatchSize = 100
+
+// blocks with the same attestation packing fingerprint are only attributed to a client if the graffiti-classified blocks
+// of the batch with that fingerprint were mostly proposed by that client
+const (
+	clientDiversityPackingMinSamples = 20
+	clientDiversityPackingMinShare   = 0.8
+)
+
+const (
+	ClientSignalGraffiti = "graffiti"
+	ClientSignalProposer = "proposer"
+	ClientSignalPacking  = "packing"
+	ClientSignalNone     = "none"
+)
+
+// WriteClientDiversity classifies the consensus client of all proposed blocks of finalized epochs that have not been classified yet
+// and updates the per epoch, per day and per validator client diversity statistics.
+func WriteClientDiversity() error {
+	exportStart := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("db_update_client_diversity").Observe(time.Since(exportStart).Seconds())
+	}()
+
+	latestFinalizedEpoch, err := GetLatestFinalizedEpoch()
+	if err != nil {
+		return fmt.Errorf("error getting latest finalized epoch from db %w", err)
+	}
+
+	var lastClassifiedEpoch sql.NullInt64
+	err = WriterDb.Get(&lastClassifiedEpoch, `SELECT MAX(epoch) FROM blocks_clients`)
+	if err != nil {
+		return fmt.Errorf("error retrieving last epoch of blocks_clients: %w", err)
+	}
+	firstEpoch := uint64(0)
+	if lastClassifiedEpoch.Valid {
+		firstEpoch = uint64(lastClassifiedEpoch.Int64) + 1
+	}
+
+	for from := firstEpoch; from <= latestFinalizedEpoch; from += clientDiversityBatchSize {
+		to := from + clientDiversityBatchSize - 1
+		if to > latestFinalizedEpoch {
+			to = latestFinalizedEpoch
+		}
+		err := writeClientDiversityForEpochs(from, to)
+		if err != nil {
+			return err
+		}
+	}
+
+	if firstEpoch <= latestFinalizedEpoch {
+		logger.WithFields(logrus.Fields{"firstEpoch": firstEpoch, "lastEpoch": latestFinalizedEpoch}).Infof("client diversity export completed, took %v", time.Since(exportStart))
+	}
+	return nil
+}
+
+func writeClientDiversityForEpochs(firstEpoch, lastEpoch uint64) error {
+	blocks := []struct {
+		Slot             uint64        `db:"slot"`
+		Epoch            uint64        `db:"epoch"`
+		Proposer         uint64        `db:"proposer"`
+		GraffitiText     string        `db:"graffiti_text"`
+		AttestationSlots pq.Int64Array `db:"attestation_slots"`
+	}{}
+	err := WriterDb.Select(&blocks, `
+		SELECT
+			b.slot,
+			b.epoch,
+			b.proposer,
+			COALESCE(b.graffiti_text, '') AS graffiti_text,
+			COALESCE((SELECT array_agg(ba.slot ORDER BY ba.block_index) FROM blocks_attestations ba WHERE ba.block_slot = b.slot AND ba.block_root = b.blockroot), '{}') AS attestation_slots
+		FROM blocks b
+		WHERE b.epoch >= $1 AND b.epoch <= $2 AND b.status = '1' AND b.slot > 0
+		ORDER BY b.slot`, firstEpoch, lastEpoch)
+	if err != nil {
+		return fmt.Errorf("error retrieving blocks of epochs %v-%v for client diversity: %w", firstEpoch, lastEpoch, err)
+	}
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	proposers := make([]int64, 0, len(blocks))
+	seenProposers := make(map[uint64]bool, len(blocks))
+	for _, b := range blocks {
+		if !seenProposers[b.Proposer] {
+			seenProposers[b.Proposer] = true
+			proposers = append(proposers, int64(b.Proposer))
+		}
+	}
+
+	// the client a validator used for its previous graffiti-classified blocks
+	history := []struct {
+		ValidatorIndex uint64 `db:"validatorindex"`
+		Client         string `db:"client"`
+	}{}
+	err = WriterDb.Select(&history, `
+		SELECT DISTINCT ON (validatorindex) validatorindex, client
+		FROM validator_clients
+		WHERE validatorindex = ANY($1) AND graffiti_blocks_count > 0
+		ORDER BY validatorindex, last_slot DESC`, pq.Array(proposers))
+	if err != nil {
+		return fmt.Errorf("error retrieving validator_clients of epochs %v-%v: %w", firstEpoch, lastEpoch, err)
+	}
+	proposerClients := make(map[uint64]string, len(history))
+	for _, h := range history {
+		proposerClients[h.ValidatorIndex] = h.Client
+	}
+
+	maxAttestations := int(utils.Config.Chain.ClConfig.MaxAttestations)
+	clients := make([]string, len(blocks))
+	confidences := make([]float64, len(blocks))
+	signals := make([]string, len(blocks))
+	fingerprints := make([]string, len(blocks))
+	packingCounts := make(map[string]map[string]int)
+
+	for i, b := range blocks {
+		fingerprints[i] = utils.AttestationPackingFingerprint(b.Slot, b.AttestationSlots, maxAttestations)
+
+		client, confidence := utils.ClassifyGraffiti(b.GraffitiText)
+		if client != utils.UnknownConsensusClient {
+			clients[i], confidences[i], signals[i] = client, confidence, ClientSignalGraffiti
+			proposerClients[b.Proposer] = client
+			if packingCounts[fingerprints[i]] == nil {
+				packingCounts[fingerprints[i]] = make(map[string]int)
+			}
+			packingCounts[fingerprints[i]][client]++
+			continue
+		}
+		if client, ok := proposerClients[b.Proposer]; ok {
+			clients[i], confidences[i], signals[i] = client, 0.7, ClientSignalProposer
+			continue
+		}
+		clients[i], confidences[i], signals[i] = utils.UnknownConsensusClient, 0, ClientSignalNone
+	}
+
+	// attribute the remaining blocks by their attestation packing fingerprint
+	for i := range blocks {
+		if signals[i] != ClientSignalNone {
+			continue
+		}
+		total, bestCount, bestClient := 0, 0, ""
+		for client, count := range packingCounts[fingerprints[i]] {
+			total += count
+			if count > bestCount || (count == bestCount && client < bestClient) {
+				bestCount, bestClient = count, client
+			}
+		}
+		if total < clientDiversityPackingMinSamples {
+			continue
+		}
+		share := float64(bestCount) / float64(total)
+		if share >= clientDiversityPackingMinShare {
+			clients[i], confidences[i], signals[i] = bestClient, share*0.5, ClientSignalPacking
+		}
+	}
+
+	slots := make([]int64, len(blocks))
+	epochs := make([]int64, len(blocks))
+	blockProposers := make([]int64, len(blocks))
+	for i, b := range blocks {
+		slots[i] = int64(b.Slot)
+		epochs[i] = int64(b.Epoch)
+		blockProposers[i] = int64(b.Proposer)
+	}
+
+	firstDay := firstEpoch / utils.EpochsPerDay()
+	lastDay := lastEpoch / utils.EpochsPerDay()
+	firstDayEpoch := firstDay * utils.EpochsPerDay()
+	lastDayEpoch := (lastDay+1)*utils.EpochsPerDay() - 1
+
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction for client diversity: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO blocks_clients (slot, epoch, proposer, client, confidence, signal)
+		SELECT * FROM unnest($1::int[], $2::int[], $3::int[], $4::text[], $5::float[], $6::text[])
+		ON CONFLICT (slot) DO UPDATE SET
+			epoch = excluded.epoch,
+			proposer = excluded.proposer,
+			client = excluded.client,
+			confidence = excluded.confidence,
+			signal = excluded.signal`,
+		pq.Array(slots), pq.Array(epochs), pq.Array(blockProposers), pq.Array(clients), pq.Array(confidences), pq.Array(signals))
+	if err != nil {
+		return fmt.Errorf("error writing blocks_clients of epochs %v-%v: %w", firstEpoch, lastEpoch, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO client_diversity_epochs (epoch, client, blocks_count)
+		SELECT epoch, client, COUNT(*)
+		FROM blocks_clients
+		WHERE epoch >= $1 AND epoch <= $2
+		GROUP BY epoch, client
+		ON CONFLICT (epoch, client) DO UPDATE SET blocks_count = excluded.blocks_count`, firstEpoch, lastEpoch)
+	if err != nil {
+		return fmt.Errorf("error writing client_diversity_epochs of epochs %v-%v: %w", firstEpoch, lastEpoch, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO client_diversity_days (day, client, blocks_count, proposers)
+		SELECT epoch / $3, client, COUNT(*), COUNT(DISTINCT proposer)
+		FROM blocks_clients
+		WHERE epoch >= $1 AND epoch <= $2
+		GROUP BY epoch / $3, client
+		ON CONFLICT (day, client) DO UPDATE SET blocks_count = excluded.blocks_count, proposers = excluded.proposers`,
+		firstDayEpoch, lastDayEpoch, utils.EpochsPerDay())
+	if err != nil {
+		return fmt.Errorf("error writing client_diversity_days of days %v-%v: %w", firstDay, lastDay, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO validator_clients (validatorindex, client, blocks_count, graffiti_blocks_count, last_slot)
+		SELECT proposer, client, COUNT(*), COUNT(*) FILTER (WHERE signal = $2), MAX(slot)
+		FROM blocks_clients
+		WHERE proposer = ANY($1)
+		GROUP BY proposer, client
+		ON CONFLICT (validatorindex, client) DO UPDATE SET
+			blocks_count = excluded.blocks_count,
+			graffiti_blocks_count = excluded.graffiti_blocks_count,
+			last_slot = excluded.last_slot`, pq.Array(proposers), ClientSignalGraffiti)
+	if err != nil {
+		return fmt.Errorf("error writing validator_clients of epochs %v-%v: %w", firstEpoch, lastEpoch, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing client diversity of epochs %v-%v: %w", firstEpoch, lastEpoch, err)
+	}
+	return nil
+}
+
+// GetClientDiversity returns the number of blocks attributed to each consensus client between from and to (inclusive),
+// resolution is either "epoch" or "day"
+func GetClientDiversity(resolution string, from, to uint64) ([]*types.ClientDiversityEntry, error) {
+	var query string
+	switch resolution {
+	case "epoch":
+		query = `
+			SELECT epoch AS period, client, blocks_count, 0 AS proposers,
+				blocks_count::float / SUM(blocks_count) OVER (PARTITION BY epoch) AS share
+			FROM client_diversity_epochs
+			WHERE epoch >= $1 AND epoch <= $2
+			ORDER BY epoch, client`
+	case "day":
+		query = `
+			SELECT day AS period, client, blocks_count, proposers,
+				blocks_count::float / SUM(blocks_count) OVER (PARTITION BY day) AS share
+			FROM client_diversity_days
+			WHERE day >= $1 AND day <= $2
+			ORDER BY day, client`
+	default:
+		return nil, fmt.Errorf("invalid resolution %v", resolution)
+	}
+
+	entries := []*types.ClientDiversityEntry{}
+	err := ReaderDb.Select(&entries, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving client diversity by %v: %w", resolution, err)
+	}
+	return entries, nil
+}
+
+// GetValidatorClients returns the consensus clients the blocks of the given validators were attributed to
+func GetValidatorClients(validators []uint64) ([]*types.ValidatorClientEntry, error) {
+	entries := []*types.ValidatorClientEntry{}
+	err := ReaderDb.Select(&entries, `
+		SELECT validatorindex, client, blocks_count, graffiti_blocks_count, last_slot,
+			blocks_count::float / SUM(blocks_count) OVER (PARTITION BY validatorindex) AS share
+		FROM validator_clients
+		WHERE validatorindex = ANY($1)
+		ORDER BY validatorindex, blocks_count DESC`, pq.Array(validators))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator_clients: %w", err)
+	}
+	return entries, nil
+}
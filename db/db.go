@@ -2055,6 +2055,22 @@ func SaveChartSeriesPoint(date time.Time, indicator string, value any) error {
 	return err
 }
 
+// UpdateMarketCapChartSeriesPoint recomputes the MARKET_CAP chart series point of a day from the supply of the day and the given usd price
+func UpdateMarketCapChartSeriesPoint(date time.Time, usd float64, tx *sqlx.Tx) error {
+	if utils.Config.Chain.ClConfig.DepositChainID != 1 {
+		return nil
+	}
+	_, err := tx.Exec(`
+		UPDATE chart_series m SET value = (e.value / 1e18 + $2) * $3
+		FROM chart_series e
+		WHERE m.indicator = 'MARKET_CAP' AND m.time = $1 AND e.indicator = 'TOTAL_EMISSION' AND e.time = $1`,
+		date, mainnetCrowdSaleSupply, usd)
+	if err != nil {
+		return fmt.Errorf("error updating MARKET_CAP chart_series: %w", err)
+	}
+	return nil
+}
+
 func GetSlotWithdrawals(slot uint64) ([]*types.Withdrawals, error) {
 	var withdrawals []*types.Withdrawals
 
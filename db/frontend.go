@@ -405,6 +405,142 @@ func DeleteAllSubscription(userID uint64, network string, eventName types.EventN
 	return err
 }
 
+// AddMachineMetricAlert stores a custom machine metric alert together with the subscription its notifications are sent for
+func AddMachineMetricAlert(alert *types.MachineMetricAlert) error {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	err = tx.Get(&alert.ID, `
+		INSERT INTO users_machine_alerts (user_id, machine, process, field, mode, comparator, threshold, duration_minutes, created_ts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, TO_TIMESTAMP($9))
+		RETURNING id`,
+		alert.UserID, alert.Machine, alert.Process, alert.Field, alert.Mode, alert.Comparator, alert.Threshold, alert.DurationMinutes, now.Unix())
+	if err != nil {
+		return fmt.Errorf("error inserting machine metric alert: %w", err)
+	}
+	alert.CreatedTs = time.Unix(now.Unix(), 0)
+
+	_, err = tx.Exec("INSERT INTO users_subscriptions (user_id, event_name, event_filter, created_ts, created_epoch, event_threshold) VALUES ($1, $2, $3, TO_TIMESTAMP($4), $5, $6)",
+		alert.UserID, types.MonitoringMachineCustomAlertEventName, fmt.Sprintf("%d", alert.ID), now.Unix(), utils.TimeToEpoch(now), alert.Threshold)
+	if err != nil {
+		return fmt.Errorf("error inserting subscription of machine metric alert: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// GetMachineMetricAlerts returns the custom machine metric alerts of a user, alerts that were unsubscribed are omitted
+func GetMachineMetricAlerts(userID uint64) ([]*types.MachineMetricAlert, error) {
+	alerts := []*types.MachineMetricAlert{}
+	err := FrontendReaderDB.Select(&alerts, `
+		SELECT a.id, a.user_id, a.machine, a.process, a.field, a.mode, a.comparator, a.threshold, a.duration_minutes, a.created_ts
+		FROM users_machine_alerts a
+		INNER JOIN users_subscriptions us ON us.user_id = a.user_id AND us.event_name = $2 AND us.event_filter = a.id::TEXT
+		WHERE a.user_id = $1
+		ORDER BY a.id`,
+		userID, types.MonitoringMachineCustomAlertEventName)
+	return alerts, err
+}
+
+// CountMachineMetricAlerts returns the number of custom machine metric alerts of a user, alerts that were unsubscribed are not counted
+func CountMachineMetricAlerts(userID uint64) (uint64, error) {
+	var count uint64
+	err := FrontendWriterDB.Get(&count, `
+		SELECT COUNT(*)
+		FROM users_machine_alerts a
+		INNER JOIN users_subscriptions us ON us.user_id = a.user_id AND us.event_name = $2 AND us.event_filter = a.id::TEXT
+		WHERE a.user_id = $1`,
+		userID, types.MonitoringMachineCustomAlertEventName)
+	return count, err
+}
+
+// MachineMetricAlertMachineFilter returns the event filter of the subscription the notifications of an alert for a single machine are sent for
+func MachineMetricAlertMachineFilter(alertID uint64, machine string) string {
+	return fmt.Sprintf("%d:%s", alertID, machine)
+}
+
+// GetMachineMetricAlertMachineSubscriptions returns the per machine subscriptions of all custom machine metric alerts by their event filter
+func GetMachineMetricAlertMachineSubscriptions() (map[string]*types.Subscription, error) {
+	subs := []*types.Subscription{}
+	err := FrontendWriterDB.Select(&subs, `
+		SELECT id, user_id, event_name, event_filter, last_sent_ts, last_sent_epoch, created_ts, created_epoch, event_threshold, ENCODE(unsubscribe_hash, 'hex') AS unsubscribe_hash
+		FROM users_subscriptions
+		WHERE event_name = $1 AND event_filter LIKE '%:%'`,
+		types.MonitoringMachineCustomAlertEventName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting machine subscriptions of machine metric alerts: %w", err)
+	}
+	subsByFilter := make(map[string]*types.Subscription, len(subs))
+	for _, sub := range subs {
+		subsByFilter[sub.EventFilter] = sub
+	}
+	return subsByFilter, nil
+}
+
+// AddMachineMetricAlertMachineSubscription creates the subscription the notifications of an alert for a single machine are sent for, so the resend interval applies per machine.
+// It shares the unsubscribe hash of the alert subscription, unsubscribing from a notification therefore removes the alert for all machines.
+func AddMachineMetricAlertMachineSubscription(alertSubscriptionID, alertID uint64, machine string, epoch uint64) (uint64, string, error) {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback()
+
+	var unsubscribeHash string
+	err = tx.Get(&unsubscribeHash, `
+		UPDATE users_subscriptions
+		SET unsubscribe_hash = COALESCE(unsubscribe_hash, SHA256(CONVERT_TO(id::TEXT || user_id::TEXT || event_name || created_ts::TEXT, 'UTF8')))
+		WHERE id = $1
+		RETURNING ENCODE(unsubscribe_hash, 'hex')`, alertSubscriptionID)
+	if err != nil {
+		return 0, "", fmt.Errorf("error setting unsubscribe hash of machine metric alert subscription %v: %w", alertSubscriptionID, err)
+	}
+
+	var id uint64
+	now := time.Now()
+	err = tx.Get(&id, `
+		INSERT INTO users_subscriptions (user_id, event_name, event_filter, created_ts, created_epoch, event_threshold, unsubscribe_hash)
+		SELECT user_id, event_name, $2, TO_TIMESTAMP($3), $4, event_threshold, unsubscribe_hash FROM users_subscriptions WHERE id = $1
+		ON CONFLICT (user_id, event_name, event_filter) DO UPDATE SET unsubscribe_hash = excluded.unsubscribe_hash
+		RETURNING id`,
+		alertSubscriptionID, MachineMetricAlertMachineFilter(alertID, machine), now.Unix(), epoch)
+	if err != nil {
+		return 0, "", fmt.Errorf("error inserting machine subscription of machine metric alert %v: %w", alertID, err)
+	}
+
+	return id, unsubscribeHash, tx.Commit()
+}
+
+// DeleteMachineMetricAlert deletes a custom machine metric alert of a user and its subscriptions, it returns false if the alert does not exist
+func DeleteMachineMetricAlert(userID, alertID uint64) (bool, error) {
+	tx, err := FrontendWriterDB.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM users_machine_alerts WHERE user_id = $1 AND id = $2", userID, alertID)
+	if err != nil {
+		return false, fmt.Errorf("error deleting machine metric alert: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec("DELETE FROM users_subscriptions WHERE user_id = $1 AND event_name = $2 AND (event_filter = $3 OR event_filter LIKE $4)",
+		userID, types.MonitoringMachineCustomAlertEventName, fmt.Sprintf("%d", alertID), MachineMetricAlertMachineFilter(alertID, "%"))
+	if err != nil {
+		return false, fmt.Errorf("error deleting subscription of machine metric alert: %w", err)
+	}
+
+	return deleted > 0, tx.Commit()
+}
+
 func InsertMobileSubscription(tx *sql.Tx, userID uint64, paymentDetails types.MobileSubscription, store, receipt string, expiration int64, rejectReson string, extSubscriptionId string) error {
 	now := time.Now()
 	nowTs := now.Unix()
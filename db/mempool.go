@@ -0,0 +1,197 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"github.com/lib/pq"
+)
+
+// SaveMempoolTransactions stores newly seen pending transactions, transactions that are already tracked keep their first seen timestamp.
+// Pending transactions of the same sender and nonce that were seen earlier are marked as replaced.
+func SaveMempoolTransactions(txs []*types.MempoolTransaction) error {
+	if len(txs) == 0 {
+		return nil
+	}
+
+	hashes := make(pq.ByteaArray, 0, len(txs))
+	froms := make(pq.ByteaArray, 0, len(txs))
+	tos := make(pq.ByteaArray, 0, len(txs))
+	methods := make(pq.ByteaArray, 0, len(txs))
+	nonces := make(pq.Int64Array, 0, len(txs))
+	values := make(pq.StringArray, 0, len(txs))
+	gas := make(pq.Int64Array, 0, len(txs))
+	gasPrices := make(pq.StringArray, 0, len(txs))
+	tips := make(pq.StringArray, 0, len(txs))
+	firstSeen := make(pq.Float64Array, 0, len(txs))
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+		froms = append(froms, tx.From)
+		tos = append(tos, tx.To)
+		methods = append(methods, tx.Method)
+		nonces = append(nonces, int64(tx.Nonce))
+		values = append(values, tx.Value.String())
+		gas = append(gas, int64(tx.Gas))
+		gasPrices = append(gasPrices, tx.GasPrice.String())
+		tip := ""
+		if tx.MaxPriorityFeePerGas.Valid {
+			tip = tx.MaxPriorityFeePerGas.Decimal.String()
+		}
+		tips = append(tips, tip)
+		firstSeen = append(firstSeen, float64(tx.FirstSeenTs.UnixMilli())/1000)
+	}
+
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// empty byte arrays are used for missing receivers (contract creations) and methods (plain transfers)
+	_, err = tx.Exec(`
+		INSERT INTO mempool_transactions (hash, from_address, to_address, method, nonce, value, gas, gas_price, max_priority_fee_per_gas, first_seen_ts)
+		SELECT hash, from_address, NULLIF(to_address, '\x'::BYTEA), NULLIF(method, '\x'::BYTEA), nonce, value::NUMERIC, gas, gas_price::NUMERIC, NULLIF(tip, '')::NUMERIC, TO_TIMESTAMP(first_seen)
+		FROM UNNEST($1::BYTEA[], $2::BYTEA[], $3::BYTEA[], $4::BYTEA[], $5::BIGINT[], $6::TEXT[], $7::BIGINT[], $8::TEXT[], $9::TEXT[], $10::DOUBLE PRECISION[])
+			AS t(hash, from_address, to_address, method, nonce, value, gas, gas_price, tip, first_seen)
+		ON CONFLICT (hash) DO NOTHING`,
+		hashes, froms, tos, methods, nonces, values, gas, gasPrices, tips, firstSeen)
+	if err != nil {
+		return fmt.Errorf("error saving mempool transactions: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE mempool_transactions m SET status = $2, replaced_by = n.hash, removed_ts = n.first_seen_ts
+		FROM mempool_transactions n
+		WHERE n.hash = ANY($1) AND m.from_address = n.from_address AND m.nonce = n.nonce AND m.hash <> n.hash
+			AND m.status = $3 AND m.first_seen_ts <= n.first_seen_ts`,
+		hashes, types.MempoolTxStatusReplaced, types.MempoolTxStatusPending)
+	if err != nil {
+		return fmt.Errorf("error marking replaced mempool transactions: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// MarkMempoolTransactionsIncluded marks the tracked transactions of a block as included.
+// Not yet included transactions of the same sender and nonce are marked as replaced by the included transaction.
+func MarkMempoolTransactionsIncluded(blockNumber uint64, blockTs time.Time, hashes [][]byte) (int64, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// transactions that were considered dropped or replaced can still be included, reorged transactions are moved to their new block
+	res, err := tx.Exec(`
+		UPDATE mempool_transactions SET status = $1, block_number = $2, included_ts = $3, removed_ts = NULL, replaced_by = NULL
+		WHERE hash = ANY($4) AND (status <> $1 OR block_number <> $2)`,
+		types.MempoolTxStatusIncluded, blockNumber, blockTs, pq.ByteaArray(hashes))
+	if err != nil {
+		return 0, fmt.Errorf("error marking mempool transactions of block %v as included: %w", blockNumber, err)
+	}
+	included, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE mempool_transactions m SET status = $2, replaced_by = i.hash, removed_ts = $3
+		FROM mempool_transactions i
+		WHERE i.hash = ANY($1) AND m.from_address = i.from_address AND m.nonce = i.nonce AND m.hash <> i.hash AND m.status IN ($4, $5)`,
+		pq.ByteaArray(hashes), types.MempoolTxStatusReplaced, blockTs, types.MempoolTxStatusPending, types.MempoolTxStatusDropped)
+	if err != nil {
+		return 0, fmt.Errorf("error marking mempool transactions replaced by block %v: %w", blockNumber, err)
+	}
+
+	return included, tx.Commit()
+}
+
+// MarkMempoolTransactionsDropped marks pending transactions that were first seen before seenBefore and are no longer part of the mempool as dropped
+func MarkMempoolTransactionsDropped(poolHashes [][]byte, seenBefore, now time.Time) (int64, error) {
+	res, err := WriterDb.Exec(`
+		UPDATE mempool_transactions SET status = $1, removed_ts = $2
+		WHERE status = $3 AND first_seen_ts < $4 AND NOT (hash = ANY($5))`,
+		types.MempoolTxStatusDropped, now, types.MempoolTxStatusPending, seenBefore, pq.ByteaArray(poolHashes))
+	if err != nil {
+		return 0, fmt.Errorf("error marking dropped mempool transactions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// DeleteOldMempoolTransactions removes tracked mempool transactions first seen before the given time
+func DeleteOldMempoolTransactions(before time.Time) (int64, error) {
+	res, err := WriterDb.Exec(`DELETE FROM mempool_transactions WHERE first_seen_ts < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting mempool transactions older than %v: %w", before, err)
+	}
+	return res.RowsAffected()
+}
+
+// GetMempoolTransactions returns the tracked mempool transactions matching the filter, most recently seen first
+func GetMempoolTransactions(filter *types.MempoolTransactionsFilter) ([]*types.MempoolTransaction, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if len(filter.From) > 0 {
+		addCondition("from_address = $%d", filter.From)
+	}
+	if len(filter.To) > 0 {
+		addCondition("to_address = $%d", filter.To)
+	}
+	if len(filter.Method) > 0 {
+		addCondition("method = $%d", filter.Method)
+	}
+	if filter.MinFee != nil {
+		addCondition("gas_price >= $%d", filter.MinFee.String())
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	txs := []*types.MempoolTransaction{}
+	err := ReaderDb.Select(&txs, fmt.Sprintf(`
+		SELECT hash, from_address, to_address, method, nonce, value, gas, gas_price, max_priority_fee_per_gas, first_seen_ts, status, block_number, included_ts, removed_ts, replaced_by
+		FROM mempool_transactions
+		%s
+		ORDER BY first_seen_ts DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving mempool transactions: %w", err)
+	}
+	return txs, nil
+}
+
+// GetMempoolStats summarizes the mempool transactions first seen within the window, the pending count covers all tracked transactions
+func GetMempoolStats(window time.Duration) (*types.MempoolStats, error) {
+	stats := &types.MempoolStats{WindowSeconds: uint64(window.Seconds())}
+	err := ReaderDb.Get(stats, `
+		SELECT
+			(SELECT COUNT(*) FROM mempool_transactions WHERE status = $2) AS pending,
+			COUNT(*) FILTER (WHERE status = $3) AS included,
+			COUNT(*) FILTER (WHERE status = $4) AS replaced,
+			COUNT(*) FILTER (WHERE status = $5) AS dropped,
+			COALESCE(PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY GREATEST(EXTRACT(EPOCH FROM included_ts - first_seen_ts), 0)) FILTER (WHERE status = $3), 0) AS median_time_to_inclusion,
+			COALESCE(PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY GREATEST(EXTRACT(EPOCH FROM included_ts - first_seen_ts), 0)) FILTER (WHERE status = $3), 0) AS p90_time_to_inclusion
+		FROM mempool_transactions
+		WHERE first_seen_ts >= $1`,
+		time.Now().Add(-window), types.MempoolTxStatusPending, types.MempoolTxStatusIncluded, types.MempoolTxStatusReplaced, types.MempoolTxStatusDropped)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving mempool stats: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create rewards_exports table';
+CREATE TABLE IF NOT EXISTS
+    rewards_exports (
+        id VARCHAR(40) NOT NULL,
+        user_id BIGINT,
+        validators INT[] NOT NULL,
+        currency VARCHAR(10) NOT NULL,
+        format VARCHAR(20) NOT NULL,
+        start_day INT NOT NULL,
+        end_day INT NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        error TEXT,
+        created_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+        finished_ts TIMESTAMP WITHOUT TIME ZONE,
+        data bytea,
+        PRIMARY KEY (id)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_rewards_exports_status_created_ts ON rewards_exports (status, created_ts);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop rewards_exports table';
+DROP TABLE IF EXISTS rewards_exports;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create users_reports table';
+CREATE TABLE IF NOT EXISTS
+    users_reports (
+        id BIGSERIAL NOT NULL,
+        user_id BIGINT NOT NULL,
+        subscription_id BIGINT,
+        name VARCHAR(100) NOT NULL DEFAULT '',
+        period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+        period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+        currency VARCHAR(10) NOT NULL,
+        format VARCHAR(20) NOT NULL,
+        filename VARCHAR(200) NOT NULL,
+        data bytea NOT NULL,
+        created_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (id)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_users_reports_user_id ON users_reports (user_id, period_end);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop users_reports table';
+DROP TABLE IF EXISTS users_reports;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create node_jobs_messages table';
+CREATE TABLE IF NOT EXISTS
+    node_jobs_messages (
+        node_job_id VARCHAR(40) NOT NULL,
+        validatorindex BIGINT NOT NULL,
+        status VARCHAR(40) NOT NULL,
+        -- can be one of: SUBMITTED_TO_NODE, COMPLETED, FAILED
+        submit_count INT NOT NULL DEFAULT 0,
+        -- number of beacon nodes that accepted the last submission
+        submitted_nodes INT NOT NULL DEFAULT 0,
+        last_submitted_time TIMESTAMP WITHOUT TIME ZONE,
+        included_slot BIGINT,
+        PRIMARY KEY (node_job_id, validatorindex)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop node_jobs_messages table';
+DROP TABLE IF EXISTS node_jobs_messages;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create validator_stats_intraday table';
+-- rolling aggregate of the current (not yet finalized) day, holds the same columns as validator_stats
+CREATE TABLE IF NOT EXISTS
+    validator_stats_intraday (LIKE validator_stats INCLUDING DEFAULTS);
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - add primary key to validator_stats_intraday table';
+ALTER TABLE validator_stats_intraday ADD PRIMARY KEY (validatorindex, DAY);
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create validator_stats_intraday_status table';
+CREATE TABLE IF NOT EXISTS
+    validator_stats_intraday_status (
+        DAY INT NOT NULL,
+        -- last epoch that has been aggregated into validator_stats_intraday
+        last_epoch INT NOT NULL,
+        updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (DAY)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop validator_stats_intraday_status table';
+DROP TABLE IF EXISTS validator_stats_intraday_status;
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'down SQL query - drop validator_stats_intraday table';
+DROP TABLE IF EXISTS validator_stats_intraday;
+-- +goose StatementEnd
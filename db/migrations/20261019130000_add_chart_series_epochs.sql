@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create chart_series_epochs table';
+CREATE TABLE IF NOT EXISTS
+    chart_series_epochs (
+        epoch INT NOT NULL,
+        indicator CHARACTER VARYING(50) NOT NULL,
+        VALUE FLOAT NOT NULL,
+        PRIMARY KEY (indicator, epoch)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create chart_series_epochs epoch index';
+CREATE INDEX IF NOT EXISTS idx_chart_series_epochs_epoch ON chart_series_epochs (epoch);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop chart_series_epochs table';
+DROP TABLE IF EXISTS chart_series_epochs;
+-- +goose StatementEnd
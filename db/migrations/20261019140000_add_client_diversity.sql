@@ -0,0 +1,73 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create blocks_clients table';
+CREATE TABLE IF NOT EXISTS
+    blocks_clients (
+        slot INT NOT NULL,
+        epoch INT NOT NULL,
+        proposer INT NOT NULL,
+        client CHARACTER VARYING(20) NOT NULL,
+        confidence FLOAT NOT NULL,
+        signal CHARACTER VARYING(20) NOT NULL,
+        PRIMARY KEY (slot)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create blocks_clients epoch index';
+CREATE INDEX IF NOT EXISTS idx_blocks_clients_epoch ON blocks_clients (epoch);
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create blocks_clients proposer index';
+CREATE INDEX IF NOT EXISTS idx_blocks_clients_proposer ON blocks_clients (proposer);
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create client_diversity_epochs table';
+CREATE TABLE IF NOT EXISTS
+    client_diversity_epochs (
+        epoch INT NOT NULL,
+        client CHARACTER VARYING(20) NOT NULL,
+        blocks_count INT NOT NULL,
+        PRIMARY KEY (epoch, client)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create client_diversity_days table';
+CREATE TABLE IF NOT EXISTS
+    client_diversity_days (
+        DAY INT NOT NULL,
+        client CHARACTER VARYING(20) NOT NULL,
+        blocks_count INT NOT NULL,
+        proposers INT NOT NULL,
+        PRIMARY KEY (DAY, client)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create validator_clients table';
+CREATE TABLE IF NOT EXISTS
+    validator_clients (
+        validatorindex INT NOT NULL,
+        client CHARACTER VARYING(20) NOT NULL,
+        blocks_count INT NOT NULL,
+        graffiti_blocks_count INT NOT NULL,
+        last_slot INT NOT NULL,
+        PRIMARY KEY (validatorindex, client)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop validator_clients table';
+DROP TABLE IF EXISTS validator_clients;
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'down SQL query - drop client_diversity_days table';
+DROP TABLE IF EXISTS client_diversity_days;
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'down SQL query - drop client_diversity_epochs table';
+DROP TABLE IF EXISTS client_diversity_epochs;
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'down SQL query - drop blocks_clients table';
+DROP TABLE IF EXISTS blocks_clients;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create relays_blocks_payouts table';
+CREATE TABLE IF NOT EXISTS
+    relays_blocks_payouts (
+        tag_id VARCHAR NOT NULL,
+        block_slot INT NOT NULL,
+        block_root bytea NOT NULL,
+        exec_block_number INT NOT NULL,
+        claimed_value NUMERIC NOT NULL,
+        paid_value NUMERIC NOT NULL,
+        status CHARACTER VARYING(20) NOT NULL,
+        verified_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+        PRIMARY KEY (block_slot, block_root, tag_id)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create relays_blocks_payouts status index';
+CREATE INDEX IF NOT EXISTS idx_relays_blocks_payouts_status ON relays_blocks_payouts (status)
+WHERE
+    status != 'valid';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop relays_blocks_payouts table';
+DROP TABLE IF EXISTS relays_blocks_payouts;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - create relays_bids table';
+CREATE TABLE IF NOT EXISTS
+    relays_bids (
+        tag_id VARCHAR NOT NULL,
+        slot INT NOT NULL,
+        block_hash bytea NOT NULL,
+        builder_pubkey bytea NOT NULL,
+        VALUE NUMERIC NOT NULL,
+        timestamp_ms BIGINT NOT NULL,
+        PRIMARY KEY (slot, tag_id, block_hash)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create relays_bids builder_pubkey index';
+CREATE INDEX IF NOT EXISTS idx_relays_bids_builder_pubkey ON relays_bids (builder_pubkey);
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create relays_bids_slots table';
+CREATE TABLE IF NOT EXISTS
+    relays_bids_slots (
+        tag_id VARCHAR NOT NULL,
+        slot INT NOT NULL,
+        bids_count INT NOT NULL,
+        top_value NUMERIC NOT NULL,
+        PRIMARY KEY (slot, tag_id)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - create relays_bids_slots tag_id index';
+CREATE INDEX IF NOT EXISTS idx_relays_bids_slots_tag_id ON relays_bids_slots (tag_id, slot);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop relays_bids_slots table';
+DROP TABLE IF EXISTS relays_bids_slots;
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'down SQL query - drop relays_bids table';
+DROP TABLE IF EXISTS relays_bids;
+-- +goose StatementEnd
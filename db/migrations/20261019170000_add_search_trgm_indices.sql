@@ -0,0 +1,20 @@
+-- +goose NO TRANSACTION
+-- +goose Up
+SELECT 'up SQL query - add trigram indices for fuzzy search';
+
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_validator_names_name_trgm ON validator_names USING gin (NAME gin_trgm_ops);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_ens_name_trgm ON ens USING gin (ens_name gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+SELECT 'down SQL query - remove trigram indices for fuzzy search';
+
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS idx_validator_names_name_trgm;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS idx_ens_name_trgm;
+-- +goose StatementEnd
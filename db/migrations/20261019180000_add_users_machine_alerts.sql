@@ -0,0 +1,29 @@
+-- +goose Up
+SELECT 'up SQL query - add users_machine_alerts table';
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS
+    users_machine_alerts (
+        id SERIAL NOT NULL,
+        user_id INT NOT NULL,
+        machine TEXT NOT NULL DEFAULT '',
+        process VARCHAR(20) NOT NULL,
+        field VARCHAR(100) NOT NULL,
+        mode VARCHAR(10) NOT NULL DEFAULT 'value',
+        comparator VARCHAR(3) NOT NULL,
+        threshold DOUBLE PRECISION NOT NULL,
+        duration_minutes INT NOT NULL DEFAULT 0,
+        created_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+        PRIMARY KEY (id)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_users_machine_alerts_user_id ON users_machine_alerts (user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+SELECT 'down SQL query - remove users_machine_alerts table';
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS users_machine_alerts;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+SELECT 'up SQL query - add price_provenance table';
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS
+    price_provenance (
+        ts TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+        sources TEXT[] NOT NULL,
+        outliers TEXT[] NOT NULL DEFAULT '{}',
+        confidence DOUBLE PRECISION NOT NULL,
+        quotes JSONB NOT NULL DEFAULT '{}',
+        updated_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (ts)
+    );
+-- +goose StatementEnd
+
+-- +goose Down
+SELECT 'down SQL query - remove price_provenance table';
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS price_provenance;
+-- +goose StatementEnd
@@ -0,0 +1,43 @@
+-- +goose Up
+SELECT 'up SQL query - add mempool_transactions table';
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS
+    mempool_transactions (
+        hash BYTEA NOT NULL,
+        from_address BYTEA NOT NULL,
+        to_address BYTEA,
+        method BYTEA,
+        nonce BIGINT NOT NULL,
+        value NUMERIC NOT NULL DEFAULT 0,
+        gas BIGINT NOT NULL DEFAULT 0,
+        gas_price NUMERIC NOT NULL DEFAULT 0,
+        max_priority_fee_per_gas NUMERIC,
+        first_seen_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+        status VARCHAR(10) NOT NULL DEFAULT 'pending',
+        block_number BIGINT,
+        included_ts TIMESTAMP WITHOUT TIME ZONE,
+        removed_ts TIMESTAMP WITHOUT TIME ZONE,
+        replaced_by BYTEA,
+        PRIMARY KEY (hash)
+    );
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_mempool_transactions_first_seen_ts ON mempool_transactions (first_seen_ts);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_mempool_transactions_from_address_nonce ON mempool_transactions (from_address, nonce);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_mempool_transactions_to_address ON mempool_transactions (to_address);
+-- +goose StatementEnd
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_mempool_transactions_pending ON mempool_transactions (first_seen_ts) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+SELECT 'down SQL query - remove mempool_transactions table';
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS mempool_transactions;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- +goose Up
+SELECT 'up SQL query - add validator_tag_rules table';
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS
+    validator_tag_rules (
+        id SERIAL NOT NULL,
+        tag CHARACTER VARYING(100) NOT NULL,
+        rule_type CHARACTER VARYING(30) NOT NULL,
+        pattern TEXT NOT NULL,
+        enabled BOOLEAN NOT NULL DEFAULT TRUE,
+        source CHARACTER VARYING(50) NOT NULL DEFAULT 'admin',
+        -- the evaluation of a rule continues at its cursors (exclusive upper bounds of the already evaluated deposits, slots and validators)
+        deposit_block_cursor BIGINT NOT NULL DEFAULT 0,
+        slot_cursor BIGINT NOT NULL DEFAULT 0,
+        validator_index_cursor BIGINT NOT NULL DEFAULT 0,
+        updated_ts TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT NOW(),
+        PRIMARY KEY (id),
+        UNIQUE (tag, rule_type, pattern)
+    );
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE validator_tags ADD COLUMN IF NOT EXISTS rule_id INT REFERENCES validator_tag_rules (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_validator_tags_rule_id ON validator_tags (rule_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_validator_tags_tag ON validator_tags (tag);
+-- +goose StatementEnd
+
+-- +goose Down
+SELECT 'down SQL query - remove validator_tag_rules table';
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_validator_tags_tag;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_validator_tags_rule_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE validator_tags DROP COLUMN IF EXISTS rule_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS validator_tag_rules;
+-- +goose StatementEnd
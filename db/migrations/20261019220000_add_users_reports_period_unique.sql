@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - remove duplicate scheduled reports';
+DELETE FROM users_reports a USING users_reports b
+WHERE a.subscription_id = b.subscription_id AND a.period_start = b.period_start AND a.period_end = b.period_end AND a.id > b.id;
+-- +goose StatementEnd
+-- +goose StatementBegin
+SELECT 'up SQL query - add unique index on the period of scheduled reports';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_reports_subscription_period ON users_reports (subscription_id, period_start, period_end);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - drop unique index on the period of scheduled reports';
+DROP INDEX IF EXISTS idx_users_reports_subscription_period;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - add attempts and error to relays_blocks_payouts';
+ALTER TABLE relays_blocks_payouts
+ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 1,
+ADD COLUMN IF NOT EXISTS error TEXT,
+ALTER COLUMN paid_value DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - remove attempts and error from relays_blocks_payouts';
+DELETE FROM relays_blocks_payouts WHERE paid_value IS NULL;
+ALTER TABLE relays_blocks_payouts
+DROP COLUMN IF EXISTS attempts,
+DROP COLUMN IF EXISTS error,
+ALTER COLUMN paid_value SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - add error to relays_bids_slots';
+ALTER TABLE relays_bids_slots
+ADD COLUMN IF NOT EXISTS error TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - remove error from relays_bids_slots';
+DELETE FROM relays_bids_slots WHERE error IS NOT NULL;
+ALTER TABLE relays_bids_slots
+DROP COLUMN IF EXISTS error;
+-- +goose StatementEnd
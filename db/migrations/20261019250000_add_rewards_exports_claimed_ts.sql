@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query - add claimed_ts to rewards_exports';
+ALTER TABLE rewards_exports
+ADD COLUMN IF NOT EXISTS claimed_ts TIMESTAMP WITHOUT TIME ZONE;
+-- +goose StatementEnd
+-- +goose StatementBegin
+UPDATE rewards_exports SET claimed_ts = NOW() WHERE status = 'running';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query - remove claimed_ts from rewards_exports';
+ALTER TABLE rewards_exports
+DROP COLUMN IF EXISTS claimed_ts;
+-- +goose StatementEnd
@@ -2,10 +2,10 @@ package db
 
 import (
 	"bytes"
-	"database/sql"
+	"context"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"strings"
 	"time"
 
@@ -13,6 +13,11 @@ import (
 	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
 
 	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
@@ -49,15 +54,39 @@ func GetNodeJobValidatorInfos(job *types.NodeJob) ([]types.NodeJobValidatorInfo,
 		}
 
 		indicesArr = append(indicesArr, uint64(jobData.Message.ValidatorIndex))
+	} else if job.Type == types.ConsolidationRequestsNodeJobType || job.Type == types.WithdrawalRequestsNodeJobType {
+		requests, err := getExecutionLayerRequests(job)
+		if err != nil {
+			return nil, err
+		}
+		pubkeysArr := [][]byte{}
+		for _, r := range requests {
+			pubkeysArr = append(pubkeysArr, r.SourcePubkey)
+		}
+		dbValis := []types.NodeJobValidatorInfo{}
+		err = WriterDb.Select(&dbValis, `select validatorindex, pubkey, withdrawalcredentials, exitepoch, status from validators where pubkey = any($1) order by validatorindex`, pq.ByteaArray(pubkeysArr))
+		if err != nil {
+			return nil, err
+		}
+		return setNodeJobValidatorInfosStatus(job, dbValis), nil
 	} else {
 		return []types.NodeJobValidatorInfo{}, nil
 	}
 
 	dbValis := []types.NodeJobValidatorInfo{}
-	err := WriterDb.Select(&dbValis, `select validatorindex, pubkey, withdrawalcredentials, exitepoch, status from validators where validatorindex = any($1)`, pq.Array(indicesArr))
+	err := WriterDb.Select(&dbValis, `
+		select v.validatorindex, v.pubkey, v.withdrawalcredentials, v.exitepoch, v.status, m.status as message_status, coalesce(m.submit_count, 0) as submit_count, coalesce(m.submitted_nodes, 0) as submitted_nodes, m.included_slot
+		from validators v
+		left join node_jobs_messages m on m.node_job_id = $2 and m.validatorindex = v.validatorindex
+		where v.validatorindex = any($1)
+		order by v.validatorindex`, pq.Array(indicesArr), job.ID)
 	if err != nil {
 		return nil, err
 	}
+	return setNodeJobValidatorInfosStatus(job, dbValis), nil
+}
+
+func setNodeJobValidatorInfosStatus(job *types.NodeJob, dbValis []types.NodeJobValidatorInfo) []types.NodeJobValidatorInfo {
 	jobStatus := "Pending"
 	switch job.Status {
 	case types.SubmittedToNodeNodeJobStatus:
@@ -69,12 +98,20 @@ func GetNodeJobValidatorInfos(job *types.NodeJob) ([]types.NodeJobValidatorInfo,
 	}
 	for i, info := range dbValis {
 		status := jobStatus
+		switch types.NodeJobStatus(info.MessageStatus.String) {
+		case types.SubmittedToNodeNodeJobStatus:
+			status = fmt.Sprintf("Submitted to %v node(s), waiting for inclusion (submission %v)", info.SubmittedNodes, info.SubmitCount)
+		case types.CompletedNodeJobStatus:
+			status = "Included"
+		case types.FailedNodeJobStatus:
+			status = fmt.Sprintf("Not included after %v submissions", info.SubmitCount)
+		}
 		if strings.HasPrefix(info.Status, "exit") && job.Type == types.BLSToExecutionChangesNodeJobType {
 			status = fmt.Sprintf("%s (Validator Status: Exited)", status)
 		}
 		dbValis[i].Status = status
 	}
-	return dbValis, nil
+	return dbValis
 }
 
 func CreateNodeJob(data []byte) (*types.NodeJob, error) {
@@ -89,6 +126,10 @@ func CreateNodeJob(data []byte) (*types.NodeJob, error) {
 		return CreateBLSToExecutionChangesNodeJob(j)
 	case types.VoluntaryExitsNodeJobType:
 		return CreateVoluntaryExitNodeJob(j)
+	case types.ConsolidationRequestsNodeJobType:
+		return CreateConsolidationRequestsNodeJob(j)
+	case types.WithdrawalRequestsNodeJobType:
+		return CreateWithdrawalRequestsNodeJob(j)
 	}
 }
 
@@ -102,6 +143,14 @@ func UpdateNodeJobs() error {
 	if err != nil {
 		return fmt.Errorf("error updating voluntary-exit-job: %w", err)
 	}
+	err = UpdateExecutionLayerRequestsNodeJobs(types.ConsolidationRequestsNodeJobType)
+	if err != nil {
+		return fmt.Errorf("error updating consolidation-requests-job: %w", err)
+	}
+	err = UpdateExecutionLayerRequestsNodeJobs(types.WithdrawalRequestsNodeJobType)
+	if err != nil {
+		return fmt.Errorf("error updating withdrawal-requests-job: %w", err)
+	}
 	return nil
 }
 
@@ -115,6 +164,14 @@ func SubmitNodeJobs() error {
 	if err != nil {
 		return err
 	}
+	err = SubmitExecutionLayerRequestsNodeJobs(types.ConsolidationRequestsNodeJobType)
+	if err != nil {
+		return err
+	}
+	err = SubmitExecutionLayerRequestsNodeJobs(types.WithdrawalRequestsNodeJobType)
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -246,41 +303,28 @@ func UpdateBLSToExecutionChangesNodeJob(job *types.NodeJob) error {
 	if !ok {
 		return fmt.Errorf("invalid job-data")
 	}
-	toCheck := map[uint64]bool{}
 	indicesArr := []uint64{}
 	for _, op := range jobData {
 		indicesArr = append(indicesArr, uint64(op.Message.ValidatorIndex))
-		toCheck[uint64(op.Message.ValidatorIndex)] = true
 	}
-	dbValis := []struct {
-		Index                 uint64 `db:"validatorindex"`
-		WithdrawalCredentials []byte `db:"withdrawalcredentials"`
-	}{}
 	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "status": job.Status, "validators": len(indicesArr)}).Infof("checking node_job")
-	err := WriterDb.Select(&dbValis, `select validatorindex, withdrawalcredentials from validators where validatorindex = any($1)`, pq.Array(indicesArr))
-	if err != nil {
-		return err
-	}
-
-	for _, v := range dbValis {
-		if v.WithdrawalCredentials[0] == 1 {
-			delete(toCheck, v.Index)
-		} else {
-			// not all valis have been updated yet
-			return nil
+	return trackNodeJobInclusion(job, indicesArr, "blocks_bls_change", func(indices []uint64) (int, error) {
+		resubmit := map[uint64]bool{}
+		for _, idx := range indices {
+			resubmit[idx] = true
 		}
-		if len(toCheck) == 0 {
-			// all validatrors have been completed
-			job.CompletedTime.Time = time.Now()
-			job.CompletedTime.Valid = true
-			_, err = WriterDb.Exec(`update node_jobs set status = $1, completed_time = $2 where id = $3`, types.CompletedNodeJobStatus, job.CompletedTime.Time, job.ID)
-			if err != nil {
-				return err
+		ops := []*capella.SignedBLSToExecutionChange{}
+		for _, op := range jobData {
+			if resubmit[uint64(op.Message.ValidatorIndex)] {
+				ops = append(ops, op)
 			}
-			logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "status": types.CompletedNodeJobStatus, "validators": len(indicesArr)}).Infof("updated node_job")
 		}
-	}
-	return nil
+		data, err := json.Marshal(ops)
+		if err != nil {
+			return 0, err
+		}
+		return submitToBeaconNodes(job, "/eth/v1/beacon/pool/bls_to_execution_changes", data), nil
+	})
 }
 
 func SubmitBLSToExecutionChangesNodeJobs() error {
@@ -304,25 +348,32 @@ func SubmitBLSToExecutionChangesNodeJobs() error {
 }
 
 func SubmitBLSToExecutionChangesNodeJob(job *types.NodeJob) error {
-	client := &http.Client{Timeout: time.Second * 10}
-	url := fmt.Sprintf("%s/eth/v1/beacon/pool/bls_to_execution_changes", utils.Config.NodeJobsProcessor.ClEndpoint)
-	resp, err := client.Post(url, "application/json", bytes.NewReader(job.RawData))
-	if err != nil {
-		return err
+	jobData, ok := job.GetBLSToExecutionChangesNodeJobData()
+	if !ok {
+		return fmt.Errorf("invalid job-data")
 	}
+	indicesArr := []uint64{}
+	for _, op := range jobData {
+		indicesArr = append(indicesArr, uint64(op.Message.ValidatorIndex))
+	}
+	accepted := submitToBeaconNodes(job, "/eth/v1/beacon/pool/bls_to_execution_changes", job.RawData)
+	return saveNodeJobSubmitted(job, indicesArr, accepted)
+}
+
+// saveNodeJobSubmitted stores the result of the first submission of a job, it fails if no beacon node accepted the job
+func saveNodeJobSubmitted(job *types.NodeJob, indices []uint64, accepted int) error {
 	jobStatus := types.SubmittedToNodeNodeJobStatus
-	if resp.StatusCode != 200 {
-		d, _ := io.ReadAll(resp.Body)
-		if len(d) > 1000 {
-			d = d[:1000]
-		}
+	if accepted == 0 {
 		jobStatus = types.FailedNodeJobStatus
-		logrus.WithFields(logrus.Fields{"data": string(d), "status": resp.Status, "jobID": job.ID}).Warnf("failed submitting a job")
 	}
 	job.Status = jobStatus
 	job.SubmittedToNodeTime.Time = time.Now()
 	job.SubmittedToNodeTime.Valid = true
-	_, err = WriterDb.Exec(`update node_jobs set status = $1, submitted_to_node_time = $2 where id = $3`, job.Status, job.SubmittedToNodeTime.Time, job.ID)
+	_, err := WriterDb.Exec(`update node_jobs set status = $1, submitted_to_node_time = $2 where id = $3`, job.Status, job.SubmittedToNodeTime.Time, job.ID)
+	if err != nil {
+		return err
+	}
+	err = saveNodeJobSubmission(job, indices, accepted)
 	if err != nil {
 		return err
 	}
@@ -396,21 +447,24 @@ func UpdateVoluntaryExitNodeJob(job *types.NodeJob) error {
 	if !ok {
 		return fmt.Errorf("invalid job-data")
 	}
-	dbResult := struct {
-		Status string `db:"status"`
-	}{}
-	err := WriterDb.Get(&dbResult, `select status from validators where validatorindex = $1`, uint64(jobData.Message.ValidatorIndex))
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("validator not found")
-	}
+	return trackNodeJobInclusion(job, []uint64{uint64(jobData.Message.ValidatorIndex)}, "blocks_voluntaryexits", func(indices []uint64) (int, error) {
+		return submitToBeaconNodes(job, "/eth/v1/beacon/pool/voluntary_exits", job.RawData), nil
+	})
+}
+
+func SubmitVoluntaryExitNodeJobs() error {
+	maxSubmittedJobs := 100
+	jobs := []*types.NodeJob{}
+	err := WriterDb.Select(&jobs, `select id, type, status, created_time, submitted_to_node_time, completed_time, data from node_jobs where type = $1 and status = $2 order by created_time limit $4-(select count(*) from node_jobs where type = $1 and status = $3)`, types.VoluntaryExitsNodeJobType, types.PendingNodeJobStatus, types.SubmittedToNodeNodeJobStatus, maxSubmittedJobs)
 	if err != nil {
 		return err
 	}
-	if strings.HasPrefix(dbResult.Status, "exit") {
-		job.Status = types.CompletedNodeJobStatus
-		job.CompletedTime.Time = time.Now()
-		job.CompletedTime.Valid = true
-		_, err = WriterDb.Exec(`update node_jobs set status = $1, completed_time = $2 where id = $3`, job.Status, job.CompletedTime.Time, job.ID)
+	for _, job := range jobs {
+		err = job.ParseData()
+		if err != nil {
+			return err
+		}
+		err = SubmitVoluntaryExitNodeJob(job)
 		if err != nil {
 			return err
 		}
@@ -418,19 +472,211 @@ func UpdateVoluntaryExitNodeJob(job *types.NodeJob) error {
 	return nil
 }
 
-func SubmitVoluntaryExitNodeJobs() error {
-	maxSubmittedJobs := 100
+func SubmitVoluntaryExitNodeJob(job *types.NodeJob) error {
+	jobData, ok := job.GetVoluntaryExitsNodeJobData()
+	if !ok {
+		return fmt.Errorf("invalid job-data")
+	}
+	accepted := submitToBeaconNodes(job, "/eth/v1/beacon/pool/voluntary_exits", job.RawData)
+	return saveNodeJobSubmitted(job, []uint64{uint64(jobData.Message.ValidatorIndex)}, accepted)
+}
+
+// getExecutionLayerRequests decodes and verifies the signed transactions of a consolidation- or withdrawal-requests job
+func getExecutionLayerRequests(job *types.NodeJob) ([]*types.ExecutionLayerRequest, error) {
+	var rawTxs []hexutil.Bytes
+	var verify func([]byte) (*types.ExecutionLayerRequest, error)
+	switch job.Type {
+	case types.ConsolidationRequestsNodeJobType:
+		d, ok := job.GetConsolidationRequestsNodeJobData()
+		if !ok {
+			return nil, fmt.Errorf("invalid consolidation requests job-data")
+		}
+		rawTxs = d.ConsolidationRequests
+		verify = utils.VerifyConsolidationRequestTransaction
+	case types.WithdrawalRequestsNodeJobType:
+		d, ok := job.GetWithdrawalRequestsNodeJobData()
+		if !ok {
+			return nil, fmt.Errorf("invalid withdrawal requests job-data")
+		}
+		rawTxs = d.WithdrawalRequests
+		verify = utils.VerifyWithdrawalRequestTransaction
+	default:
+		return nil, fmt.Errorf("job-type %v has no execution layer requests", job.Type)
+	}
+	requests := make([]*types.ExecutionLayerRequest, 0, len(rawTxs))
+	for i, rawTx := range rawTxs {
+		r, err := verify(rawTx)
+		if err != nil {
+			return nil, types.CreateNodeJobUserError{Message: fmt.Sprintf("can not verify transaction %v: %v", i, err)}
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+type executionLayerRequestValidator struct {
+	Index                 uint64 `db:"validatorindex"`
+	Pubkey                []byte `db:"pubkey"`
+	WithdrawalCredentials []byte `db:"withdrawalcredentials"`
+	Status                string `db:"status"`
+}
+
+func (v executionLayerRequestValidator) checkRequestSource(sender common.Address) error {
+	if len(v.WithdrawalCredentials) != 32 || (v.WithdrawalCredentials[0] != 1 && v.WithdrawalCredentials[0] != 2) {
+		return fmt.Errorf("validator with index %v has no execution layer withdrawal credentials", v.Index)
+	}
+	if !bytes.Equal(v.WithdrawalCredentials[12:], sender.Bytes()) {
+		return fmt.Errorf("transaction sender %v does not match the withdrawal address of validator with index %v", sender.Hex(), v.Index)
+	}
+	return v.checkActive()
+}
+
+func (v executionLayerRequestValidator) checkActive() error {
+	switch v.Status {
+	case "exited", "exiting_online", "exiting_offline":
+		return fmt.Errorf("validator with index %v has exited", v.Index)
+	case "slashed", "slashing_offline", "slashing_online":
+		return fmt.Errorf("validator with index %v has been slashed", v.Index)
+	case "deposited", "pending":
+		return fmt.Errorf("validator with index %v is not active yet", v.Index)
+	default:
+		return nil
+	}
+}
+
+func getExecutionLayerRequestValidators(requests []*types.ExecutionLayerRequest) (map[string]executionLayerRequestValidator, error) {
+	pubkeysArr := [][]byte{}
+	for _, r := range requests {
+		pubkeysArr = append(pubkeysArr, r.SourcePubkey)
+		if len(r.TargetPubkey) > 0 {
+			pubkeysArr = append(pubkeysArr, r.TargetPubkey)
+		}
+	}
+	dbValis := []executionLayerRequestValidator{}
+	err := WriterDb.Select(&dbValis, `select validatorindex, pubkey, withdrawalcredentials, status from validators where pubkey = any($1)`, pq.ByteaArray(pubkeysArr))
+	if err != nil {
+		return nil, err
+	}
+	valisByPubkey := make(map[string]executionLayerRequestValidator, len(dbValis))
+	for _, v := range dbValis {
+		valisByPubkey[hex.EncodeToString(v.Pubkey)] = v
+	}
+	return valisByPubkey, nil
+}
+
+func CreateConsolidationRequestsNodeJob(nj *types.NodeJob) (*types.NodeJob, error) {
+	return createExecutionLayerRequestsNodeJob(nj, checkConsolidationRequest)
+}
+
+func CreateWithdrawalRequestsNodeJob(nj *types.NodeJob) (*types.NodeJob, error) {
+	return createExecutionLayerRequestsNodeJob(nj, checkWithdrawalRequest)
+}
+
+func createExecutionLayerRequestsNodeJob(nj *types.NodeJob, check func(*types.ExecutionLayerRequest, map[string]executionLayerRequestValidator) (*executionLayerRequestValidator, error)) (*types.NodeJob, error) {
+	if len(nj.RawData) > 1e6 {
+		return nil, types.CreateNodeJobUserError{Message: "data-size exceeds maximum of 1MB"}
+	}
+	requests, err := getExecutionLayerRequests(nj)
+	if err != nil {
+		return nil, err
+	}
+	valisByPubkey, err := getExecutionLayerRequestValidators(requests)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for _, r := range requests {
+		source, err := check(r, valisByPubkey)
+		if err != nil {
+			return nil, types.CreateNodeJobUserError{Message: err.Error()}
+		}
+		if seen[hex.EncodeToString(r.SourcePubkey)] {
+			return nil, types.CreateNodeJobUserError{Message: fmt.Sprintf("multiple entries for the same validator: %v", source.Index)}
+		}
+		seen[hex.EncodeToString(r.SourcePubkey)] = true
+	}
+
+	return insertExecutionLayerRequestsNodeJob(nj, len(requests))
+}
+
+// checkConsolidationRequest checks a consolidation request against the current validator state and returns the source validator
+func checkConsolidationRequest(r *types.ExecutionLayerRequest, valisByPubkey map[string]executionLayerRequestValidator) (*executionLayerRequestValidator, error) {
+	source, exists := valisByPubkey[hex.EncodeToString(r.SourcePubkey)]
+	if !exists {
+		return nil, fmt.Errorf("unknown source validator %#x", r.SourcePubkey)
+	}
+	target, exists := valisByPubkey[hex.EncodeToString(r.TargetPubkey)]
+	if !exists {
+		return &source, fmt.Errorf("unknown target validator %#x", r.TargetPubkey)
+	}
+	err := source.checkRequestSource(r.Sender)
+	if err != nil {
+		return &source, err
+	}
+	err = target.checkActive()
+	if err != nil {
+		return &source, err
+	}
+	if source.Index == target.Index {
+		// a request with source == target switches the validator to compounding credentials (0x01 -> 0x02)
+		if source.WithdrawalCredentials[0] != 1 {
+			return &source, fmt.Errorf("validator with index %v already has compounding withdrawal credentials", source.Index)
+		}
+	} else if len(target.WithdrawalCredentials) == 0 || target.WithdrawalCredentials[0] != 2 {
+		return &source, fmt.Errorf("target validator with index %v has no compounding withdrawal credentials", target.Index)
+	}
+	return &source, nil
+}
+
+// checkWithdrawalRequest checks a withdrawal request against the current validator state and returns the source validator
+func checkWithdrawalRequest(r *types.ExecutionLayerRequest, valisByPubkey map[string]executionLayerRequestValidator) (*executionLayerRequestValidator, error) {
+	source, exists := valisByPubkey[hex.EncodeToString(r.SourcePubkey)]
+	if !exists {
+		return nil, fmt.Errorf("unknown validator %#x", r.SourcePubkey)
+	}
+	err := source.checkRequestSource(r.Sender)
+	if err != nil {
+		return &source, err
+	}
+	// partial withdrawals are only processed for validators with compounding credentials
+	if r.Amount != 0 && source.WithdrawalCredentials[0] != 2 {
+		return &source, fmt.Errorf("partial withdrawal requested for validator with index %v which has no compounding withdrawal credentials", source.Index)
+	}
+	return &source, nil
+}
+
+func insertExecutionLayerRequestsNodeJob(nj *types.NodeJob, requestCount int) (*types.NodeJob, error) {
+	nj.ID = uuid.New().String()
+	nj.Status = types.PendingNodeJobStatus
+	_, err := WriterDb.Exec(`insert into node_jobs (id, type, status, data, created_time) values ($1, $2, $3, $4, now())`, nj.ID, nj.Type, nj.Status, nj.RawData)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting into node_jobs: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{"id": nj.ID, "type": nj.Type, "requests": requestCount}).Infof("created node_job")
+	return nj, nil
+}
+
+func UpdateExecutionLayerRequestsNodeJobs(jobType types.NodeJobType) error {
 	jobs := []*types.NodeJob{}
-	err := WriterDb.Select(&jobs, `select id, type, status, created_time, submitted_to_node_time, completed_time, data from node_jobs where type = $1 and status = $2 order by created_time limit $4-(select count(*) from node_jobs where type = $1 and status = $3)`, types.VoluntaryExitsNodeJobType, types.PendingNodeJobStatus, types.SubmittedToNodeNodeJobStatus, maxSubmittedJobs)
+	err := WriterDb.Select(&jobs, `select id, type, status, created_time, submitted_to_node_time, completed_time, data from node_jobs where type = $1 and status = $2`, jobType, types.SubmittedToNodeNodeJobStatus)
 	if err != nil {
 		return err
 	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	client, err := ethclient.Dial(utils.Config.NodeJobsProcessor.ElEndpoint)
+	if err != nil {
+		return fmt.Errorf("error dialing el-endpoint: %w", err)
+	}
+	defer client.Close()
 	for _, job := range jobs {
-		err = job.ParseData()
+		err := job.ParseData()
 		if err != nil {
 			return err
 		}
-		err = SubmitVoluntaryExitNodeJob(job)
+		err = UpdateExecutionLayerRequestsNodeJob(client, job)
 		if err != nil {
 			return err
 		}
@@ -438,29 +684,104 @@ func SubmitVoluntaryExitNodeJobs() error {
 	return nil
 }
 
-func SubmitVoluntaryExitNodeJob(job *types.NodeJob) error {
-	client := &http.Client{Timeout: time.Second * 10}
-	url := fmt.Sprintf("%s/eth/v1/beacon/pool/voluntary_exits", utils.Config.NodeJobsProcessor.ClEndpoint)
-	resp, err := client.Post(url, "application/json", bytes.NewReader(job.RawData))
+// UpdateExecutionLayerRequestsNodeJob completes the job once all request transactions have been included successfully,
+// it fails the job if any transaction reverted or has not been included within a day
+func UpdateExecutionLayerRequestsNodeJob(client *ethclient.Client, job *types.NodeJob) error {
+	requests, err := getExecutionLayerRequests(job)
 	if err != nil {
 		return err
 	}
-	jobStatus := types.SubmittedToNodeNodeJobStatus
-	if resp.StatusCode != 200 {
-		d, _ := io.ReadAll(resp.Body)
-		if len(d) > 1000 {
-			d = d[:1000]
+	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "status": job.Status, "requests": len(requests)}).Infof("checking node_job")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	jobStatus := types.CompletedNodeJobStatus
+	for _, r := range requests {
+		receipt, err := client.TransactionReceipt(ctx, r.TxHash)
+		if err == ethereum.NotFound {
+			if job.SubmittedToNodeTime.Valid && time.Since(job.SubmittedToNodeTime.Time) > time.Hour*24 {
+				logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "tx": r.TxHash.Hex()}).Warnf("node_job transaction has not been included")
+				jobStatus = types.FailedNodeJobStatus
+				break
+			}
+			// not all transactions have been included yet
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting receipt of tx %v: %w", r.TxHash.Hex(), err)
+		}
+		if receipt.Status != gethtypes.ReceiptStatusSuccessful {
+			logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "tx": r.TxHash.Hex()}).Warnf("node_job transaction failed")
+			jobStatus = types.FailedNodeJobStatus
+			break
+		}
+	}
+
+	job.Status = jobStatus
+	job.CompletedTime.Time = time.Now()
+	job.CompletedTime.Valid = true
+	_, err = WriterDb.Exec(`update node_jobs set status = $1, completed_time = $2 where id = $3`, job.Status, job.CompletedTime.Time, job.ID)
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "status": job.Status, "requests": len(requests)}).Infof("updated node_job")
+	return nil
+}
+
+func SubmitExecutionLayerRequestsNodeJobs(jobType types.NodeJobType) error {
+	maxSubmittedJobs := 100
+	jobs := []*types.NodeJob{}
+	err := WriterDb.Select(&jobs, `select id, type, status, created_time, submitted_to_node_time, completed_time, data from node_jobs where type = $1 and status = $2 order by created_time limit $4-(select count(*) from node_jobs where type = $1 and status = $3)`, jobType, types.PendingNodeJobStatus, types.SubmittedToNodeNodeJobStatus, maxSubmittedJobs)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		err = job.ParseData()
+		if err != nil {
+			return err
+		}
+		err = SubmitExecutionLayerRequestsNodeJob(job)
+		if err != nil {
+			return fmt.Errorf("error calling SubmitExecutionLayerRequestsNodeJob for job %v: %w", job.ID, err)
 		}
+	}
+	return nil
+}
+
+// SubmitExecutionLayerRequestsNodeJob sends the request transactions of a job to all configured execution nodes,
+// the job fails if a transaction was not accepted by any node
+func SubmitExecutionLayerRequestsNodeJob(job *types.NodeJob) error {
+	var rawTxs []hexutil.Bytes
+	if d, ok := job.GetConsolidationRequestsNodeJobData(); ok {
+		rawTxs = d.ConsolidationRequests
+	} else if d, ok := job.GetWithdrawalRequestsNodeJobData(); ok {
+		rawTxs = d.WithdrawalRequests
+	} else {
+		return fmt.Errorf("invalid job-data")
+	}
+
+	txs := make([]*gethtypes.Transaction, 0, len(rawTxs))
+	for _, rawTx := range rawTxs {
+		tx := &gethtypes.Transaction{}
+		err := tx.UnmarshalBinary(rawTx)
+		if err != nil {
+			return err
+		}
+		txs = append(txs, tx)
+	}
+
+	jobStatus := types.SubmittedToNodeNodeJobStatus
+	if submitToExecutionNodes(job, txs) == 0 {
 		jobStatus = types.FailedNodeJobStatus
-		logrus.WithFields(logrus.Fields{"res": string(d), "status": resp.Status, "jobID": job.ID, "jobType": job.Type}).Warnf("failed submitting a job")
 	}
 	job.Status = jobStatus
 	job.SubmittedToNodeTime.Time = time.Now()
 	job.SubmittedToNodeTime.Valid = true
-	_, err = WriterDb.Exec(`update node_jobs set status = $1, submitted_to_node_time = $2 where id = $3`, job.Status, job.SubmittedToNodeTime.Time, job.ID)
+	_, err := WriterDb.Exec(`update node_jobs set status = $1, submitted_to_node_time = $2 where id = $3`, job.Status, job.SubmittedToNodeTime.Time, job.ID)
 	if err != nil {
 		return err
 	}
-	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type}).Infof("submitted node_job")
+	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "status": jobStatus}).Infof("submitted node_job")
 	return nil
 }
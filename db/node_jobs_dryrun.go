@@ -0,0 +1,309 @@
+package db
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	ethutil "github.com/wealdtech/go-eth2-util"
+)
+
+// minimum number of epochs a validator has to be active before it can exit (SHARD_COMMITTEE_PERIOD)
+const nodeJobDryRunShardCommitteePeriod = 256
+
+type nodeJobDryRunValidator struct {
+	Index                 uint64 `db:"validatorindex"`
+	Pubkey                []byte `db:"pubkey"`
+	WithdrawalCredentials []byte `db:"withdrawalcredentials"`
+	ActivationEpoch       uint64 `db:"activationepoch"`
+	Status                string `db:"status"`
+}
+
+// DryRunNodeJob runs all checks of CreateNodeJob against every message of the job without queueing it.
+// Signatures are verified with a single batch verification, only if that fails the messages are verified one by one.
+func DryRunNodeJob(data []byte) (*types.NodeJobDryRunReport, error) {
+	j, err := types.NewNodeJob(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(j.RawData) > 1e6 {
+		return nil, types.CreateNodeJobUserError{Message: "data-size exceeds maximum of 1MB"}
+	}
+
+	start := time.Now()
+	report := &types.NodeJobDryRunReport{Type: j.Type}
+	switch j.Type {
+	default:
+		return nil, fmt.Errorf("unknown job-type %v", j.Type)
+	case types.BLSToExecutionChangesNodeJobType:
+		err = dryRunBLSToExecutionChangesNodeJob(j, report)
+	case types.VoluntaryExitsNodeJobType:
+		err = dryRunVoluntaryExitNodeJob(j, report)
+	case types.ConsolidationRequestsNodeJobType, types.WithdrawalRequestsNodeJobType:
+		err = dryRunExecutionLayerRequestsNodeJob(j, report)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report.Valid = true
+	for _, m := range report.Messages {
+		m.Valid = len(m.Errors) == 0
+		if !m.Valid {
+			report.Valid = false
+		}
+	}
+	logrus.WithFields(logrus.Fields{"type": j.Type, "messages": len(report.Messages), "valid": report.Valid, "duration": time.Since(start)}).Infof("dry-ran node_job")
+	return report, nil
+}
+
+func getNodeJobDryRunValidators(indices []uint64) (map[uint64]nodeJobDryRunValidator, error) {
+	dbValis := []nodeJobDryRunValidator{}
+	err := WriterDb.Select(&dbValis, `select validatorindex, pubkey, withdrawalcredentials, activationepoch, status from validators where validatorindex = any($1)`, pq.Array(indices))
+	if err != nil {
+		return nil, err
+	}
+	valis := make(map[uint64]nodeJobDryRunValidator, len(dbValis))
+	for _, v := range dbValis {
+		valis[v.Index] = v
+	}
+	return valis, nil
+}
+
+// dryRunVerifySignatures batch-verifies the given signature sets (one per message). If the batch does not verify each set is
+// verified on its own and messages with invalid signatures are checked against the other fork versions of the chain to detect
+// messages that have been signed for the wrong fork.
+func dryRunVerifySignatures(report *types.NodeJobDryRunReport, messages []*types.NodeJobDryRunMessage, sets []utils.BlsSignatureSet, expectedFork string, signingRoot func(i int, forkVersion []byte) (phase0.Root, error)) {
+	ok, err := utils.BatchVerifyBlsSignatures(sets)
+	if err == nil && ok {
+		report.BatchVerified = true
+		return
+	}
+
+	forkVersions := utils.ForkVersions()
+	forkNames := make([]string, 0, len(forkVersions))
+	for name := range forkVersions {
+		forkNames = append(forkNames, name)
+	}
+	sort.Strings(forkNames)
+
+	for i, set := range sets {
+		ok, err := utils.BatchVerifyBlsSignatures([]utils.BlsSignatureSet{set})
+		if err != nil {
+			messages[i].AddError("%v", err)
+			continue
+		}
+		if ok {
+			continue
+		}
+		wrongFork := ""
+		for _, name := range forkNames {
+			if name == expectedFork {
+				continue
+			}
+			root, err := signingRoot(i, forkVersions[name])
+			if err != nil {
+				continue
+			}
+			set.SigningRoot = root
+			ok, err := utils.BatchVerifyBlsSignatures([]utils.BlsSignatureSet{set})
+			if err == nil && ok {
+				wrongFork = name
+				break
+			}
+		}
+		if wrongFork != "" {
+			messages[i].AddError("signature has been created with the %v fork version %#x, expected the %v fork version", wrongFork, forkVersions[wrongFork], expectedFork)
+		} else {
+			messages[i].AddError("signature does not verify")
+		}
+	}
+}
+
+func dryRunBLSToExecutionChangesNodeJob(j *types.NodeJob, report *types.NodeJobDryRunReport) error {
+	ops, ok := j.GetBLSToExecutionChangesNodeJobData()
+	if !ok {
+		return types.CreateNodeJobUserError{Message: "invalid data"}
+	}
+
+	indices := make([]uint64, 0, len(ops))
+	seen := map[uint64]bool{}
+	for i, op := range ops {
+		m := &types.NodeJobDryRunMessage{Position: i, ValidatorIndex: uint64(op.Message.ValidatorIndex)}
+		if seen[m.ValidatorIndex] {
+			m.AddError("multiple entries for the same validator")
+		}
+		seen[m.ValidatorIndex] = true
+		indices = append(indices, m.ValidatorIndex)
+		report.Messages = append(report.Messages, m)
+	}
+
+	valis, err := getNodeJobDryRunValidators(indices)
+	if err != nil {
+		return err
+	}
+	queued := []uint64{}
+	err = WriterDb.Select(&queued, `select validatorindex from node_jobs_bls_changes_validators where validatorindex = any($1)`, pq.Array(indices))
+	if err != nil {
+		return err
+	}
+	queuedByIndex := make(map[uint64]bool, len(queued))
+	for _, idx := range queued {
+		queuedByIndex[idx] = true
+	}
+
+	genesisForkVersion := utils.MustParseHex(utils.Config.Chain.ClConfig.GenesisForkVersion)
+	sets := make([]utils.BlsSignatureSet, 0, len(ops))
+	setMessages := make([]*types.NodeJobDryRunMessage, 0, len(ops))
+	setOps := make([]int, 0, len(ops))
+	for i, op := range ops {
+		m := report.Messages[i]
+		v, exists := valis[m.ValidatorIndex]
+		if !exists {
+			m.AddError("unknown validator")
+		} else {
+			m.Pubkey = v.Pubkey
+			withdrawalCredentials := ethutil.SHA256(op.Message.FromBLSPubkey[:])
+			withdrawalCredentials[0] = byte(0) // BLS_WITHDRAWAL_PREFIX
+			switch {
+			case len(v.WithdrawalCredentials) > 0 && v.WithdrawalCredentials[0] != 0:
+				m.AddError("validator already has 0x%02x withdrawal credentials", v.WithdrawalCredentials[0])
+			case hex.EncodeToString(withdrawalCredentials) != hex.EncodeToString(v.WithdrawalCredentials):
+				m.AddError("fromBLSPubkey does not match the withdrawal credentials of the validator")
+			}
+			if strings.HasPrefix(v.Status, "exit") || strings.HasPrefix(v.Status, "slash") {
+				m.AddWarning("validator has already exited, the withdrawal address will only be used for the remaining balance")
+			}
+		}
+		if queuedByIndex[m.ValidatorIndex] {
+			m.AddError("there is already a job for this validator")
+		}
+
+		root, err := utils.BlsToExecutionChangeSigningRoot(op, genesisForkVersion)
+		if err != nil {
+			m.AddError("%v", err)
+			continue
+		}
+		sets = append(sets, utils.BlsSignatureSet{Signature: op.Signature[:], Pubkey: op.Message.FromBLSPubkey[:], SigningRoot: root})
+		setMessages = append(setMessages, m)
+		setOps = append(setOps, i)
+	}
+
+	dryRunVerifySignatures(report, setMessages, sets, "genesis", func(i int, forkVersion []byte) (phase0.Root, error) {
+		return utils.BlsToExecutionChangeSigningRoot(ops[setOps[i]], forkVersion)
+	})
+	return nil
+}
+
+func dryRunVoluntaryExitNodeJob(j *types.NodeJob, report *types.NodeJobDryRunReport) error {
+	op, ok := j.GetVoluntaryExitsNodeJobData()
+	if !ok {
+		return types.CreateNodeJobUserError{Message: "invalid data"}
+	}
+	m := &types.NodeJobDryRunMessage{ValidatorIndex: uint64(op.Message.ValidatorIndex)}
+	report.Messages = append(report.Messages, m)
+
+	valis, err := getNodeJobDryRunValidators([]uint64{m.ValidatorIndex})
+	if err != nil {
+		return err
+	}
+	v, exists := valis[m.ValidatorIndex]
+	if !exists {
+		m.AddError("unknown validator")
+		return nil
+	}
+	m.Pubkey = v.Pubkey
+
+	switch v.Status {
+	case "exited", "exiting_online", "exiting_offline":
+		m.AddError("validator has exited")
+	case "slashed", "slashing_offline", "slashing_online":
+		m.AddError("validator has been slashed")
+	case "deposited", "pending":
+		m.AddError("validator is not active yet")
+	default:
+	}
+	currentEpoch := uint64(utils.TimeToEpoch(time.Now()))
+	if uint64(op.Message.Epoch) > currentEpoch {
+		m.AddError("exit epoch %v is in the future (current epoch %v)", op.Message.Epoch, currentEpoch)
+	}
+	if v.ActivationEpoch+nodeJobDryRunShardCommitteePeriod > currentEpoch {
+		m.AddError("validator has to be active for %v epochs before it can exit (eligible at epoch %v)", nodeJobDryRunShardCommitteePeriod, v.ActivationEpoch+nodeJobDryRunShardCommitteePeriod)
+	}
+
+	// exits are signed with the capella fork version, see EIP-7044
+	capellaForkVersion := utils.MustParseHex(utils.Config.Chain.ClConfig.CappellaForkVersion)
+	root, err := utils.VoluntaryExitSigningRoot(op, capellaForkVersion)
+	if err != nil {
+		m.AddError("%v", err)
+		return nil
+	}
+	sets := []utils.BlsSignatureSet{{Signature: op.Signature[:], Pubkey: v.Pubkey, SigningRoot: root}}
+	dryRunVerifySignatures(report, report.Messages, sets, "capella", func(i int, forkVersion []byte) (phase0.Root, error) {
+		return utils.VoluntaryExitSigningRoot(op, forkVersion)
+	})
+	return nil
+}
+
+func dryRunExecutionLayerRequestsNodeJob(j *types.NodeJob, report *types.NodeJobDryRunReport) error {
+	var rawTxs []hexutil.Bytes
+	verify := utils.VerifyConsolidationRequestTransaction
+	check := checkConsolidationRequest
+	if d, ok := j.GetConsolidationRequestsNodeJobData(); ok {
+		rawTxs = d.ConsolidationRequests
+	} else if d, ok := j.GetWithdrawalRequestsNodeJobData(); ok {
+		rawTxs = d.WithdrawalRequests
+		verify = utils.VerifyWithdrawalRequestTransaction
+		check = checkWithdrawalRequest
+	} else {
+		return types.CreateNodeJobUserError{Message: "invalid data"}
+	}
+
+	requests := make([]*types.ExecutionLayerRequest, len(rawTxs))
+	verified := []*types.ExecutionLayerRequest{}
+	for i, rawTx := range rawTxs {
+		m := &types.NodeJobDryRunMessage{Position: i}
+		report.Messages = append(report.Messages, m)
+		r, err := verify(rawTx)
+		if err != nil {
+			m.AddError("can not verify transaction: %v", err)
+			continue
+		}
+		m.Pubkey = r.SourcePubkey
+		requests[i] = r
+		verified = append(verified, r)
+	}
+
+	valisByPubkey, err := getExecutionLayerRequestValidators(verified)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for i, r := range requests {
+		if r == nil {
+			continue
+		}
+		m := report.Messages[i]
+		source, err := check(r, valisByPubkey)
+		if source != nil {
+			m.ValidatorIndex = source.Index
+		}
+		if err != nil {
+			m.AddError("%v", err)
+		}
+		if seen[hex.EncodeToString(r.SourcePubkey)] {
+			m.AddError("multiple entries for the same validator")
+		}
+		seen[hex.EncodeToString(r.SourcePubkey)] = true
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultNodeJobResubmitEpochs = 4
+const defaultNodeJobMaxSubmissions = 10
+
+// nodeJobClEndpoints returns all beacon nodes node jobs are submitted to
+func nodeJobClEndpoints() []string {
+	return uniqueNodeJobEndpoints(append([]string{utils.Config.NodeJobsProcessor.ClEndpoint}, utils.Config.NodeJobsProcessor.ClEndpoints...))
+}
+
+// nodeJobElEndpoints returns all execution nodes the transactions of node jobs are submitted to
+func nodeJobElEndpoints() []string {
+	return uniqueNodeJobEndpoints(append([]string{utils.Config.NodeJobsProcessor.ElEndpoint}, utils.Config.NodeJobsProcessor.ElEndpoints...))
+}
+
+func uniqueNodeJobEndpoints(configured []string) []string {
+	endpoints := []string{}
+	seen := map[string]bool{}
+	for _, endpoint := range configured {
+		endpoint = strings.TrimSuffix(strings.TrimSpace(endpoint), "/")
+		if endpoint == "" || seen[endpoint] {
+			continue
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// submitToBeaconNodes posts data to the given pool-endpoint of all configured beacon nodes and returns the number of nodes that accepted it
+func submitToBeaconNodes(job *types.NodeJob, path string, data []byte) int {
+	client := &http.Client{Timeout: time.Second * 10}
+	endpoints := nodeJobClEndpoints()
+
+	accepted := 0
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint string) {
+			defer wg.Done()
+			resp, err := client.Post(endpoint+path, "application/json", bytes.NewReader(data))
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"endpoint": endpoint, "jobID": job.ID, "jobType": job.Type}).WithError(err).Warnf("failed submitting a job")
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != 200 {
+				d, _ := io.ReadAll(resp.Body)
+				if len(d) > 1000 {
+					d = d[:1000]
+				}
+				logrus.WithFields(logrus.Fields{"endpoint": endpoint, "res": string(d), "status": resp.Status, "jobID": job.ID, "jobType": job.Type}).Warnf("failed submitting a job")
+				return
+			}
+			mu.Lock()
+			accepted++
+			mu.Unlock()
+		}(endpoint)
+	}
+	wg.Wait()
+	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "accepted": accepted, "nodes": len(endpoints)}).Infof("submitted node_job to beacon nodes")
+	return accepted
+}
+
+// submitToExecutionNodes sends the transactions of a job to all configured execution nodes and returns the lowest number of nodes that accepted a transaction
+func submitToExecutionNodes(job *types.NodeJob, txs []*gethtypes.Transaction) int {
+	endpoints := nodeJobElEndpoints()
+
+	accepted := make([]int, len(txs))
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+			defer cancel()
+			client, err := ethclient.DialContext(ctx, endpoint)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"endpoint": endpoint, "jobID": job.ID, "jobType": job.Type}).WithError(err).Warnf("failed submitting a job")
+				return
+			}
+			defer client.Close()
+			for i, tx := range txs {
+				err := client.SendTransaction(ctx, tx)
+				if err != nil && !strings.Contains(err.Error(), "already known") {
+					logrus.WithFields(logrus.Fields{"endpoint": endpoint, "res": err.Error(), "tx": tx.Hash().Hex(), "jobID": job.ID, "jobType": job.Type}).Warnf("failed submitting a job")
+					continue
+				}
+				mu.Lock()
+				accepted[i]++
+				mu.Unlock()
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+
+	minAccepted := len(endpoints)
+	for _, a := range accepted {
+		if a < minAccepted {
+			minAccepted = a
+		}
+	}
+	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "accepted": minAccepted, "nodes": len(endpoints)}).Infof("submitted node_job to execution nodes")
+	return minAccepted
+}
+
+// saveNodeJobSubmission records a (re-)submission of the messages of the given validators.
+// Messages that were not accepted by any node stay submitted, they are resubmitted until they are included or the maximum number of submissions is reached.
+func saveNodeJobSubmission(job *types.NodeJob, indices []uint64, accepted int) error {
+	_, err := WriterDb.Exec(`
+		insert into node_jobs_messages (node_job_id, validatorindex, status, submit_count, submitted_nodes, last_submitted_time)
+		select $1, unnest($2::bigint[]), $3, 1, $4, now()
+		on conflict (node_job_id, validatorindex) do update set
+			submit_count = node_jobs_messages.submit_count + 1,
+			submitted_nodes = excluded.submitted_nodes,
+			last_submitted_time = excluded.last_submitted_time`,
+		job.ID, pq.Array(indices), types.SubmittedToNodeNodeJobStatus, accepted)
+	if err != nil {
+		return fmt.Errorf("error saving node_jobs_messages of job %v: %w", job.ID, err)
+	}
+	return nil
+}
+
+// trackNodeJobInclusion stores the slot each message of a submitted job has been included in (looked up in opsTable),
+// resubmits messages that have not been included after utils.Config.NodeJobsProcessor.ResubmitEpochs and
+// finalizes the job once every message has been included or has been given up on
+func trackNodeJobInclusion(job *types.NodeJob, indices []uint64, opsTable string, resubmit func(indices []uint64) (int, error)) error {
+	// jobs submitted before messages have been tracked count as submitted once
+	_, err := WriterDb.Exec(`
+		insert into node_jobs_messages (node_job_id, validatorindex, status, submit_count, submitted_nodes, last_submitted_time)
+		select $1, unnest($2::bigint[]), $3, 1, 0, $4
+		on conflict (node_job_id, validatorindex) do nothing`,
+		job.ID, pq.Array(indices), types.SubmittedToNodeNodeJobStatus, job.SubmittedToNodeTime)
+	if err != nil {
+		return fmt.Errorf("error inserting node_jobs_messages of job %v: %w", job.ID, err)
+	}
+
+	inclusions := []struct {
+		ValidatorIndex int64 `db:"validatorindex"`
+		Slot           int64 `db:"block_slot"`
+	}{}
+	err = WriterDb.Select(&inclusions, fmt.Sprintf(`
+		select ops.validatorindex, min(ops.block_slot) as block_slot
+		from %s ops
+		inner join blocks b on b.blockroot = ops.block_root and b.status = '1'
+		where ops.validatorindex = any($1)
+		group by ops.validatorindex`, opsTable), pq.Array(indices))
+	if err != nil {
+		return fmt.Errorf("error getting inclusions from %v: %w", opsTable, err)
+	}
+	if len(inclusions) > 0 {
+		includedIndices := make([]int64, 0, len(inclusions))
+		includedSlots := make([]int64, 0, len(inclusions))
+		for _, inclusion := range inclusions {
+			includedIndices = append(includedIndices, inclusion.ValidatorIndex)
+			includedSlots = append(includedSlots, inclusion.Slot)
+		}
+		_, err = WriterDb.Exec(`
+			update node_jobs_messages m set status = $2, included_slot = i.slot
+			from unnest($3::bigint[], $4::bigint[]) as i(validatorindex, slot)
+			where m.node_job_id = $1 and m.validatorindex = i.validatorindex and m.included_slot is null`,
+			job.ID, types.CompletedNodeJobStatus, pq.Array(includedIndices), pq.Array(includedSlots))
+		if err != nil {
+			return fmt.Errorf("error updating inclusions of job %v: %w", job.ID, err)
+		}
+	}
+
+	messages := []*types.NodeJobMessage{}
+	err = WriterDb.Select(&messages, `select node_job_id, validatorindex, status, submit_count, submitted_nodes, last_submitted_time, included_slot from node_jobs_messages where node_job_id = $1`, job.ID)
+	if err != nil {
+		return fmt.Errorf("error getting node_jobs_messages of job %v: %w", job.ID, err)
+	}
+
+	resubmitEpochs := utils.Config.NodeJobsProcessor.ResubmitEpochs
+	if resubmitEpochs == 0 {
+		resubmitEpochs = defaultNodeJobResubmitEpochs
+	}
+	maxSubmissions := utils.Config.NodeJobsProcessor.MaxSubmissions
+	if maxSubmissions <= 0 {
+		maxSubmissions = defaultNodeJobMaxSubmissions
+	}
+	resubmitAfter := time.Duration(resubmitEpochs*utils.Config.Chain.ClConfig.SlotsPerEpoch*utils.Config.Chain.ClConfig.SecondsPerSlot) * time.Second
+
+	pending := 0
+	failed := 0
+	toResubmit := []uint64{}
+	toFail := []uint64{}
+	for _, m := range messages {
+		switch m.Status {
+		case types.CompletedNodeJobStatus:
+			continue
+		case types.FailedNodeJobStatus:
+			failed++
+			continue
+		}
+		if m.LastSubmittedTime.Valid && time.Since(m.LastSubmittedTime.Time) < resubmitAfter {
+			pending++
+			continue
+		}
+		if m.SubmitCount >= maxSubmissions {
+			toFail = append(toFail, m.ValidatorIndex)
+			failed++
+			continue
+		}
+		toResubmit = append(toResubmit, m.ValidatorIndex)
+		pending++
+	}
+
+	if len(toFail) > 0 {
+		_, err = WriterDb.Exec(`update node_jobs_messages set status = $2 where node_job_id = $1 and validatorindex = any($3)`, job.ID, types.FailedNodeJobStatus, pq.Array(toFail))
+		if err != nil {
+			return fmt.Errorf("error failing node_jobs_messages of job %v: %w", job.ID, err)
+		}
+		logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "messages": len(toFail), "submissions": maxSubmissions}).Warnf("giving up on node_job messages that have not been included")
+	}
+
+	if len(toResubmit) > 0 {
+		accepted, err := resubmit(toResubmit)
+		if err != nil {
+			return fmt.Errorf("error resubmitting job %v: %w", job.ID, err)
+		}
+		err = saveNodeJobSubmission(job, toResubmit, accepted)
+		if err != nil {
+			return err
+		}
+		logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "messages": len(toResubmit), "accepted": accepted}).Infof("resubmitted node_job messages")
+	}
+
+	if pending > 0 {
+		return nil
+	}
+
+	job.Status = types.CompletedNodeJobStatus
+	if failed > 0 {
+		job.Status = types.FailedNodeJobStatus
+	}
+	job.CompletedTime.Time = time.Now()
+	job.CompletedTime.Valid = true
+	_, err = WriterDb.Exec(`update node_jobs set status = $1, completed_time = $2 where id = $3`, job.Status, job.CompletedTime.Time, job.ID)
+	if err != nil {
+		return err
+	}
+	logrus.WithFields(logrus.Fields{"id": job.ID, "type": job.Type, "status": job.Status, "messages": len(messages), "failed": failed}).Infof("updated node_job")
+	return nil
+}
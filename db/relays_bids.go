@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+)
+
+// GetRelayBidCompetition returns the bid competition of the slots between fromSlot and toSlot (inclusive) for which received bids have been exported successfully.
+// The runner-up value is the highest bid of a builder other than the one whose block was delivered.
+func GetRelayBidCompetition(fromSlot, toSlot uint64) ([]*types.RelayBidCompetition, error) {
+	competition := []*types.RelayBidCompetition{}
+	err := ReaderDb.Select(&competition, `
+		WITH slots AS (
+			SELECT slot, SUM(bids_count) AS bids_count, COUNT(*) FILTER (WHERE bids_count > 0) AS relays_count, MAX(top_value) AS top_bid_value
+			FROM relays_bids_slots
+			WHERE slot >= $1 AND slot <= $2 AND error IS NULL
+			GROUP BY slot
+		),
+		delivered AS (
+			SELECT DISTINCT ON (rb.block_slot) rb.block_slot AS slot, rb.value, rb.builder_pubkey
+			FROM relays_blocks rb
+			INNER JOIN blocks b ON b.slot = rb.block_slot AND b.blockroot = rb.block_root AND b.status = '1'
+			WHERE rb.block_slot >= $1 AND rb.block_slot <= $2
+			ORDER BY rb.block_slot, rb.value DESC
+		),
+		competition AS (
+			SELECT
+				s.slot,
+				s.bids_count,
+				s.relays_count,
+				(SELECT COUNT(DISTINCT rbid.builder_pubkey) FROM relays_bids rbid WHERE rbid.slot = s.slot) AS builders_count,
+				s.top_bid_value,
+				COALESCE((SELECT '0x' || encode(rbid.builder_pubkey, 'hex') FROM relays_bids rbid WHERE rbid.slot = s.slot ORDER BY rbid.value DESC LIMIT 1), '') AS top_bid_builder,
+				COALESCE(d.value, 0) AS delivered_value,
+				COALESCE('0x' || encode(d.builder_pubkey, 'hex'), '') AS winning_builder,
+				COALESCE((SELECT MAX(rbid.value) FROM relays_bids rbid WHERE rbid.slot = s.slot AND rbid.builder_pubkey != d.builder_pubkey), 0) AS runner_up_value
+			FROM slots s
+			LEFT JOIN delivered d ON d.slot = s.slot
+		)
+		SELECT
+			*,
+			GREATEST(delivered_value - runner_up_value, 0) AS winning_margin,
+			delivered_value > 0 AND delivered_value >= top_bid_value AS delivered_is_best
+		FROM competition
+		ORDER BY slot DESC`, fromSlot, toSlot)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relay bid competition of slots %v-%v: %w", fromSlot, toSlot, err)
+	}
+	return competition, nil
+}
+
+// GetRelayBuilderBidStats returns the number of stored bids, the number of slots bid on and the number of slots won per builder between fromSlot and toSlot (inclusive)
+func GetRelayBuilderBidStats(fromSlot, toSlot uint64, limit uint64) ([]*types.RelayBuilderBidStats, error) {
+	stats := []*types.RelayBuilderBidStats{}
+	err := ReaderDb.Select(&stats, `
+		SELECT
+			'0x' || encode(b.builder_pubkey, 'hex') AS builder_pubkey,
+			COUNT(*) AS bids_count,
+			COUNT(DISTINCT b.slot) AS slots_bid,
+			(
+				SELECT COUNT(DISTINCT rb.block_slot)
+				FROM relays_blocks rb
+				WHERE rb.builder_pubkey = b.builder_pubkey AND rb.block_slot >= $1 AND rb.block_slot <= $2
+			) AS slots_won,
+			MAX(b.value) AS max_bid_value
+		FROM relays_bids b
+		WHERE b.slot >= $1 AND b.slot <= $2
+		GROUP BY b.builder_pubkey
+		ORDER BY slots_won DESC, bids_count DESC
+		LIMIT $3`, fromSlot, toSlot, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving builder bid stats of slots %v-%v: %w", fromSlot, toSlot, err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,290 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// GetValidatorRewardsLedger returns the consensus rewards, execution fees and mev rewards of the given validators per day
+// as well as their individual deposits and withdrawals. Amounts are denominated in the main currency of the chain.
+// The entries are sorted by day, validator and type.
+func GetValidatorRewardsLedger(validators []uint64, startDay, endDay uint64) ([]*types.RewardsLedgerEntry, error) {
+	if len(validators) == 0 {
+		return []*types.RewardsLedgerEntry{}, nil
+	}
+	validatorsArr := pq.Array(validators)
+
+	firstEpoch, _ := utils.GetFirstAndLastEpochForDay(startDay)
+	_, lastEpoch := utils.GetFirstAndLastEpochForDay(endDay)
+	firstSlot := firstEpoch * utils.Config.Chain.ClConfig.SlotsPerEpoch
+	lastSlot := (lastEpoch+1)*utils.Config.Chain.ClConfig.SlotsPerEpoch - 1
+
+	gweiToMain := decimal.NewFromInt(1e9)
+	weiToMain := decimal.NewFromInt(1e18)
+
+	type incomeRow struct {
+		ValidatorIndex uint64          `db:"validatorindex"`
+		Day            int64           `db:"day"`
+		ClRewardsGwei  int64           `db:"cl_rewards_gwei"`
+		ElRewardsWei   decimal.Decimal `db:"mev_rewards_wei"`
+	}
+	income := []incomeRow{}
+	err := ReaderDb.Select(&income, `
+		SELECT
+			validatorindex,
+			day,
+			COALESCE(cl_rewards_gwei, 0) AS cl_rewards_gwei,
+			COALESCE(mev_rewards_wei, 0) AS mev_rewards_wei
+		FROM validator_stats
+		WHERE validatorindex = ANY($1) AND day BETWEEN $2 AND $3`, validatorsArr, startDay, endDay)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator income for ledger: %w", err)
+	}
+
+	// mev_rewards_wei contains the relay reported payment for relayed blocks and the tx fees for locally built blocks,
+	// therefore the relay payments have to be retrieved separately to split execution fees and mev rewards
+	type relayRow struct {
+		ValidatorIndex uint64          `db:"validatorindex"`
+		Day            int64           `db:"day"`
+		Value          decimal.Decimal `db:"value"`
+	}
+	relayRewards := []relayRow{}
+	err = ReaderDb.Select(&relayRewards, `
+		SELECT
+			blocks.proposer AS validatorindex,
+			blocks.epoch / $4 AS day,
+			SUM(rb.value) AS value
+		FROM blocks
+		INNER JOIN (
+			SELECT block_root, MAX(value) AS value
+			FROM relays_blocks
+			WHERE block_slot BETWEEN $2 AND $3
+			GROUP BY block_root
+		) rb ON rb.block_root = blocks.blockroot
+		WHERE blocks.proposer = ANY($1) AND blocks.slot BETWEEN $2 AND $3 AND blocks.status = '1'
+		GROUP BY 1, 2`, validatorsArr, firstSlot, lastSlot, utils.EpochsPerDay())
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving relay rewards for ledger: %w", err)
+	}
+	relayRewardsMap := make(map[uint64]map[int64]decimal.Decimal)
+	for _, r := range relayRewards {
+		if relayRewardsMap[r.ValidatorIndex] == nil {
+			relayRewardsMap[r.ValidatorIndex] = make(map[int64]decimal.Decimal)
+		}
+		relayRewardsMap[r.ValidatorIndex][r.Day] = r.Value
+	}
+
+	ledger := make([]*types.RewardsLedgerEntry, 0, len(income)*2)
+	for _, r := range income {
+		if r.ClRewardsGwei != 0 {
+			ledger = append(ledger, &types.RewardsLedgerEntry{
+				Day:            r.Day,
+				ValidatorIndex: r.ValidatorIndex,
+				Type:           types.RewardsLedgerConsensusReward,
+				Amount:         decimal.NewFromInt(r.ClRewardsGwei).Div(gweiToMain),
+			})
+		}
+
+		mev := relayRewardsMap[r.ValidatorIndex][r.Day]
+		if mev.IsPositive() {
+			ledger = append(ledger, &types.RewardsLedgerEntry{
+				Day:            r.Day,
+				ValidatorIndex: r.ValidatorIndex,
+				Type:           types.RewardsLedgerMevReward,
+				Amount:         mev.Div(weiToMain),
+			})
+		}
+
+		fees := r.ElRewardsWei.Sub(mev)
+		if fees.IsPositive() {
+			ledger = append(ledger, &types.RewardsLedgerEntry{
+				Day:            r.Day,
+				ValidatorIndex: r.ValidatorIndex,
+				Type:           types.RewardsLedgerExecutionFee,
+				Amount:         fees.Div(weiToMain),
+			})
+		}
+	}
+
+	type movementRow struct {
+		ValidatorIndex uint64 `db:"validatorindex"`
+		Slot           uint64 `db:"slot"`
+		Amount         int64  `db:"amount"`
+	}
+
+	deposits := []movementRow{}
+	err = ReaderDb.Select(&deposits, `
+		SELECT validators.validatorindex, blocks_deposits.block_slot AS slot, blocks_deposits.amount
+		FROM blocks_deposits
+		INNER JOIN validators ON blocks_deposits.publickey = validators.pubkey
+		INNER JOIN blocks ON blocks_deposits.block_root = blocks.blockroot
+		WHERE validators.validatorindex = ANY($1) AND blocks.slot BETWEEN $2 AND $3 AND (blocks.status = '1' OR blocks.slot = 0) AND blocks_deposits.valid_signature`,
+		validatorsArr, firstSlot, lastSlot)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving deposits for ledger: %w", err)
+	}
+	for _, d := range deposits {
+		ledger = append(ledger, &types.RewardsLedgerEntry{
+			Day:            int64(utils.DayOfSlot(d.Slot)),
+			ValidatorIndex: d.ValidatorIndex,
+			Type:           types.RewardsLedgerDeposit,
+			Slot:           d.Slot,
+			Amount:         decimal.NewFromInt(d.Amount).Div(gweiToMain),
+		})
+	}
+
+	withdrawals := []movementRow{}
+	err = ReaderDb.Select(&withdrawals, `
+		SELECT blocks_withdrawals.validatorindex, blocks_withdrawals.block_slot AS slot, blocks_withdrawals.amount
+		FROM blocks_withdrawals
+		INNER JOIN blocks ON blocks_withdrawals.block_root = blocks.blockroot
+		WHERE blocks_withdrawals.validatorindex = ANY($1) AND blocks_withdrawals.block_slot BETWEEN $2 AND $3 AND blocks.status = '1'`,
+		validatorsArr, firstSlot, lastSlot)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving withdrawals for ledger: %w", err)
+	}
+	for _, w := range withdrawals {
+		ledger = append(ledger, &types.RewardsLedgerEntry{
+			Day:            int64(utils.DayOfSlot(w.Slot)),
+			ValidatorIndex: w.ValidatorIndex,
+			Type:           types.RewardsLedgerWithdrawal,
+			Slot:           w.Slot,
+			Amount:         decimal.NewFromInt(w.Amount).Div(gweiToMain),
+		})
+	}
+
+	for _, e := range ledger {
+		if e.Slot > 0 {
+			e.Date = utils.SlotToTime(e.Slot)
+		} else {
+			e.Date = utils.DayToTime(e.Day)
+		}
+	}
+
+	sort.SliceStable(ledger, func(i, j int) bool {
+		if ledger[i].Day != ledger[j].Day {
+			return ledger[i].Day < ledger[j].Day
+		}
+		if ledger[i].ValidatorIndex != ledger[j].ValidatorIndex {
+			return ledger[i].ValidatorIndex < ledger[j].ValidatorIndex
+		}
+		if ledger[i].Slot != ledger[j].Slot {
+			return ledger[i].Slot < ledger[j].Slot
+		}
+		return ledger[i].Type < ledger[j].Type
+	})
+
+	return ledger, nil
+}
+
+// CreateRewardsExport queues a new rewards export that will be generated in the background
+func CreateRewardsExport(userID uint64, validators []uint64, currency string, format types.RewardsExportFormat, startDay, endDay uint64) (*types.RewardsExport, error) {
+	export := &types.RewardsExport{
+		ID:       uuid.New().String(),
+		Currency: currency,
+		Format:   format,
+		StartDay: int64(startDay),
+		EndDay:   int64(endDay),
+		Status:   types.RewardsExportStatusPending,
+	}
+	if userID > 0 {
+		export.UserID = sql.NullInt64{Int64: int64(userID), Valid: true}
+	}
+	for _, v := range validators {
+		export.Validators = append(export.Validators, int64(v))
+	}
+
+	err := FrontendWriterDB.Get(&export.CreatedTs, `
+		INSERT INTO rewards_exports (id, user_id, validators, currency, format, start_day, end_day, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_ts`,
+		export.ID, export.UserID, export.Validators, export.Currency, export.Format, export.StartDay, export.EndDay, export.Status)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting rewards export: %w", err)
+	}
+	return export, nil
+}
+
+// GetRewardsExport returns the rewards export with the given id of the given user, including the generated file if it is finished.
+// sql.ErrNoRows is returned if the export does not exist or belongs to another user.
+func GetRewardsExport(id string, userID uint64) (*types.RewardsExport, error) {
+	if len(id) > 40 {
+		return nil, fmt.Errorf("invalid id")
+	}
+	export := &types.RewardsExport{}
+	err := FrontendWriterDB.Get(export, `
+		SELECT id, user_id, validators, currency, format, start_day, end_day, status, error, created_ts, finished_ts, data
+		FROM rewards_exports
+		WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// rewards exports that are running for longer than this are considered abandoned (e.g. the worker crashed) and are claimed again
+const rewardsExportClaimTimeout = time.Hour
+
+// ClaimPendingRewardsExport marks the oldest pending or abandoned running rewards export as running and returns it.
+// sql.ErrNoRows is returned if there is no export to claim.
+func ClaimPendingRewardsExport() (*types.RewardsExport, error) {
+	export := &types.RewardsExport{}
+	err := FrontendWriterDB.Get(export, `
+		UPDATE rewards_exports SET status = $1, claimed_ts = NOW()
+		WHERE id = (
+			SELECT id FROM rewards_exports
+			WHERE status = $2 OR (status = $1 AND claimed_ts < NOW() - $3 * INTERVAL '1 second')
+			ORDER BY created_ts
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, validators, currency, format, start_day, end_day, status, created_ts`,
+		types.RewardsExportStatusRunning, types.RewardsExportStatusPending, rewardsExportClaimTimeout.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// FinishRewardsExport stores the result of a rewards export, if exportErr is set the export is marked as failed
+func FinishRewardsExport(id string, data []byte, exportErr error) error {
+	var err error
+	if exportErr != nil {
+		_, err = FrontendWriterDB.Exec(`UPDATE rewards_exports SET status = $2, error = $3, finished_ts = NOW() WHERE id = $1`,
+			id, types.RewardsExportStatusFailed, exportErr.Error())
+	} else {
+		_, err = FrontendWriterDB.Exec(`UPDATE rewards_exports SET status = $2, data = $3, finished_ts = NOW() WHERE id = $1`,
+			id, types.RewardsExportStatusDone, data)
+	}
+	if err != nil {
+		return fmt.Errorf("error updating rewards export %v: %w", id, err)
+	}
+	return nil
+}
+
+// DeleteExpiredRewardsExports removes finished exports that are older than the given number of days
+func DeleteExpiredRewardsExports(days uint64) error {
+	_, err := FrontendWriterDB.Exec(`DELETE FROM rewards_exports WHERE status IN ($1, $2) AND finished_ts < NOW() - $3 * INTERVAL '1 day'`,
+		types.RewardsExportStatusDone, types.RewardsExportStatusFailed, days)
+	return err
+}
+
+// RequeueRewardsExports marks finished rewards exports overlapping the given days as pending so they are generated again,
+// e.g. after the historic prices of these days were corrected. The number of requeued exports is returned.
+func RequeueRewardsExports(startDay, endDay uint64) (int64, error) {
+	res, err := FrontendWriterDB.Exec(`UPDATE rewards_exports SET status = $1 WHERE status = $2 AND start_day <= $4 AND end_day >= $3`,
+		types.RewardsExportStatusPending, types.RewardsExportStatusDone, startDay, endDay)
+	if err != nil {
+		return 0, fmt.Errorf("error requeueing rewards exports of days %v-%v: %w", startDay, endDay, err)
+	}
+	return res.RowsAffected()
+}
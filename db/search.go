@@ -0,0 +1,205 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+)
+
+// search results are ranked by how they match the query: exact matches first, then prefix matches, then fuzzy (trigram) matches
+const (
+	SearchScoreExact  = 1.0
+	SearchScorePrefix = 0.8
+	SearchScoreFuzzy  = 0.6
+)
+
+var searchLikeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// searchPrefixPattern returns a LIKE pattern matching all values starting with query
+func searchPrefixPattern(query string) string {
+	return searchLikeEscaper.Replace(query) + "%"
+}
+
+// searchMatchSQL returns the match kind and score of column for the query ($1) and its prefix pattern ($2)
+func searchMatchSQL(column string) string {
+	return fmt.Sprintf(`
+		CASE WHEN LOWER(%[1]s) = LOWER($1) THEN 'exact' WHEN %[1]s ILIKE $2 THEN 'prefix' ELSE 'fuzzy' END AS match,
+		CASE WHEN LOWER(%[1]s) = LOWER($1) THEN %[2]v WHEN %[1]s ILIKE $2 THEN %[3]v ELSE similarity(%[1]s, $1) * %[4]v END AS score`,
+		column, SearchScoreExact, SearchScorePrefix, SearchScoreFuzzy)
+}
+
+type searchRow struct {
+	Value string  `db:"value"`
+	Label string  `db:"label"`
+	Match string  `db:"match"`
+	Score float64 `db:"score"`
+	Count uint64  `db:"count"`
+}
+
+func searchRowsToResults(rows []*searchRow, resultType string, resultURL func(row *searchRow) string) []*types.SearchResult {
+	results := make([]*types.SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &types.SearchResult{
+			Type:  resultType,
+			Value: row.Value,
+			Label: row.Label,
+			Match: row.Match,
+			Score: row.Score,
+			Count: row.Count,
+			URL:   resultURL(row),
+		})
+	}
+	return results
+}
+
+// SearchValidatorsByIndex returns the validator with the given index
+func SearchValidatorsByIndex(index uint64) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, `
+		SELECT validatorindex::text AS value, COALESCE(validator_names.name, '') AS label, 'exact' AS match, $2::float AS score, 0 AS count
+		FROM validators
+		LEFT JOIN validator_names ON validator_names.publickey = validators.pubkey
+		WHERE validatorindex = $1`, index, SearchScoreExact)
+	if err != nil {
+		return nil, fmt.Errorf("error searching validator by index %v: %w", index, err)
+	}
+	return searchRowsToResults(rows, "validator", func(row *searchRow) string { return "/validator/" + row.Value }), nil
+}
+
+// SearchValidatorsByPubkey returns the validators whose public key starts with the given hex prefix (without 0x)
+func SearchValidatorsByPubkey(pubkeyPrefix string, limit int) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, `
+		SELECT validatorindex::text AS value, '0x' || pubkeyhex AS label, CASE WHEN pubkeyhex = $1 THEN 'exact' ELSE 'prefix' END AS match,
+			CASE WHEN pubkeyhex = $1 THEN $3::float ELSE $4::float END AS score, 0 AS count
+		FROM validators
+		WHERE pubkeyhex LIKE ($1 || '%')
+		ORDER BY validatorindex
+		LIMIT $2`, strings.ToLower(pubkeyPrefix), limit, SearchScoreExact, SearchScorePrefix)
+	if err != nil {
+		return nil, fmt.Errorf("error searching validators by pubkey %v: %w", pubkeyPrefix, err)
+	}
+	return searchRowsToResults(rows, "validator", func(row *searchRow) string { return "/validator/" + row.Value }), nil
+}
+
+// SearchValidatorsByName returns the validators whose name matches the query exactly, by prefix or fuzzily
+func SearchValidatorsByName(query string, limit int) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, fmt.Sprintf(`
+		SELECT validators.validatorindex::text AS value, validator_names.name AS label, %s, 0 AS count
+		FROM validator_names
+		INNER JOIN validators ON validators.pubkey = validator_names.publickey
+		WHERE validator_names.name ILIKE $2 OR validator_names.name %% $1
+		ORDER BY score DESC, validators.validatorindex
+		LIMIT $3`, searchMatchSQL("validator_names.name")), query, searchPrefixPattern(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching validators by name %v: %w", query, err)
+	}
+	return searchRowsToResults(rows, "validator", func(row *searchRow) string { return "/validator/" + row.Value }), nil
+}
+
+// SearchValidatorTags returns the validator tags matching the query exactly or by prefix together with the number of tagged validators
+func SearchValidatorTags(query string, limit int) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, fmt.Sprintf(`
+		SELECT tag AS value, tag AS label, %s, COUNT(*) AS count
+		FROM validator_tags
+		WHERE tag ILIKE $2
+		GROUP BY tag
+		ORDER BY score DESC, count DESC
+		LIMIT $3`, searchMatchSQL("tag")), query, searchPrefixPattern(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching validator tags %v: %w", query, err)
+	}
+	return searchRowsToResults(rows, "validator_tag", func(row *searchRow) string { return "" }), nil
+}
+
+// SearchSlot returns the slot with the given number
+func SearchSlot(slot uint64) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, `
+		SELECT slot::text AS value, '0x' || ENCODE(blockroot, 'hex') AS label, 'exact' AS match, $2::float AS score, 0 AS count
+		FROM blocks
+		WHERE slot = $1
+		ORDER BY status
+		LIMIT 1`, slot, SearchScoreExact)
+	if err != nil {
+		return nil, fmt.Errorf("error searching slot %v: %w", slot, err)
+	}
+	return searchRowsToResults(rows, "slot", func(row *searchRow) string { return "/slot/" + row.Value }), nil
+}
+
+// SearchSlotsByRoot returns the slots whose block root or state root equals the given hash
+func SearchSlotsByRoot(root []byte) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, `
+		SELECT slot::text AS value, '0x' || ENCODE(blockroot, 'hex') AS label, 'exact' AS match, $2::float AS score, 0 AS count
+		FROM blocks
+		WHERE blockroot = $1 OR stateroot = $1
+		ORDER BY slot
+		LIMIT 10`, root, SearchScoreExact)
+	if err != nil {
+		return nil, fmt.Errorf("error searching slots by root %#x: %w", root, err)
+	}
+	return searchRowsToResults(rows, "slot", func(row *searchRow) string { return "/slot/" + strings.TrimPrefix(row.Label, "0x") }), nil
+}
+
+// SearchEpoch returns the epoch with the given number
+func SearchEpoch(epoch uint64) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, `SELECT epoch::text AS value, '' AS label, 'exact' AS match, $2::float AS score, 0 AS count FROM epochs WHERE epoch = $1`, epoch, SearchScoreExact)
+	if err != nil {
+		return nil, fmt.Errorf("error searching epoch %v: %w", epoch, err)
+	}
+	return searchRowsToResults(rows, "epoch", func(row *searchRow) string { return "/epoch/" + row.Value }), nil
+}
+
+// SearchBlocksByHash returns the execution blocks with the given block hash
+func SearchBlocksByHash(hash []byte) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, `
+		SELECT exec_block_number::text AS value, '0x' || ENCODE(exec_block_hash, 'hex') AS label, 'exact' AS match, $2::float AS score, 0 AS count
+		FROM blocks
+		WHERE exec_block_hash = $1 AND exec_block_number IS NOT NULL`, hash, SearchScoreExact)
+	if err != nil {
+		return nil, fmt.Errorf("error searching blocks by hash %#x: %w", hash, err)
+	}
+	return searchRowsToResults(rows, "block", func(row *searchRow) string { return "/block/" + row.Value }), nil
+}
+
+// SearchGraffiti returns the graffitis matching the query exactly, by prefix or fuzzily together with the number of blocks that used them
+func SearchGraffiti(query string, limit int) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, fmt.Sprintf(`
+		SELECT graffiti_text AS value, graffiti_text AS label, %s, SUM(count) AS count
+		FROM graffiti_stats
+		WHERE graffiti_text ILIKE $2 OR graffiti_text %% $1
+		GROUP BY graffiti_text
+		ORDER BY score DESC, count DESC
+		LIMIT $3`, searchMatchSQL("graffiti_text")), query, searchPrefixPattern(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching graffiti %v: %w", query, err)
+	}
+	for _, row := range rows {
+		row.Label = utils.FormatGraffitiString(row.Label)
+	}
+	return searchRowsToResults(rows, "graffiti", func(row *searchRow) string { return "/slots?q=" + url.QueryEscape(row.Value) }), nil
+}
+
+// SearchEnsNames returns the currently valid ENS names matching the query exactly, by prefix or fuzzily
+func SearchEnsNames(query string, limit int) ([]*types.SearchResult, error) {
+	rows := []*searchRow{}
+	err := ReaderDb.Select(&rows, fmt.Sprintf(`
+		SELECT ens_name AS value, '0x' || ENCODE(address, 'hex') AS label, %s, 0 AS count
+		FROM ens
+		WHERE (ens_name ILIKE $2 OR ens_name %% $1) AND valid_to >= now() AND address IS NOT NULL
+		ORDER BY score DESC, is_primary_name DESC
+		LIMIT $3`, searchMatchSQL("ens_name")), query, searchPrefixPattern(query), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching ens names %v: %w", query, err)
+	}
+	return searchRowsToResults(rows, "ens", func(row *searchRow) string { return "/address/" + row.Value }), nil
+}
@@ -27,6 +27,94 @@ import (
 	pgxdecimal "github.com/jackc/pgx-shopspring-decimal"
 )
 
+// ether sold in the mainnet crowd sale, the supply used for the MARKET_CAP chart series is the crowd sale plus the TOTAL_EMISSION
+const mainnetCrowdSaleSupply = 72009990.50
+
+// validatorStatsColumns are the columns of the validator_stats table in the order of validatorStatsRowValues
+var validatorStatsColumns = []string{
+	"validatorindex",
+	"day",
+	"start_balance",
+	"end_balance",
+	"min_balance",
+	"max_balance",
+	"start_effective_balance",
+	"end_effective_balance",
+	"min_effective_balance",
+	"max_effective_balance",
+	"missed_attestations",
+	"missed_attestations_total",
+	"orphaned_attestations",
+	"participated_sync",
+	"participated_sync_total",
+	"missed_sync",
+	"missed_sync_total",
+	"orphaned_sync",
+	"orphaned_sync_total",
+	"proposed_blocks",
+	"missed_blocks",
+	"orphaned_blocks",
+	"attester_slashings",
+	"proposer_slashings",
+	"deposits",
+	"deposits_total",
+	"deposits_amount",
+	"deposits_amount_total",
+	"withdrawals",
+	"withdrawals_total",
+	"withdrawals_amount",
+	"withdrawals_amount_total",
+	"cl_rewards_gwei",
+	"cl_rewards_gwei_total",
+	"el_rewards_wei",
+	"el_rewards_wei_total",
+	"mev_rewards_wei",
+	"mev_rewards_wei_total",
+}
+
+func validatorStatsRowValues(row *types.ValidatorStatsTableDbRow) []interface{} {
+	return []interface{}{
+		row.ValidatorIndex,
+		row.Day,
+		row.StartBalance,
+		row.EndBalance,
+		row.MinBalance,
+		row.MaxBalance,
+		row.StartEffectiveBalance,
+		row.EndEffectiveBalance,
+		row.MinEffectiveBalance,
+		row.MaxEffectiveBalance,
+		row.MissedAttestations,
+		row.MissedAttestationsTotal,
+		row.OrphanedAttestations,
+		row.ParticipatedSync,
+		row.ParticipatedSyncTotal,
+		row.MissedSync,
+		row.MissedSyncTotal,
+		row.OrphanedSync,
+		row.OrphanedSyncTotal,
+		row.ProposedBlocks,
+		row.MissedBlocks,
+		row.OrphanedBlocks,
+		row.AttesterSlashings,
+		row.ProposerSlashing,
+		row.Deposits,
+		row.DepositsTotal,
+		row.DepositsAmount,
+		row.DepositsAmountTotal,
+		row.Withdrawals,
+		row.WithdrawalsTotal,
+		row.WithdrawalsAmount,
+		row.WithdrawalsAmountTotal,
+		row.ClRewardsGWei,
+		row.ClRewardsGWeiTotal,
+		row.ElRewardsWei,
+		row.ElRewardsWeiTotal,
+		row.MEVRewardsWei,
+		row.MEVRewardsWeiTotal,
+	}
+}
+
 func WriteValidatorStatisticsForDay(day uint64, client rpc.Client) error {
 	exportStart := time.Now()
 	defer func() {
@@ -195,31 +283,7 @@ func WriteValidatorStatisticsForDay(day uint64, client rpc.Client) error {
 			return fmt.Errorf("logic error when retrieving previous day data for validator %v (%v wanted, %v retrieved)", index, data.ValidatorIndex, previousDayData.ValidatorIndex)
 		}
 
-		// update attestation totals
-		data.MissedAttestationsTotal = previousDayData.MissedAttestationsTotal + data.MissedAttestations
-
-		// update sync total
-		data.ParticipatedSyncTotal = previousDayData.ParticipatedSyncTotal + data.ParticipatedSync
-		data.MissedSyncTotal = previousDayData.MissedSyncTotal + data.MissedSync
-		data.OrphanedSyncTotal = previousDayData.OrphanedSyncTotal + data.OrphanedSync
-
-		// calculate cl reward & update totals
-		data.ClRewardsGWei = data.EndBalance - previousDayData.EndBalance + data.WithdrawalsAmount - data.DepositsAmount
-		data.ClRewardsGWeiTotal = previousDayData.ClRewardsGWeiTotal + data.ClRewardsGWei
-
-		// update el reward total
-		data.ElRewardsWeiTotal = previousDayData.ElRewardsWeiTotal.Add(data.ElRewardsWei)
-
-		// update mev reward total
-		data.MEVRewardsWeiTotal = previousDayData.MEVRewardsWeiTotal.Add(data.MEVRewardsWei)
-
-		// update withdrawal total
-		data.WithdrawalsTotal = previousDayData.WithdrawalsTotal + data.Withdrawals
-		data.WithdrawalsAmountTotal = previousDayData.WithdrawalsAmountTotal + data.WithdrawalsAmount
-
-		// update deposits total
-		data.DepositsTotal = previousDayData.DepositsTotal + data.Deposits
-		data.DepositsAmountTotal = previousDayData.DepositsAmountTotal + data.DepositsAmount
+		updateValidatorStatsTotals(data, previousDayData)
 
 		if statisticsData1d != nil && len(statisticsData1d) > index {
 			data.ClPerformance1d = data.ClRewardsGWeiTotal - statisticsData1d[index].ClRewardsGWeiTotal
@@ -283,86 +347,8 @@ func WriteValidatorStatisticsForDay(day uint64, client rpc.Client) error {
 			return err
 		}
 
-		_, err = tx.CopyFrom(context.Background(), pgx.Identifier{"validator_stats"}, []string{
-			"validatorindex",
-			"day",
-			"start_balance",
-			"end_balance",
-			"min_balance",
-			"max_balance",
-			"start_effective_balance",
-			"end_effective_balance",
-			"min_effective_balance",
-			"max_effective_balance",
-			"missed_attestations",
-			"missed_attestations_total",
-			"orphaned_attestations",
-			"participated_sync",
-			"participated_sync_total",
-			"missed_sync",
-			"missed_sync_total",
-			"orphaned_sync",
-			"orphaned_sync_total",
-			"proposed_blocks",
-			"missed_blocks",
-			"orphaned_blocks",
-			"attester_slashings",
-			"proposer_slashings",
-			"deposits",
-			"deposits_total",
-			"deposits_amount",
-			"deposits_amount_total",
-			"withdrawals",
-			"withdrawals_total",
-			"withdrawals_amount",
-			"withdrawals_amount_total",
-			"cl_rewards_gwei",
-			"cl_rewards_gwei_total",
-			"el_rewards_wei",
-			"el_rewards_wei_total",
-			"mev_rewards_wei",
-			"mev_rewards_wei_total",
-		}, pgx.CopyFromSlice(len(validatorData), func(i int) ([]interface{}, error) {
-			return []interface{}{
-				validatorData[i].ValidatorIndex,
-				validatorData[i].Day,
-				validatorData[i].StartBalance,
-				validatorData[i].EndBalance,
-				validatorData[i].MinBalance,
-				validatorData[i].MaxBalance,
-				validatorData[i].StartEffectiveBalance,
-				validatorData[i].EndEffectiveBalance,
-				validatorData[i].MinEffectiveBalance,
-				validatorData[i].MaxEffectiveBalance,
-				validatorData[i].MissedAttestations,
-				validatorData[i].MissedAttestationsTotal,
-				validatorData[i].OrphanedAttestations,
-				validatorData[i].ParticipatedSync,
-				validatorData[i].ParticipatedSyncTotal,
-				validatorData[i].MissedSync,
-				validatorData[i].MissedSyncTotal,
-				validatorData[i].OrphanedSync,
-				validatorData[i].OrphanedSyncTotal,
-				validatorData[i].ProposedBlocks,
-				validatorData[i].MissedBlocks,
-				validatorData[i].OrphanedBlocks,
-				validatorData[i].AttesterSlashings,
-				validatorData[i].ProposerSlashing,
-				validatorData[i].Deposits,
-				validatorData[i].DepositsTotal,
-				validatorData[i].DepositsAmount,
-				validatorData[i].DepositsAmountTotal,
-				validatorData[i].Withdrawals,
-				validatorData[i].WithdrawalsTotal,
-				validatorData[i].WithdrawalsAmount,
-				validatorData[i].WithdrawalsAmountTotal,
-				validatorData[i].ClRewardsGWei,
-				validatorData[i].ClRewardsGWeiTotal,
-				validatorData[i].ElRewardsWei,
-				validatorData[i].ElRewardsWeiTotal,
-				validatorData[i].MEVRewardsWei,
-				validatorData[i].MEVRewardsWeiTotal,
-			}, nil
+		_, err = tx.CopyFrom(context.Background(), pgx.Identifier{"validator_stats"}, validatorStatsColumns, pgx.CopyFromSlice(len(validatorData), func(i int) ([]interface{}, error) {
+			return validatorStatsRowValues(validatorData[i]), nil
 		}))
 
 		if err != nil {
@@ -462,6 +448,11 @@ func WriteValidatorStatisticsForDay(day uint64, client rpc.Client) error {
 			return fmt.Errorf("error in WriteValidatorStatsExported: %w", err)
 		}
 
+		// the final row supersedes the intra-day aggregate of the day
+		if err := deleteValidatorIntraDayStatistics(day, tx); err != nil {
+			return fmt.Errorf("error in deleteValidatorIntraDayStatistics: %w", err)
+		}
+
 		err = tx.Commit(context.Background())
 		if err != nil {
 			return err
@@ -479,6 +470,35 @@ func WriteValidatorStatisticsForDay(day uint64, client rpc.Client) error {
 	return nil
 }
 
+// updateValidatorStatsTotals calculates the cl rewards of the day and accumulates the totals of the row on top of the row of the previous day
+func updateValidatorStatsTotals(data, previous *types.ValidatorStatsTableDbRow) {
+	// update attestation totals
+	data.MissedAttestationsTotal = previous.MissedAttestationsTotal + data.MissedAttestations
+
+	// update sync total
+	data.ParticipatedSyncTotal = previous.ParticipatedSyncTotal + data.ParticipatedSync
+	data.MissedSyncTotal = previous.MissedSyncTotal + data.MissedSync
+	data.OrphanedSyncTotal = previous.OrphanedSyncTotal + data.OrphanedSync
+
+	// calculate cl reward & update totals
+	data.ClRewardsGWei = data.EndBalance - previous.EndBalance + data.WithdrawalsAmount - data.DepositsAmount
+	data.ClRewardsGWeiTotal = previous.ClRewardsGWeiTotal + data.ClRewardsGWei
+
+	// update el reward total
+	data.ElRewardsWeiTotal = previous.ElRewardsWeiTotal.Add(data.ElRewardsWei)
+
+	// update mev reward total
+	data.MEVRewardsWeiTotal = previous.MEVRewardsWeiTotal.Add(data.MEVRewardsWei)
+
+	// update withdrawal total
+	data.WithdrawalsTotal = previous.WithdrawalsTotal + data.Withdrawals
+	data.WithdrawalsAmountTotal = previous.WithdrawalsAmountTotal + data.WithdrawalsAmount
+
+	// update deposits total
+	data.DepositsTotal = previous.DepositsTotal + data.Deposits
+	data.DepositsAmountTotal = previous.DepositsAmountTotal + data.DepositsAmount
+}
+
 func WriteValidatorStatsExported(day uint64, tx pgx.Tx) error {
 
 	start := time.Now()
@@ -624,14 +644,17 @@ func WriteValidatorTotalPerformance(day uint64, tx pgx.Tx) error {
 }
 
 func gatherValidatorBlockStats(day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
+	return gatherValidatorBlockStatsForEpochs(firstEpoch, lastEpoch, data, mux)
+}
+
+func gatherValidatorBlockStatsForEpochs(firstEpoch, lastEpoch uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
 	exportStart := time.Now()
 	defer func() {
 		metrics.TaskDuration.WithLabelValues("db_update_validator_block_stats").Observe(time.Since(exportStart).Seconds())
 	}()
 
-	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
 	logger := logger.WithFields(logrus.Fields{
-		"day":        day,
 		"firstEpoch": firstEpoch,
 		"lastEpoch":  lastEpoch,
 	})
@@ -652,7 +675,7 @@ func gatherValidatorBlockStats(day uint64, data []*types.ValidatorStatsTableDbRo
 		;`,
 		firstEpoch, lastEpoch, MaxSqlInteger)
 	if err != nil {
-		return fmt.Errorf("error retrieving blocks for day [%v], firstEpoch [%v] and lastEpoch [%v]: %w", day, firstEpoch, lastEpoch, err)
+		return fmt.Errorf("error retrieving blocks for firstEpoch [%v] and lastEpoch [%v]: %w", firstEpoch, lastEpoch, err)
 	}
 
 	mux.Lock()
@@ -679,7 +702,7 @@ func gatherValidatorBlockStats(day uint64, data []*types.ValidatorStatsTableDbRo
 		`,
 		firstEpoch, lastEpoch, MaxSqlInteger)
 	if err != nil {
-		return fmt.Errorf("error retrieving slashings for day [%v], firstEpoch [%v] and lastEpoch [%v]: %w", day, firstEpoch, lastEpoch, err)
+		return fmt.Errorf("error retrieving slashings for firstEpoch [%v] and lastEpoch [%v]: %w", firstEpoch, lastEpoch, err)
 	}
 
 	mux.Lock()
@@ -694,14 +717,17 @@ func gatherValidatorBlockStats(day uint64, data []*types.ValidatorStatsTableDbRo
 }
 
 func gatherValidatorElIcome(day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
+	return gatherValidatorElIcomeForEpochs(firstEpoch, lastEpoch, data, mux)
+}
+
+func gatherValidatorElIcomeForEpochs(firstEpoch, lastEpoch uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
 	exportStart := time.Now()
 	defer func() {
 		metrics.TaskDuration.WithLabelValues("db_update_validator_el_income_stats").Observe(time.Since(exportStart).Seconds())
 	}()
 
-	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
 	logger := logger.WithFields(logrus.Fields{
-		"day":        day,
 		"firstEpoch": firstEpoch,
 		"lastEpoch":  lastEpoch,
 	})
@@ -817,11 +843,6 @@ func gatherValidatorBalances(client rpc.Client, day uint64, data []*types.Valida
 }
 
 func gatherValidatorDepositWithdrawals(day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
-	exportStart := time.Now()
-	defer func() {
-		metrics.TaskDuration.WithLabelValues("db_update_validator_deposit_withdrawal_stats").Observe(time.Since(exportStart).Seconds())
-	}()
-
 	// The end_balance of a day is the balance after the first slot of the last epoch of that day.
 	// Therefore the last 31 slots of the day are not included in the end_balance of that day.
 	// Since our income calculation is base on subtracting end_balances the deposits and withdrawals that happen during those slots must be added to the next day instead.
@@ -831,8 +852,16 @@ func gatherValidatorDepositWithdrawals(day uint64, data []*types.ValidatorStatsT
 	}
 	lastSlot := utils.GetLastBalanceInfoSlotForDay(day)
 
+	return gatherValidatorDepositWithdrawalsForSlots(firstSlot, lastSlot, data, mux)
+}
+
+func gatherValidatorDepositWithdrawalsForSlots(firstSlot, lastSlot uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+	exportStart := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("db_update_validator_deposit_withdrawal_stats").Observe(time.Since(exportStart).Seconds())
+	}()
+
 	logger := logger.WithFields(logrus.Fields{
-		"day":       day,
 		"firstSlot": firstSlot,
 		"lastSlot":  lastSlot,
 	})
@@ -855,7 +884,7 @@ func gatherValidatorDepositWithdrawals(day uint64, data []*types.ValidatorStatsT
 
 	err := WriterDb.Select(&resDeposits, depositsQry, firstSlot, lastSlot)
 	if err != nil {
-		return fmt.Errorf("error retrieving deposits for day [%v], firstSlot [%v] and lastSlot [%v]: %w", day, firstSlot, lastSlot, err)
+		return fmt.Errorf("error retrieving deposits for firstSlot [%v] and lastSlot [%v]: %w", firstSlot, lastSlot, err)
 	}
 
 	mux.Lock()
@@ -879,7 +908,7 @@ func gatherValidatorDepositWithdrawals(day uint64, data []*types.ValidatorStatsT
 			group by validatorindex;`
 	err = WriterDb.Select(&resWithdrawals, withdrawalsQuery, firstSlot, lastSlot)
 	if err != nil {
-		return fmt.Errorf("error retrieving withdrawals for day [%v], firstSlot [%v] and lastSlot [%v]: %w", day, firstSlot, lastSlot, err)
+		return fmt.Errorf("error retrieving withdrawals for firstSlot [%v] and lastSlot [%v]: %w", firstSlot, lastSlot, err)
 	}
 
 	mux.Lock()
@@ -894,20 +923,23 @@ func gatherValidatorDepositWithdrawals(day uint64, data []*types.ValidatorStatsT
 }
 
 func GatherValidatorSyncDutiesForDay(validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
+	return gatherValidatorSyncDutiesForEpochs(firstEpoch, lastEpoch, data, mux)
+}
+
+func gatherValidatorSyncDutiesForEpochs(firstEpoch, lastEpoch uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
 	exportStart := time.Now()
 	defer func() {
 		metrics.TaskDuration.WithLabelValues("db_update_validator_sync_stats").Observe(time.Since(exportStart).Seconds())
 	}()
 
-	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
 	if firstEpoch < utils.Config.Chain.ClConfig.AltairForkEpoch && lastEpoch > utils.Config.Chain.ClConfig.AltairForkEpoch {
 		firstEpoch = utils.Config.Chain.ClConfig.AltairForkEpoch
 	} else if lastEpoch < utils.Config.Chain.ClConfig.AltairForkEpoch {
-		logger.Infof("day %v is pre-altair, skipping sync committee export", day)
+		logger.Infof("epochs %v-%v are pre-altair, skipping sync committee export", firstEpoch, lastEpoch)
 		return nil
 	}
 	logger := logger.WithFields(logrus.Fields{
-		"day":         day,
 		"firstEpoch":  firstEpoch,
 		"lastEpoch":   lastEpoch,
 		"startPeriod": utils.SyncPeriodOfEpoch(firstEpoch),
@@ -918,7 +950,7 @@ func GatherValidatorSyncDutiesForDay(validators []uint64, day uint64, data []*ty
 	//map to hold the sync committee members for a given period
 	syncCommittees := make(map[types.SyncCommitteePeriod]map[types.CommitteeIndex]types.ValidatorIndex)
 
-	// iterate over all proposed slots of the statistics day
+	// iterate over all proposed slots of the epoch range
 	rows, err := ReaderDb.Query("SELECT slot, syncaggregate_bits FROM blocks WHERE epoch >= $1 AND epoch <= $2 AND status = '1'", firstEpoch, lastEpoch)
 
 	if err != nil {
@@ -990,14 +1022,17 @@ func GatherValidatorSyncDutiesForDay(validators []uint64, day uint64, data []*ty
 }
 
 func gatherValidatorMissedAttestationsStatisticsForDay(validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
+	return gatherValidatorMissedAttestationsStatisticsForEpochs(validators, firstEpoch, lastEpoch, data, mux)
+}
+
+func gatherValidatorMissedAttestationsStatisticsForEpochs(validators []uint64, firstEpoch, lastEpoch uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
 	exportStart := time.Now()
 	defer func() {
 		metrics.TaskDuration.WithLabelValues("db_update_validator_failed_att_stats").Observe(time.Since(exportStart).Seconds())
 	}()
 
-	firstEpoch, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
 	logger := logger.WithFields(logrus.Fields{
-		"day":        day,
 		"firstEpoch": firstEpoch,
 		"lastEpoch":  lastEpoch,
 	})
@@ -1117,6 +1152,11 @@ func gatherValidatorMissedAttestationsStatisticsForDay(validators []uint64, day
 }
 
 func GatherStatisticsForDay(day int64) ([]*types.ValidatorStatsTableDbRow, error) {
+	return gatherStatisticsFromTable("validator_stats", day)
+}
+
+// gatherStatisticsFromTable returns the rows of the given day from validator_stats or a table with the same columns, ordered by validatorindex
+func gatherStatisticsFromTable(table string, day int64) ([]*types.ValidatorStatsTableDbRow, error) {
 
 	if day < 0 {
 		return nil, nil
@@ -1128,11 +1168,11 @@ func GatherStatisticsForDay(day int64) ([]*types.ValidatorStatsTableDbRow, error
 
 	start := time.Now()
 
-	logger.Infof("gathering existing statistics for day %v", day)
+	logger.Infof("gathering existing statistics from %v for day %v", table, day)
 
 	ret := make([]*types.ValidatorStatsTableDbRow, 0)
 
-	err := WriterDb.Select(&ret, `SELECT 
+	err := WriterDb.Select(&ret, fmt.Sprintf(`SELECT 
 		validatorindex, 
 		day, 
 		COALESCE(start_balance, 0) AS start_balance,
@@ -1171,14 +1211,14 @@ func GatherStatisticsForDay(day int64) ([]*types.ValidatorStatsTableDbRow, error
 		COALESCE(el_rewards_wei_total, 0) AS el_rewards_wei_total,
 		COALESCE(mev_rewards_wei, 0) AS mev_rewards_wei,
 		COALESCE(mev_rewards_wei_total, 0) AS mev_rewards_wei_total
-	 from validator_stats WHERE day = $1 ORDER BY validatorindex
-	`, day)
+	 from %s WHERE day = $1 ORDER BY validatorindex
+	`, table), day)
 
 	if err != nil {
-		return nil, fmt.Errorf("error statistics for day %v data: %w", day, err)
+		return nil, fmt.Errorf("error statistics from %v for day %v data: %w", table, day, err)
 	}
 
-	logrus.Infof("gathering existing statistics for day %v completed, took %v", day, time.Since(start))
+	logrus.Infof("gathering existing statistics from %v for day %v completed, took %v", table, day, time.Since(start))
 	return ret, nil
 }
 
@@ -1364,6 +1404,12 @@ func WriteChartSeriesForDay(day int64) error {
 		return err
 	}
 
+	// config-defined indicators are written separately, a broken indicator query must not fail the export of the built-in series
+	err = WriteCustomChartSeriesForDay(day)
+	if err != nil {
+		utils.LogError(err, "error writing custom chart_series", 0, map[string]interface{}{"day": day})
+	}
+
 	logger.Infof("marking day export as completed in the chart_series_status table for day %v", day)
 	_, err = WriterDb.Exec("insert into chart_series_status (day, status) values ($1, true)", day)
 	if err != nil {
@@ -1380,26 +1426,7 @@ func WriteConsensusChartSeriesForDay(day int64) error {
 		return nil
 	}
 
-	epochsPerDay := utils.EpochsPerDay()
-	beaconchainDay := day * int64(epochsPerDay)
-
-	startDate := utils.EpochToTime(uint64(beaconchainDay))
-	dateTrunc := time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
-
-	// inclusive slot
-	firstSlot := utils.TimeToFirstSlotOfEpoch(uint64(dateTrunc.Unix()))
-
-	epochOffset := firstSlot % utils.Config.Chain.ClConfig.SlotsPerEpoch
-	firstSlot = firstSlot - epochOffset
-	firstEpoch := firstSlot / utils.Config.Chain.ClConfig.SlotsPerEpoch
-	// exclusive slot
-	lastSlot := int64(firstSlot) + int64(epochsPerDay*utils.Config.Chain.ClConfig.SlotsPerEpoch)
-	if firstSlot == 0 {
-		nextDateTrunc := time.Date(startDate.Year(), startDate.Month(), startDate.Day()+1, 0, 0, 0, 0, time.UTC)
-		lastSlot = int64(utils.TimeToFirstSlotOfEpoch(uint64(nextDateTrunc.Unix())))
-	}
-	lastEpoch := lastSlot / int64(utils.Config.Chain.ClConfig.SlotsPerEpoch)
-	lastSlot = lastEpoch * int64(utils.Config.Chain.ClConfig.SlotsPerEpoch)
+	startDate, dateTrunc, firstSlot, lastSlot, firstEpoch, lastEpoch := consensusChartSeriesDayBounds(day)
 
 	logrus.WithFields(logrus.Fields{"day": day, "firstSlot": firstSlot, "lastSlot": lastSlot, "firstEpoch": firstEpoch, "lastEpoch": lastEpoch, "startDate": startDate, "dateTrunc": dateTrunc}).Infof("exporting consensus chart_series")
 
@@ -1463,6 +1490,31 @@ func WriteConsensusChartSeriesForDay(day int64) error {
 	return nil
 }
 
+// consensusChartSeriesDayBounds returns the utc day a chart_series day is stored at together with its first slot & epoch (inclusive) and its last slot & epoch (exclusive)
+func consensusChartSeriesDayBounds(day int64) (startDate, dateTrunc time.Time, firstSlot uint64, lastSlot int64, firstEpoch uint64, lastEpoch int64) {
+	epochsPerDay := utils.EpochsPerDay()
+	beaconchainDay := day * int64(epochsPerDay)
+
+	startDate = utils.EpochToTime(uint64(beaconchainDay))
+	dateTrunc = time.Date(startDate.Year(), startDate.Month(), startDate.Day(), 0, 0, 0, 0, time.UTC)
+
+	// inclusive slot
+	firstSlot = utils.TimeToFirstSlotOfEpoch(uint64(dateTrunc.Unix()))
+
+	epochOffset := firstSlot % utils.Config.Chain.ClConfig.SlotsPerEpoch
+	firstSlot = firstSlot - epochOffset
+	firstEpoch = firstSlot / utils.Config.Chain.ClConfig.SlotsPerEpoch
+	// exclusive slot
+	lastSlot = int64(firstSlot) + int64(epochsPerDay*utils.Config.Chain.ClConfig.SlotsPerEpoch)
+	if firstSlot == 0 {
+		nextDateTrunc := time.Date(startDate.Year(), startDate.Month(), startDate.Day()+1, 0, 0, 0, 0, time.UTC)
+		lastSlot = int64(utils.TimeToFirstSlotOfEpoch(uint64(nextDateTrunc.Unix())))
+	}
+	lastEpoch = lastSlot / int64(utils.Config.Chain.ClConfig.SlotsPerEpoch)
+	lastSlot = lastEpoch * int64(utils.Config.Chain.ClConfig.SlotsPerEpoch)
+	return startDate, dateTrunc, firstSlot, lastSlot, firstEpoch, lastEpoch
+}
+
 func WriteExecutionChartSeriesForDay(day int64) error {
 	if utils.Config.Chain.ClConfig.DepositChainID != 1 {
 		// logger.Warnf("not writing chart_series for execution: chainId != 1: %v", utils.Config.Chain.ClConfig.DepositChainID)
@@ -1773,9 +1825,8 @@ func WriteExecutionChartSeriesForDay(day int64) error {
 
 	switch utils.Config.Chain.ClConfig.DepositChainID {
 	case 1:
-		crowdSale := 72009990.50
-		logger.Infof("Exporting MARKET_CAP: %v", newEmission.Div(decimal.NewFromInt(1e18)).Add(decimal.NewFromFloat(crowdSale)).Mul(decimal.NewFromFloat(price.GetPrice(utils.Config.Frontend.MainCurrency, "USD"))).String())
-		err = SaveChartSeriesPoint(dateTrunc, "MARKET_CAP", newEmission.Div(decimal.NewFromInt(1e18)).Add(decimal.NewFromFloat(crowdSale)).Mul(decimal.NewFromFloat(price.GetPrice(utils.Config.Frontend.MainCurrency, "USD"))).String())
+		logger.Infof("Exporting MARKET_CAP: %v", newEmission.Div(decimal.NewFromInt(1e18)).Add(decimal.NewFromFloat(mainnetCrowdSaleSupply)).Mul(decimal.NewFromFloat(price.GetPrice(utils.Config.Frontend.MainCurrency, "USD"))).String())
+		err = SaveChartSeriesPoint(dateTrunc, "MARKET_CAP", newEmission.Div(decimal.NewFromInt(1e18)).Add(decimal.NewFromFloat(mainnetCrowdSaleSupply)).Mul(decimal.NewFromFloat(price.GetPrice(utils.Config.Frontend.MainCurrency, "USD"))).String())
 		if err != nil {
 			return fmt.Errorf("error calculating MARKET_CAP chart_series: %w", err)
 		}
@@ -0,0 +1,328 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/rpc"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	pgxdecimal "github.com/jackc/pgx-shopspring-decimal"
+)
+
+// WriteValidatorIntraDayStatistics aggregates all epochs of the current day that have not been aggregated yet into the validator_stats_intraday table.
+// Attestations of an epoch can be included until the end of the next epoch, therefore the aggregate lags one epoch behind the latest finalized epoch.
+// Once the day has been exported to validator_stats the intra-day rows of the day are removed.
+func WriteValidatorIntraDayStatistics(client rpc.Client) error {
+	exportStart := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("db_update_validator_stats_intraday").Observe(time.Since(exportStart).Seconds())
+	}()
+
+	latestFinalizedEpoch, err := GetLatestFinalizedEpoch()
+	if err != nil {
+		return fmt.Errorf("error getting latest finalized epoch from db %w", err)
+	}
+	if latestFinalizedEpoch == 0 {
+		return nil
+	}
+	lastEpoch := latestFinalizedEpoch - 1
+	day := lastEpoch / utils.EpochsPerDay()
+	firstEpochOfDay, _ := utils.GetFirstAndLastEpochForDay(day)
+
+	logger := logger.WithFields(logrus.Fields{
+		"day":       day,
+		"lastEpoch": lastEpoch,
+	})
+
+	exported := []struct {
+		Day    int64 `db:"day"`
+		Status bool  `db:"status"`
+	}{}
+	err = WriterDb.Select(&exported, `SELECT day, status FROM validator_stats_status WHERE day = $1 OR day = $2`, day, int64(day)-1)
+	if err != nil {
+		return fmt.Errorf("error retrieving exported state: %w", err)
+	}
+	dayExported := false
+	previousDayExported := day == 0
+	for _, e := range exported {
+		if e.Day == int64(day) {
+			dayExported = e.Status
+		} else {
+			previousDayExported = e.Status
+		}
+	}
+	if dayExported {
+		logger.Infof("skipping intra-day statistics as day %v is already exported", day)
+		return nil
+	}
+	if !previousDayExported {
+		return fmt.Errorf("cannot aggregate intra-day statistics of day %v as day %v has not been exported yet", day, int64(day)-1)
+	}
+
+	fromEpoch := firstEpochOfDay
+	var aggregatedEpoch int64
+	err = WriterDb.Get(&aggregatedEpoch, `SELECT last_epoch FROM validator_stats_intraday_status WHERE day = $1`, day)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("error retrieving intra-day statistics status of day %v: %w", day, err)
+	}
+	if err == nil {
+		fromEpoch = uint64(aggregatedEpoch) + 1
+	}
+	if fromEpoch > lastEpoch {
+		return nil
+	}
+
+	logger.Infof("aggregating intra-day statistics for epochs %v-%v", fromEpoch, lastEpoch)
+
+	existingData := []*types.ValidatorStatsTableDbRow{}
+	if fromEpoch > firstEpochOfDay {
+		existingData, err = gatherStatisticsFromTable("validator_stats_intraday", int64(day))
+		if err != nil {
+			return err
+		}
+	}
+
+	maxValidatorIndex, err := BigtableClient.GetMaxValidatorindexForEpoch(lastEpoch)
+	if err != nil {
+		return err
+	}
+	validators := make([]uint64, 0, maxValidatorIndex)
+	validatorData := make([]*types.ValidatorStatsTableDbRow, 0, maxValidatorIndex)
+	// epochData holds the statistics of the epochs that are aggregated in this run
+	epochData := make([]*types.ValidatorStatsTableDbRow, 0, maxValidatorIndex)
+	validatorDataMux := &sync.Mutex{}
+
+	for i := uint64(0); i <= maxValidatorIndex; i++ {
+		validators = append(validators, i)
+		if i < uint64(len(existingData)) {
+			if existingData[i].ValidatorIndex != i {
+				return fmt.Errorf("logic error when retrieving intra-day data for validator %v (%v retrieved)", i, existingData[i].ValidatorIndex)
+			}
+			validatorData = append(validatorData, existingData[i])
+		} else {
+			validatorData = append(validatorData, &types.ValidatorStatsTableDbRow{
+				ValidatorIndex: i,
+				Day:            int64(day),
+			})
+		}
+		epochData = append(epochData, &types.ValidatorStatsTableDbRow{
+			ValidatorIndex: i,
+			Day:            int64(day),
+		})
+	}
+
+	// deposits and withdrawals are attributed to the balance they are reflected in (see gatherValidatorDepositWithdrawals)
+	firstSlot := (fromEpoch-1)*utils.Config.Chain.ClConfig.SlotsPerEpoch + 1
+	if fromEpoch == 0 {
+		firstSlot = 0
+	}
+	lastSlot := lastEpoch * utils.Config.Chain.ClConfig.SlotsPerEpoch
+
+	g := &errgroup.Group{}
+
+	g.Go(func() error {
+		if err := gatherValidatorMissedAttestationsStatisticsForEpochs(validators, fromEpoch, lastEpoch, epochData, validatorDataMux); err != nil {
+			return fmt.Errorf("error in gatherValidatorMissedAttestationsStatisticsForEpochs: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		if err := gatherValidatorSyncDutiesForEpochs(fromEpoch, lastEpoch, epochData, validatorDataMux); err != nil {
+			return fmt.Errorf("error in gatherValidatorSyncDutiesForEpochs: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		if err := gatherValidatorDepositWithdrawalsForSlots(firstSlot, lastSlot, epochData, validatorDataMux); err != nil {
+			return fmt.Errorf("error in gatherValidatorDepositWithdrawalsForSlots: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		if err := gatherValidatorBlockStatsForEpochs(fromEpoch, lastEpoch, epochData, validatorDataMux); err != nil {
+			return fmt.Errorf("error in gatherValidatorBlockStatsForEpochs: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		if err := gatherValidatorElIcomeForEpochs(fromEpoch, lastEpoch, epochData, validatorDataMux); err != nil {
+			return fmt.Errorf("error in gatherValidatorElIcomeForEpochs: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		if err := gatherValidatorIntraDayBalances(client, firstEpochOfDay, fromEpoch, lastEpoch, validatorData, validatorDataMux); err != nil {
+			return fmt.Errorf("error in gatherValidatorIntraDayBalances: %w", err)
+		}
+		return nil
+	})
+
+	var statisticsData1d []*types.ValidatorStatsTableDbRow
+	g.Go(func() error {
+		var err error
+		statisticsData1d, err = GatherStatisticsForDay(int64(day) - 1) // convert to int64 to avoid underflows
+		if err != nil {
+			return fmt.Errorf("error in GatherPreviousDayStatisticsData: %w", err)
+		}
+		return nil
+	})
+
+	err = g.Wait()
+	if err != nil {
+		return err
+	}
+
+	for index, data := range validatorData {
+		e := epochData[index]
+
+		data.MissedAttestations += e.MissedAttestations
+		data.ParticipatedSync += e.ParticipatedSync
+		data.MissedSync += e.MissedSync
+		data.OrphanedSync += e.OrphanedSync
+		data.ProposedBlocks += e.ProposedBlocks
+		data.MissedBlocks += e.MissedBlocks
+		data.OrphanedBlocks += e.OrphanedBlocks
+		data.AttesterSlashings += e.AttesterSlashings
+		data.ProposerSlashing += e.ProposerSlashing
+		data.Deposits += e.Deposits
+		data.DepositsAmount += e.DepositsAmount
+		data.Withdrawals += e.Withdrawals
+		data.WithdrawalsAmount += e.WithdrawalsAmount
+		data.ElRewardsWei = data.ElRewardsWei.Add(e.ElRewardsWei)
+		data.MEVRewardsWei = data.MEVRewardsWei.Add(e.MEVRewardsWei)
+
+		previousDayData := &types.ValidatorStatsTableDbRow{
+			ValidatorIndex: data.ValidatorIndex,
+		}
+		if index < len(statisticsData1d) && day > 0 {
+			previousDayData = statisticsData1d[index]
+		}
+		if data.ValidatorIndex != previousDayData.ValidatorIndex {
+			return fmt.Errorf("logic error when retrieving previous day data for validator %v (%v wanted, %v retrieved)", index, data.ValidatorIndex, previousDayData.ValidatorIndex)
+		}
+
+		updateValidatorStatsTotals(data, previousDayData)
+	}
+
+	conn, err := WriterDb.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("error retrieving raw sql connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		conn := driverConn.(*stdlib.Conn).Conn()
+
+		pgxdecimal.Register(conn.TypeMap())
+		tx, err := conn.Begin(context.Background())
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(context.Background())
+
+		// rows of previous days have been superseded by the final export of those days
+		err = deleteValidatorIntraDayStatistics(day, tx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.CopyFrom(context.Background(), pgx.Identifier{"validator_stats_intraday"}, validatorStatsColumns, pgx.CopyFromSlice(len(validatorData), func(i int) ([]interface{}, error) {
+			return validatorStatsRowValues(validatorData[i]), nil
+		}))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(), `
+			INSERT INTO validator_stats_intraday_status (day, last_epoch, updated_at) VALUES ($1, $2, NOW())
+			ON CONFLICT (day) DO UPDATE SET last_epoch = excluded.last_epoch, updated_at = excluded.updated_at`, day, lastEpoch)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit(context.Background())
+	})
+	if err != nil {
+		return fmt.Errorf("error during intra-day statistics data insert: %w", err)
+	}
+
+	logger.Infof("intra-day statistics export for epochs %v-%v completed, took %v", fromEpoch, lastEpoch, time.Since(exportStart))
+	return nil
+}
+
+// gatherValidatorIntraDayBalances sets the start balances of the day when the first epochs of the day are aggregated and the end balances to the balances of lastEpoch.
+// The min and max balances cover the balances seen by the runs of the day, the range of a row is initialized by the first balance it sees.
+func gatherValidatorIntraDayBalances(client rpc.Client, firstEpochOfDay, fromEpoch, lastEpoch uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+	if fromEpoch == firstEpochOfDay {
+		firstEpochBalances, err := client.GetValidatorState(firstEpochOfDay)
+		if err != nil {
+			return fmt.Errorf("error in GetValidatorState for epoch [%v]: %w", firstEpochOfDay, err)
+		}
+		mux.Lock()
+		for _, stat := range firstEpochBalances.Data {
+			if uint64(stat.Index) >= uint64(len(data)) {
+				continue
+			}
+			data[stat.Index].StartBalance = int64(stat.Balance)
+			data[stat.Index].StartEffectiveBalance = int64(stat.Validator.EffectiveBalance)
+			updateValidatorIntraDayBalanceRange(data[stat.Index], int64(stat.Balance), int64(stat.Validator.EffectiveBalance))
+		}
+		mux.Unlock()
+	}
+
+	lastEpochBalances, err := client.GetValidatorState(lastEpoch)
+	if err != nil {
+		return fmt.Errorf("error in GetValidatorState for epoch [%v]: %w", lastEpoch, err)
+	}
+	mux.Lock()
+	for _, stat := range lastEpochBalances.Data {
+		if uint64(stat.Index) >= uint64(len(data)) {
+			continue
+		}
+		data[stat.Index].EndBalance = int64(stat.Balance)
+		data[stat.Index].EndEffectiveBalance = int64(stat.Validator.EffectiveBalance)
+		updateValidatorIntraDayBalanceRange(data[stat.Index], int64(stat.Balance), int64(stat.Validator.EffectiveBalance))
+	}
+	mux.Unlock()
+	return nil
+}
+
+// updateValidatorIntraDayBalanceRange extends the min and max balances of a row by a balance, rows without a range yet start at the balance
+func updateValidatorIntraDayBalanceRange(row *types.ValidatorStatsTableDbRow, balance, effectiveBalance int64) {
+	if row.MinBalance == 0 && row.MaxBalance == 0 {
+		row.MinBalance = balance
+		row.MaxBalance = balance
+	}
+	if row.MinEffectiveBalance == 0 && row.MaxEffectiveBalance == 0 {
+		row.MinEffectiveBalance = effectiveBalance
+		row.MaxEffectiveBalance = effectiveBalance
+	}
+	row.MinBalance = min(row.MinBalance, balance)
+	row.MaxBalance = max(row.MaxBalance, balance)
+	row.MinEffectiveBalance = min(row.MinEffectiveBalance, effectiveBalance)
+	row.MaxEffectiveBalance = max(row.MaxEffectiveBalance, effectiveBalance)
+}
+
+// deleteValidatorIntraDayStatistics removes the intra-day rows of the given day and all days before it
+func deleteValidatorIntraDayStatistics(day uint64, tx pgx.Tx) error {
+	_, err := tx.Exec(context.Background(), "DELETE FROM validator_stats_intraday WHERE day <= $1", day)
+	if err != nil {
+		return fmt.Errorf("error deleting intra-day statistics up to day %v: %w", day, err)
+	}
+	_, err = tx.Exec(context.Background(), "DELETE FROM validator_stats_intraday_status WHERE day <= $1", day)
+	if err != nil {
+		return fmt.Errorf("error deleting intra-day statistics status up to day %v: %w", day, err)
+	}
+	return nil
+}
@@ -0,0 +1,469 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/rpc"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+
+	pgxdecimal "github.com/jackc/pgx-shopspring-decimal"
+)
+
+// validatorStatsColumnGroup is a set of validator_stats columns that is gathered from a single source and can be recomputed on its own
+type validatorStatsColumnGroup struct {
+	// validator_stats_status columns tracking the progress of the group
+	statusColumns []string
+	// validator_stats columns written by the group, including the accumulated totals
+	columns []string
+	reset   func(row *types.ValidatorStatsTableDbRow)
+	gather  func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error
+	// accumulate updates the totals of the group based on the row of the previous day
+	accumulate func(row, previous *types.ValidatorStatsTableDbRow)
+}
+
+var validatorStatsColumnGroups = map[string]validatorStatsColumnGroup{
+	"balances": {
+		statusColumns: []string{"balance_exported"},
+		columns:       []string{"start_balance", "end_balance", "start_effective_balance", "end_effective_balance"},
+		reset: func(row *types.ValidatorStatsTableDbRow) {
+			row.StartBalance, row.EndBalance, row.StartEffectiveBalance, row.EndEffectiveBalance = 0, 0, 0, 0
+		},
+		gather: func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+			return gatherValidatorBalances(client, day, data, mux)
+		},
+		accumulate: func(row, previous *types.ValidatorStatsTableDbRow) {},
+	},
+	"el_income": {
+		statusColumns: []string{"el_rewards_exported"},
+		columns:       []string{"el_rewards_wei", "el_rewards_wei_total", "mev_rewards_wei", "mev_rewards_wei_total"},
+		reset: func(row *types.ValidatorStatsTableDbRow) {
+			row.ElRewardsWei, row.MEVRewardsWei = decimal.Zero, decimal.Zero
+		},
+		gather: func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+			return gatherValidatorElIcome(day, data, mux)
+		},
+		accumulate: func(row, previous *types.ValidatorStatsTableDbRow) {
+			row.ElRewardsWeiTotal = previous.ElRewardsWeiTotal.Add(row.ElRewardsWei)
+			row.MEVRewardsWeiTotal = previous.MEVRewardsWeiTotal.Add(row.MEVRewardsWei)
+		},
+	},
+	"block_stats": {
+		statusColumns: []string{"block_stats_exported"},
+		columns:       []string{"proposed_blocks", "missed_blocks", "orphaned_blocks", "attester_slashings", "proposer_slashings"},
+		reset: func(row *types.ValidatorStatsTableDbRow) {
+			row.ProposedBlocks, row.MissedBlocks, row.OrphanedBlocks, row.AttesterSlashings, row.ProposerSlashing = 0, 0, 0, 0, 0
+		},
+		gather: func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+			return gatherValidatorBlockStats(day, data, mux)
+		},
+		accumulate: func(row, previous *types.ValidatorStatsTableDbRow) {},
+	},
+	"sync_duties": {
+		statusColumns: []string{"sync_duties_exported"},
+		columns:       []string{"participated_sync", "participated_sync_total", "missed_sync", "missed_sync_total", "orphaned_sync", "orphaned_sync_total"},
+		reset: func(row *types.ValidatorStatsTableDbRow) {
+			row.ParticipatedSync, row.MissedSync, row.OrphanedSync = 0, 0, 0
+		},
+		gather: func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+			return GatherValidatorSyncDutiesForDay(validators, day, data, mux)
+		},
+		accumulate: func(row, previous *types.ValidatorStatsTableDbRow) {
+			row.ParticipatedSyncTotal = previous.ParticipatedSyncTotal + row.ParticipatedSync
+			row.MissedSyncTotal = previous.MissedSyncTotal + row.MissedSync
+			row.OrphanedSyncTotal = previous.OrphanedSyncTotal + row.OrphanedSync
+		},
+	},
+	"missed_attestations": {
+		statusColumns: []string{"failed_attestations_exported"},
+		columns:       []string{"missed_attestations", "missed_attestations_total"},
+		reset: func(row *types.ValidatorStatsTableDbRow) {
+			row.MissedAttestations = 0
+		},
+		gather: func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+			return gatherValidatorMissedAttestationsStatisticsForDay(validators, day, data, mux)
+		},
+		accumulate: func(row, previous *types.ValidatorStatsTableDbRow) {
+			row.MissedAttestationsTotal = previous.MissedAttestationsTotal + row.MissedAttestations
+		},
+	},
+	"deposits_withdrawals": {
+		statusColumns: []string{"withdrawals_deposits_exported"},
+		columns:       []string{"deposits", "deposits_total", "deposits_amount", "deposits_amount_total", "withdrawals", "withdrawals_total", "withdrawals_amount", "withdrawals_amount_total"},
+		reset: func(row *types.ValidatorStatsTableDbRow) {
+			row.Deposits, row.DepositsAmount, row.Withdrawals, row.WithdrawalsAmount = 0, 0, 0, 0
+		},
+		gather: func(client rpc.Client, validators []uint64, day uint64, data []*types.ValidatorStatsTableDbRow, mux *sync.Mutex) error {
+			return gatherValidatorDepositWithdrawals(day, data, mux)
+		},
+		accumulate: func(row, previous *types.ValidatorStatsTableDbRow) {
+			row.DepositsTotal = previous.DepositsTotal + row.Deposits
+			row.DepositsAmountTotal = previous.DepositsAmountTotal + row.DepositsAmount
+			row.WithdrawalsTotal = previous.WithdrawalsTotal + row.Withdrawals
+			row.WithdrawalsAmountTotal = previous.WithdrawalsAmountTotal + row.WithdrawalsAmount
+		},
+	},
+}
+
+// ValidatorStatsColumnGroups lists the column groups that can be recomputed with RecomputeValidatorStatisticsColumns
+var ValidatorStatsColumnGroups = []string{"balances", "el_income", "block_stats", "sync_duties", "missed_attestations", "deposits_withdrawals"}
+
+// ParseValidatorStatsColumnGroups parses a comma separated list of column groups
+func ParseValidatorStatsColumnGroups(s string) ([]string, error) {
+	groups := []string{}
+	seen := map[string]bool{}
+	for _, g := range strings.Split(s, ",") {
+		g = strings.TrimSpace(g)
+		if g == "" || seen[g] {
+			continue
+		}
+		if _, exists := validatorStatsColumnGroups[g]; !exists {
+			return nil, fmt.Errorf("unknown column group %q, supported groups: %v", g, strings.Join(ValidatorStatsColumnGroups, ", "))
+		}
+		seen[g] = true
+		groups = append(groups, g)
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("no column group given, supported groups: %v", strings.Join(ValidatorStatsColumnGroups, ", "))
+	}
+	return groups, nil
+}
+
+// validatorStatsRecomputesClRewards returns true if cl rewards have to be recomputed as they are derived from balances, deposits and withdrawals
+func validatorStatsRecomputesClRewards(groups []string) bool {
+	for _, g := range groups {
+		if g == "balances" || g == "deposits_withdrawals" {
+			return true
+		}
+	}
+	return false
+}
+
+func validatorStatsStatusColumns(groups []string) []string {
+	columns := []string{}
+	for _, g := range groups {
+		columns = append(columns, validatorStatsColumnGroups[g].statusColumns...)
+	}
+	if validatorStatsRecomputesClRewards(groups) {
+		columns = append(columns, "cl_rewards_exported")
+	}
+	return columns
+}
+
+// RecomputeValidatorStatisticsColumns recomputes only the given column groups of the already exported days firstDay to lastDay.
+// Days are processed in order as the totals of a day are based on the totals of the previous day, the totals of the exported days
+// after lastDay are rebuilt on top of the recomputed totals. Progress is tracked per column group in validator_stats_status, if resume
+// is set days that have already been recomputed for all groups are skipped.
+func RecomputeValidatorStatisticsColumns(groups []string, firstDay, lastDay uint64, client rpc.Client, resume bool) error {
+	if firstDay > lastDay {
+		return fmt.Errorf("invalid day range %v-%v", firstDay, lastDay)
+	}
+	lastExportedDay, err := GetLastExportedStatisticDay()
+	if err != nil {
+		return fmt.Errorf("error retrieving last exported statistics day: %w", err)
+	}
+	if lastDay > lastExportedDay {
+		return fmt.Errorf("cannot recompute day %v as only days up to %v have been exported", lastDay, lastExportedDay)
+	}
+
+	statusColumns := validatorStatsStatusColumns(groups)
+	if !resume {
+		setClauses := make([]string, 0, len(statusColumns))
+		for _, c := range statusColumns {
+			setClauses = append(setClauses, c+" = false")
+		}
+		_, err = WriterDb.Exec(fmt.Sprintf(`UPDATE validator_stats_status SET %s WHERE day >= $1 AND day <= $2`, strings.Join(setClauses, ", ")), firstDay, lastDay)
+		if err != nil {
+			return fmt.Errorf("error resetting column group status for days %v-%v: %w", firstDay, lastDay, err)
+		}
+	}
+
+	for day := firstDay; day <= lastDay; day++ {
+		err = RecomputeValidatorStatisticsColumnsForDay(groups, day, client)
+		if err != nil {
+			return err
+		}
+		metrics.Progress.WithLabelValues("statistics_recompute_columns").Set(float64(day-firstDay+1) / float64(lastDay-firstDay+1))
+	}
+
+	// the daily values of the following days did not change, their totals are rebuilt on top of the recomputed totals
+	for day := lastDay + 1; day <= lastExportedDay; day++ {
+		err = propagateValidatorStatisticsTotals(groups, day)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// propagateValidatorStatisticsTotals recalculates the totals of the given column groups of a day from the totals of the previous day
+func propagateValidatorStatisticsTotals(groups []string, day uint64) error {
+	columns := []string{}
+	for _, group := range groups {
+		columns = append(columns, validatorStatsColumnGroups[group].columns...)
+	}
+	if validatorStatsRecomputesClRewards(groups) {
+		columns = append(columns, "cl_rewards_gwei_total")
+	}
+	setClauses := []string{}
+	for _, c := range columns {
+		if daily, isTotal := strings.CutSuffix(c, "_total"); isTotal {
+			setClauses = append(setClauses, fmt.Sprintf("%s = p.%s + vs.%s", c, c, daily))
+		}
+	}
+	if len(setClauses) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	_, err := WriterDb.Exec(fmt.Sprintf(`
+		UPDATE validator_stats vs SET %s
+		FROM validator_stats p
+		WHERE vs.day = $1 AND p.day = $1 - 1 AND p.validatorindex = vs.validatorindex`, strings.Join(setClauses, ", ")), day)
+	if err != nil {
+		return fmt.Errorf("error propagating the totals of column groups %v to day %v: %w", strings.Join(groups, ", "), day, err)
+	}
+	logger.WithFields(logrus.Fields{"day": day, "groups": strings.Join(groups, ",")}).Infof("propagated recomputed totals, took %v", time.Since(start))
+	return nil
+}
+
+// RecomputeValidatorStatisticsColumnsForDay recomputes the given column groups of an exported day and updates only their columns
+func RecomputeValidatorStatisticsColumnsForDay(groups []string, day uint64, client rpc.Client) error {
+	start := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("db_recompute_validator_stats_columns").Observe(time.Since(start).Seconds())
+	}()
+	logger := logger.WithFields(logrus.Fields{"day": day, "groups": strings.Join(groups, ",")})
+
+	statusColumns := validatorStatsStatusColumns(groups)
+	done := false
+	err := WriterDb.Get(&done, fmt.Sprintf(`SELECT status AND %s FROM validator_stats_status WHERE day = $1`, strings.Join(statusColumns, " AND ")), day)
+	if err != nil {
+		return fmt.Errorf("error retrieving column group status for day %v: %w", day, err)
+	}
+	if done {
+		logger.Infof("skipping day as all column groups have already been recomputed")
+		return nil
+	}
+
+	_, lastEpoch := utils.GetFirstAndLastEpochForDay(day)
+	maxValidatorIndex, err := BigtableClient.GetMaxValidatorindexForEpoch(lastEpoch)
+	if err != nil {
+		return err
+	}
+
+	validatorData, err := GatherStatisticsForDay(int64(day))
+	if err != nil {
+		return err
+	}
+	if uint64(len(validatorData)) != maxValidatorIndex+1 {
+		return fmt.Errorf("day %v has statistics for %v validators, expected %v", day, len(validatorData), maxValidatorIndex+1)
+	}
+	validators := make([]uint64, 0, len(validatorData))
+	for i, row := range validatorData {
+		if row.ValidatorIndex != uint64(i) {
+			return fmt.Errorf("logic error when retrieving statistics of day %v for validator %v (retrieved %v)", day, i, row.ValidatorIndex)
+		}
+		validators = append(validators, row.ValidatorIndex)
+		for _, g := range groups {
+			validatorStatsColumnGroups[g].reset(row)
+		}
+	}
+
+	var statisticsData1d []*types.ValidatorStatsTableDbRow
+	g := &errgroup.Group{}
+	validatorDataMux := &sync.Mutex{}
+	for _, group := range groups {
+		group := group
+		g.Go(func() error {
+			err := validatorStatsColumnGroups[group].gather(client, validators, day, validatorData, validatorDataMux)
+			if err != nil {
+				return fmt.Errorf("error gathering column group %v: %w", group, err)
+			}
+			return nil
+		})
+	}
+	g.Go(func() error {
+		var err error
+		statisticsData1d, err = GatherStatisticsForDay(int64(day) - 1) // convert to int64 to avoid underflows
+		if err != nil {
+			return fmt.Errorf("error in GatherPreviousDayStatisticsData: %w", err)
+		}
+		return nil
+	})
+	err = g.Wait()
+	if err != nil {
+		return err
+	}
+
+	columns := []string{}
+	for _, group := range groups {
+		columns = append(columns, validatorStatsColumnGroups[group].columns...)
+	}
+	recomputeClRewards := validatorStatsRecomputesClRewards(groups)
+	if recomputeClRewards {
+		columns = append(columns, "cl_rewards_gwei", "cl_rewards_gwei_total")
+	}
+
+	for index, data := range validatorData {
+		previousDayData := &types.ValidatorStatsTableDbRow{
+			ValidatorIndex: data.ValidatorIndex,
+		}
+		if index < len(statisticsData1d) && day > 0 {
+			previousDayData = statisticsData1d[index]
+		}
+		for _, group := range groups {
+			validatorStatsColumnGroups[group].accumulate(data, previousDayData)
+		}
+		if recomputeClRewards {
+			data.ClRewardsGWei = data.EndBalance - previousDayData.EndBalance + data.WithdrawalsAmount - data.DepositsAmount
+			data.ClRewardsGWeiTotal = previousDayData.ClRewardsGWeiTotal + data.ClRewardsGWei
+		}
+	}
+
+	conn, err := WriterDb.Conn(context.Background())
+	if err != nil {
+		return fmt.Errorf("error retrieving raw sql connection: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.Raw(func(driverConn interface{}) error {
+		conn := driverConn.(*stdlib.Conn).Conn()
+
+		pgxdecimal.Register(conn.TypeMap())
+		tx, err := conn.Begin(context.Background())
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(context.Background())
+
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`CREATE TEMP TABLE validator_stats_recompute ON COMMIT DROP AS SELECT validatorindex, %s FROM validator_stats WITH NO DATA`, strings.Join(columns, ", ")))
+		if err != nil {
+			return fmt.Errorf("error creating temp table: %w", err)
+		}
+
+		logger.Infof("bulk loading recomputed columns")
+		_, err = tx.CopyFrom(context.Background(), pgx.Identifier{"validator_stats_recompute"}, append([]string{"validatorindex"}, columns...), pgx.CopyFromSlice(len(validatorData), func(i int) ([]interface{}, error) {
+			values := make([]interface{}, 0, len(columns)+1)
+			values = append(values, validatorData[i].ValidatorIndex)
+			for _, c := range columns {
+				v, err := validatorStatsColumnValue(validatorData[i], c)
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, v)
+			}
+			return values, nil
+		}))
+		if err != nil {
+			return fmt.Errorf("error copying into temp table: %w", err)
+		}
+
+		setClauses := make([]string, 0, len(columns))
+		for _, c := range columns {
+			setClauses = append(setClauses, fmt.Sprintf("%s = r.%s", c, c))
+		}
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`UPDATE validator_stats vs SET %s FROM validator_stats_recompute r WHERE vs.day = $1 AND vs.validatorindex = r.validatorindex`, strings.Join(setClauses, ", ")), day)
+		if err != nil {
+			return fmt.Errorf("error updating validator_stats: %w", err)
+		}
+
+		statusClauses := make([]string, 0, len(statusColumns))
+		for _, c := range statusColumns {
+			statusClauses = append(statusClauses, c+" = true")
+		}
+		_, err = tx.Exec(context.Background(), fmt.Sprintf(`UPDATE validator_stats_status SET %s WHERE day = $1`, strings.Join(statusClauses, ", ")), day)
+		if err != nil {
+			return fmt.Errorf("error marking column groups as recomputed: %w", err)
+		}
+
+		return tx.Commit(context.Background())
+	})
+	if err != nil {
+		return fmt.Errorf("error writing recomputed columns of day %v: %w", day, err)
+	}
+
+	// validator_performance is not touched, it is rebuilt from the (now updated) totals with the next daily export
+	logger.Infof("recomputing column groups completed, took %v", time.Since(start))
+	return nil
+}
+
+func validatorStatsColumnValue(row *types.ValidatorStatsTableDbRow, column string) (interface{}, error) {
+	switch column {
+	case "start_balance":
+		return row.StartBalance, nil
+	case "end_balance":
+		return row.EndBalance, nil
+	case "start_effective_balance":
+		return row.StartEffectiveBalance, nil
+	case "end_effective_balance":
+		return row.EndEffectiveBalance, nil
+	case "missed_attestations":
+		return row.MissedAttestations, nil
+	case "missed_attestations_total":
+		return row.MissedAttestationsTotal, nil
+	case "participated_sync":
+		return row.ParticipatedSync, nil
+	case "participated_sync_total":
+		return row.ParticipatedSyncTotal, nil
+	case "missed_sync":
+		return row.MissedSync, nil
+	case "missed_sync_total":
+		return row.MissedSyncTotal, nil
+	case "orphaned_sync":
+		return row.OrphanedSync, nil
+	case "orphaned_sync_total":
+		return row.OrphanedSyncTotal, nil
+	case "proposed_blocks":
+		return row.ProposedBlocks, nil
+	case "missed_blocks":
+		return row.MissedBlocks, nil
+	case "orphaned_blocks":
+		return row.OrphanedBlocks, nil
+	case "attester_slashings":
+		return row.AttesterSlashings, nil
+	case "proposer_slashings":
+		return row.ProposerSlashing, nil
+	case "deposits":
+		return row.Deposits, nil
+	case "deposits_total":
+		return row.DepositsTotal, nil
+	case "deposits_amount":
+		return row.DepositsAmount, nil
+	case "deposits_amount_total":
+		return row.DepositsAmountTotal, nil
+	case "withdrawals":
+		return row.Withdrawals, nil
+	case "withdrawals_total":
+		return row.WithdrawalsTotal, nil
+	case "withdrawals_amount":
+		return row.WithdrawalsAmount, nil
+	case "withdrawals_amount_total":
+		return row.WithdrawalsAmountTotal, nil
+	case "cl_rewards_gwei":
+		return row.ClRewardsGWei, nil
+	case "cl_rewards_gwei_total":
+		return row.ClRewardsGWeiTotal, nil
+	case "el_rewards_wei":
+		return row.ElRewardsWei, nil
+	case "el_rewards_wei_total":
+		return row.ElRewardsWeiTotal, nil
+	case "mev_rewards_wei":
+		return row.MEVRewardsWei, nil
+	case "mev_rewards_wei_total":
+		return row.MEVRewardsWeiTotal, nil
+	default:
+		return nil, fmt.Errorf("unknown validator_stats column %v", column)
+	}
+}
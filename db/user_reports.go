@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/lib/pq"
+)
+
+// InsertUserReport archives a generated scheduled report.
+// A schedule is archived only once per period, if the period was already archived (e.g. by a retried notification run) the archived report is kept.
+func InsertUserReport(report *types.UserReport) error {
+	err := FrontendWriterDB.Get(report, `
+		INSERT INTO users_reports (user_id, subscription_id, name, period_start, period_end, currency, format, filename, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (subscription_id, period_start, period_end) DO NOTHING
+		RETURNING id, created_ts`,
+		report.UserID, report.SubscriptionID, report.Name, report.PeriodStart, report.PeriodEnd, report.Currency, report.Format, report.Filename, report.Data)
+	if err == sql.ErrNoRows {
+		archived, err := GetUserReportOfPeriod(report.SubscriptionID.Int64, report.PeriodStart, report.PeriodEnd)
+		if err != nil {
+			return fmt.Errorf("error getting archived report of subscription %v: %w", report.SubscriptionID.Int64, err)
+		}
+		*report = *archived
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error inserting report for user %v: %w", report.UserID, err)
+	}
+	return nil
+}
+
+// GetUserReportOfPeriod returns the archived report of a schedule for a period including its data
+func GetUserReportOfPeriod(subscriptionID int64, start, end time.Time) (*types.UserReport, error) {
+	report := &types.UserReport{}
+	err := FrontendWriterDB.Get(report, `
+		SELECT id, user_id, subscription_id, name, period_start, period_end, currency, format, filename, data, created_ts
+		FROM users_reports
+		WHERE subscription_id = $1 AND period_start = $2 AND period_end = $3`, subscriptionID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetUserReports returns the archived reports of a user without their data, newest first
+func GetUserReports(userID uint64) ([]*types.UserReport, error) {
+	reports := []*types.UserReport{}
+	err := FrontendWriterDB.Select(&reports, `
+		SELECT id, user_id, subscription_id, name, period_start, period_end, currency, format, filename, created_ts
+		FROM users_reports
+		WHERE user_id = $1
+		ORDER BY period_end DESC, id DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reports of user %v: %w", userID, err)
+	}
+	return reports, nil
+}
+
+// GetUserReport returns an archived report including its data, reports of other users are not returned
+func GetUserReport(userID, id uint64) (*types.UserReport, error) {
+	report := &types.UserReport{}
+	err := FrontendWriterDB.Get(report, `
+		SELECT id, user_id, subscription_id, name, period_start, period_end, currency, format, filename, data, created_ts
+		FROM users_reports
+		WHERE user_id = $1 AND id = $2`, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetUserTaggedValidatorIndices returns the indices of all validators the user has tagged with the given tag
+func GetUserTaggedValidatorIndices(userID uint64, tag string) ([]uint64, error) {
+	var pubkeys [][]byte
+	err := FrontendWriterDB.Select(&pubkeys, `SELECT validator_publickey FROM users_validators_tags WHERE user_id = $1 AND tag = $2`, userID, tag)
+	if err != nil {
+		return nil, fmt.Errorf("error getting validators with tag %v of user %v: %w", tag, userID, err)
+	}
+	if len(pubkeys) == 0 {
+		return []uint64{}, nil
+	}
+
+	var indices []uint64
+	err = ReaderDb.Select(&indices, `SELECT validatorindex FROM validators WHERE pubkey = ANY($1) ORDER BY validatorindex`, pq.ByteaArray(pubkeys))
+	if err != nil {
+		return nil, fmt.Errorf("error getting indices of tagged validators: %w", err)
+	}
+	return indices, nil
+}
@@ -0,0 +1,343 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// pool tags of validators that were tagged without a rule by the former pool tag updater are taken over by the matching rule,
+// tags assigned by other rules or by the rocketpool and ssv exporters (which tag without a rule as well) are kept
+const validatorTagRuleConflictSQL = `ON CONFLICT (publickey, tag) DO UPDATE SET rule_id = EXCLUDED.rule_id WHERE validator_tags.rule_id IS NULL AND validator_tags.tag LIKE 'pool:%'`
+
+// validatorTagRuleSourceStakePools is the source of the rules synced from the stake pool stats
+const validatorTagRuleSourceStakePools = "stake_pools_stats"
+
+// GetValidatorTagRules returns all validator tag rules together with the number of validators they tagged
+func GetValidatorTagRules() ([]*types.ValidatorTagRule, error) {
+	rules := []*types.ValidatorTagRule{}
+	err := ReaderDb.Select(&rules, `
+		SELECT r.id, r.tag, r.rule_type, r.pattern, r.enabled, r.source, r.deposit_block_cursor, r.slot_cursor, r.validator_index_cursor, r.updated_ts,
+			(SELECT COUNT(*) FROM validator_tags t WHERE t.rule_id = r.id) AS tagged_validators
+		FROM validator_tag_rules r
+		ORDER BY r.tag, r.id`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator tag rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetEnabledValidatorTagRules returns the validator tag rules that are evaluated by the tag updater
+func GetEnabledValidatorTagRules() ([]*types.ValidatorTagRule, error) {
+	rules := []*types.ValidatorTagRule{}
+	err := WriterDb.Select(&rules, `
+		SELECT id, tag, rule_type, pattern, enabled, source, deposit_block_cursor, slot_cursor, validator_index_cursor, updated_ts
+		FROM validator_tag_rules
+		WHERE enabled
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving enabled validator tag rules: %w", err)
+	}
+	return rules, nil
+}
+
+var ErrInvalidValidatorTagRulePattern = errors.New("invalid pattern")
+
+// ErrValidatorTagRuleNotDeletable is returned when deleting a rule that would be recreated by the next stake pool sync
+var ErrValidatorTagRuleNotDeletable = errors.New("rules synced from the stake pool stats are recreated after deleting them, disable the rule instead")
+
+// ValidateValidatorTagRuleGraffiti checks that a graffiti pattern is a valid postgres regular expression,
+// invalid patterns are reported as ErrInvalidValidatorTagRulePattern
+func ValidateValidatorTagRuleGraffiti(pattern string) error {
+	var matched bool
+	err := ReaderDb.Get(&matched, `SELECT '' ~ $1`, pattern)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "2201B" { // invalid_regular_expression
+		return fmt.Errorf("%w, not supported by postgres: %v", ErrInvalidValidatorTagRulePattern, pqErr.Message)
+	}
+	if err != nil {
+		return fmt.Errorf("error validating graffiti pattern: %w", err)
+	}
+	return nil
+}
+
+// InsertValidatorTagRule stores a new rule, it is evaluated from the start by the next run of the tag updater
+func InsertValidatorTagRule(rule *types.ValidatorTagRule) error {
+	_, err := WriterDb.Exec(`
+		INSERT INTO validator_tag_rules (tag, rule_type, pattern, enabled, source)
+		VALUES ($1, $2, $3, $4, $5)`,
+		rule.Tag, rule.RuleType, rule.Pattern, rule.Enabled, rule.Source)
+	if err != nil {
+		return fmt.Errorf("error inserting validator tag rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateValidatorTagRule replaces a rule and removes the tags it assigned so far, the rule is evaluated from the start again if it is enabled
+func UpdateValidatorTagRule(rule *types.ValidatorTagRule) error {
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = removeValidatorTagRuleTags(tx, rule.Id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE validator_tag_rules SET tag = $2, rule_type = $3, pattern = $4, enabled = $5,
+			deposit_block_cursor = 0, slot_cursor = 0, validator_index_cursor = 0, updated_ts = NOW()
+		WHERE id = $1`,
+		rule.Id, rule.Tag, rule.RuleType, rule.Pattern, rule.Enabled)
+	if err != nil {
+		return fmt.Errorf("error updating validator tag rule %v: %w", rule.Id, err)
+	}
+	return tx.Commit()
+}
+
+// DeleteValidatorTagRule deletes a rule together with the tags it assigned
+func DeleteValidatorTagRule(id uint64) error {
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source string
+	err = tx.Get(&source, `SELECT source FROM validator_tag_rules WHERE id = $1 FOR UPDATE`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving validator tag rule %v: %w", id, err)
+	}
+	if source == validatorTagRuleSourceStakePools {
+		return ErrValidatorTagRuleNotDeletable
+	}
+
+	err = removeValidatorTagRuleTags(tx, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM validator_tag_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error deleting validator tag rule %v: %w", id, err)
+	}
+	return tx.Commit()
+}
+
+// removeValidatorTagRuleTags removes the tags assigned by a rule, other rules assigning the same tag are evaluated from the start again
+// as validators they match might only have been tagged by the removed rule
+func removeValidatorTagRuleTags(tx *sqlx.Tx, id uint64) error {
+	_, err := tx.Exec(`DELETE FROM validator_tags WHERE rule_id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("error removing tags of validator tag rule %v: %w", id, err)
+	}
+	_, err = tx.Exec(`
+		UPDATE validator_tag_rules SET deposit_block_cursor = 0, slot_cursor = 0, validator_index_cursor = 0
+		WHERE id <> $1 AND tag = (SELECT tag FROM validator_tag_rules WHERE id = $1)`, id)
+	if err != nil {
+		return fmt.Errorf("error resetting validator tag rules sharing the tag of rule %v: %w", id, err)
+	}
+	return nil
+}
+
+// SyncStakePoolValidatorTagRules adds a deposit address rule for every stake pool address that has no rule yet.
+// Existing rules are kept so stake pool rules can be disabled by admins.
+func SyncStakePoolValidatorTagRules() (int64, error) {
+	res, err := WriterDb.Exec(`
+		INSERT INTO validator_tag_rules (tag, rule_type, pattern, source)
+		SELECT DISTINCT FORMAT('pool:%s', name), $1, '0x' || LOWER(address), $2
+		FROM stake_pools_stats
+		WHERE name NOT LIKE '%Rocketpool -%'
+		ON CONFLICT (tag, rule_type, pattern) DO NOTHING`, types.ValidatorTagRuleDepositAddress, validatorTagRuleSourceStakePools)
+	if err != nil {
+		return 0, fmt.Errorf("error syncing stake pool validator tag rules: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// GetValidatorTagRuleCursorEnds returns the exclusive upper bounds up to which the rules can be evaluated,
+// the deposit blocks of all exported deposits and the slots of all finalized epochs
+func GetValidatorTagRuleCursorEnds(slotsPerEpoch uint64) (uint64, uint64, error) {
+	ends := struct {
+		DepositBlockEnd uint64 `db:"deposit_block_end"`
+		SlotEnd         uint64 `db:"slot_end"`
+	}{}
+	err := WriterDb.Get(&ends, `
+		SELECT
+			COALESCE((SELECT MAX(block_number) + 1 FROM eth1_deposits), 0) AS deposit_block_end,
+			COALESCE((SELECT (epoch + 1) * $1 FROM epochs WHERE finalized ORDER BY epoch DESC LIMIT 1), 0) AS slot_end`, slotsPerEpoch)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error retrieving validator tag rule cursor ends: %w", err)
+	}
+	return ends.DepositBlockEnd, ends.SlotEnd, nil
+}
+
+// GetValidatorPubkeysFromIndex returns the pubkeys of up to limit validators starting at the given index
+// together with the exclusive upper bound of the returned validator indices
+func GetValidatorPubkeysFromIndex(start, limit uint64) ([][]byte, uint64, error) {
+	rows := []struct {
+		Index  uint64 `db:"validatorindex"`
+		Pubkey []byte `db:"pubkey"`
+	}{}
+	err := WriterDb.Select(&rows, `SELECT validatorindex, pubkey FROM validators WHERE validatorindex >= $1 ORDER BY validatorindex LIMIT $2`, start, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error retrieving validator pubkeys from index %v: %w", start, err)
+	}
+	pubkeys := make([][]byte, 0, len(rows))
+	end := start
+	for _, row := range rows {
+		pubkeys = append(pubkeys, row.Pubkey)
+		end = row.Index + 1
+	}
+	return pubkeys, end, nil
+}
+
+// validatorTagRuleQuery selects the pubkeys of the validators matching a rule, $1 and $2 are reserved for the tag and the rule id
+type validatorTagRuleQuery struct {
+	query string
+	args  []interface{}
+}
+
+// ApplyValidatorTagRule tags the validators matching a deposit address, withdrawal credentials, fee recipient or graffiti rule
+// within the deposits up to depositBlockEnd and the blocks up to slotEnd (both exclusive) that were not evaluated yet and advances the cursors of the rule
+func ApplyValidatorTagRule(rule *types.ValidatorTagRule, depositBlockEnd, slotEnd uint64) (int64, error) {
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	queries := []validatorTagRuleQuery{}
+	switch rule.RuleType {
+	case types.ValidatorTagRuleDepositAddress:
+		queries = append(queries, validatorTagRuleQuery{`SELECT DISTINCT publickey FROM eth1_deposits WHERE from_address = $3 AND block_number >= $4 AND block_number < $5`,
+			[]interface{}{common.FromHex(rule.Pattern), rule.DepositBlockCursor, depositBlockEnd}})
+	case types.ValidatorTagRuleWithdrawalCredentials:
+		credentials := common.FromHex(rule.Pattern)
+		queries = append(queries, validatorTagRuleQuery{`SELECT DISTINCT publickey FROM eth1_deposits WHERE withdrawal_credentials = $3 AND block_number >= $4 AND block_number < $5`,
+			[]interface{}{credentials, rule.DepositBlockCursor, depositBlockEnd}})
+		// validators with bls credentials can change to the address credentials of the rule
+		if credentials[0] == 0x01 {
+			queries = append(queries, validatorTagRuleQuery{`SELECT DISTINCT pubkey FROM blocks_bls_change WHERE address = $3 AND block_slot >= $4 AND block_slot < $5`,
+				[]interface{}{credentials[12:], rule.SlotCursor, slotEnd}})
+		}
+	case types.ValidatorTagRuleFeeRecipient:
+		queries = append(queries, validatorTagRuleQuery{`SELECT DISTINCT v.pubkey FROM blocks b INNER JOIN validators v ON v.validatorindex = b.proposer
+			WHERE b.exec_fee_recipient = $3 AND b.status = '1' AND b.slot >= $4 AND b.slot < $5`,
+			[]interface{}{common.FromHex(rule.Pattern), rule.SlotCursor, slotEnd}})
+	case types.ValidatorTagRuleGraffiti:
+		queries = append(queries, validatorTagRuleQuery{`SELECT DISTINCT v.pubkey FROM blocks b INNER JOIN validators v ON v.validatorindex = b.proposer
+			WHERE b.graffiti_text ~ $3 AND b.status = '1' AND b.slot >= $4 AND b.slot < $5`,
+			[]interface{}{rule.Pattern, rule.SlotCursor, slotEnd}})
+	default:
+		return 0, fmt.Errorf("validator tag rule type %v can not be applied within the db", rule.RuleType)
+	}
+
+	tagged := int64(0)
+	for _, q := range queries {
+		res, err := tx.Exec(fmt.Sprintf(`INSERT INTO validator_tags (publickey, tag, rule_id) SELECT pubkeys.*, $1::VARCHAR, $2::INT FROM (%s) AS pubkeys %s`, q.query, validatorTagRuleConflictSQL),
+			append([]interface{}{rule.Tag, rule.Id}, q.args...)...)
+		if err != nil {
+			return 0, fmt.Errorf("error applying validator tag rule %v: %w", rule.Id, err)
+		}
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		tagged += rows
+	}
+
+	// cursors that are not used by the rule type are advanced as well, they are reset together on every change of the rule
+	_, err = tx.Exec(`UPDATE validator_tag_rules SET deposit_block_cursor = GREATEST(deposit_block_cursor, $2), slot_cursor = GREATEST(slot_cursor, $3) WHERE id = $1`,
+		rule.Id, depositBlockEnd, slotEnd)
+	if err != nil {
+		return 0, fmt.Errorf("error advancing cursors of validator tag rule %v: %w", rule.Id, err)
+	}
+	return tagged, tx.Commit()
+}
+
+// TagValidatorsOfRule tags the registered validators and untags the unregistered validators for a registry rule
+// and moves the validator index cursor of the rule to validatorIndexEnd (exclusive).
+// Nothing is changed if the rule was reset (e.g. by an update) since it was loaded.
+func TagValidatorsOfRule(rule *types.ValidatorTagRule, registered, unregistered [][]byte, validatorIndexEnd uint64) error {
+	tx, err := WriterDb.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE validator_tag_rules SET validator_index_cursor = $3 WHERE id = $1 AND validator_index_cursor = $2`, rule.Id, rule.ValidatorIndexCursor, validatorIndexEnd)
+	if err != nil {
+		return fmt.Errorf("error moving validator index cursor of validator tag rule %v: %w", rule.Id, err)
+	}
+	if rows, err := res.RowsAffected(); err != nil || rows == 0 {
+		return fmt.Errorf("validator tag rule %v was changed during its evaluation", rule.Id)
+	}
+
+	if len(registered) > 0 {
+		_, err = tx.Exec(fmt.Sprintf(`INSERT INTO validator_tags (publickey, tag, rule_id) SELECT UNNEST($3::BYTEA[]), $1::VARCHAR, $2::INT %s`, validatorTagRuleConflictSQL),
+			rule.Tag, rule.Id, pq.ByteaArray(registered))
+		if err != nil {
+			return fmt.Errorf("error tagging validators of validator tag rule %v: %w", rule.Id, err)
+		}
+	}
+	if len(unregistered) > 0 {
+		_, err = tx.Exec(`DELETE FROM validator_tags WHERE rule_id = $1 AND publickey = ANY($2)`, rule.Id, pq.ByteaArray(unregistered))
+		if err != nil {
+			return fmt.Errorf("error untagging validators of validator tag rule %v: %w", rule.Id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetValidatorsByTags returns the validators carrying all tags of the filter, ordered by validator index
+func GetValidatorsByTags(filter *types.ValidatorTagsFilter) ([]*types.ApiValidatorTagsResponse, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if len(filter.Tags) > 0 {
+		args = append(args, pq.Array(filter.Tags), len(filter.Tags))
+		conditions = append(conditions, `v.pubkey IN (SELECT publickey FROM validator_tags WHERE tag = ANY($1) GROUP BY publickey HAVING COUNT(DISTINCT tag) = $2)`)
+	}
+	if filter.RuleId > 0 {
+		args = append(args, filter.RuleId)
+		conditions = append(conditions, fmt.Sprintf(`v.pubkey IN (SELECT publickey FROM validator_tags WHERE rule_id = $%d)`, len(args)))
+	}
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("no tag or rule provided to filter validators by")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows := []struct {
+		Index  uint64         `db:"validatorindex"`
+		Pubkey []byte         `db:"pubkey"`
+		Tags   pq.StringArray `db:"tags"`
+	}{}
+	err := ReaderDb.Select(&rows, fmt.Sprintf(`
+		SELECT v.validatorindex, v.pubkey, COALESCE((SELECT ARRAY_AGG(tag ORDER BY tag) FROM validator_tags t WHERE t.publickey = v.pubkey), '{}') AS tags
+		FROM validators v
+		WHERE %s
+		ORDER BY v.validatorindex
+		LIMIT $%d OFFSET $%d`, strings.Join(conditions, " AND "), len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validators by tags: %w", err)
+	}
+
+	res := make([]*types.ApiValidatorTagsResponse, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, &types.ApiValidatorTagsResponse{
+			Validatorindex: row.Index,
+			Pubkey:         fmt.Sprintf("%#x", row.Pubkey),
+			Tags:           row.Tags,
+		})
+	}
+	return res, nil
+}
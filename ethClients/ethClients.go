@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
 	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
 
 	"github.com/sirupsen/logrus"
@@ -219,6 +220,42 @@ func updateEthClientNetShare() {
 	}
 }
 
+// updateConsensusClientBlockShare sets the network share of the consensus clients to their share of the blocks proposed in the last 7 days,
+// as classified by the client diversity export of the statistics service
+func updateConsensusClientBlockShare() {
+	shares := []struct {
+		Client string  `db:"client"`
+		Share  float64 `db:"share"`
+	}{}
+	err := db.ReaderDb.Select(&shares, `
+		SELECT client, SUM(blocks_count)::float / NULLIF(SUM(SUM(blocks_count)) OVER (), 0) AS share
+		FROM client_diversity_days
+		WHERE day > (SELECT MAX(day) FROM client_diversity_days) - 7
+		GROUP BY client`)
+	if err != nil {
+		logger.Errorf("error retrieving consensus client block share: %v", err)
+		return
+	}
+
+	for _, item := range shares {
+		share := fmt.Sprintf("%.1f%%", item.Share*100.0)
+		switch item.Client {
+		case "teku":
+			ethClients.Teku.NetworkShare = share
+		case "prysm":
+			ethClients.Prysm.NetworkShare = share
+		case "nimbus":
+			ethClients.Nimbus.NetworkShare = share
+		case "lighthouse":
+			ethClients.Lighthouse.NetworkShare = share
+		case "lodestar":
+			ethClients.Lodestar.NetworkShare = share
+		default:
+			continue
+		}
+	}
+}
+
 func updateEthClient() {
 	curTime := time.Now()
 	// sending 8 requests to github per call
@@ -234,6 +271,7 @@ func updateEthClient() {
 	defer bannerClientsMux.Unlock()
 	bannerClients = []clientUpdateInfo{}
 	updateEthClientNetShare()
+	updateConsensusClientBlockShare()
 	ethClients.Geth.ClientReleaseVersion, ethClients.Geth.ClientReleaseDate = prepareEthClientData("/ethereum/go-ethereum", "Geth", curTime)
 	ethClients.Nethermind.ClientReleaseVersion, ethClients.Nethermind.ClientReleaseDate = prepareEthClientData("/NethermindEth/nethermind", "Nethermind", curTime)
 	ethClients.Besu.ClientReleaseVersion, ethClients.Besu.ClientReleaseDate = prepareEthClientData("/hyperledger/besu", "Besu", curTime)
@@ -1,7 +1,6 @@
 package exporter
 
 import (
-	"bytes"
 	"context"
 	"encoding/hex"
 	"encoding/json"
@@ -16,17 +15,13 @@ import (
 	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
 	"github.com/gobitfly/eth2-beaconchain-explorer/version"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
-	"github.com/aws/aws-sdk-go-v2/credentials"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/coocood/freecache"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
 type BlobIndexer struct {
-	S3Client   *s3.Client
+	Store      BlobStore
 	running    bool
 	runningMu  *sync.Mutex
 	clEndpoint string
@@ -34,27 +29,12 @@ type BlobIndexer struct {
 }
 
 func NewBlobIndexer() (*BlobIndexer, error) {
-	s3Resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
-		return aws.Endpoint{
-			PartitionID:       "aws",
-			URL:               utils.Config.BlobIndexer.S3.Endpoint,
-			SigningRegion:     "us-east-2",
-			HostnameImmutable: true,
-		}, nil
-	})
-	s3Client := s3.NewFromConfig(aws.Config{
-		Region: "us-east-2",
-		Credentials: credentials.NewStaticCredentialsProvider(
-			utils.Config.BlobIndexer.S3.AccessKeyId,
-			utils.Config.BlobIndexer.S3.AccessKeySecret,
-			"",
-		),
-		EndpointResolverWithOptions: s3Resolver,
-	}, func(o *s3.Options) {
-		o.UsePathStyle = true
-	})
+	store, err := NewBlobStore(context.Background())
+	if err != nil {
+		return nil, err
+	}
 	bi := &BlobIndexer{
-		S3Client:   s3Client,
+		Store:      store,
 		runningMu:  &sync.Mutex{},
 		clEndpoint: "http://" + utils.Config.Indexer.Node.Host + ":" + utils.Config.Indexer.Node.Port,
 		cache:      freecache.NewCache(1024 * 1024),
@@ -71,12 +51,18 @@ func (bi *BlobIndexer) Start() {
 	bi.running = true
 	bi.runningMu.Unlock()
 
-	logrus.WithFields(logrus.Fields{"version": version.Version, "clEndpoint": bi.clEndpoint, "s3Endpoint": utils.Config.BlobIndexer.S3.Endpoint}).Infof("starting blobindexer")
+	logrus.WithFields(logrus.Fields{"version": version.Version, "clEndpoint": bi.clEndpoint, "store": bi.Store.Name(), "retentionEpochs": utils.Config.BlobIndexer.RetentionEpochs}).Infof("starting blobindexer")
 	for {
 		err := bi.Index()
 		if err != nil {
 			logrus.WithFields(logrus.Fields{"error": err}).Errorf("failed indexing blobs")
 		}
+		if utils.Config.BlobIndexer.RetentionEpochs > 0 {
+			err = bi.Prune()
+			if err != nil {
+				logrus.WithFields(logrus.Fields{"error": err}).Errorf("failed pruning blobs")
+			}
+		}
 		time.Sleep(time.Second * 10)
 	}
 }
@@ -178,9 +164,8 @@ func (bi *BlobIndexer) Index() error {
 			return err
 		}
 		if batchEnd <= finalizedHeader.Data.Header.Message.Slot {
-			err := bi.PutIndexerStatus(BlobIndexerStatus{
-				LastIndexedFinalizedSlot: batchEnd,
-			})
+			status.LastIndexedFinalizedSlot = batchEnd
+			err := bi.PutIndexerStatus(*status)
 			if err != nil {
 				return fmt.Errorf("error updating indexer status at slot %v: %w", batchEnd, err)
 			}
@@ -233,42 +218,46 @@ func (bi *BlobIndexer) IndexBlobsAtSlot(slot uint64) error {
 				return fmt.Errorf("error decoding blob at index %v: %w", i, err)
 			}
 
+			kzgProof, err := hex.DecodeString(strings.Replace(d.KzgProof, "0x", "", -1))
+			if err != nil {
+				return fmt.Errorf("error decoding kzgProof at index %v: %s: %w", i, d.KzgProof, err)
+			}
+
 			versionedBlobHash := fmt.Sprintf("%#x", utils.VersionedBlobHash(kzgCommitment).Bytes())
-			key := fmt.Sprintf("blobs/%s", versionedBlobHash)
+			key := BlobKey(versionedBlobHash)
+
+			tCheckObj := time.Now()
+			exists, err := bi.Store.Exists(gCtx, key)
+			metrics.TaskDuration.WithLabelValues("blobindexer_check_blob").Observe(time.Since(tCheckObj).Seconds())
+			if err != nil {
+				return fmt.Errorf("error checking blob: %s (%v/%v): %w", key, d.Slot, d.Index, err)
+			}
+			if exists {
+				return nil
+			}
 
-			tS3HeadObj := time.Now()
-			_, err = bi.S3Client.HeadObject(gCtx, &s3.HeadObjectInput{
-				Bucket: &utils.Config.BlobIndexer.S3.Bucket,
-				Key:    &key,
+			// never store a blob that does not match its commitment
+			tVerify := time.Now()
+			err = VerifyBlob(versionedBlobHash, blob, kzgCommitment, kzgProof)
+			metrics.TaskDuration.WithLabelValues("blobindexer_verify_blob").Observe(time.Since(tVerify).Seconds())
+			if err != nil {
+				return fmt.Errorf("error verifying blob: %s (%v/%v): %w", key, d.Slot, d.Index, err)
+			}
+
+			//logrus.WithFields(logrus.Fields{"slot": d.Slot, "index": d.Index, "key": key}).Infof("putting blob")
+			tPutObj := time.Now()
+			err = bi.Store.Put(gCtx, key, blob, map[string]string{
+				"slot":              fmt.Sprintf("%d", d.Slot),
+				"index":             fmt.Sprintf("%d", d.Index),
+				"block_root":        d.BlockRoot,
+				"block_parent_root": d.BlockParentRoot,
+				"proposer_index":    fmt.Sprintf("%d", d.ProposerIndex),
+				"kzg_commitment":    d.KzgCommitment,
+				"kzg_proof":         d.KzgProof,
 			})
-			metrics.TaskDuration.WithLabelValues("blobindexer_check_blob").Observe(time.Since(tS3HeadObj).Seconds())
+			metrics.TaskDuration.WithLabelValues("blobindexer_put_blob").Observe(time.Since(tPutObj).Seconds())
 			if err != nil {
-				// Only put the object if it does not exist yet
-				var httpResponseErr *awshttp.ResponseError
-				if errors.As(err, &httpResponseErr) && (httpResponseErr.HTTPStatusCode() == 404 || httpResponseErr.HTTPStatusCode() == 403) {
-					//logrus.WithFields(logrus.Fields{"slot": d.Slot, "index": d.Index, "key": key}).Infof("putting blob")
-					tS3PutObj := time.Now()
-					_, putErr := bi.S3Client.PutObject(gCtx, &s3.PutObjectInput{
-						Bucket: &utils.Config.BlobIndexer.S3.Bucket,
-						Key:    &key,
-						Body:   bytes.NewReader(blob),
-						Metadata: map[string]string{
-							"slot":              fmt.Sprintf("%d", d.Slot),
-							"index":             fmt.Sprintf("%d", d.Index),
-							"block_root":        d.BlockRoot,
-							"block_parent_root": d.BlockParentRoot,
-							"proposer_index":    fmt.Sprintf("%d", d.ProposerIndex),
-							"kzg_commitment":    d.KzgCommitment,
-							"kzg_proof":         d.KzgProof,
-						},
-					})
-					metrics.TaskDuration.WithLabelValues("blobindexer_put_blob").Observe(time.Since(tS3PutObj).Seconds())
-					if putErr != nil {
-						return fmt.Errorf("error putting object: %s (%v/%v): %w", key, d.Slot, d.Index, putErr)
-					}
-					return nil
-				}
-				return fmt.Errorf("error getting headObject: %s (%v/%v): %w", key, d.Slot, d.Index, err)
+				return fmt.Errorf("error putting object: %s (%v/%v): %w", key, d.Slot, d.Index, err)
 			}
 			return nil
 		})
@@ -281,6 +270,116 @@ func (bi *BlobIndexer) IndexBlobsAtSlot(slot uint64) error {
 	return nil
 }
 
+// BlobRetentionStartSlot returns the first slot whose blobs are kept according to utils.Config.BlobIndexer.RetentionEpochs,
+// 0 if blobs are kept forever or the retention window reaches back to genesis
+func BlobRetentionStartSlot() uint64 {
+	retentionEpochs := utils.Config.BlobIndexer.RetentionEpochs
+	if retentionEpochs == 0 {
+		return 0
+	}
+	currentEpoch := uint64(utils.TimeToEpoch(time.Now()))
+	if currentEpoch <= retentionEpochs {
+		return 0
+	}
+	return (currentEpoch - retentionEpochs) * utils.Config.Chain.ClConfig.SlotsPerEpoch
+}
+
+// Prune deletes all blobs of slots that are older than utils.Config.BlobIndexer.RetentionEpochs.
+// The keys of the blobs are derived from the blob_kzg_commitments of the blocks, so pruning does not depend on the sidecars still being available on the node.
+func (bi *BlobIndexer) Prune() error {
+	status, err := bi.GetIndexerStatus()
+	if err != nil {
+		return err
+	}
+
+	cutoffSlot := BlobRetentionStartSlot()
+	if cutoffSlot == 0 {
+		return nil
+	}
+	slotsPerEpoch := utils.Config.Chain.ClConfig.SlotsPerEpoch
+	// never prune blobs that have not been indexed yet
+	if cutoffSlot > status.LastIndexedFinalizedSlot {
+		cutoffSlot = status.LastIndexedFinalizedSlot
+	}
+
+	denebForkSlot := utils.Config.Chain.ClConfig.DenebForkEpoch * slotsPerEpoch
+	startSlot := status.LastPrunedSlot + 1
+	if status.LastPrunedSlot < denebForkSlot {
+		startSlot = denebForkSlot
+	}
+	if startSlot >= cutoffSlot {
+		return nil
+	}
+
+	start := time.Now()
+	logrus.WithFields(logrus.Fields{"startSlot": startSlot, "cutoffSlot": cutoffSlot}).Infof("pruning blobs")
+
+	deleted := uint64(0)
+	deletedMu := &sync.Mutex{}
+	batchSize := uint64(100)
+	for batchStart := startSlot; batchStart < cutoffSlot; batchStart += batchSize {
+		batchEnd := batchStart + batchSize - 1
+		if batchEnd >= cutoffSlot {
+			batchEnd = cutoffSlot - 1
+		}
+		g, gCtx := errgroup.WithContext(context.Background())
+		g.SetLimit(4)
+		for slot := batchStart; slot <= batchEnd; slot++ {
+			slot := slot
+			g.Go(func() error {
+				commitments, err := bi.GetBlobKzgCommitmentsAtSlot(gCtx, slot)
+				if err != nil {
+					return err
+				}
+				for _, c := range commitments {
+					commitment, err := hex.DecodeString(strings.Replace(c, "0x", "", -1))
+					if err != nil {
+						return fmt.Errorf("error decoding kzgCommitment at slot %v: %s: %w", slot, c, err)
+					}
+					key := BlobKey(fmt.Sprintf("%#x", utils.VersionedBlobHash(commitment).Bytes()))
+					err = bi.Store.Delete(gCtx, key)
+					if err != nil {
+						return fmt.Errorf("error deleting blob %s at slot %v: %w", key, slot, err)
+					}
+				}
+				deletedMu.Lock()
+				deleted += uint64(len(commitments))
+				deletedMu.Unlock()
+				return nil
+			})
+		}
+		err = g.Wait()
+		if err != nil {
+			return err
+		}
+		status.LastPrunedSlot = batchEnd
+		err = bi.PutIndexerStatus(*status)
+		if err != nil {
+			return fmt.Errorf("error updating indexer status at slot %v: %w", batchEnd, err)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{"lastPrunedSlot": status.LastPrunedSlot, "deleted": deleted, "duration": time.Since(start)}).Infof("finished pruning blobs")
+	return nil
+}
+
+// GetBlobKzgCommitmentsAtSlot returns the blob_kzg_commitments of the block at the given slot, an empty slice is returned for missed slots
+func (bi *BlobIndexer) GetBlobKzgCommitmentsAtSlot(ctx context.Context, slot uint64) ([]string, error) {
+	block := &BeaconBlindedBlockResponse{}
+	err := utils.HttpReq(ctx, http.MethodGet, fmt.Sprintf("%s/eth/v1/beacon/blinded_blocks/%d", bi.clEndpoint, slot), nil, block)
+	if err != nil {
+		var httpErr *utils.HttpReqHttpError
+		if errors.As(err, &httpErr) && httpErr.StatusCode == 404 {
+			// no block at this slot
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("error getting block at slot %v: %w", slot, err)
+	}
+	return block.Data.Message.Body.BlobKzgCommitments, nil
+}
+
+const blobIndexerStatusKey = "blob-indexer-status.json"
+
 func (bi *BlobIndexer) GetIndexerStatus() (*BlobIndexerStatus, error) {
 	start := time.Now()
 	defer func() {
@@ -288,21 +387,15 @@ func (bi *BlobIndexer) GetIndexerStatus() (*BlobIndexerStatus, error) {
 	}()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
-	key := "blob-indexer-status.json"
-	obj, err := bi.S3Client.GetObject(ctx, &s3.GetObjectInput{
-		Bucket: &utils.Config.BlobIndexer.S3.Bucket,
-		Key:    &key,
-	})
+	data, _, err := bi.Store.Get(ctx, blobIndexerStatusKey)
 	if err != nil {
-		// If the object that you request doesn’t exist, the error that Amazon S3 returns depends on whether you also have the s3:ListBucket permission. If you have the s3:ListBucket permission on the bucket, Amazon S3 returns an HTTP status code 404 (Not Found) error. If you don’t have the s3:ListBucket permission, Amazon S3 returns an HTTP status code 403 ("access denied") error.
-		var httpResponseErr *awshttp.ResponseError
-		if errors.As(err, &httpResponseErr) && (httpResponseErr.HTTPStatusCode() == 404 || httpResponseErr.HTTPStatusCode() == 403) {
+		if errors.Is(err, ErrBlobNotFound) {
 			return &BlobIndexerStatus{}, nil
 		}
 		return nil, err
 	}
 	status := &BlobIndexerStatus{}
-	err = json.NewDecoder(obj.Body).Decode(status)
+	err = json.Unmarshal(data, status)
 	return status, err
 }
 
@@ -313,25 +406,14 @@ func (bi *BlobIndexer) PutIndexerStatus(status BlobIndexerStatus) error {
 	}()
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
-	key := "blob-indexer-status.json"
-	contentType := "application/json"
 	body, err := json.Marshal(&status)
 	if err != nil {
 		return err
 	}
-	_, err = bi.S3Client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:      &utils.Config.BlobIndexer.S3.Bucket,
-		Key:         &key,
-		Body:        bytes.NewReader(body),
-		ContentType: &contentType,
-		Metadata: map[string]string{
-			"last_indexed_finalized_slot": fmt.Sprintf("%d", status.LastIndexedFinalizedSlot),
-		},
+	return bi.Store.Put(ctx, blobIndexerStatusKey, body, map[string]string{
+		"last_indexed_finalized_slot": fmt.Sprintf("%d", status.LastIndexedFinalizedSlot),
+		"last_pruned_slot":            fmt.Sprintf("%d", status.LastPrunedSlot),
 	})
-	if err != nil {
-		return err
-	}
-	return nil
 }
 
 type BeaconSpecResponse struct {
@@ -340,6 +422,7 @@ type BeaconSpecResponse struct {
 
 type BlobIndexerStatus struct {
 	LastIndexedFinalizedSlot uint64 `json:"last_indexed_finalized_slot"`
+	LastPrunedSlot           uint64 `json:"last_pruned_slot"`
 	// LastIndexedFinalizedRoot string `json:"last_indexed_finalized_root"`
 	// IndexedUnfinalized       map[string]uint64 `json:"indexed_unfinalized"`
 }
@@ -366,6 +449,17 @@ type BeaconBlobSidecarsResponseBlob struct {
 	KzgProof        string `json:"kzg_proof"`
 }
 
+type BeaconBlindedBlockResponse struct {
+	Data struct {
+		Message struct {
+			Slot uint64 `json:"slot,string"`
+			Body struct {
+				BlobKzgCommitments []string `json:"blob_kzg_commitments"`
+			} `json:"body"`
+		} `json:"message"`
+	} `json:"data"`
+}
+
 type BeaconFinalityCheckpointsResponse struct {
 	ExecutionOptimistic bool `json:"execution_optimistic"`
 	Finalized           bool `json:"finalized"`
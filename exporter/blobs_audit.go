@@ -0,0 +1,194 @@
+package exporter
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// BlobGap is a range of slots in which at least one blob of every slot with blobs is missing in the blob store
+type BlobGap struct {
+	StartSlot    uint64
+	EndSlot      uint64
+	MissingBlobs uint64
+}
+
+const (
+	blobSlotNoBlobs uint8 = iota
+	blobSlotComplete
+	blobSlotMissing
+)
+
+// Audit compares the blob_kzg_commitments of all blocks in [startSlot, endSlot] with the objects that exist in the blob store
+// and returns the ranges of slots with missing blobs. Slots without blobs do not interrupt a gap.
+// Slots before the retention window are skipped, their blobs are pruned and no longer served by the node.
+func (bi *BlobIndexer) Audit(startSlot, endSlot uint64, concurrency int) ([]BlobGap, error) {
+	if endSlot < startSlot {
+		return nil, fmt.Errorf("endSlot < startSlot: %v < %v", endSlot, startSlot)
+	}
+	if retentionStartSlot := BlobRetentionStartSlot(); startSlot < retentionStartSlot {
+		logrus.WithFields(logrus.Fields{"startSlot": startSlot, "retentionStartSlot": retentionStartSlot}).Infof("skipping slots before the retention window")
+		if endSlot < retentionStartSlot {
+			return nil, nil
+		}
+		startSlot = retentionStartSlot
+	}
+
+	slotStates := make([]uint8, endSlot-startSlot+1)
+	missingBySlot := make(map[uint64]uint64)
+	missingMu := &sync.Mutex{}
+	checked := atomic.Uint64{}
+	missing := atomic.Uint64{}
+
+	start := time.Now()
+	logrus.WithFields(logrus.Fields{"startSlot": startSlot, "endSlot": endSlot, "concurrency": concurrency}).Infof("auditing blobs")
+	stop := bi.logProgress("audit", &checked, uint64(len(slotStates)))
+	defer stop()
+
+	g, gCtx := errgroup.WithContext(context.Background())
+	g.SetLimit(concurrency)
+	for slot := startSlot; slot <= endSlot; slot++ {
+		slot := slot
+		g.Go(func() error {
+			select {
+			case <-gCtx.Done():
+				return gCtx.Err()
+			default:
+			}
+			commitments, err := bi.GetBlobKzgCommitmentsAtSlot(gCtx, slot)
+			if err != nil {
+				return err
+			}
+			state := blobSlotNoBlobs
+			if len(commitments) > 0 {
+				state = blobSlotComplete
+			}
+			slotMissing := uint64(0)
+			for _, c := range commitments {
+				commitment, err := hex.DecodeString(strings.Replace(c, "0x", "", -1))
+				if err != nil {
+					return fmt.Errorf("error decoding kzgCommitment at slot %v: %s: %w", slot, c, err)
+				}
+				key := BlobKey(fmt.Sprintf("%#x", utils.VersionedBlobHash(commitment).Bytes()))
+				exists, err := bi.Store.Exists(gCtx, key)
+				if err != nil {
+					return fmt.Errorf("error checking blob %s at slot %v: %w", key, slot, err)
+				}
+				if !exists {
+					slotMissing++
+				}
+			}
+			if slotMissing > 0 {
+				state = blobSlotMissing
+				missingMu.Lock()
+				missingBySlot[slot] = slotMissing
+				missingMu.Unlock()
+				metrics.Progress.WithLabelValues("blobindexer_audit_missing_blobs").Set(float64(missing.Add(slotMissing)))
+			}
+			// every goroutine writes a distinct element
+			slotStates[slot-startSlot] = state
+			metrics.Progress.WithLabelValues("blobindexer_audit_checked_slots").Set(float64(checked.Add(1)))
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := []BlobGap{}
+	var current *BlobGap
+	for i, state := range slotStates {
+		slot := startSlot + uint64(i)
+		switch state {
+		case blobSlotMissing:
+			if current == nil {
+				current = &BlobGap{StartSlot: slot}
+			}
+			current.EndSlot = slot
+			current.MissingBlobs += missingBySlot[slot]
+		case blobSlotComplete:
+			if current != nil {
+				gaps = append(gaps, *current)
+				current = nil
+			}
+		}
+	}
+	if current != nil {
+		gaps = append(gaps, *current)
+	}
+
+	logrus.WithFields(logrus.Fields{"startSlot": startSlot, "endSlot": endSlot, "gaps": len(gaps), "missingBlobs": missing.Load(), "duration": time.Since(start)}).Infof("finished auditing blobs")
+	return gaps, nil
+}
+
+// Backfill indexes all slots of the given gaps with bounded parallelism.
+// Slots that fail are logged and skipped, the number of failed slots is returned.
+func (bi *BlobIndexer) Backfill(gaps []BlobGap, concurrency int) (uint64, error) {
+	total := uint64(0)
+	for _, gap := range gaps {
+		total += gap.EndSlot - gap.StartSlot + 1
+	}
+
+	done := atomic.Uint64{}
+	failed := atomic.Uint64{}
+
+	start := time.Now()
+	logrus.WithFields(logrus.Fields{"gaps": len(gaps), "slots": total, "concurrency": concurrency}).Infof("backfilling blobs")
+	stop := bi.logProgress("backfill", &done, total)
+	defer stop()
+
+	g := &errgroup.Group{}
+	g.SetLimit(concurrency)
+	for _, gap := range gaps {
+		for slot := gap.StartSlot; slot <= gap.EndSlot; slot++ {
+			slot := slot
+			g.Go(func() error {
+				err := bi.IndexBlobsAtSlot(slot)
+				if err != nil {
+					logrus.WithFields(logrus.Fields{"slot": slot, "error": err}).Errorf("failed backfilling blobs")
+					metrics.Progress.WithLabelValues("blobindexer_backfill_failed_slots").Set(float64(failed.Add(1)))
+				}
+				metrics.Progress.WithLabelValues("blobindexer_backfill_done_slots").Set(float64(done.Add(1)))
+				return nil
+			})
+		}
+	}
+	err := g.Wait()
+	if err != nil {
+		return failed.Load(), err
+	}
+
+	logrus.WithFields(logrus.Fields{"slots": total, "failed": failed.Load(), "duration": time.Since(start)}).Infof("finished backfilling blobs")
+	return failed.Load(), nil
+}
+
+// logProgress logs the progress of a long running task every 10 seconds until the returned function is called
+func (bi *BlobIndexer) logProgress(task string, done *atomic.Uint64, total uint64) func() {
+	metrics.Progress.WithLabelValues(fmt.Sprintf("blobindexer_%s_total_slots", task)).Set(float64(total))
+	ticker := time.NewTicker(time.Second * 10)
+	quit := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				d := done.Load()
+				logrus.WithFields(logrus.Fields{"done": d, "total": total, "percent": fmt.Sprintf("%.2f", float64(d)*100/float64(max(total, 1)))}).Infof("%s progress", task)
+			case <-quit:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() { close(quit) }
+}
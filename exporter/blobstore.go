@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto/kzg4844"
+)
+
+// ErrBlobNotFound is returned by a BlobStore if the requested object does not exist
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore is a storage backend for blob sidecars. Objects are addressed by keys like blobs/<versionedHash>
+// and carry string metadata (slot, index, kzg_commitment, kzg_proof, ...).
+type BlobStore interface {
+	Name() string
+	Exists(ctx context.Context, key string) (bool, error)
+	Get(ctx context.Context, key string) ([]byte, map[string]string, error)
+	Put(ctx context.Context, key string, data []byte, metadata map[string]string) error
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore creates the blob store configured in utils.Config.BlobIndexer.Store, s3 is used if no store is configured
+func NewBlobStore(ctx context.Context) (BlobStore, error) {
+	switch utils.Config.BlobIndexer.Store {
+	case "", "s3":
+		return NewS3BlobStore(), nil
+	case "filesystem":
+		return NewFilesystemBlobStore(utils.Config.BlobIndexer.Filesystem.Path)
+	case "gcs":
+		return NewGcsBlobStore(ctx)
+	default:
+		return nil, fmt.Errorf("unknown blob store %v", utils.Config.BlobIndexer.Store)
+	}
+}
+
+// BlobKey returns the key of the blob with the given versioned hash (0x prefixed)
+func BlobKey(versionedHash string) string {
+	return fmt.Sprintf("blobs/%s", strings.ToLower(versionedHash))
+}
+
+// VerifyBlob checks that the versioned hash is derived from the kzg commitment and that the kzg proof
+// proves the blob against the commitment
+func VerifyBlob(versionedHash string, blob, kzgCommitment, kzgProof []byte) error {
+	if len(kzgCommitment) != len(kzg4844.Commitment{}) {
+		return fmt.Errorf("invalid kzg commitment length %v", len(kzgCommitment))
+	}
+	if len(kzgProof) != len(kzg4844.Proof{}) {
+		return fmt.Errorf("invalid kzg proof length %v", len(kzgProof))
+	}
+	if len(blob) != len(kzg4844.Blob{}) {
+		return fmt.Errorf("invalid blob length %v", len(blob))
+	}
+
+	computedHash := fmt.Sprintf("%#x", utils.VersionedBlobHash(kzgCommitment).Bytes())
+	if !strings.EqualFold(computedHash, versionedHash) {
+		return fmt.Errorf("versioned hash mismatch: %v != %v", computedHash, versionedHash)
+	}
+
+	var b kzg4844.Blob
+	var c kzg4844.Commitment
+	var p kzg4844.Proof
+	copy(b[:], blob)
+	copy(c[:], kzgCommitment)
+	copy(p[:], kzgProof)
+	err := kzg4844.VerifyBlobProof(b, c, p)
+	if err != nil {
+		return fmt.Errorf("error verifying kzg proof of blob %v: %w", versionedHash, err)
+	}
+	return nil
+}
+
+// VerifyStoredBlob verifies a blob read from a blob store against the kzg commitment and proof stored in its metadata
+func VerifyStoredBlob(versionedHash string, blob []byte, metadata map[string]string) error {
+	kzgCommitment, err := hexutil.Decode(BlobMetadataValue(metadata, "kzg_commitment"))
+	if err != nil {
+		return fmt.Errorf("error decoding kzg_commitment of blob %v: %w", versionedHash, err)
+	}
+	kzgProof, err := hexutil.Decode(BlobMetadataValue(metadata, "kzg_proof"))
+	if err != nil {
+		return fmt.Errorf("error decoding kzg_proof of blob %v: %w", versionedHash, err)
+	}
+	return VerifyBlob(versionedHash, blob, kzgCommitment, kzgProof)
+}
+
+// BlobMetadataValue returns a metadata value of a stored blob, some backends normalize the casing of metadata keys
+func BlobMetadataValue(metadata map[string]string, key string) string {
+	if v, ok := metadata[key]; ok {
+		return v
+	}
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,142 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemBlobStore stores blobs on the local filesystem. Blobs are sharded into directories by the
+// first two bytes of their hash (blobs/ab/cd/0x01abcd...), metadata is stored next to the blob as <name>.meta.json
+type FilesystemBlobStore struct {
+	root string
+}
+
+func NewFilesystemBlobStore(root string) (*FilesystemBlobStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("no path configured for filesystem blob store")
+	}
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("error creating blob store directory %v: %w", root, err)
+	}
+	return &FilesystemBlobStore{root: root}, nil
+}
+
+func (s *FilesystemBlobStore) Name() string {
+	return "filesystem:" + s.root
+}
+
+// path returns the location of the object with the given key on disk
+func (s *FilesystemBlobStore) path(key string) (string, error) {
+	key = filepath.Clean("/" + key)
+	if strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid key %v", key)
+	}
+	dir, name := filepath.Split(key)
+	// versioned hashes always start with 0x01, shard by the following two bytes
+	if strings.HasPrefix(name, "0x") && len(name) >= 8 {
+		dir = filepath.Join(dir, name[4:6], name[6:8])
+	}
+	return filepath.Join(s.root, dir, name), nil
+}
+
+func (s *FilesystemBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *FilesystemBlobStore) Get(ctx context.Context, key string) ([]byte, map[string]string, error) {
+	p, err := s.path(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata := map[string]string{}
+	meta, err := os.ReadFile(p + ".meta.json")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	if len(meta) > 0 {
+		err = json.Unmarshal(meta, &metadata)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error decoding metadata of %v: %w", key, err)
+		}
+	}
+	return data, metadata, nil
+}
+
+func (s *FilesystemBlobStore) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return err
+	}
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	// write the metadata first so that an existing blob file always has its metadata
+	err = writeFileAtomic(p+".meta.json", meta)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p, data)
+}
+
+func (s *FilesystemBlobStore) Delete(ctx context.Context, key string) error {
+	p, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	for _, f := range []string{p, p + ".meta.json"} {
+		err = os.Remove(f)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes the file to a temporary file in the same directory and renames it afterwards
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
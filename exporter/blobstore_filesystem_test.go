@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testBlobKey = "blobs/0x01abcdef0123456789abcdef0123456789abcdef0123456789abcdef01234567"
+
+func TestFilesystemBlobStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewFilesystemBlobStore(root)
+	if err != nil {
+		t.Fatalf("error creating blob store: %v", err)
+	}
+
+	data := []byte("blob data")
+	metadata := map[string]string{"slot": "123", "index": "0", "kzg_commitment": "0x01"}
+	err = store.Put(ctx, testBlobKey, data, metadata)
+	if err != nil {
+		t.Fatalf("error putting blob: %v", err)
+	}
+
+	exists, err := store.Exists(ctx, testBlobKey)
+	if err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true", exists, err)
+	}
+	gotData, gotMetadata, err := store.Get(ctx, testBlobKey)
+	if err != nil {
+		t.Fatalf("error getting blob: %v", err)
+	}
+	if !bytes.Equal(gotData, data) {
+		t.Errorf("Get() data = %q, want %q", gotData, data)
+	}
+	if !reflect.DeepEqual(gotMetadata, metadata) {
+		t.Errorf("Get() metadata = %v, want %v", gotMetadata, metadata)
+	}
+
+	// blobs are sharded by the two bytes following the 0x01 version byte
+	_, err = os.Stat(filepath.Join(root, "blobs", "ab", "cd", filepath.Base(testBlobKey)))
+	if err != nil {
+		t.Errorf("blob not stored in its shard directory: %v", err)
+	}
+
+	err = store.Delete(ctx, testBlobKey)
+	if err != nil {
+		t.Fatalf("error deleting blob: %v", err)
+	}
+	exists, err = store.Exists(ctx, testBlobKey)
+	if err != nil || exists {
+		t.Errorf("Exists() after Delete() = %v, %v, want false", exists, err)
+	}
+}
+
+func TestFilesystemBlobStoreMissingBlob(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFilesystemBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("error creating blob store: %v", err)
+	}
+
+	exists, err := store.Exists(ctx, testBlobKey)
+	if err != nil || exists {
+		t.Errorf("Exists() = %v, %v, want false", exists, err)
+	}
+	_, _, err = store.Get(ctx, testBlobKey)
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get() error = %v, want %v", err, ErrBlobNotFound)
+	}
+	err = store.Delete(ctx, testBlobKey)
+	if err != nil {
+		t.Errorf("Delete() of a missing blob returned %v", err)
+	}
+}
+
+func TestFilesystemBlobStoreOverwrite(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store, err := NewFilesystemBlobStore(root)
+	if err != nil {
+		t.Fatalf("error creating blob store: %v", err)
+	}
+
+	err = store.Put(ctx, testBlobKey, []byte("first"), map[string]string{"slot": "1", "index": "0"})
+	if err != nil {
+		t.Fatalf("error putting blob: %v", err)
+	}
+	err = store.Put(ctx, testBlobKey, []byte("second"), map[string]string{"slot": "2"})
+	if err != nil {
+		t.Fatalf("error overwriting blob: %v", err)
+	}
+
+	data, metadata, err := store.Get(ctx, testBlobKey)
+	if err != nil {
+		t.Fatalf("error getting blob: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Get() data = %q, want %q", data, "second")
+	}
+	if !reflect.DeepEqual(metadata, map[string]string{"slot": "2"}) {
+		t.Errorf("Get() metadata = %v, want the metadata of the second write", metadata)
+	}
+
+	// no temporary files are left behind
+	files, err := os.ReadDir(filepath.Join(root, "blobs", "ab", "cd"))
+	if err != nil {
+		t.Fatalf("error reading shard directory: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("shard directory contains %v files, want the blob and its metadata", len(files))
+	}
+}
@@ -0,0 +1,95 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// GcsBlobStore stores blobs in a google cloud storage (or compatible) bucket
+type GcsBlobStore struct {
+	client *storage.Client
+	bucket *storage.BucketHandle
+}
+
+func NewGcsBlobStore(ctx context.Context) (*GcsBlobStore, error) {
+	cfg := utils.Config.BlobIndexer.Gcs
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("no bucket configured for gcs blob store")
+	}
+	opts := []option.ClientOption{}
+	if cfg.Endpoint != "" {
+		opts = append(opts, option.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error creating gcs client: %w", err)
+	}
+	return &GcsBlobStore{
+		client: client,
+		bucket: client.Bucket(cfg.Bucket),
+	}, nil
+}
+
+func (s *GcsBlobStore) Name() string {
+	return "gcs:" + utils.Config.BlobIndexer.Gcs.Bucket
+}
+
+func (s *GcsBlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.bucket.Object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *GcsBlobStore) Get(ctx context.Context, key string) ([]byte, map[string]string, error) {
+	r, err := s.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	attrs, err := s.bucket.Object(key).Attrs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, attrs.Metadata, nil
+}
+
+func (s *GcsBlobStore) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	w := s.bucket.Object(key).NewWriter(ctx)
+	w.Metadata = metadata
+	_, err := w.Write(data)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+func (s *GcsBlobStore) Delete(ctx context.Context, key string) error {
+	err := s.bucket.Object(key).Delete(ctx)
+	if err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
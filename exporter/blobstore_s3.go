@@ -0,0 +1,112 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3BlobStore stores blobs in an s3 compatible bucket
+type S3BlobStore struct {
+	S3Client *s3.Client
+	bucket   string
+}
+
+func NewS3BlobStore() *S3BlobStore {
+	s3Resolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
+		return aws.Endpoint{
+			PartitionID:       "aws",
+			URL:               utils.Config.BlobIndexer.S3.Endpoint,
+			SigningRegion:     "us-east-2",
+			HostnameImmutable: true,
+		}, nil
+	})
+	s3Client := s3.NewFromConfig(aws.Config{
+		Region: "us-east-2",
+		Credentials: credentials.NewStaticCredentialsProvider(
+			utils.Config.BlobIndexer.S3.AccessKeyId,
+			utils.Config.BlobIndexer.S3.AccessKeySecret,
+			"",
+		),
+		EndpointResolverWithOptions: s3Resolver,
+	}, func(o *s3.Options) {
+		o.UsePathStyle = true
+	})
+	return &S3BlobStore{
+		S3Client: s3Client,
+		bucket:   utils.Config.BlobIndexer.S3.Bucket,
+	}
+}
+
+func (s *S3BlobStore) Name() string {
+	return "s3:" + utils.Config.BlobIndexer.S3.Endpoint + "/" + s.bucket
+}
+
+// isS3NotFound returns true if the error is a not found error.
+// If the object that you request doesn’t exist, the error that Amazon S3 returns depends on whether you also have the s3:ListBucket permission. If you have the s3:ListBucket permission on the bucket, Amazon S3 returns an HTTP status code 404 (Not Found) error. If you don’t have the s3:ListBucket permission, Amazon S3 returns an HTTP status code 403 ("access denied") error.
+func isS3NotFound(err error) bool {
+	var httpResponseErr *awshttp.ResponseError
+	return errors.As(err, &httpResponseErr) && (httpResponseErr.HTTPStatusCode() == 404 || httpResponseErr.HTTPStatusCode() == 403)
+}
+
+func (s *S3BlobStore) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.S3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *S3BlobStore) Get(ctx context.Context, key string) ([]byte, map[string]string, error) {
+	obj, err := s.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			return nil, nil, ErrBlobNotFound
+		}
+		return nil, nil, err
+	}
+	defer obj.Body.Close()
+	data, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, obj.Metadata, nil
+}
+
+func (s *S3BlobStore) Put(ctx context.Context, key string, data []byte, metadata map[string]string) error {
+	_, err := s.S3Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		Body:     bytes.NewReader(data),
+		Metadata: metadata,
+	})
+	return err
+}
+
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	_, err := s.S3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil && !isS3NotFound(err) {
+		return err
+	}
+	return nil
+}
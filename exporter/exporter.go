@@ -36,6 +36,9 @@ func Start(client rpc.Client) {
 
 	if utils.Config.MevBoostRelayExporter.Enabled {
 		go mevBoostRelaysExporter()
+		if utils.Config.MevBoostRelayExporter.VerifyPayouts {
+			go mevBoostRelaysPayoutVerifier()
+		}
 	}
 	// wait until the beacon-node is available
 	for {
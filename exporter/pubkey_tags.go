@@ -1,42 +1,167 @@
 package exporter
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gobitfly/eth2-beaconchain-explorer/db"
 	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethRPC "github.com/ethereum/go-ethereum/rpc"
+	"github.com/sirupsen/logrus"
 )
 
+// number of validators checked against a registry contract per rule and run, and per rpc batch
+const validatorTagRegistryValidatorsPerRun = 5000
+const validatorTagRegistryBatchSize = 100
+
+// UpdatePubkeyTag evaluates the validator tag rules on the deposits, finalized blocks and validators added since their last evaluation.
+// Stake pool deposit addresses are added as rules before every run.
 func UpdatePubkeyTag() {
 	logger.Infoln("Started Pubkey Tags Updater")
 	for {
 		start := time.Now()
 
-		tx, err := db.WriterDb.Beginx()
+		err := updateValidatorTags()
 		if err != nil {
-			logger.WithError(err).Error("Error connecting to DB")
-			// return err
-		}
-		_, err = tx.Exec(`INSERT INTO validator_tags (publickey, tag)
-		SELECT publickey, FORMAT('pool:%s', sps.name) tag
-		FROM eth1_deposits
-		inner join stake_pools_stats as sps on ENCODE(from_address::bytea, 'hex')=sps.address
-		WHERE sps.name NOT LIKE '%Rocketpool -%'
-		ON CONFLICT (publickey, tag) DO NOTHING;`)
+			utils.LogError(err, "error updating validator tags", 0)
+		}
+
+		logger.Infof("Updating Pubkey Tags took %v sec.", time.Since(start).Seconds())
+		metrics.TaskDuration.WithLabelValues("validator_pubkey_tag_updater").Observe(time.Since(start).Seconds())
+
+		time.Sleep(time.Minute)
+	}
+}
+
+func updateValidatorTags() error {
+	added, err := db.SyncStakePoolValidatorTagRules()
+	if err != nil {
+		return err
+	}
+	if added > 0 {
+		logger.Infof("added %v validator tag rules for stake pool deposit addresses", added)
+	}
+
+	rules, err := db.GetEnabledValidatorTagRules()
+	if err != nil {
+		return err
+	}
+	depositBlockEnd, slotEnd, err := db.GetValidatorTagRuleCursorEnds(utils.Config.Chain.ClConfig.SlotsPerEpoch)
+	if err != nil {
+		return err
+	}
+
+	var client *gethRPC.Client
+	for _, rule := range rules {
+		var tagged int64
+		if rule.RuleType == types.ValidatorTagRuleRegistry {
+			if client == nil {
+				client, err = gethRPC.Dial(utils.Config.Eth1GethEndpoint)
+				if err != nil {
+					return fmt.Errorf("error dialing eth1 endpoint for registry rules: %w", err)
+				}
+				defer client.Close()
+			}
+			tagged, err = applyValidatorTagRegistryRule(client, rule)
+		} else {
+			tagged, err = db.ApplyValidatorTagRule(rule, depositBlockEnd, slotEnd)
+		}
+		// a failing rule (e.g. an unreachable registry) does not block the evaluation of the other rules
 		if err != nil {
-			logger.WithError(err).Error("Error updating validator_tags")
-			// return err
+			utils.LogError(err, "error applying validator tag rule", 0, map[string]interface{}{"rule": rule.Id, "tag": rule.Tag, "type": rule.RuleType})
+			continue
+		}
+		if tagged > 0 {
+			logger.WithFields(logrus.Fields{"rule": rule.Id, "tag": rule.Tag, "type": rule.RuleType}).Infof("tagged %v validators", tagged)
 		}
+	}
+	return nil
+}
+
+// applyValidatorTagRegistryRule calls the method of the registry contract of a rule for the next validators after the cursor of the rule,
+// tags the validators the registry returns true for and removes the tag from validators that are no longer registered.
+// Once all validators were checked the cursor is reset so registrations and deregistrations are picked up by the next pass.
+func applyValidatorTagRegistryRule(client *gethRPC.Client, rule *types.ValidatorTagRule) (int64, error) {
+	address, method, err := utils.ParseValidatorTagRegistryPattern(rule.Pattern)
+	if err != nil {
+		return 0, err
+	}
+	pubkeys, validatorIndexEnd, err := db.GetValidatorPubkeysFromIndex(rule.ValidatorIndexCursor, validatorTagRegistryValidatorsPerRun)
+	if err != nil {
+		return 0, err
+	}
+	if len(pubkeys) == 0 {
+		if rule.ValidatorIndexCursor > 0 {
+			// all validators were checked, start the next pass
+			return 0, db.TagValidatorsOfRule(rule, nil, nil, 0)
+		}
+		return 0, nil
+	}
+
+	bytesType, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return 0, err
+	}
+	selector := crypto.Keccak256([]byte(method))[:4]
+	to := hexutil.Bytes(address).String()
 
-		err = tx.Commit()
+	registered := [][]byte{}
+	unregistered := [][]byte{}
+	for i := 0; i < len(pubkeys); i += validatorTagRegistryBatchSize {
+		end := i + validatorTagRegistryBatchSize
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+
+		batch := make([]gethRPC.BatchElem, 0, end-i)
+		for _, pubkey := range pubkeys[i:end] {
+			args, err := abi.Arguments{{Type: bytesType}}.Pack(pubkey)
+			if err != nil {
+				return 0, fmt.Errorf("error encoding registry call for pubkey %#x: %w", pubkey, err)
+			}
+			batch = append(batch, gethRPC.BatchElem{
+				Method: "eth_call",
+				Args:   []interface{}{map[string]interface{}{"to": to, "data": hexutil.Bytes(append(append([]byte{}, selector...), args...))}, "latest"},
+				Result: &hexutil.Bytes{},
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		err = client.BatchCallContext(ctx, batch)
+		cancel()
 		if err != nil {
-			logger.WithError(err).Error("Error committing transaction")
+			return 0, fmt.Errorf("error calling registry %#x: %w", address, err)
 		}
-		tx.Rollback()
 
-		logger.Infof("Updating Pubkey Tags took %v sec.", time.Since(start).Seconds())
-		metrics.TaskDuration.WithLabelValues("validator_pubkey_tag_updater").Observe(time.Since(start).Seconds())
+		for j, elem := range batch {
+			if elem.Error != nil {
+				// registries commonly revert for unknown pubkeys, any other error stops the run without advancing the cursor
+				if strings.HasPrefix(elem.Error.Error(), "execution reverted") {
+					unregistered = append(unregistered, pubkeys[i+j])
+					continue
+				}
+				return 0, fmt.Errorf("error calling registry %#x for pubkey %#x: %w", address, pubkeys[i+j], elem.Error)
+			}
+			result := *elem.Result.(*hexutil.Bytes)
+			if len(result) == 32 && result[31] == 1 {
+				registered = append(registered, pubkeys[i+j])
+			} else {
+				unregistered = append(unregistered, pubkeys[i+j])
+			}
+		}
+	}
 
-		time.Sleep(time.Minute * 10)
+	err = db.TagValidatorsOfRule(rule, registered, unregistered, validatorIndexEnd)
+	if err != nil {
+		return 0, err
 	}
+	return int64(len(registered)), nil
 }
@@ -103,6 +103,13 @@ func singleRelayExport(r types.Relay, wg *sync.WaitGroup, mux *sync.Mutex) {
 	}
 
 	r.Logger.Infof("finished syncing payloads from relay")
+
+	if utils.Config.MevBoostRelayExporter.ReceivedBids.Enabled {
+		err = exportRelayBids(r)
+		if err != nil {
+			r.Logger.Warnf("failed to export received bids for relay: %v", err)
+		}
+	}
 }
 
 func fetchDeliveredPayloads(r types.Relay, offset uint64) ([]BidTrace, error) {
@@ -0,0 +1,233 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/lib/pq"
+)
+
+// relays are polled once per slot, so the number of slots exported per run limits the number of requests per minute
+const relayBidsMaxSlotsPerRun = 300
+
+// number of consecutive slots that failed before the relay is considered unavailable and the run is stopped
+const relayBidsMaxConsecutiveErrors = 10
+
+type ReceivedBidTrace struct {
+	BidTrace
+	BlockNumber uint64 `json:"block_number,string"`
+	NumTx       uint64 `json:"num_tx,string"`
+	TimestampMs uint64 `json:"timestamp_ms,string"`
+}
+
+// relayBidsRetentionSlots returns the number of slots received bids are kept for
+func relayBidsRetentionSlots() uint64 {
+	days := utils.Config.MevBoostRelayExporter.ReceivedBids.RetentionDays
+	if days == 0 {
+		days = 14
+	}
+	return days * uint64(utils.Day/time.Second) / utils.Config.Chain.ClConfig.SecondsPerSlot
+}
+
+// relayBidsMaxPerSlot returns the number of bids stored per relay and slot, only the highest bids are kept
+func relayBidsMaxPerSlot() int {
+	if utils.Config.MevBoostRelayExporter.ReceivedBids.MaxBidsPerSlot > 0 {
+		return utils.Config.MevBoostRelayExporter.ReceivedBids.MaxBidsPerSlot
+	}
+	return 50
+}
+
+func fetchReceivedBids(r types.Relay, slot uint64) ([]ReceivedBidTrace, error) {
+	var bids []ReceivedBidTrace
+	url := fmt.Sprintf("%s/relay/v1/data/bidtraces/builder_blocks_received?slot=%d", r.Endpoint, slot)
+	r.Logger.Debugf("calling %v", url)
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving received bids of slot %v: %w", slot, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error retrieving received bids of slot %v: unexpected status code %v", slot, resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&bids)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding received bids of slot %v: %w", slot, err)
+	}
+	return bids, nil
+}
+
+// exportRelayBids stores the bids a relay received for the slots since the last export and removes bids outside of the retention window.
+// Per slot only the highest bids are stored, the number of received bids and the top bid are kept in relays_bids_slots.
+func exportRelayBids(r types.Relay) error {
+	var headSlot uint64
+	err := db.ReaderDb.Get(&headSlot, `SELECT COALESCE(MAX(slot), 0) FROM blocks`)
+	if err != nil {
+		return fmt.Errorf("error retrieving head slot: %w", err)
+	}
+	if headSlot == 0 {
+		return nil
+	}
+	// the auction of the head slot might still be running
+	lastSlot := headSlot - 1
+
+	retention := relayBidsRetentionSlots()
+	firstSlot := uint64(0)
+	if lastSlot > retention {
+		firstSlot = lastSlot - retention
+	}
+
+	var lastExportedSlot *uint64
+	err = db.ReaderDb.Get(&lastExportedSlot, `SELECT MAX(slot) FROM relays_bids_slots WHERE tag_id = $1`, r.ID)
+	if err != nil {
+		return fmt.Errorf("error retrieving last exported bids slot: %w", err)
+	}
+	if lastExportedSlot != nil && *lastExportedSlot+1 > firstSlot {
+		firstSlot = *lastExportedSlot + 1
+	}
+	// bids are not backfilled, the first export starts with the most recent slots
+	if lastExportedSlot == nil && lastSlot >= relayBidsMaxSlotsPerRun {
+		firstSlot = lastSlot - relayBidsMaxSlotsPerRun + 1
+	}
+	if firstSlot+relayBidsMaxSlotsPerRun <= lastSlot {
+		lastSlot = firstSlot + relayBidsMaxSlotsPerRun - 1
+	}
+
+	// slots that failed are recorded once a later slot was exported, so a slot that fails permanently does not stall the export
+	// while the slots are retried by the next run if the relay is unavailable
+	failedSlots := map[uint64]error{}
+	for slot := firstSlot; slot <= lastSlot; slot++ {
+		bids, err := fetchReceivedBids(r, slot)
+		if err != nil {
+			r.Logger.Warnf("error exporting received bids of slot %v: %v", slot, err)
+			failedSlots[slot] = err
+			if len(failedSlots) >= relayBidsMaxConsecutiveErrors {
+				return fmt.Errorf("error exporting received bids of %v consecutive slots: %w", len(failedSlots), err)
+			}
+			time.Sleep(time.Millisecond * 100)
+			continue
+		}
+		for failedSlot, fetchErr := range failedSlots {
+			err = saveRelayBidsError(r, failedSlot, fetchErr)
+			if err != nil {
+				return err
+			}
+		}
+		failedSlots = map[uint64]error{}
+		err = saveRelayBids(r, slot, bids)
+		if err != nil {
+			return err
+		}
+		time.Sleep(time.Millisecond * 100)
+	}
+
+	if headSlot > retention {
+		_, err = db.WriterDb.Exec(`DELETE FROM relays_bids WHERE tag_id = $1 AND slot < $2`, r.ID, headSlot-retention)
+		if err != nil {
+			return fmt.Errorf("error deleting relays_bids older than slot %v: %w", headSlot-retention, err)
+		}
+		_, err = db.WriterDb.Exec(`DELETE FROM relays_bids_slots WHERE tag_id = $1 AND slot < $2`, r.ID, headSlot-retention)
+		if err != nil {
+			return fmt.Errorf("error deleting relays_bids_slots older than slot %v: %w", headSlot-retention, err)
+		}
+	}
+
+	if firstSlot <= lastSlot {
+		r.Logger.Infof("exported received bids of slots %v-%v", firstSlot, lastSlot)
+	}
+	return nil
+}
+
+func saveRelayBids(r types.Relay, slot uint64, bids []ReceivedBidTrace) error {
+	// builders resubmit the same block, keep the highest bid per block hash
+	byHash := make(map[string]ReceivedBidTrace, len(bids))
+	for _, bid := range bids {
+		if bid.Slot != slot {
+			continue
+		}
+		existing, ok := byHash[bid.BlockHash]
+		if !ok || bid.Value.BigInt().Cmp(existing.Value.BigInt()) > 0 {
+			byHash[bid.BlockHash] = bid
+		}
+	}
+	unique := make([]ReceivedBidTrace, 0, len(byHash))
+	for _, bid := range byHash {
+		unique = append(unique, bid)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].Value.BigInt().Cmp(unique[j].Value.BigInt()) > 0
+	})
+
+	topValue := "0"
+	if len(unique) > 0 {
+		topValue = unique[0].Value.BigInt().String()
+	}
+	bidsCount := len(unique)
+	if len(unique) > relayBidsMaxPerSlot() {
+		unique = unique[:relayBidsMaxPerSlot()]
+	}
+
+	blockHashes := make([][]byte, len(unique))
+	builders := make([][]byte, len(unique))
+	values := make([]string, len(unique))
+	timestamps := make([]int64, len(unique))
+	for i, bid := range unique {
+		blockHashes[i] = utils.MustParseHex(bid.BlockHash)
+		builders[i] = utils.MustParseHex(bid.BuilderPubkey)
+		values[i] = bid.Value.BigInt().String()
+		timestamps[i] = int64(bid.TimestampMs)
+	}
+
+	tx, err := db.WriterDb.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if len(unique) > 0 {
+		_, err = tx.Exec(`
+			INSERT INTO relays_bids (tag_id, slot, block_hash, builder_pubkey, value, timestamp_ms)
+			SELECT $1, $2, * FROM unnest($3::bytea[], $4::bytea[], $5::numeric[], $6::bigint[])
+			ON CONFLICT (slot, tag_id, block_hash) DO UPDATE SET
+				builder_pubkey = excluded.builder_pubkey,
+				value = excluded.value,
+				timestamp_ms = excluded.timestamp_ms`,
+			r.ID, slot, pq.Array(blockHashes), pq.Array(builders), pq.Array(values), pq.Array(timestamps))
+		if err != nil {
+			return fmt.Errorf("error saving received bids of slot %v: %w", slot, err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO relays_bids_slots (tag_id, slot, bids_count, top_value)
+		VALUES ($1, $2, $3, $4::numeric)
+		ON CONFLICT (slot, tag_id) DO UPDATE SET bids_count = excluded.bids_count, top_value = excluded.top_value, error = NULL`,
+		r.ID, slot, bidsCount, topValue)
+	if err != nil {
+		return fmt.Errorf("error saving received bids summary of slot %v: %w", slot, err)
+	}
+
+	return tx.Commit()
+}
+
+// saveRelayBidsError records a slot whose received bids could not be exported, it is excluded from the bid competition
+func saveRelayBidsError(r types.Relay, slot uint64, fetchErr error) error {
+	_, err := db.WriterDb.Exec(`
+		INSERT INTO relays_bids_slots (tag_id, slot, bids_count, top_value, error)
+		VALUES ($1, $2, 0, 0, $3)
+		ON CONFLICT (slot, tag_id) DO NOTHING`,
+		r.ID, slot, fetchErr.Error())
+	if err != nil {
+		return fmt.Errorf("error saving failed received bids export of slot %v: %w", slot, err)
+	}
+	return nil
+}
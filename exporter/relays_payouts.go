@@ -0,0 +1,180 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const relayPayoutVerificationBatchSize = 500
+
+// payouts that could not be verified are retried after relayPayoutVerificationRetryDelay until they failed relayPayoutVerificationMaxAttempts times
+const relayPayoutVerificationMaxAttempts = 5
+const relayPayoutVerificationRetryDelay = time.Hour
+
+const (
+	RelayPayoutStatusValid     = "valid"
+	RelayPayoutStatusUnderpaid = "underpaid"
+	RelayPayoutStatusUnpaid    = "unpaid"
+	RelayPayoutStatusError     = "error"
+)
+
+type relayBlockPayout struct {
+	TagID                string `db:"tag_id"`
+	BlockSlot            uint64 `db:"block_slot"`
+	BlockRoot            []byte `db:"block_root"`
+	ExecBlockHash        []byte `db:"exec_block_hash"`
+	ExecBlockNumber      uint64 `db:"exec_block_number"`
+	ProposerFeeRecipient []byte `db:"proposer_fee_recipient"`
+	Value                string `db:"value"`
+}
+
+// mevBoostRelaysPayoutVerifier compares the proposer payment relays reported for their finalized blocks with the
+// amount the proposer fee recipient actually received in the execution block
+func mevBoostRelaysPayoutVerifier() {
+	for {
+		processed, err := verifyRelayBlockPayouts()
+		if err != nil {
+			utils.LogError(err, "error verifying relay block payouts", 0)
+		}
+		if err != nil || processed < relayPayoutVerificationBatchSize {
+			time.Sleep(time.Minute)
+		}
+	}
+}
+
+func verifyRelayBlockPayouts() (int, error) {
+	start := time.Now()
+	defer func() {
+		metrics.TaskDuration.WithLabelValues("exporter_verify_relay_payouts").Observe(time.Since(start).Seconds())
+	}()
+
+	latestFinalizedEpoch, err := db.GetLatestFinalizedEpoch()
+	if err != nil {
+		return 0, fmt.Errorf("error getting latest finalized epoch from db: %w", err)
+	}
+	lastSlot := (latestFinalizedEpoch+1)*utils.Config.Chain.ClConfig.SlotsPerEpoch - 1
+
+	payouts := []*relayBlockPayout{}
+	err = db.ReaderDb.Select(&payouts, `
+		SELECT
+			rb.tag_id,
+			rb.block_slot,
+			rb.block_root,
+			rb.exec_block_hash,
+			b.exec_block_number,
+			rb.proposer_fee_recipient,
+			rb.value::text AS value
+		FROM relays_blocks rb
+		INNER JOIN blocks b ON b.slot = rb.block_slot AND b.blockroot = rb.block_root
+		LEFT JOIN relays_blocks_payouts p ON p.block_slot = rb.block_slot AND p.block_root = rb.block_root AND p.tag_id = rb.tag_id
+		WHERE
+			(p.block_slot IS NULL OR (p.status = $3 AND p.attempts < $4 AND p.verified_ts < (NOW() AT TIME ZONE 'utc') - $5 * INTERVAL '1 second')) AND
+			b.exec_block_number IS NOT NULL AND rb.block_slot <= $1
+		ORDER BY rb.block_slot DESC
+		LIMIT $2`, lastSlot, relayPayoutVerificationBatchSize, RelayPayoutStatusError, relayPayoutVerificationMaxAttempts, relayPayoutVerificationRetryDelay.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving unverified relay blocks: %w", err)
+	}
+	if len(payouts) == 0 {
+		return 0, nil
+	}
+
+	verified := 0
+	underpaid := 0
+	failed := 0
+	for _, payout := range payouts {
+		status, paid, err := verifyRelayBlockPayout(payout)
+		if err != nil {
+			logger.WithFields(logrus.Fields{"relay": payout.TagID, "slot": payout.BlockSlot}).Warnf("error verifying payout of relay block: %v", err)
+			// the failed attempt is recorded so the block does not keep older blocks from being verified
+			_, err = db.WriterDb.Exec(`
+				INSERT INTO relays_blocks_payouts (tag_id, block_slot, block_root, exec_block_number, claimed_value, status, error)
+				VALUES ($1, $2, $3, $4, $5::numeric, $6, $7)
+				ON CONFLICT (block_slot, block_root, tag_id) DO UPDATE SET
+					attempts = relays_blocks_payouts.attempts + 1,
+					error = excluded.error,
+					verified_ts = (NOW() AT TIME ZONE 'utc')`,
+				payout.TagID, payout.BlockSlot, payout.BlockRoot, payout.ExecBlockNumber, payout.Value, RelayPayoutStatusError, err.Error())
+			if err != nil {
+				return verified, fmt.Errorf("error saving failed payout verification of relay %v at slot %v: %w", payout.TagID, payout.BlockSlot, err)
+			}
+			failed++
+			continue
+		}
+
+		tx, err := db.WriterDb.Beginx()
+		if err != nil {
+			return verified, fmt.Errorf("error starting db transaction: %w", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO relays_blocks_payouts (tag_id, block_slot, block_root, exec_block_number, claimed_value, paid_value, status)
+			VALUES ($1, $2, $3, $4, $5::numeric, $6::numeric, $7)
+			ON CONFLICT (block_slot, block_root, tag_id) DO UPDATE SET
+				exec_block_number = excluded.exec_block_number,
+				claimed_value = excluded.claimed_value,
+				paid_value = excluded.paid_value,
+				status = excluded.status,
+				error = NULL,
+				verified_ts = (NOW() AT TIME ZONE 'utc')`,
+			payout.TagID, payout.BlockSlot, payout.BlockRoot, payout.ExecBlockNumber, payout.Value, paid.String(), status)
+		if err != nil {
+			tx.Rollback()
+			return verified, fmt.Errorf("error saving payout verification of relay %v at slot %v: %w", payout.TagID, payout.BlockSlot, err)
+		}
+		if status != RelayPayoutStatusValid {
+			underpaid++
+			// blocks with an invalid relay reward are excluded from the relay statistics
+			_, err = tx.Exec(`
+				INSERT INTO blocks_tags (slot, blockroot, tag_id)
+				SELECT $1, $2, id FROM tags WHERE id = 'invalid-relay-reward'
+				ON CONFLICT DO NOTHING`, payout.BlockSlot, payout.BlockRoot)
+			if err != nil {
+				tx.Rollback()
+				return verified, fmt.Errorf("error tagging invalid relay reward at slot %v: %w", payout.BlockSlot, err)
+			}
+		}
+		err = tx.Commit()
+		if err != nil {
+			return verified, fmt.Errorf("error committing payout verification of relay %v at slot %v: %w", payout.TagID, payout.BlockSlot, err)
+		}
+		verified++
+	}
+
+	logger.WithFields(logrus.Fields{"verified": verified, "underpaid": underpaid, "failed": failed, "duration": time.Since(start)}).Infof("verified relay block payouts")
+	// failed verifications count as processed so the next batch is verified right away
+	return verified + failed, nil
+}
+
+// verifyRelayBlockPayout returns the payout status of a relay block together with the amount the fee recipient received
+func verifyRelayBlockPayout(payout *relayBlockPayout) (string, *big.Int, error) {
+	claimed, ok := new(big.Int).SetString(payout.Value, 10)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid relay value %v", payout.Value)
+	}
+
+	block, err := db.BigtableClient.GetBlockFromBlocksTable(payout.ExecBlockNumber)
+	if err != nil {
+		return "", nil, fmt.Errorf("error retrieving execution block %v: %w", payout.ExecBlockNumber, err)
+	}
+	if !bytes.Equal(block.GetHash(), payout.ExecBlockHash) {
+		return "", nil, fmt.Errorf("execution block %v has hash %#x, relay reported %#x", payout.ExecBlockNumber, block.GetHash(), payout.ExecBlockHash)
+	}
+
+	paid := db.CalculateProposerPaymentFromBlock(block, payout.ProposerFeeRecipient)
+	switch {
+	case paid.Cmp(claimed) >= 0:
+		return RelayPayoutStatusValid, paid, nil
+	case paid.Sign() == 0:
+		return RelayPayoutStatusUnpaid, paid, nil
+	default:
+		return RelayPayoutStatusUnderpaid, paid, nil
+	}
+}
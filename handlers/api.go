@@ -1731,6 +1731,7 @@ type ApiValidatorResponse struct {
 
 // ApiValidatorDailyStats godoc
 // @Summary Get the daily validator stats by the validator index
+// @Description The current day is returned as an intra-day aggregate (intraday: true) that is updated each finalized epoch until the day is finalized
 // @Tags Validator
 // @Produce  json
 // @Param  index path string true "Validator index"
@@ -1784,6 +1785,7 @@ func ApiValidatorDailyStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// the current day is not exported to validator_stats until it is finalized, its intra-day aggregate is returned instead
 	rows, err := db.ReaderDb.Query(`
 		SELECT 
 		validatorindex,
@@ -1809,8 +1811,39 @@ func ApiValidatorDailyStats(w http.ResponseWriter, r *http.Request) {
 		COALESCE(withdrawals_amount, 0) AS withdrawals_amount,
 		COALESCE(participated_sync, 0) AS participated_sync,
 		COALESCE(missed_sync, 0) AS missed_sync,
-		COALESCE(orphaned_sync, 0) AS orphaned_sync
-	FROM validator_stats WHERE validatorindex = $1 and day <= $2 and day >= $3 ORDER BY day DESC`, index, endDay, startDay)
+		COALESCE(orphaned_sync, 0) AS orphaned_sync,
+		false AS intraday
+	FROM validator_stats WHERE validatorindex = $1 and day <= $2 and day >= $3
+	UNION ALL
+		SELECT 
+		validatorindex,
+		day,
+		start_balance,
+		end_balance,
+		min_balance,
+		max_balance,
+		start_effective_balance,
+		end_effective_balance,
+		min_effective_balance,
+		max_effective_balance,
+		COALESCE(missed_attestations, 0) AS missed_attestations,
+		0 AS orphaned_attestations,
+		COALESCE(proposed_blocks, 0) AS proposed_blocks,
+		COALESCE(missed_blocks, 0) AS missed_blocks,
+		COALESCE(orphaned_blocks, 0) AS orphaned_blocks,
+		COALESCE(attester_slashings, 0) AS attester_slashings,
+		COALESCE(proposer_slashings, 0) AS proposer_slashings,
+		COALESCE(deposits, 0) AS deposits,
+		COALESCE(deposits_amount, 0) AS deposits_amount,
+		COALESCE(withdrawals, 0) AS withdrawals,
+		COALESCE(withdrawals_amount, 0) AS withdrawals_amount,
+		COALESCE(participated_sync, 0) AS participated_sync,
+		COALESCE(missed_sync, 0) AS missed_sync,
+		COALESCE(orphaned_sync, 0) AS orphaned_sync,
+		true AS intraday
+	FROM validator_stats_intraday WHERE validatorindex = $1 and day <= $2 and day >= $3
+		AND NOT EXISTS (SELECT 1 FROM validator_stats_status WHERE validator_stats_status.day = validator_stats_intraday.day AND status)
+	ORDER BY day DESC`, index, endDay, startDay)
 	if err != nil {
 		SendBadRequestResponse(w, r.URL.String(), "could not retrieve db results")
 		return
@@ -3598,10 +3631,18 @@ func ClientStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	execution, err := db.BigtableClient.GetMachineMetricsExecution(claims.UserID, int(limit), int(offset))
+	if err != nil {
+		logger.Errorf("execution stat error : %v", err)
+		SendBadRequestResponse(w, r.URL.String(), "could not retrieve executionnode stats from db")
+		return
+	}
+
 	data := &types.StatsDataStruct{
 		Validator: validator,
 		Node:      node,
 		System:    system,
+		Execution: execution,
 	}
 
 	SendOKResponse(j, r.URL.String(), []interface{}{data})
@@ -3719,7 +3760,7 @@ func insertStats(userData *types.UserWithPremium, machine string, body *map[stri
 		return fmt.Errorf("this version is not supported")
 	}
 
-	if parsedMeta.Process != "validator" && parsedMeta.Process != "beaconnode" && parsedMeta.Process != "slasher" && parsedMeta.Process != "system" {
+	if parsedMeta.Process != "validator" && parsedMeta.Process != "beaconnode" && parsedMeta.Process != "executionnode" && parsedMeta.Process != "slasher" && parsedMeta.Process != "system" {
 		SendBadRequestResponse(w, r.URL.String(), "unknown process")
 		return fmt.Errorf("unknown process")
 	}
@@ -3781,6 +3822,20 @@ func insertStats(userData *types.UserWithPremium, machine string, body *map[stri
 			SendBadRequestResponse(w, r.URL.String(), "could not parse beaconnode")
 			return err
 		}
+	} else if parsedMeta.Process == "executionnode" {
+		var parsedResponse *types.MachineMetricExecution
+		err = DecodeMapStructure(body, &parsedResponse)
+		if err != nil {
+			logger.Warnf("Could not parse stats (executionnode stats) | %v", err)
+			SendBadRequestResponse(w, r.URL.String(), "could not parse executionnode")
+			return err
+		}
+		data, err = proto.Marshal(parsedResponse)
+		if err != nil {
+			logger.Errorf("Could not parse stats (executionnode stats) | %v", err)
+			SendBadRequestResponse(w, r.URL.String(), "could not parse executionnode")
+			return err
+		}
 	}
 
 	err = db.BigtableClient.SaveMachineMetric(parsedMeta.Process, userData.ID, machine, data)
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/exporter"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/gorilla/mux"
+)
+
+var blobStore exporter.BlobStore
+var blobStoreMux sync.Mutex
+
+var versionedHashRE = regexp.MustCompile(`^0x01[0-9a-fA-F]{62}$`)
+
+// getBlobStore returns the blob store the blob indexer writes to, it is created on first use.
+// Only a successfully created store is kept, so a failed creation is retried by the next request.
+func getBlobStore() (exporter.BlobStore, error) {
+	blobStoreMux.Lock()
+	defer blobStoreMux.Unlock()
+	if blobStore != nil {
+		return blobStore, nil
+	}
+	cfg := utils.Config.BlobIndexer
+	if cfg.Store == "" && cfg.S3.Bucket == "" {
+		return nil, fmt.Errorf("no blob store configured")
+	}
+	store, err := exporter.NewBlobStore(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	blobStore = store
+	return blobStore, nil
+}
+
+// writeBlobResponse writes the response body with support for range requests,
+// the raw ssz encoding is returned if the query parameter format=ssz is set
+func writeBlobResponse(w http.ResponseWriter, r *http.Request, name string, jsonData interface{}, sszData []byte) {
+	var body []byte
+	if r.URL.Query().Get("format") == "ssz" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		body = sszData
+		name += ".ssz"
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		var err error
+		body, err = json.Marshal(&types.ApiResponse{Status: "OK", Data: jsonData})
+		if err != nil {
+			logger.WithError(err).Error("error serializing blob response")
+			sendServerErrorResponse(w, r.URL.String(), "could not serialize data results")
+			return
+		}
+		name += ".json"
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(body))
+}
+
+// ApiBlob godoc
+// @Summary Get a blob by its versioned hash
+// @Tags Blobs
+// @Description Returns the blob with the given versioned hash together with its kzg commitment and proof.
+// @Description The kzg proof of the blob is verified before it is returned. Use format=ssz to get the raw ssz encoded blob, range requests are supported.
+// @Produce json
+// @Produce octet-stream
+// @Param versionedHash path string true "Versioned hash of the blob"
+// @Param format query string false "Set to ssz to return the raw blob"
+// @Success 200 {object} types.ApiResponse{data=types.ApiBlobResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Failure 404 {object} types.ApiResponse
+// @Router /api/v1/blobs/{versionedHash} [get]
+func ApiBlob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	versionedHash := strings.ToLower(vars["versionedHash"])
+	if !versionedHashRE.MatchString(versionedHash) {
+		SendBadRequestResponse(w, r.URL.String(), "invalid versioned hash provided")
+		return
+	}
+
+	store, err := getBlobStore()
+	if err != nil {
+		sendErrorWithCodeResponse(w, r.URL.String(), "blobs are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+	blob, metadata, err := store.Get(ctx, exporter.BlobKey(versionedHash))
+	if errors.Is(err, exporter.ErrBlobNotFound) {
+		sendErrorWithCodeResponse(w, r.URL.String(), "blob not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.LogError(err, "error getting blob from blob store", 0, map[string]interface{}{"versionedHash": versionedHash})
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve blob")
+		return
+	}
+
+	err = exporter.VerifyStoredBlob(versionedHash, blob, metadata)
+	if err != nil {
+		utils.LogError(err, "stored blob failed verification", 0, map[string]interface{}{"versionedHash": versionedHash})
+		sendServerErrorResponse(w, r.URL.String(), "blob failed kzg verification")
+		return
+	}
+
+	slot, _ := strconv.ParseUint(exporter.BlobMetadataValue(metadata, "slot"), 10, 64)
+	index, _ := strconv.ParseUint(exporter.BlobMetadataValue(metadata, "index"), 10, 64)
+	data := &types.ApiBlobResponse{
+		VersionedHash:   versionedHash,
+		Slot:            slot,
+		Index:           index,
+		BlockRoot:       exporter.BlobMetadataValue(metadata, "block_root"),
+		BlockParentRoot: exporter.BlobMetadataValue(metadata, "block_parent_root"),
+		ProposerIndex:   exporter.BlobMetadataValue(metadata, "proposer_index"),
+		KzgCommitment:   exporter.BlobMetadataValue(metadata, "kzg_commitment"),
+		KzgProof:        exporter.BlobMetadataValue(metadata, "kzg_proof"),
+		Blob:            fmt.Sprintf("%#x", blob),
+	}
+	writeBlobResponse(w, r, versionedHash, data, blob)
+}
+
+// ApiSlotBlobs godoc
+// @Summary Get the blobs of a specific slot
+// @Tags Slot
+// @Description Returns the blobs of the canonical block at the given slot ordered by their index.
+// @Description The kzg proof of every blob is verified before it is returned. Use format=ssz to get the ssz encoded list of blobs, range requests are supported.
+// @Produce json
+// @Produce octet-stream
+// @Param slot path string true "Block slot"
+// @Param format query string false "Set to ssz to return the raw blobs"
+// @Success 200 {object} types.ApiResponse{data=[]types.ApiBlobResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/slot/{slot}/blobs [get]
+func ApiSlotBlobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+
+	slot, err := strconv.ParseUint(vars["slot"], 10, 64)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), "invalid block slot provided")
+		return
+	}
+
+	var sidecars []struct {
+		BlockSlot         uint64 `db:"block_slot"`
+		BlockRoot         []byte `db:"block_root"`
+		Index             uint64 `db:"index"`
+		KzgCommitment     []byte `db:"kzg_commitment"`
+		KzgProof          []byte `db:"kzg_proof"`
+		BlobVersionedHash []byte `db:"blob_versioned_hash"`
+	}
+	err = db.ReaderDb.Select(&sidecars, `
+		SELECT bbs.block_slot, bbs.block_root, bbs.index, bbs.kzg_commitment, bbs.kzg_proof, bbs.blob_versioned_hash
+		FROM blocks_blob_sidecars bbs
+		INNER JOIN blocks b ON b.blockroot = bbs.block_root AND b.status = '1'
+		WHERE bbs.block_slot = $1
+		ORDER BY bbs.index`, slot)
+	if err != nil {
+		logger.WithError(err).Error("error getting blocks_blob_sidecars")
+		SendBadRequestResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]*types.ApiBlobResponse, 0, len(sidecars))
+	ssz := make([]byte, 0, len(sidecars)*131072)
+	if len(sidecars) > 0 {
+		store, err := getBlobStore()
+		if err != nil {
+			sendErrorWithCodeResponse(w, r.URL.String(), "blobs are not available", http.StatusServiceUnavailable)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), time.Second*20)
+		defer cancel()
+		for _, s := range sidecars {
+			versionedHash := fmt.Sprintf("%#x", s.BlobVersionedHash)
+			blob, _, err := store.Get(ctx, exporter.BlobKey(versionedHash))
+			if errors.Is(err, exporter.ErrBlobNotFound) {
+				sendErrorWithCodeResponse(w, r.URL.String(), fmt.Sprintf("blob %v not found", versionedHash), http.StatusNotFound)
+				return
+			}
+			if err != nil {
+				utils.LogError(err, "error getting blob from blob store", 0, map[string]interface{}{"versionedHash": versionedHash, "slot": slot})
+				sendServerErrorResponse(w, r.URL.String(), "could not retrieve blob")
+				return
+			}
+
+			// verify against the commitment and proof of the block instead of the metadata of the stored object
+			err = exporter.VerifyBlob(versionedHash, blob, s.KzgCommitment, s.KzgProof)
+			if err != nil {
+				utils.LogError(err, "stored blob failed verification", 0, map[string]interface{}{"versionedHash": versionedHash, "slot": slot})
+				sendServerErrorResponse(w, r.URL.String(), "blob failed kzg verification")
+				return
+			}
+
+			data = append(data, &types.ApiBlobResponse{
+				VersionedHash: versionedHash,
+				Slot:          s.BlockSlot,
+				Index:         s.Index,
+				BlockRoot:     fmt.Sprintf("%#x", s.BlockRoot),
+				KzgCommitment: fmt.Sprintf("%#x", s.KzgCommitment),
+				KzgProof:      fmt.Sprintf("%#x", s.KzgProof),
+				Blob:          fmt.Sprintf("%#x", blob),
+			})
+			ssz = append(ssz, blob...)
+		}
+	}
+
+	writeBlobResponse(w, r, fmt.Sprintf("blobs_%d", slot), data, ssz)
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+)
+
+// ApiChartSeries godoc
+// @Summary Get the values of a chart series indicator
+// @Tags Charts
+// @Description Returns the daily values of a chart series indicator aggregated to the given resolution.
+// @Description If no aggregation is given the default aggregation of the indicator is used (see /api/v1/charts/indicators).
+// @Produce json
+// @Param indicator query string true "Name of the indicator"
+// @Param from query string false "Start of the range as unix timestamp or YYYY-MM-DD (default: genesis)"
+// @Param to query string false "End of the range as unix timestamp or YYYY-MM-DD (default: now)"
+// @Param resolution query string false "Resolution of the series: day, week or month (default: day)"
+// @Param aggregation query string false "Aggregation function: sum, avg, min, max, first or last"
+// @Success 200 {object} types.ApiResponse{data=types.ApiChartSeriesResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/charts/series [get]
+func ApiChartSeries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	indicator, ok := db.GetChartSeriesIndicator(q.Get("indicator"))
+	if !ok {
+		SendBadRequestResponse(w, r.URL.String(), "invalid indicator parameter")
+		return
+	}
+
+	from := time.Unix(int64(utils.Config.Chain.GenesisTimestamp), 0)
+	if q.Get("from") != "" {
+		t, err := parseChartSeriesTime(q.Get("from"))
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid from parameter")
+			return
+		}
+		from = t
+	}
+	to := time.Now()
+	if q.Get("to") != "" {
+		t, err := parseChartSeriesTime(q.Get("to"))
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid to parameter")
+			return
+		}
+		to = t
+	}
+	if from.After(to) {
+		SendBadRequestResponse(w, r.URL.String(), "from must be before to")
+		return
+	}
+
+	resolution := q.Get("resolution")
+	if resolution == "" {
+		resolution = "day"
+	}
+	if !utils.SliceContains(db.ChartSeriesResolutions, resolution) {
+		SendBadRequestResponse(w, r.URL.String(), "invalid resolution parameter")
+		return
+	}
+
+	aggregation := q.Get("aggregation")
+	if aggregation == "" {
+		aggregation = indicator.Aggregation
+	}
+	if !utils.SliceContains(db.ChartSeriesAggregations(), aggregation) {
+		SendBadRequestResponse(w, r.URL.String(), "invalid aggregation parameter")
+		return
+	}
+
+	data, err := db.GetChartSeries(indicator, from.UTC(), to.UTC(), resolution, aggregation)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving chart series %v", indicator.Name)
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{types.ApiChartSeriesResponse{
+		Indicator:   indicator.Name,
+		Resolution:  resolution,
+		Aggregation: aggregation,
+		Data:        data,
+	}})
+}
+
+// ApiChartSeriesIndicators godoc
+// @Summary Get all chart series indicators
+// @Tags Charts
+// @Description Returns the built-in indicators and the indicators defined in the explorer config together with their default aggregation.
+// @Produce json
+// @Success 200 {object} types.ApiResponse{data=[]types.ChartSeriesIndicator}
+// @Router /api/v1/charts/indicators [get]
+func ApiChartSeriesIndicators(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{db.ChartSeriesIndicators()})
+}
+
+// parseChartSeriesTime parses a unix timestamp or a YYYY-MM-DD date
+func parseChartSeriesTime(value string) (time.Time, error) {
+	if ts, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(ts, 0), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %v: %w", value, err)
+	}
+	return t, nil
+}
@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/golang/snappy"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
+)
+
+// maximum size of an uncompressed remote-write or otlp request body
+const clientMetricsMaxBodySize = 16 * 1024 * 1024
+
+// ClientStatsPostPrometheus godoc
+// @Summary Push machine metrics via prometheus remote-write
+// @Tags Misc
+// @Description Accepts a snappy compressed prometheus remote-write request and stores the contained node exporter, beacon node, validator client and execution client metrics as machine metrics.
+// @Description The api key is read from the apikey query parameter, the apikey header or a bearer token. The machine name is read from the machine label, the machine query parameter or the host of the instance label.
+// @Description Series are assigned to a process by their process label (system, beaconnode, validator or executionnode), the node_ metric prefix or a job label containing beacon, validator, execution or the name of an execution client.
+// @Description At most one metric per machine and process is stored per minute, configure remote_write with a single shard and a batch send deadline of one minute.
+// @Accept application/x-protobuf
+// @Param apikey query string false "Api key"
+// @Param machine query string false "Machine name"
+// @Success 204
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/client/metrics/prometheus [post]
+func ClientStatsPostPrometheus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userData, ok := clientMetricsUser(w, r)
+	if !ok {
+		return
+	}
+
+	compressed, err := io.ReadAll(io.LimitReader(r.Body, clientMetricsMaxBodySize))
+	if err != nil {
+		logger.Warnf("error reading body | err: %v", err)
+		SendBadRequestResponse(w, r.URL.String(), "could not read body")
+		return
+	}
+	size, err := snappy.DecodedLen(compressed)
+	if err != nil || size > clientMetricsMaxBodySize {
+		SendBadRequestResponse(w, r.URL.String(), "invalid snappy compressed body")
+		return
+	}
+	body, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), "invalid snappy compressed body")
+		return
+	}
+
+	samples, err := utils.DecodeRemoteWriteRequest(body)
+	if err != nil {
+		logger.Warnf("could not decode remote-write request | %v", err)
+		SendBadRequestResponse(w, r.URL.String(), "could not decode remote-write request")
+		return
+	}
+
+	metrics := utils.MachineMetricsFromSamples(samples, r.URL.Query().Get("machine"), "prometheus-remote-write")
+	if !saveClientMetrics(w, r, userData, metrics) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ClientStatsPostOTLP godoc
+// @Summary Push machine metrics via OTLP/HTTP
+// @Tags Misc
+// @Description Accepts a protobuf encoded (optionally gzip compressed) OTLP metrics export request and stores the contained node exporter, beacon node, validator client and execution client metrics as machine metrics.
+// @Description Metric and attribute names are converted to prometheus names, the service.name and service.instance.id resource attributes are used as job and instance labels.
+// @Description Api key, machine and process are determined like for the prometheus remote-write endpoint, only gauges and cumulative sums are used.
+// @Accept application/x-protobuf
+// @Param apikey query string false "Api key"
+// @Param machine query string false "Machine name"
+// @Success 200
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/client/metrics/otlp [post]
+func ClientStatsPostOTLP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-protobuf") {
+		sendErrorWithCodeResponse(w, r.URL.String(), "only protobuf encoded otlp requests are supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	userData, ok := clientMetricsUser(w, r)
+	if !ok {
+		return
+	}
+
+	reader := io.Reader(r.Body)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid gzip compressed body")
+			return
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	body, err := io.ReadAll(io.LimitReader(reader, clientMetricsMaxBodySize))
+	if err != nil {
+		logger.Warnf("error reading body | err: %v", err)
+		SendBadRequestResponse(w, r.URL.String(), "could not read body")
+		return
+	}
+
+	samples, err := utils.DecodeOTLPMetricsRequest(body)
+	if err != nil {
+		logger.Warnf("could not decode otlp request | %v", err)
+		SendBadRequestResponse(w, r.URL.String(), "could not decode otlp request")
+		return
+	}
+
+	metrics := utils.MachineMetricsFromSamples(samples, r.URL.Query().Get("machine"), "otlp")
+	if !saveClientMetrics(w, r, userData, metrics) {
+		return
+	}
+	// an empty body is a valid ExportMetricsServiceResponse
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+// clientMetricsUser returns the user of the api key passed as query parameter, header or bearer token
+func clientMetricsUser(w http.ResponseWriter, r *http.Request) (*types.UserWithPremium, bool) {
+	if utils.Config.Frontend.DisableStatsInserts {
+		SendBadRequestResponse(w, r.URL.String(), "service temporarily unavailable")
+		return nil, false
+	}
+
+	apiKey := r.URL.Query().Get("apikey")
+	if apiKey == "" {
+		apiKey = r.Header.Get("apikey")
+	}
+	if apiKey == "" {
+		apiKey = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	userData, err := db.GetUserIdByApiKey(apiKey)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), "no user found with api key")
+		return nil, false
+	}
+	return userData, true
+}
+
+// saveClientMetrics merges the machine metrics of a user into the stored metrics of the current minute
+func saveClientMetrics(w http.ResponseWriter, r *http.Request, userData *types.UserWithPremium, metrics []*utils.MachineMetric) bool {
+	if len(metrics) == 0 {
+		return true
+	}
+
+	maxNodes := GetUserPremiumByPackage(userData.Product.String).MaxNodes
+	count, err := db.BigtableClient.GetMachineMetricsMachineCount(userData.ID)
+	if err != nil {
+		logger.Errorf("Could not get max machine count| %v", err)
+		SendBadRequestResponse(w, r.URL.String(), "could not get machine count")
+		return false
+	}
+	if count > maxNodes {
+		sendErrorWithCodeResponse(w, r.URL.String(), "reached max machine count", 402)
+		return false
+	}
+
+	for _, metric := range metrics {
+		if metric.Machine == "" {
+			SendBadRequestResponse(w, r.URL.String(), "missing machine name")
+			return false
+		}
+		// remote-write and otlp exporters split a scrape into several requests, the pushes of a minute are merged into a single metric
+		err = db.BigtableClient.MergeMachineMetric(metric.Process, userData.ID, metric.Machine, metric.Metric)
+		if err != nil {
+			logger.Errorf("Could not store stats | %v", err)
+			SendBadRequestResponse(w, r.URL.String(), fmt.Sprintf("could not store stats: %v", err))
+			return false
+		}
+	}
+	return true
+}
+
+// ClientStatsQuery godoc
+// @Summary Get the machine metrics of a time range
+// @Tags User
+// @Description Returns the system, beacon node, validator and execution node metrics of the authenticated user within a time range. Long time ranges are served from 5m and 1h rollups,
+// @Description by default the finest resolution with at most 1500 data points per machine is used. Counters keep their latest value within a rollup bucket, gauges are averaged.
+// @Produce json
+// @Param from query int false "Start of the time range as unix timestamp (default: 3 hours before to)"
+// @Param to query int false "End of the time range as unix timestamp (default: now)"
+// @Param resolution query string false "Resolution of the metrics: auto, 1m, 5m or 1h (default: auto)"
+// @Param process query string false "Only return the metrics of a process: system, beaconnode, validator or executionnode"
+// @Param machine query string false "Only return the metrics of a machine"
+// @Success 200 {object} types.ApiResponse{data=types.MachineMetricsQueryResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/stats/query [get]
+func ClientStatsQuery(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := queryClientStats(r, time.Hour*3)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{data})
+}
+
+// ClientStatsExport godoc
+// @Summary Export the machine metrics history of a machine
+// @Tags User
+// @Description Exports the metrics of a machine within a time range as csv (one process per file) or json. Resolution and time range are handled like for the stats query.
+// @Produce json
+// @Produce text/csv
+// @Param machine query string true "Machine name"
+// @Param format query string false "Export format: csv or json (default: csv)"
+// @Param process query string false "Process to export, required for csv: system, beaconnode, validator or executionnode (default: system)"
+// @Param from query int false "Start of the time range as unix timestamp (default: 30 days before to)"
+// @Param to query int false "End of the time range as unix timestamp (default: now)"
+// @Param resolution query string false "Resolution of the metrics: auto, 1m, 5m or 1h (default: auto)"
+// @Success 200 {object} types.MachineMetricsQueryResponse
+// @Failure 400 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/stats/export [get]
+func ClientStatsExport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	machine := q.Get("machine")
+	if machine == "" {
+		SendBadRequestResponse(w, r.URL.String(), "missing machine parameter")
+		return
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		SendBadRequestResponse(w, r.URL.String(), "invalid format, must be csv or json")
+		return
+	}
+	if format == "csv" && q.Get("process") == "" {
+		q.Set("process", "system")
+		r.URL.RawQuery = q.Encode()
+	}
+
+	data, err := queryClientStats(r, utils.Day*30)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("machine-metrics-%s-%s-%d-%d", machine, data.Resolution, data.From, data.To)
+	if format == "json" {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".json"))
+		err = json.NewEncoder(w).Encode(data)
+		if err != nil {
+			logger.WithError(err).Errorf("error encoding machine metrics export")
+		}
+		return
+	}
+
+	metrics := []proto.Message{}
+	for _, m := range data.System {
+		metrics = append(metrics, m)
+	}
+	for _, m := range data.Node {
+		metrics = append(metrics, m)
+	}
+	for _, m := range data.Validator {
+		metrics = append(metrics, m)
+	}
+	for _, m := range data.Execution {
+		metrics = append(metrics, m)
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s-%s.csv", filename, q.Get("process"))))
+	err = writeMachineMetricsCsv(w, utils.NewMachineMetric(q.Get("process")), metrics)
+	if err != nil {
+		logger.WithError(err).Errorf("error writing machine metrics csv export")
+	}
+}
+
+// maximum number of custom machine metric alerts per user
+const clientStatsMaxAlerts = 50
+
+// ClientStatsAlerts godoc
+// @Summary Get the custom machine metric alerts
+// @Tags User
+// @Description Returns the custom machine metric alerts of the authenticated user.
+// @Produce json
+// @Success 200 {object} types.ApiResponse{data=[]types.MachineMetricAlert}
+// @Failure 400 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/stats/alerts [get]
+func ClientStatsAlerts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := getAuthClaims(r)
+
+	alerts, err := db.GetMachineMetricAlerts(claims.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving machine metric alerts: %v", err)
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve alerts")
+		return
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{alerts})
+}
+
+// ClientStatsAlertAdd godoc
+// @Summary Add a custom machine metric alert
+// @Tags User
+// @Description Adds an alert on a numeric field of the system, beaconnode, validator or executionnode metrics, e.g. network_peers_connected, disk_node_reads_total or network_node_bytes_total_transmit.
+// @Description The virtual beaconnode and executionnode field sync_distance contains the number of slots or blocks the head of the node is behind. The rate mode compares the per second increase of counters.
+// @Description The alert triggers once the condition held for duration_minutes and is sent through the monitoring_custom_alert notification, an empty machine applies the alert to all machines.
+// @Accept json
+// @Produce json
+// @Param alert body types.MachineMetricAlert true "Alert with process, field, mode (value or rate), comparator (gt, gte, lt, lte, eq or neq), threshold, duration_minutes and an optional machine"
+// @Success 200 {object} types.ApiResponse{data=types.MachineMetricAlert}
+// @Failure 400 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/stats/alerts [post]
+func ClientStatsAlertAdd(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := getAuthClaims(r)
+
+	if !getUserPremium(r).NotificationThresholds {
+		sendErrorWithCodeResponse(w, r.URL.String(), "custom alerts require a premium subscription", 402)
+		return
+	}
+
+	alert := &types.MachineMetricAlert{}
+	err := json.NewDecoder(r.Body).Decode(alert)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), "could not read body")
+		return
+	}
+	alert.UserID = claims.UserID
+	if alert.Mode == "" {
+		alert.Mode = "value"
+	}
+	err = utils.ValidateMachineMetricAlert(alert)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	count, err := db.CountMachineMetricAlerts(claims.UserID)
+	if err != nil {
+		logger.Errorf("error counting machine metric alerts: %v", err)
+		sendServerErrorResponse(w, r.URL.String(), "could not add alert")
+		return
+	}
+	if count >= clientStatsMaxAlerts {
+		SendBadRequestResponse(w, r.URL.String(), fmt.Sprintf("reached the maximum of %v alerts", clientStatsMaxAlerts))
+		return
+	}
+
+	err = db.AddMachineMetricAlert(alert)
+	if err != nil {
+		logger.Errorf("error adding machine metric alert: %v", err)
+		sendServerErrorResponse(w, r.URL.String(), "could not add alert")
+		return
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{alert})
+}
+
+// ClientStatsAlertDelete godoc
+// @Summary Delete a custom machine metric alert
+// @Tags User
+// @Produce json
+// @Param id path int true "Alert id"
+// @Success 200 {object} types.ApiResponse
+// @Failure 400 {object} types.ApiResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/user/stats/alerts/{id} [delete]
+func ClientStatsAlertDelete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	claims := getAuthClaims(r)
+
+	alertID, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), "invalid alert id")
+		return
+	}
+
+	deleted, err := db.DeleteMachineMetricAlert(claims.UserID, alertID)
+	if err != nil {
+		logger.Errorf("error deleting machine metric alert: %v", err)
+		sendServerErrorResponse(w, r.URL.String(), "could not delete alert")
+		return
+	}
+	if !deleted {
+		SendBadRequestResponse(w, r.URL.String(), "alert not found")
+		return
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), nil)
+}
+
+// queryClientStats returns the machine metrics of the authenticated user for the time range, resolution, process and machine given by the query parameters
+func queryClientStats(r *http.Request, defaultRange time.Duration) (*types.MachineMetricsQueryResponse, error) {
+	q := r.URL.Query()
+	claims := getAuthClaims(r)
+	premium := getUserPremium(r)
+
+	to := time.Now()
+	if q.Get("to") != "" {
+		ts, err := strconv.ParseInt(q.Get("to"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid to parameter")
+		}
+		to = time.Unix(ts, 0)
+	}
+	from := to.Add(-defaultRange)
+	if q.Get("from") != "" {
+		ts, err := strconv.ParseInt(q.Get("from"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid from parameter")
+		}
+		from = time.Unix(ts, 0)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("from must be before to")
+	}
+	// users without a subscription can only access the most recent stats, subscribers the full retention of each resolution
+	if premium.Package == "standard" {
+		oldest := time.Now().Add(-time.Minute * time.Duration(premium.MaxStats))
+		if from.Before(oldest) {
+			from = oldest
+		}
+		if !from.Before(to) {
+			return nil, fmt.Errorf("the time range is outside of the accessible history of %v minutes", premium.MaxStats)
+		}
+	}
+
+	resolution, err := db.GetMachineMetricsResolution(q.Get("resolution"), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	process := q.Get("process")
+	if process != "" && utils.NewMachineMetric(process) == nil {
+		return nil, fmt.Errorf("invalid process %v", process)
+	}
+	machine := q.Get("machine")
+
+	data := &types.MachineMetricsQueryResponse{
+		Resolution: resolution.Name,
+		From:       from.Unix(),
+		To:         to.Unix(),
+		System:     []*types.MachineMetricSystem{},
+		Node:       []*types.MachineMetricNode{},
+		Validator:  []*types.MachineMetricValidator{},
+		Execution:  []*types.MachineMetricExecution{},
+	}
+	if process == "" || process == "system" {
+		data.System, err = db.BigtableClient.GetMachineMetricsSystemRange(claims.UserID, machine, resolution, from, to)
+		if err != nil {
+			logger.Errorf("system stat query error: %v", err)
+			return nil, fmt.Errorf("could not retrieve system stats from db")
+		}
+	}
+	if process == "" || process == "beaconnode" {
+		data.Node, err = db.BigtableClient.GetMachineMetricsNodeRange(claims.UserID, machine, resolution, from, to)
+		if err != nil {
+			logger.Errorf("node stat query error: %v", err)
+			return nil, fmt.Errorf("could not retrieve beaconnode stats from db")
+		}
+	}
+	if process == "" || process == "validator" {
+		data.Validator, err = db.BigtableClient.GetMachineMetricsValidatorRange(claims.UserID, machine, resolution, from, to)
+		if err != nil {
+			logger.Errorf("validator stat query error: %v", err)
+			return nil, fmt.Errorf("could not retrieve validator stats from db")
+		}
+	}
+	if process == "" || process == "executionnode" {
+		data.Execution, err = db.BigtableClient.GetMachineMetricsExecutionRange(claims.UserID, machine, resolution, from, to)
+		if err != nil {
+			logger.Errorf("execution stat query error: %v", err)
+			return nil, fmt.Errorf("could not retrieve executionnode stats from db")
+		}
+	}
+	return data, nil
+}
+
+// writeMachineMetricsCsv writes the metrics as csv with one column per protobuf field of the template message
+func writeMachineMetricsCsv(w io.Writer, template proto.Message, metrics []proto.Message) error {
+	fields := template.ProtoReflect().Descriptor().Fields()
+	header := make([]string, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		header = append(header, string(fields.Get(i).Name()))
+	}
+
+	writer := csv.NewWriter(w)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, metric := range metrics {
+		m := metric.ProtoReflect()
+		record := make([]string, 0, fields.Len())
+		for i := 0; i < fields.Len(); i++ {
+			record = append(record, m.Get(fields.Get(i)).String())
+		}
+		err = writer.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
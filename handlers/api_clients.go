@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/services"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/gorilla/mux"
+)
+
+// ApiClientDiversity godoc
+// @Summary Get the consensus client diversity estimated from the proposed blocks
+// @Tags Clients
+// @Description Returns the number of proposed blocks attributed to each consensus client per epoch or day.
+// @Description Blocks are classified by their graffiti, the previous blocks of their proposer and their attestation packing; blocks that could not be classified are reported as "unknown".
+// @Produce json
+// @Param resolution query string false "Resolution: epoch or day (default: day)"
+// @Param from query int false "First epoch or day (default: last 30 days or last 100 epochs)"
+// @Param to query int false "Last epoch or day (default: latest)"
+// @Success 200 {object} types.ApiResponse{data=[]types.ClientDiversityEntry}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/clients/diversity [get]
+func ApiClientDiversity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	resolution := q.Get("resolution")
+	if resolution == "" {
+		resolution = "day"
+	}
+
+	var latest, defaultRange uint64
+	switch resolution {
+	case "epoch":
+		latest = services.LatestFinalizedEpoch()
+		defaultRange = 100
+	case "day":
+		latest = services.LatestFinalizedEpoch() / utils.EpochsPerDay()
+		defaultRange = 30
+	default:
+		SendBadRequestResponse(w, r.URL.String(), "invalid resolution parameter")
+		return
+	}
+
+	to := latest
+	if q.Get("to") != "" {
+		v, err := strconv.ParseUint(q.Get("to"), 10, 64)
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid to parameter")
+			return
+		}
+		to = v
+	}
+	from := uint64(0)
+	if to >= defaultRange {
+		from = to - defaultRange + 1
+	}
+	if q.Get("from") != "" {
+		v, err := strconv.ParseUint(q.Get("from"), 10, 64)
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid from parameter")
+			return
+		}
+		from = v
+	}
+	if from > to {
+		SendBadRequestResponse(w, r.URL.String(), "from must be before to")
+		return
+	}
+
+	entries, err := db.GetClientDiversity(resolution, from, to)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving client diversity")
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, entry)
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), data)
+}
+
+// ApiValidatorClient godoc
+// @Summary Get the consensus clients the proposed blocks of validators were attributed to
+// @Tags Validator
+// @Description Returns for each validator the consensus clients its proposed blocks were attributed to, together with the number of blocks and the share of the validator's blocks.
+// @Produce json
+// @Param  indexOrPubkey path string true "Up to 100 validator indicesOrPubkeys, comma separated"
+// @Success 200 {object} types.ApiResponse{data=[]types.ValidatorClientEntry}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/validator/{indexOrPubkey}/client [get]
+func ApiValidatorClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	maxValidators := getUserPremium(r).MaxValidators
+
+	queryIndices, err := parseApiValidatorParamToIndices(vars["indexOrPubkey"], maxValidators)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	entries, err := db.GetValidatorClients(queryIndices)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving validator clients")
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, entry)
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), data)
+}
@@ -277,6 +277,27 @@ func ApiEth1GasNowData(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ApiEth1GasOracle godoc
+// @Summary Gets EIP-1559 fee recommendations in wei.
+// @Tags Execution
+// @Description The recommendations are derived from the effective priority fees paid in the recent blocks and the projected base fee of the next blocks. They are split into the same inclusion speeds as the gasnow endpoint rapid (1 block), fast (5 blocks), standard (15 blocks) and slow (50 blocks), each with the share of recent blocks in which a transaction paying the recommended priority fee would have been included in time as confidence.
+// @Produce json
+// @Success 200 {object} types.ApiResponse{data=types.GasOracleData}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/gasoracle [get]
+func ApiEth1GasOracle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	oracleData := services.LatestGasOracleData()
+	if oracleData == nil {
+		logger.Errorf("error gas oracle data is not defined. The frontend updater might not be running.")
+		SendBadRequestResponse(w, r.URL.String(), "error gas oracle data is currently not available.")
+		return
+	}
+
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{oracleData})
+}
+
 // ApiEth1Address godoc
 // @Summary Gets information about an Ethereum address.
 // @Tags Execution
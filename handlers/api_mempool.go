@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const mempoolApiMaxLimit = 1000
+
+// ApiEth1Mempool godoc
+// @Summary Gets the transactions tracked in the mempool
+// @Tags Execution
+// @Description Returns pending, included, replaced and dropped transactions seen in the mempool, most recently seen first. Included transactions contain the time to inclusion in seconds.
+// @Produce json
+// @Param from query string false "sender address"
+// @Param to query string false "receiver address"
+// @Param method query string false "method selector (e.g. 0xa9059cbb) or method signature (e.g. transfer(address,uint256))"
+// @Param min_fee query string false "minimum gas price or max fee per gas in wei"
+// @Param status query string false "pending, included, replaced or dropped"
+// @Param offset query int false "data offset" default(0)
+// @Param limit query int false "data limit (ranging from 1 to 1000)" default(100)
+// @Success 200 {object} types.ApiResponse{data=[]types.MempoolTransactionApiResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/mempool [get]
+func ApiEth1Mempool(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter, err := parseMempoolTransactionsFilter(r)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	txs, err := db.GetMempoolTransactions(filter)
+	if err != nil {
+		utils.LogError(err, "error retrieving mempool transactions", 0, map[string]interface{}{"route": r.URL.String()})
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]*types.MempoolTransactionApiResponse, 0, len(txs))
+	for _, tx := range txs {
+		data = append(data, formatMempoolTransactionForApiResponse(tx))
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{data})
+}
+
+// ApiEth1MempoolStats godoc
+// @Summary Gets statistics of the transactions tracked in the mempool
+// @Tags Execution
+// @Description Returns the number of pending transactions and the number of included, replaced and dropped transactions as well as the median and 90th percentile time to inclusion in seconds of the transactions first seen within the window.
+// @Produce json
+// @Param window query int false "window in minutes (ranging from 1 to 1440)" default(60)
+// @Success 200 {object} types.ApiResponse{data=types.MempoolStats}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/execution/mempool/stats [get]
+func ApiEth1MempoolStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	window := uint64(60)
+	if windowQuery := r.URL.Query().Get("window"); windowQuery != "" {
+		var err error
+		window, err = strconv.ParseUint(windowQuery, 10, 64)
+		if err != nil || window < 1 || window > 1440 {
+			SendBadRequestResponse(w, r.URL.String(), "invalid window, must be between 1 and 1440 minutes")
+			return
+		}
+	}
+
+	stats, err := db.GetMempoolStats(time.Minute * time.Duration(window))
+	if err != nil {
+		utils.LogError(err, "error retrieving mempool stats", 0, map[string]interface{}{"route": r.URL.String()})
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{stats})
+}
+
+func parseMempoolTransactionsFilter(r *http.Request) (*types.MempoolTransactionsFilter, error) {
+	q := r.URL.Query()
+	filter := &types.MempoolTransactionsFilter{Limit: 100}
+
+	for param, target := range map[string]*[]byte{"from": &filter.From, "to": &filter.To} {
+		address := strings.ToLower(strings.TrimPrefix(q.Get(param), "0x"))
+		if address == "" {
+			continue
+		}
+		if !utils.IsEth1Address(address) {
+			return nil, fmt.Errorf("invalid %v address", param)
+		}
+		*target = common.FromHex(address)
+	}
+
+	if method := q.Get("method"); method != "" {
+		if strings.Contains(method, "(") {
+			filter.Method = crypto.Keccak256([]byte(strings.ReplaceAll(method, " ", "")))[:4]
+		} else {
+			selector := common.FromHex(method)
+			if len(selector) != 4 {
+				return nil, fmt.Errorf("invalid method, must be a 4 byte selector or a method signature")
+			}
+			filter.Method = selector
+		}
+	}
+
+	if minFee := q.Get("min_fee"); minFee != "" {
+		fee, ok := new(big.Int).SetString(minFee, 10)
+		if !ok || fee.Sign() < 0 {
+			return nil, fmt.Errorf("invalid min_fee, must be an amount in wei")
+		}
+		filter.MinFee = fee
+	}
+
+	switch status := q.Get("status"); status {
+	case "", types.MempoolTxStatusPending, types.MempoolTxStatusIncluded, types.MempoolTxStatusReplaced, types.MempoolTxStatusDropped:
+		filter.Status = status
+	default:
+		return nil, fmt.Errorf("invalid status, must be pending, included, replaced or dropped")
+	}
+
+	if offset, err := strconv.ParseUint(q.Get("offset"), 10, 64); err == nil {
+		filter.Offset = offset
+	}
+	if limit, err := strconv.ParseUint(q.Get("limit"), 10, 64); err == nil && limit > 0 && limit <= mempoolApiMaxLimit {
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+func formatMempoolTransactionForApiResponse(tx *types.MempoolTransaction) *types.MempoolTransactionApiResponse {
+	res := &types.MempoolTransactionApiResponse{
+		Hash:      fmt.Sprintf("%#x", tx.Hash),
+		From:      common.BytesToAddress(tx.From).Hex(),
+		Nonce:     tx.Nonce,
+		Value:     tx.Value.String(),
+		Gas:       tx.Gas,
+		GasPrice:  tx.GasPrice.String(),
+		FirstSeen: tx.FirstSeenTs.Unix(),
+		Status:    tx.Status,
+	}
+	if len(tx.To) > 0 {
+		res.To = common.BytesToAddress(tx.To).Hex()
+	}
+	if len(tx.Method) > 0 {
+		res.Method = fmt.Sprintf("%#x", tx.Method)
+	}
+	if tx.MaxPriorityFeePerGas.Valid {
+		res.MaxPriorityFeePerGas = tx.MaxPriorityFeePerGas.Decimal.String()
+	}
+	if tx.BlockNumber.Valid {
+		res.BlockNumber = &tx.BlockNumber.Int64
+	}
+	if tx.IncludedTs.Valid {
+		includedAt := tx.IncludedTs.Time.Unix()
+		res.IncludedAt = &includedAt
+		// the inclusion time is the block timestamp which can be slightly before the transaction was first seen
+		timeToInclusion := tx.IncludedTs.Time.Sub(tx.FirstSeenTs).Seconds()
+		if timeToInclusion < 0 {
+			timeToInclusion = 0
+		}
+		res.TimeToInclusion = &timeToInclusion
+	}
+	if tx.RemovedTs.Valid {
+		removedAt := tx.RemovedTs.Time.Unix()
+		res.RemovedAt = &removedAt
+	}
+	if len(tx.ReplacedBy) > 0 {
+		res.ReplacedBy = fmt.Sprintf("%#x", tx.ReplacedBy)
+	}
+	return res
+}
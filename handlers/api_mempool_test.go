@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseMempoolTransactionsFilter(t *testing.T) {
+	address := common.FromHex("0x388c818ca8b9251b393131c08a736a67ccb19297")
+	transfer := common.FromHex("0xa9059cbb")
+
+	tests := []struct {
+		query   string
+		want    types.MempoolTransactionsFilter
+		wantErr bool
+	}{
+		{"", types.MempoolTransactionsFilter{Limit: 100}, false},
+		{"from=0x388C818CA8B9251b393131C08a736A67ccB19297", types.MempoolTransactionsFilter{From: address, Limit: 100}, false},
+		{"to=388c818ca8b9251b393131c08a736a67ccb19297", types.MempoolTransactionsFilter{To: address, Limit: 100}, false},
+		{"from=0x1234", types.MempoolTransactionsFilter{}, true},
+		{"to=not-an-address", types.MempoolTransactionsFilter{}, true},
+		{"method=0xa9059cbb", types.MempoolTransactionsFilter{Method: transfer, Limit: 100}, false},
+		{"method=transfer(address,%20uint256)", types.MempoolTransactionsFilter{Method: transfer, Limit: 100}, false},
+		{"method=0xa9059c", types.MempoolTransactionsFilter{}, true},
+		{"min_fee=1000000000", types.MempoolTransactionsFilter{MinFee: big.NewInt(1e9), Limit: 100}, false},
+		{"min_fee=-1", types.MempoolTransactionsFilter{}, true},
+		{"min_fee=1.5", types.MempoolTransactionsFilter{}, true},
+		{"status=replaced", types.MempoolTransactionsFilter{Status: types.MempoolTxStatusReplaced, Limit: 100}, false},
+		{"status=unknown", types.MempoolTransactionsFilter{}, true},
+		{"offset=20&limit=50", types.MempoolTransactionsFilter{Offset: 20, Limit: 50}, false},
+		{"limit=0", types.MempoolTransactionsFilter{Limit: 100}, false},
+		{"limit=1001", types.MempoolTransactionsFilter{Limit: 100}, false},
+		{"offset=abc&limit=abc", types.MempoolTransactionsFilter{Limit: 100}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMempoolTransactionsFilter(httptest.NewRequest("GET", "/api/v1/execution/mempool?"+tt.query, nil))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMempoolTransactionsFilter(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		minFeeMatches := (got.MinFee == nil) == (tt.want.MinFee == nil) && (got.MinFee == nil || got.MinFee.Cmp(tt.want.MinFee) == 0)
+		if !bytes.Equal(got.From, tt.want.From) || !bytes.Equal(got.To, tt.want.To) || !bytes.Equal(got.Method, tt.want.Method) || !minFeeMatches ||
+			got.Status != tt.want.Status || got.Offset != tt.want.Offset || got.Limit != tt.want.Limit {
+			t.Errorf("parseMempoolTransactionsFilter(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
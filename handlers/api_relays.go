@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/services"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+)
+
+const (
+	apiRelayBidCompetitionMaxSlots = 100
+	apiRelayBuilderStatsMaxDays    = 7
+)
+
+// ApiRelayBidCompetition godoc
+// @Summary Get the bid competition of slots
+// @Tags Relays
+// @Description Returns per slot the number of bids the relays received, the top bid, the delivered bid and the margin of the winning builder over the best bid of any other builder.
+// @Description Only the slots within the bid retention window of the explorer are available, at most 100 slots can be requested at once.
+// @Produce json
+// @Param from_slot query int false "First slot (default: to_slot - 99)"
+// @Param to_slot query int false "Last slot (default: head slot)"
+// @Success 200 {object} types.ApiResponse{data=[]types.RelayBidCompetition}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/relays/bids/competition [get]
+func ApiRelayBidCompetition(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	fromSlot, toSlot, err := parseApiRelaySlotRange(r, apiRelayBidCompetitionMaxSlots, apiRelayBidCompetitionMaxSlots)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	competition, err := db.GetRelayBidCompetition(fromSlot, toSlot)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving relay bid competition")
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]interface{}, 0, len(competition))
+	for _, c := range competition {
+		data = append(data, c)
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), data)
+}
+
+// ApiRelayBuilderBidStats godoc
+// @Summary Get the bid statistics of builders
+// @Tags Relays
+// @Description Returns the number of bids, the number of slots bid on and the number of slots won of the 100 most successful builders within a slot range of at most 7 days.
+// @Produce json
+// @Param from_slot query int false "First slot (default: one day before to_slot)"
+// @Param to_slot query int false "Last slot (default: head slot)"
+// @Success 200 {object} types.ApiResponse{data=[]types.RelayBuilderBidStats}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/relays/bids/builders [get]
+func ApiRelayBuilderBidStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	slotsPerDay := uint64(utils.Day/time.Second) / utils.Config.Chain.ClConfig.SecondsPerSlot
+	fromSlot, toSlot, err := parseApiRelaySlotRange(r, slotsPerDay, apiRelayBuilderStatsMaxDays*slotsPerDay)
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), err.Error())
+		return
+	}
+
+	stats, err := db.GetRelayBuilderBidStats(fromSlot, toSlot, 100)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving relay builder bid stats")
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]interface{}, 0, len(stats))
+	for _, s := range stats {
+		data = append(data, s)
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), data)
+}
+
+// parseApiRelaySlotRange parses the from_slot and to_slot query parameters, without from_slot the range spans defaultSlots slots and it may span at most maxSlots slots
+func parseApiRelaySlotRange(r *http.Request, defaultSlots, maxSlots uint64) (uint64, uint64, error) {
+	q := r.URL.Query()
+
+	toSlot := services.LatestSlot()
+	if q.Get("to_slot") != "" {
+		v, err := strconv.ParseUint(q.Get("to_slot"), 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid to_slot parameter")
+		}
+		toSlot = v
+	}
+	fromSlot := uint64(0)
+	if toSlot >= defaultSlots {
+		fromSlot = toSlot - defaultSlots + 1
+	}
+	if q.Get("from_slot") != "" {
+		v, err := strconv.ParseUint(q.Get("from_slot"), 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid from_slot parameter")
+		}
+		fromSlot = v
+	}
+	if fromSlot > toSlot {
+		return 0, 0, fmt.Errorf("from_slot must not be after to_slot")
+	}
+	if toSlot-fromSlot >= maxSlots {
+		return 0, 0, fmt.Errorf("the slot range must not exceed %v slots", maxSlots)
+	}
+	return fromSlot, toSlot, nil
+}
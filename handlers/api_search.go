@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	apiSearchDefaultLimit = 20
+	apiSearchMaxLimit     = 100
+	// number of results each searcher returns at most, results are merged and ranked before paging
+	apiSearchPerTypeLimit = 100
+)
+
+// apiSearchTypes lists the result types of the search api, results with the same score are ordered by this list
+var apiSearchTypes = []string{"validator", "slot", "epoch", "block", "transaction", "address", "token", "ens", "validator_tag", "graffiti"}
+
+// ApiSearch godoc
+// @Summary Search the explorer
+// @Tags Misc
+// @Description Searches validators (by index, public key or name), validator tags, slots, epochs, blocks, transactions, addresses, tokens, ENS names and graffiti.
+// @Description Universal Profile names are not indexed by the explorer and therefore not searched.
+// @Description Results are typed and ranked by score: exact matches score 1, prefix matches 0.8 and fuzzy (trigram) matches up to 0.6.
+// @Produce json
+// @Param q query string true "Search query"
+// @Param types query string false "Comma separated list of result types to include: validator, validator_tag, slot, epoch, block, transaction, address, token, ens, graffiti"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Param offset query int false "Number of results to skip"
+// @Success 200 {object} types.ApiResponse{data=types.ApiSearchResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/search [get]
+func ApiSearch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		SendBadRequestResponse(w, r.URL.String(), "missing q parameter")
+		return
+	}
+	if len(query) > 256 {
+		SendBadRequestResponse(w, r.URL.String(), "q parameter is too long")
+		return
+	}
+
+	limit := apiSearchDefaultLimit
+	if q.Get("limit") != "" {
+		v, err := strconv.Atoi(q.Get("limit"))
+		if err != nil || v < 1 || v > apiSearchMaxLimit {
+			SendBadRequestResponse(w, r.URL.String(), fmt.Sprintf("limit must be between 1 and %v", apiSearchMaxLimit))
+			return
+		}
+		limit = v
+	}
+	offset := 0
+	if q.Get("offset") != "" {
+		v, err := strconv.Atoi(q.Get("offset"))
+		if err != nil || v < 0 {
+			SendBadRequestResponse(w, r.URL.String(), "invalid offset parameter")
+			return
+		}
+		offset = v
+	}
+
+	enabledTypes := make(map[string]bool, len(apiSearchTypes))
+	if q.Get("types") == "" {
+		for _, t := range apiSearchTypes {
+			enabledTypes[t] = true
+		}
+	} else {
+		for _, t := range strings.Split(q.Get("types"), ",") {
+			t = strings.TrimSpace(strings.ToLower(t))
+			if !utils.SliceContains(apiSearchTypes, t) {
+				SendBadRequestResponse(w, r.URL.String(), fmt.Sprintf("invalid result type %v", t))
+				return
+			}
+			enabledTypes[t] = true
+		}
+	}
+
+	results, err := searchAll(query, enabledTypes)
+	if err != nil {
+		logger.WithError(err).Errorf("error searching for %v", query)
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve search results")
+		return
+	}
+
+	typeOrder := make(map[string]int, len(apiSearchTypes))
+	for i, t := range apiSearchTypes {
+		typeOrder[t] = i
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return typeOrder[results[i].Type] < typeOrder[results[j].Type]
+	})
+
+	response := &types.ApiSearchResponse{
+		Query:   query,
+		Total:   len(results),
+		Offset:  offset,
+		Limit:   limit,
+		Results: []*types.SearchResult{},
+	}
+	if offset < len(results) {
+		end := offset + limit
+		if end > len(results) {
+			end = len(results)
+		}
+		response.Results = results[offset:end]
+	}
+
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{response})
+}
+
+// searchAll runs all searchers of the enabled result types concurrently and returns their deduplicated results
+func searchAll(query string, enabledTypes map[string]bool) ([]*types.SearchResult, error) {
+	strippedQuery := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(query, "0x"), "0X"))
+	isHex := strippedQuery != "" && searchLikeRE.MatchString(strippedQuery)
+	number, numberErr := strconv.ParseUint(query, 10, 64)
+	isNumber := numberErr == nil
+	// validator indices, slots and epochs are stored as int columns, larger numbers can only be blocks
+	isIntNumber := isNumber && number <= math.MaxInt32
+	var hash []byte
+	if isHex && len(strippedQuery) == 64 {
+		hash, _ = hex.DecodeString(strippedQuery)
+	}
+
+	var resultsMux sync.Mutex
+	results := []*types.SearchResult{}
+	g := new(errgroup.Group)
+	search := func(resultType string, enabled bool, searcher func() ([]*types.SearchResult, error)) {
+		if !enabledTypes[resultType] || !enabled {
+			return
+		}
+		g.Go(func() error {
+			res, err := searcher()
+			if err != nil {
+				return err
+			}
+			resultsMux.Lock()
+			results = append(results, res...)
+			resultsMux.Unlock()
+			return nil
+		})
+	}
+
+	search("validator", isIntNumber, func() ([]*types.SearchResult, error) {
+		return db.SearchValidatorsByIndex(number)
+	})
+	search("validator", thresholdHexLikeRE.MatchString(strippedQuery), func() ([]*types.SearchResult, error) {
+		return db.SearchValidatorsByPubkey(strippedQuery, apiSearchPerTypeLimit)
+	})
+	search("validator", !isNumber, func() ([]*types.SearchResult, error) {
+		return db.SearchValidatorsByName(query, apiSearchPerTypeLimit)
+	})
+	search("validator_tag", !isNumber, func() ([]*types.SearchResult, error) {
+		return db.SearchValidatorTags(query, apiSearchPerTypeLimit)
+	})
+	search("slot", isIntNumber, func() ([]*types.SearchResult, error) {
+		return db.SearchSlot(number)
+	})
+	search("slot", hash != nil, func() ([]*types.SearchResult, error) {
+		return db.SearchSlotsByRoot(hash)
+	})
+	search("epoch", isIntNumber, func() ([]*types.SearchResult, error) {
+		return db.SearchEpoch(number)
+	})
+	search("block", isNumber, func() ([]*types.SearchResult, error) {
+		block, err := db.BigtableClient.GetBlockFromBlocksTable(number)
+		if err != nil {
+			if err == db.ErrBlockNotFound {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("error retrieving block %v: %w", number, err)
+		}
+		return []*types.SearchResult{{
+			Type:  "block",
+			Value: fmt.Sprintf("%v", block.Number),
+			Label: fmt.Sprintf("%#x", block.Hash),
+			Match: "exact",
+			Score: db.SearchScoreExact,
+			URL:   fmt.Sprintf("/block/%v", block.Number),
+		}}, nil
+	})
+	search("block", hash != nil, func() ([]*types.SearchResult, error) {
+		return db.SearchBlocksByHash(hash)
+	})
+	search("transaction", hash != nil, func() ([]*types.SearchResult, error) {
+		tx, err := db.BigtableClient.GetIndexedEth1Transaction(hash)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving transaction %#x: %w", hash, err)
+		}
+		if tx == nil {
+			return nil, nil
+		}
+		return []*types.SearchResult{{
+			Type:  "transaction",
+			Value: fmt.Sprintf("%#x", tx.Hash),
+			Match: "exact",
+			Score: db.SearchScoreExact,
+			URL:   fmt.Sprintf("/tx/%#x", tx.Hash),
+		}}, nil
+	})
+	search("address", isHex && !isNumber && len(strippedQuery) <= 40, func() ([]*types.SearchResult, error) {
+		return searchAddresses(strippedQuery, enabledTypes)
+	})
+	search("token", isHex && !isNumber && len(strippedQuery) <= 40 && !enabledTypes["address"], func() ([]*types.SearchResult, error) {
+		return searchAddresses(strippedQuery, enabledTypes)
+	})
+	search("ens", utils.IsValidEnsDomain(query), func() ([]*types.SearchResult, error) {
+		ensData, err := GetEnsDomain(query)
+		if err != nil || ensData == nil || ensData.Address == "" {
+			return nil, nil
+		}
+		return []*types.SearchResult{{
+			Type:  "ens",
+			Value: ensData.Domain,
+			Label: ensData.Address,
+			Match: "exact",
+			Score: db.SearchScoreExact,
+			URL:   "/address/" + ensData.Domain,
+		}}, nil
+	})
+	search("ens", !isNumber, func() ([]*types.SearchResult, error) {
+		return db.SearchEnsNames(query, apiSearchPerTypeLimit)
+	})
+	search("graffiti", true, func() ([]*types.SearchResult, error) {
+		return db.SearchGraffiti(query, apiSearchPerTypeLimit)
+	})
+
+	err := g.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	// the same entity can be found by several searchers (e.g. a validator by index and by name), keep the best match
+	best := make(map[string]*types.SearchResult, len(results))
+	deduplicated := make([]*types.SearchResult, 0, len(results))
+	for _, result := range results {
+		key := result.Type + ":" + strings.ToLower(result.Value)
+		existing, ok := best[key]
+		if !ok {
+			best[key] = result
+			deduplicated = append(deduplicated, result)
+			continue
+		}
+		if result.Score > existing.Score {
+			*existing = *result
+		}
+	}
+	return deduplicated, nil
+}
+
+// searchAddresses returns the execution layer addresses starting with the given hex prefix, addresses of tokens are returned as type token
+func searchAddresses(addressPrefix string, enabledTypes map[string]bool) ([]*types.SearchResult, error) {
+	if len(addressPrefix)%2 != 0 { // pad with 0 if uneven
+		addressPrefix = addressPrefix + "0"
+	}
+	prefix, err := hex.DecodeString(addressPrefix)
+	if err != nil {
+		return nil, nil
+	}
+	addresses, err := db.BigtableClient.SearchForAddress(prefix, apiSearchPerTypeLimit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for address prefix %#x: %w", prefix, err)
+	}
+
+	results := make([]*types.SearchResult, 0, len(addresses))
+	for _, address := range addresses {
+		resultType := "address"
+		if address.Token != "" {
+			resultType = "token"
+		}
+		if !enabledTypes[resultType] {
+			continue
+		}
+		match, score := "prefix", db.SearchScorePrefix
+		if len(address.Address) == len(addressPrefix) && len(addressPrefix) == 40 {
+			match, score = "exact", db.SearchScoreExact
+		}
+		results = append(results, &types.SearchResult{
+			Type:    resultType,
+			Value:   "0x" + address.Address,
+			Label:   address.Name,
+			Match:   match,
+			Score:   score,
+			URL:     "/address/0x" + address.Address,
+			Details: address.Token,
+		})
+	}
+	return results, nil
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/gobitfly/eth2-beaconchain-explorer/db"
@@ -85,6 +87,45 @@ func BroadcastPost(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, url, http.StatusSeeOther)
 }
 
+// ApiBroadcastDryRun godoc
+// @Summary Check signed messages without broadcasting them
+// @Tags Misc
+// @Description Runs all checks of /tools/broadcast against every message (signatures are batch verified) and returns a report per message. Nothing is queued or broadcasted.
+// @Accept json
+// @Produce json
+// @Param body body string true "Signed bls_to_execution_changes, a signed voluntary_exit, {\"consolidation_requests\": [...]} or {\"withdrawal_requests\": [...]}"
+// @Success 200 {object} types.ApiResponse{data=types.NodeJobDryRunReport}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/broadcast/dryrun [post]
+func ApiBroadcastDryRun(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1e6+1))
+	if err != nil {
+		SendBadRequestResponse(w, r.URL.String(), "could not read body")
+		return
+	}
+	if len(body) > 1e6 {
+		SendBadRequestResponse(w, r.URL.String(), "data-size exceeds maximum of 1MB")
+		return
+	}
+
+	report, err := db.DryRunNodeJob(body)
+	if err != nil {
+		var userErr types.CreateNodeJobUserError
+		if errors.As(err, &userErr) {
+			SendBadRequestResponse(w, r.URL.String(), userErr.Message)
+			return
+		}
+		logger.WithError(err).Errorf("failed dry-running a node-job")
+		sendServerErrorResponse(w, r.URL.String(), "could not check messages")
+		return
+	}
+
+	SendOKResponse(j, r.URL.String(), []interface{}{report})
+}
+
 func BroadcastStatus(w http.ResponseWriter, r *http.Request) {
 	templateFiles := append(layoutTemplateFiles, "broadcaststatus.html")
 	var tpl = templates.GetTemplate(templateFiles...)
@@ -134,6 +175,10 @@ func FormatNodeJobType(nodeJobType types.NodeJobType) string {
 		label = "Set withdrawal address"
 	case types.VoluntaryExitsNodeJobType:
 		label = "Voluntary exit"
+	case types.ConsolidationRequestsNodeJobType:
+		label = "Consolidation request"
+	case types.WithdrawalRequestsNodeJobType:
+		label = "Execution layer exit"
 	}
 	return label
 }
@@ -145,6 +190,10 @@ func FormatNodeJobTitle(nodeJobType types.NodeJobType) string {
 		label = "Withdrawal Credentials Change Request Job"
 	case types.VoluntaryExitsNodeJobType:
 		label = "Voluntary Exit Request Job"
+	case types.ConsolidationRequestsNodeJobType:
+		label = "Consolidation Request Job"
+	case types.WithdrawalRequestsNodeJobType:
+		label = "Execution Layer Exit Request Job"
 	}
 	return label
 }
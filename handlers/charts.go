@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
 	"github.com/gobitfly/eth2-beaconchain-explorer/services"
 	"github.com/gobitfly/eth2-beaconchain-explorer/templates"
 	"github.com/gobitfly/eth2-beaconchain-explorer/types"
@@ -15,6 +17,7 @@ import (
 )
 
 const CHART_PREVIEW_POINTS = 100
+const EPOCH_CHART_MAX_POINTS = 1000
 
 // Charts uses a go template for presenting the page to show charts
 func Charts(w http.ResponseWriter, r *http.Request) {
@@ -67,6 +70,8 @@ func Chart(w http.ResponseWriter, r *http.Request) {
 	switch chartVar {
 	case "slotviz":
 		SlotViz(w, r)
+	case "epochs":
+		EpochCharts(w, r)
 	default:
 		GenericChart(w, r)
 	}
@@ -165,3 +170,89 @@ func SlotViz(w http.ResponseWriter, r *http.Request) {
 		return // an error has occurred and was processed
 	}
 }
+
+// EpochCharts renders the network charts at epoch resolution
+func EpochCharts(w http.ResponseWriter, r *http.Request) {
+	templateFiles := append(layoutTemplateFiles, "epochcharts.html")
+	var epochChartsTemplate = templates.GetTemplate(templateFiles...)
+
+	w.Header().Set("Content-Type", "text/html")
+	data := InitPageData(w, r, "stats", "/charts", "Epoch Charts", templateFiles)
+	data.Meta.Path = "/charts/epochs"
+
+	indicators := make([]types.ChartSeriesIndicator, 0, len(db.EpochChartSeriesIndicators))
+	for _, indicator := range db.EpochChartSeriesIndicators {
+		if indicator.Name == "STAKED" {
+			indicator.Description = fmt.Sprintf("Staked %v", utils.Config.Frontend.ClCurrency)
+		}
+		indicators = append(indicators, indicator)
+	}
+	data.Data = indicators
+
+	if handleTemplateError(w, r, "charts.go", "EpochCharts", "", epochChartsTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
+// EpochChartData returns the values of an epoch chart series indicator, the range can be limited using the from and to epoch query parameters.
+// At most EPOCH_CHART_MAX_POINTS points are returned, larger ranges are averaged.
+func EpochChartData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	indicator := q.Get("indicator")
+	valid := false
+	for _, i := range db.EpochChartSeriesIndicators {
+		valid = valid || i.Name == indicator
+	}
+	if !valid {
+		SendBadRequestResponse(w, r.URL.String(), "invalid indicator parameter")
+		return
+	}
+
+	fromEpoch, toEpoch, err := db.GetEpochChartSeriesRange()
+	if err != nil {
+		logger.WithError(err).Error("error retrieving epoch chart series range")
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+	if q.Get("from") != "" {
+		from, err := strconv.ParseUint(q.Get("from"), 10, 64)
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid from parameter")
+			return
+		}
+		if from > fromEpoch {
+			fromEpoch = from
+		}
+	}
+	if q.Get("to") != "" {
+		to, err := strconv.ParseUint(q.Get("to"), 10, 64)
+		if err != nil {
+			SendBadRequestResponse(w, r.URL.String(), "invalid to parameter")
+			return
+		}
+		if to < toEpoch {
+			toEpoch = to
+		}
+	}
+	if fromEpoch > toEpoch {
+		SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{[]interface{}{}})
+		return
+	}
+
+	points, err := db.GetEpochChartSeries(indicator, fromEpoch, toEpoch, EPOCH_CHART_MAX_POINTS)
+	if err != nil {
+		logger.WithError(err).Errorf("error retrieving epoch chart series %v", indicator)
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	// highcharts expects [timestamp in ms, value] pairs
+	series := make([][2]float64, 0, len(points))
+	for _, p := range points {
+		series = append(series, [2]float64{float64(utils.EpochToTime(p.Epoch).UnixMilli()), p.Value})
+	}
+
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{series})
+}
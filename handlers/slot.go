@@ -372,6 +372,16 @@ func GetSlotPageData(blockSlot uint64) (*types.BlockPageData, error) {
 		return nil, fmt.Errorf("error retrieving sync-committee of block %v: %v", slotPageData.Slot, err)
 	}
 
+	if utils.Config.MevBoostRelayExporter.ReceivedBids.Enabled {
+		// the page is rendered without the bid competition if it can not be retrieved
+		competition, err := db.GetRelayBidCompetition(slotPageData.Slot, slotPageData.Slot)
+		if err != nil {
+			utils.LogError(err, "error retrieving relay bid competition", 0, map[string]interface{}{"slot": slotPageData.Slot})
+		} else if len(competition) > 0 {
+			slotPageData.BidCompetition = competition[0]
+		}
+	}
+
 	return &slotPageData, nil
 }
 
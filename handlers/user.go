@@ -489,7 +489,10 @@ func UserNotificationsCenter(w http.ResponseWriter, r *http.Request) {
 			sub.EventName == string(types.MonitoringMachineCpuLoadEventName) ||
 			sub.EventName == string(types.MonitoringMachineMemoryUsageEventName) ||
 			sub.EventName == string(types.MonitoringMachineSwitchedToETH2FallbackEventName) ||
-			sub.EventName == string(types.MonitoringMachineSwitchedToETH1FallbackEventName) {
+			sub.EventName == string(types.MonitoringMachineSwitchedToETH1FallbackEventName) ||
+			sub.EventName == string(types.MonitoringMachineCustomAlertEventName) ||
+			sub.EventName == string(types.MonitoringMachineExecutionOutOfSyncEventName) ||
+			sub.EventName == string(types.MonitoringMachineExecutionPeerCountLowEventName) {
 			typeCount.Monitoring++
 		} else if sub.EventName == utils.GetNetwork()+":"+string(types.NetworkSlashingEventName) ||
 			sub.EventName == utils.GetNetwork()+":"+string(types.NetworkValidatorActivationQueueFullEventName) ||
@@ -884,7 +887,7 @@ func UserSubscriptionsData(w http.ResponseWriter, r *http.Request) {
 				pubkey = utils.FormatPublicKey(h)
 			}
 		} else if sub.EventName == string(types.TaxReportEventName) {
-			pubkey = template.HTML(`<a href="/rewards">report</a>`)
+			pubkey = template.HTML(`<a href="/user/reports">report</a>`)
 		} else if strings.HasPrefix(string(sub.EventName), "monitoring_") {
 			pubkey = utils.FormatMachineName(sub.EventFilter)
 		}
@@ -1805,6 +1808,10 @@ func internUserNotificationsSubscribe(event, filter string, threshold float64, w
 			threshold = 0.8
 		} else if eventName == types.ValidatorIsOfflineEventName {
 			threshold = 3
+		} else if eventName == types.MonitoringMachineExecutionOutOfSyncEventName {
+			threshold = 10
+		} else if eventName == types.MonitoringMachineExecutionPeerCountLowEventName {
+			threshold = 5
 		}
 		// rocketpool thresholds are free
 	}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/services"
+	"github.com/gobitfly/eth2-beaconchain-explorer/templates"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+)
+
+// UserReports shows the tax report schedules of the user as well as all archived reports
+func UserReports(w http.ResponseWriter, r *http.Request) {
+	templateFiles := append(layoutTemplateFiles, "user/reports.html")
+	var reportsTemplate = templates.GetTemplate(templateFiles...)
+
+	w.Header().Set("Content-Type", "text/html")
+	user := getUser(r)
+
+	data := InitPageData(w, r, "user", "/user/reports", "Income Reports", templateFiles)
+	pageData := types.UserReportsPageData{
+		ScheduleLimit: USER_TAX_REPORT_SCHEDULE_LIMIT,
+		CsrfField:     csrf.TemplateField(r),
+		Flashes:       utils.GetFlashes(w, r, authSessionName),
+	}
+
+	var subscriptions []types.Subscription
+	err := db.FrontendWriterDB.Select(&subscriptions, `
+		SELECT id, user_id, event_name, event_filter, last_sent_ts, last_sent_epoch, created_ts, created_epoch, event_threshold
+		FROM users_subscriptions
+		WHERE event_name = $1 AND user_id = $2
+		ORDER BY created_ts`, strings.ToLower(utils.GetNetwork())+":"+string(types.TaxReportEventName), user.UserID)
+	if err != nil {
+		utils.LogError(err, "error getting tax report schedules", 0, map[string]interface{}{"user_id": user.UserID})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, sub := range subscriptions {
+		schedule, err := types.ParseTaxReportSchedule(sub.EventFilter)
+		if err != nil || sub.ID == nil {
+			continue
+		}
+		validators := make([]string, 0, len(schedule.Validators))
+		for _, v := range schedule.Validators {
+			validators = append(validators, strconv.FormatUint(v, 10))
+		}
+		pageData.Schedules = append(pageData.Schedules, types.UserReportScheduleRow{
+			ID:          *sub.ID,
+			Description: describeTaxReportSchedule(schedule),
+			Currency:    strings.ToUpper(schedule.Currency),
+			Validators:  strings.Join(validators, ", "),
+			LastSent:    sub.LastSent,
+		})
+	}
+
+	pageData.Reports, err = db.GetUserReports(user.UserID)
+	if err != nil {
+		utils.LogError(err, "error getting user reports", 0, map[string]interface{}{"user_id": user.UserID})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data.Data = pageData
+
+	if handleTemplateError(w, r, "user_reports.go", "UserReports", "", reportsTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
+// UserReportDownload returns an archived report of the user
+func UserReportDownload(w http.ResponseWriter, r *http.Request) {
+	user := getUser(r)
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Bad Request: invalid id", http.StatusBadRequest)
+		return
+	}
+
+	report, err := db.GetUserReport(user.UserID, id)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Report not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.LogError(err, "error getting user report", 0, map[string]interface{}{"user_id": user.UserID, "id": id})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "application/pdf"
+	if report.Format != types.TaxReportFormatPdf {
+		contentType, _ = services.RewardsExportContentType(types.RewardsExportFormat(report.Format))
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", report.Filename))
+
+	_, err = w.Write(report.Data)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error writing response")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// UserReportScheduleDelete deletes a tax report schedule of the user, archived reports of the schedule are kept
+func UserReportScheduleDelete(w http.ResponseWriter, r *http.Request) {
+	user := getUser(r)
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, "Bad Request: invalid id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = db.FrontendWriterDB.Exec("DELETE FROM users_subscriptions WHERE id = $1 AND user_id = $2 AND event_name = $3",
+		id, user.UserID, strings.ToLower(utils.GetNetwork())+":"+string(types.TaxReportEventName))
+	if err != nil {
+		utils.LogError(err, "error deleting tax report schedule", 0, map[string]interface{}{"user_id": user.UserID, "id": id})
+		utils.SetFlash(w, r, authSessionName, "Error: Could not delete the report schedule.")
+		http.Redirect(w, r, "/user/reports", http.StatusSeeOther)
+		return
+	}
+
+	utils.SetFlash(w, r, authSessionName, "The report schedule has been deleted.")
+	http.Redirect(w, r, "/user/reports", http.StatusSeeOther)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"html/template"
@@ -17,11 +18,17 @@ import (
 	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
 
 	"github.com/gorilla/csrf"
+	"github.com/gorilla/mux"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 )
 
 // var supportedCurrencies = []string{"eur", "usd", "gbp", "cny", "cad", "jpy", "rub", "aud"}
 const USER_SUBSCRIPTION_LIMIT = 8
 
+// maximum number of tax report schedules per user
+const USER_TAX_REPORT_SCHEDULE_LIMIT = 20
+
 type rewardsResp struct {
 	Currencies        []string
 	CsrfField         template.HTML
@@ -71,23 +78,74 @@ func getUserRewardSubscriptions(uid uint64) [][]string {
 		logger.Errorf("error getting prices: %v", err)
 	}
 
-	res := make([][]string, len(dbResp))
-	for i, item := range dbResp {
-		q, err := url.ParseQuery(item.EventFilter)
-		if err != nil {
+	res := make([][]string, 0, len(dbResp))
+	for _, item := range dbResp {
+		schedule, err := types.ParseTaxReportSchedule(item.EventFilter)
+		if err != nil || item.ID == nil {
 			continue
 		}
-		res[i] = []string{
-			fmt.Sprintf("%v", item.CreatedTime),
-			q.Get("currency"),
-			q.Get("validators"),
-			item.EventFilter,
+		validators := make([]string, 0, len(schedule.Validators))
+		for _, v := range schedule.Validators {
+			validators = append(validators, fmt.Sprintf("%d", v))
 		}
+		res = append(res, []string{
+			fmt.Sprintf("%v", item.CreatedTime),
+			schedule.Currency,
+			strings.Join(validators, ","),
+			describeTaxReportSchedule(schedule),
+			fmt.Sprintf("%d", *item.ID),
+		})
 	}
 
 	return res
 }
 
+// describeTaxReportSchedule returns a short human readable description of a schedule, e.g. "Monthly PDF (UTC), tag watchlist"
+func describeTaxReportSchedule(schedule *types.TaxReportSchedule) string {
+	desc := fmt.Sprintf("%s %s (%s)", cases.Title(language.English).String(string(schedule.Period)), strings.ToUpper(schedule.Format), schedule.Timezone)
+	if schedule.Name != "" {
+		desc = schedule.Name + ": " + desc
+	}
+	if schedule.Tag != "" {
+		desc += ", tag " + schedule.Tag
+	}
+	return desc
+}
+
+// parseTaxReportSchedule parses a tax report schedule from the query parameters of a subscribe request
+func parseTaxReportSchedule(q url.Values, validatorLimit int) (*types.TaxReportSchedule, error) {
+	filter := url.Values{}
+	for _, key := range []string{"validators", "currency", "period", "timezone", "format", "tag", "name"} {
+		if value := strings.TrimSpace(q.Get(key)); value != "" {
+			filter.Set(key, value)
+		}
+	}
+
+	if validators := filter.Get("validators"); validators != "" {
+		// don't allow passing validator pubkeys in the query string
+		_, queryValidatorPubkeys, err := parseValidatorsFromQueryString(validators, validatorLimit)
+		if err != nil || len(queryValidatorPubkeys) > 0 {
+			return nil, fmt.Errorf("validators could not be parsed or should be specified using Indices")
+		}
+	}
+	if len(filter.Get("tag")) > 50 || len(filter.Get("name")) > 100 {
+		return nil, fmt.Errorf("tag or name too long")
+	}
+
+	schedule, err := types.ParseTaxReportSchedule(filter.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	if !isValidCurrency(schedule.Currency) {
+		return nil, fmt.Errorf("invalid currency given")
+	}
+	if schedule.Format != types.TaxReportFormatPdf && !services.IsValidRewardsExportFormat(types.RewardsExportFormat(schedule.Format)) {
+		return nil, fmt.Errorf("invalid format given")
+	}
+	return schedule, nil
+}
+
 func isValidCurrency(currency string) bool {
 	var count uint64
 	err := db.ReaderDb.Get(&count,
@@ -237,27 +295,21 @@ func RewardNotificationSubscribe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if count >= USER_SUBSCRIPTION_LIMIT {
+	if count >= USER_TAX_REPORT_SCHEDULE_LIMIT {
 		http.Error(w, "Conflicting Request: user subscription limit reached", http.StatusConflict)
 		return
 	}
 
-	// don't allow passing validator pubkeys in the query string
-	_, queryValidatorPubkeys, err := parseValidatorsFromQueryString(validatorArr, validatorLimit)
-	if err != nil || len(queryValidatorPubkeys) > 0 {
-		http.Error(w, "Bad Request: validators could not be parsed or should be specified using Indices", http.StatusBadRequest)
-		return
-	}
-
-	if validatorArr == "" || !isValidCurrency(currency) {
-		http.Error(w, "Bad Request: no validators or invalid currency given", http.StatusBadRequest)
+	schedule, err := parseTaxReportSchedule(q, validatorLimit)
+	if err != nil {
+		http.Error(w, "Bad Request: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	err = db.AddSubscription(user.UserID,
 		utils.Config.Chain.ClConfig.ConfigName,
 		types.TaxReportEventName,
-		fmt.Sprintf("validators=%s&days=30&currency=%s", validatorArr, currency), 0)
+		schedule.EventFilter(), 0)
 
 	if err != nil {
 		utils.LogError(err, "Failed to add entry to user subscriptions", 0, errFields)
@@ -277,6 +329,7 @@ func RewardNotificationSubscribe(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// RewardNotificationUnsubscribe deletes a tax report schedule of the user, either by its subscription id or by its legacy validators and currency filter
 func RewardNotificationUnsubscribe(w http.ResponseWriter, r *http.Request) {
 	SetAutoContentType(w, r)
 	user := getUser(r)
@@ -299,30 +352,45 @@ func RewardNotificationUnsubscribe(w http.ResponseWriter, r *http.Request) {
 		"validator_limit":  validatorLimit,
 	}
 
-	// don't allow passing validator pubkeys in the query string
-	_, queryValidatorPubkeys, err := parseValidatorsFromQueryString(validatorArr, validatorLimit)
-	if err != nil || len(queryValidatorPubkeys) > 0 {
-		http.Error(w, "Bad Request: validators could not be parsed or should be specified using Indices", http.StatusBadRequest)
-		return
-	}
+	if q.Get("id") != "" {
+		id, err := strconv.ParseUint(q.Get("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Bad Request: invalid id", http.StatusBadRequest)
+			return
+		}
+		_, err = db.FrontendWriterDB.Exec("DELETE FROM users_subscriptions WHERE id = $1 AND user_id = $2 AND event_name = $3",
+			id, user.UserID, strings.ToLower(utils.GetNetwork())+":"+string(types.TaxReportEventName))
+		if err != nil {
+			utils.LogError(err, "Failed to delete entry from user subscriptions", 0, errFields)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		// don't allow passing validator pubkeys in the query string
+		_, queryValidatorPubkeys, err := parseValidatorsFromQueryString(validatorArr, validatorLimit)
+		if err != nil || len(queryValidatorPubkeys) > 0 {
+			http.Error(w, "Bad Request: validators could not be parsed or should be specified using Indices", http.StatusBadRequest)
+			return
+		}
 
-	if validatorArr == "" || !isValidCurrency(currency) {
-		http.Error(w, "Bad Request: no validators or invalid currency given", http.StatusBadRequest)
-		return
-	}
+		if validatorArr == "" || !isValidCurrency(currency) {
+			http.Error(w, "Bad Request: no validators or invalid currency given", http.StatusBadRequest)
+			return
+		}
 
-	err = db.DeleteSubscription(user.UserID,
-		utils.GetNetwork(),
-		types.TaxReportEventName,
-		fmt.Sprintf("validators=%s&days=30&currency=%s", validatorArr, currency))
+		err = db.DeleteSubscription(user.UserID,
+			utils.GetNetwork(),
+			types.TaxReportEventName,
+			fmt.Sprintf("validators=%s&days=30&currency=%s", validatorArr, currency))
 
-	if err != nil {
-		utils.LogError(err, "Failed to delete entry from user subscriptions", 0, errFields)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		if err != nil {
+			utils.LogError(err, "Failed to delete entry from user subscriptions", 0, errFields)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 	}
 
-	err = json.NewEncoder(w).Encode(struct {
+	err := json.NewEncoder(w).Encode(struct {
 		Msg string `json:"msg"`
 	}{Msg: "Subscription Deleted"})
 
@@ -359,11 +427,179 @@ func RewardGetUserSubscriptions(w http.ResponseWriter, r *http.Request) {
 	err = json.NewEncoder(w).Encode(struct {
 		Data  [][]string `json:"data"`
 		Count uint64     `json:"count"`
-	}{Data: data, Count: count})
+		Limit uint64     `json:"limit"`
+	}{Data: data, Count: count, Limit: USER_TAX_REPORT_SCHEDULE_LIMIT})
+
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error encoding json response")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseRewardsExportRange parses the days query parameter (unix timestamps of the first and last day) into days since genesis
+func parseRewardsExportRange(q url.Values) (uint64, uint64, error) {
+	t := time.Unix(int64(utils.Config.Chain.GenesisTimestamp), 0)
+	startGenesisDay := uint64(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix())
+
+	dateRange := strings.Split(q.Get("days"), "-")
+	if len(dateRange) != 2 {
+		return 0, 0, fmt.Errorf("invalid parameter days")
+	}
+	start, err := strconv.ParseUint(dateRange[0], 10, 32) //Limit to uint32 for postgres
+	if err != nil || start < startGenesisDay {
+		return 0, 0, fmt.Errorf("invalid parameter days")
+	}
+	end, err := strconv.ParseUint(dateRange[1], 10, 32) //Limit to uint32 for postgres
+	if err != nil || end < start {
+		return 0, 0, fmt.Errorf("invalid parameter days")
+	}
+
+	startDay := utils.TimeToDay(start)
+	endDay := utils.TimeToDay(end)
+	// see services.GetValidatorHist, timestamps from the ui are at the start of the day while genesis is in the middle of the day
+	if start > utils.Config.Chain.GenesisTimestamp && startDay < endDay {
+		startDay++
+	}
+	return startDay, endDay, nil
+}
+
+// RewardsExport generates an accounting export of the rewards, deposits and withdrawals of the given validators.
+// Small exports are returned directly, larger ones are generated in the background and a download link is returned.
+func RewardsExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
 
+	validatorIndexArr, _, redirect, err := handleValidatorsQuery(w, r, true)
+	if err != nil || redirect {
+		return
+	}
+
+	currency := q.Get("currency")
+	if !isValidCurrency(currency) {
+		http.Error(w, "Error: Invalid parameter currency.", http.StatusBadRequest)
+		return
+	}
+
+	format := types.RewardsExportFormat(q.Get("format"))
+	if format == "" {
+		format = types.RewardsExportFormatCSV
+	}
+	if !services.IsValidRewardsExportFormat(format) {
+		http.Error(w, "Error: Invalid parameter format.", http.StatusBadRequest)
+		return
+	}
+
+	startDay, endDay, err := parseRewardsExportRange(q)
+	if err != nil {
+		http.Error(w, "Error: Invalid parameter days.", http.StatusBadRequest)
+		return
+	}
+
+	errFields := map[string]interface{}{
+		"route":      r.URL.String(),
+		"validators": len(validatorIndexArr),
+		"startDay":   startDay,
+		"endDay":     endDay,
+		"format":     format,
+	}
+
+	if uint64(len(validatorIndexArr))*(endDay-startDay+1) > services.RewardsExportSyncValidatorDayLimit {
+		// background exports can only be retrieved by the user that created them
+		user := getUser(r)
+		if !user.Authenticated {
+			http.Error(w, "Error: Please log in to export more validator days.", http.StatusUnauthorized)
+			return
+		}
+		export, err := db.CreateRewardsExport(user.UserID, validatorIndexArr, currency, format, startDay, endDay)
+		if err != nil {
+			utils.LogError(err, "error creating rewards export", 0, errFields)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		err = json.NewEncoder(w).Encode(struct {
+			ID          string `json:"id"`
+			Status      string `json:"status"`
+			DownloadUrl string `json:"download_url"`
+		}{ID: export.ID, Status: export.Status, DownloadUrl: fmt.Sprintf("/rewards/export/%s/download", export.ID)})
+		if err != nil {
+			utils.LogError(err, "error encoding json response", 0, errFields)
+		}
+		return
+	}
+
+	ledger, currency, err := services.GetRewardsLedger(validatorIndexArr, currency, startDay, endDay)
+	if err != nil {
+		utils.LogError(err, "error getting rewards ledger", 0, errFields)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	data, err := services.GenerateRewardsExport(ledger, currency, format)
+	if err != nil {
+		utils.LogError(err, "error generating rewards export", 0, errFields)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	writeRewardsExport(w, r, data, format, startDay, endDay)
+}
+
+// RewardsExportStatus returns the status of a background rewards export
+func RewardsExportStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	export, err := db.GetRewardsExport(mux.Vars(r)["id"], getUser(r).UserID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Error: Export not found.", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.LogError(err, "error getting rewards export", 0, map[string]interface{}{"route": r.URL.String()})
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(export)
 	if err != nil {
 		logger.WithError(err).WithField("route", r.URL.String()).Error("error encoding json response")
+	}
+}
+
+// DownloadRewardsExport returns the file of a finished background rewards export
+func DownloadRewardsExport(w http.ResponseWriter, r *http.Request) {
+	export, err := db.GetRewardsExport(mux.Vars(r)["id"], getUser(r).UserID)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Error: Export not found.", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		utils.LogError(err, "error getting rewards export", 0, map[string]interface{}{"route": r.URL.String()})
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
+
+	switch export.Status {
+	case types.RewardsExportStatusDone:
+	case types.RewardsExportStatusFailed:
+		http.Error(w, "Error: Export failed.", http.StatusInternalServerError)
+		return
+	default:
+		http.Error(w, "Export is not ready yet, please try again later.", http.StatusConflict)
+		return
+	}
+
+	writeRewardsExport(w, r, export.Data, export.Format, uint64(export.StartDay), uint64(export.EndDay))
+}
+
+func writeRewardsExport(w http.ResponseWriter, r *http.Request, data []byte, format types.RewardsExportFormat, startDay, endDay uint64) {
+	contentType, extension := services.RewardsExportContentType(format)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=rewards_%s_%v_%v.%s", format, utils.DayToTime(int64(startDay)).Format("20060102"), utils.DayToTime(int64(endDay)).Format("20060102"), extension))
+	w.Header().Set("Content-Type", contentType)
+
+	_, err := w.Write(data)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Error("error writing response")
+	}
 }
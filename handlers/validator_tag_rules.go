@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/templates"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/gorilla/csrf"
+)
+
+const validatorTagsApiMaxLimit = 2000
+
+// Load Validator Tag Rules page
+func ValidatorTagRules(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := handleAdminPermissions(w, r); !isAdmin {
+		return
+	}
+
+	templateFiles := append(layoutTemplateFiles, "user/validator_tag_rules.html")
+	var userTemplate = templates.GetTemplate(templateFiles...)
+
+	w.Header().Set("Content-Type", "text/html")
+
+	rules, err := db.GetValidatorTagRules()
+	if err != nil {
+		utils.LogError(err, "error loading the validator tag rules", 0)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := InitPageData(w, r, "user", "/user/validator_tag_rules", "Validator Tag Rules", templateFiles)
+	pageData := types.ValidatorTagRulesPageData{}
+	pageData.CsrfField = csrf.TemplateField(r)
+	pageData.Rules = rules
+	pageData.RuleTypes = types.ValidatorTagRuleTypes
+	pageData.Error = r.URL.Query().Get("error")
+	pageData.New = types.ValidatorTagRule{
+		RuleType: types.ValidatorTagRuleDepositAddress,
+		Enabled:  true,
+	}
+	data.Data = pageData
+
+	if handleTemplateError(w, r, "validator_tag_rules.go", "ValidatorTagRules", "", userTemplate.ExecuteTemplate(w, "layout", data)) != nil {
+		return // an error has occurred and was processed
+	}
+}
+
+// Insert / Update Validator Tag Rule
+func ValidatorTagRulesPost(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := handleAdminPermissions(w, r); !isAdmin {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		utils.LogError(err, "error parsing form", 0)
+		http.Redirect(w, r, "/user/validator_tag_rules?error=parsingForm", http.StatusSeeOther)
+		return
+	}
+
+	rule := &types.ValidatorTagRule{
+		Tag:      r.FormValue(`tag`),
+		RuleType: r.FormValue(`ruleType`),
+		Pattern:  r.FormValue(`pattern`),
+		Enabled:  len(r.FormValue(`enabled`)) > 0,
+		Source:   "admin",
+	}
+	err = utils.NormalizeValidatorTagRule(rule)
+	if err != nil {
+		http.Redirect(w, r, "/user/validator_tag_rules?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	if rule.RuleType == types.ValidatorTagRuleGraffiti {
+		// graffiti rules are evaluated by postgres, its regular expression dialect differs from the one of go
+		err = db.ValidateValidatorTagRuleGraffiti(rule.Pattern)
+		if errors.Is(err, db.ErrInvalidValidatorTagRulePattern) {
+			http.Redirect(w, r, "/user/validator_tag_rules?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+			return
+		}
+		if err != nil {
+			utils.LogError(err, "error validating validator tag rule pattern", 0)
+			http.Redirect(w, r, "/user/validator_tag_rules?error=validatingRule", http.StatusSeeOther)
+			return
+		}
+	}
+
+	if id := r.FormValue(`id`); len(id) == 0 {
+		err = db.InsertValidatorTagRule(rule)
+		if err != nil {
+			utils.LogError(err, "error inserting new validator tag rule", 0)
+			http.Redirect(w, r, "/user/validator_tag_rules?error=insertingRule", http.StatusSeeOther)
+			return
+		}
+	} else {
+		rule.Id, err = strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			http.Redirect(w, r, "/user/validator_tag_rules?error=invalidId", http.StatusSeeOther)
+			return
+		}
+		err = db.UpdateValidatorTagRule(rule)
+		if err != nil {
+			utils.LogError(err, "error updating validator tag rule", 0, map[string]interface{}{"rule": rule.Id})
+			http.Redirect(w, r, "/user/validator_tag_rules?error=updatingRule", http.StatusSeeOther)
+			return
+		}
+	}
+
+	http.Redirect(w, r, "/user/validator_tag_rules", http.StatusSeeOther)
+}
+
+// Delete Validator Tag Rule
+func ValidatorTagRulesDeletePost(w http.ResponseWriter, r *http.Request) {
+	if isAdmin, _ := handleAdminPermissions(w, r); !isAdmin {
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		utils.LogError(err, "error parsing form", 0)
+		http.Redirect(w, r, "/user/validator_tag_rules?error=parsingForm", http.StatusSeeOther)
+		return
+	}
+	id, err := strconv.ParseUint(r.FormValue(`id`), 10, 64)
+	if err != nil {
+		http.Redirect(w, r, "/user/validator_tag_rules?error=invalidId", http.StatusSeeOther)
+		return
+	}
+
+	err = db.DeleteValidatorTagRule(id)
+	if errors.Is(err, db.ErrValidatorTagRuleNotDeletable) {
+		http.Redirect(w, r, "/user/validator_tag_rules?error="+url.QueryEscape(err.Error()), http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		utils.LogError(err, "error deleting validator tag rule", 0, map[string]interface{}{"rule": id})
+		http.Redirect(w, r, "/user/validator_tag_rules?error=notDeleted", http.StatusSeeOther)
+		return
+	}
+
+	http.Redirect(w, r, "/user/validator_tag_rules", http.StatusSeeOther)
+}
+
+// ApiValidatorsByTags godoc
+// @Summary Get the validators carrying a set of tags
+// @Tags Validator
+// @Description Returns the validators carrying all given tags (e.g. pool:lido), ordered by index. Tags are assigned by rules matching deposit addresses, withdrawal credentials, fee recipients, graffiti or on-chain registries and by the rocketpool and ssv exporters.
+// @Produce  json
+// @Param  tags query string false "Comma separated list of tags"
+// @Param  rule query int false "Only validators tagged by the tag rule with this id"
+// @Param  offset query int false "Data offset" default(0)
+// @Param  limit query int false "Data limit (ranging from 1 to 2000)" default(100)
+// @Success 200 {object} types.ApiResponse{data=[]types.ApiValidatorTagsResponse}
+// @Failure 400 {object} types.ApiResponse
+// @Router /api/v1/validators [get]
+func ApiValidatorsByTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	q := r.URL.Query()
+
+	filter := &types.ValidatorTagsFilter{
+		Offset: parseUintWithDefault(q.Get("offset"), 0),
+		Limit:  parseUintWithDefault(q.Get("limit"), 100),
+	}
+	if filter.Limit == 0 || filter.Limit > validatorTagsApiMaxLimit {
+		filter.Limit = validatorTagsApiMaxLimit
+	}
+	for _, tag := range strings.Split(q.Get("tags"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+	if rule := q.Get("rule"); rule != "" {
+		var err error
+		filter.RuleId, err = strconv.ParseUint(rule, 10, 64)
+		if err != nil || filter.RuleId == 0 {
+			SendBadRequestResponse(w, r.URL.String(), "invalid rule id provided")
+			return
+		}
+	}
+	if len(filter.Tags) == 0 && filter.RuleId == 0 {
+		SendBadRequestResponse(w, r.URL.String(), "no tags or rule provided")
+		return
+	}
+
+	data, err := db.GetValidatorsByTags(filter)
+	if err != nil {
+		utils.LogError(err, "error retrieving validators by tags", 0, map[string]interface{}{"route": r.URL.String()})
+		sendServerErrorResponse(w, r.URL.String(), "could not retrieve db results")
+		return
+	}
+	SendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{data})
+}
@@ -70,6 +70,10 @@ var (
 		Name: "counter",
 		Help: "Counter of events with name in labels",
 	}, []string{"name"})
+	Progress = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "progress",
+		Help: "Gauge of the progress of long running tasks with name in labels",
+	}, []string{"name"})
 )
 
 var logger = logrus.New().WithField("module", "metrics")
@@ -0,0 +1,74 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/contracts/chainlink_feed"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"golang.org/x/sync/errgroup"
+)
+
+// ChainlinkProvider reads prices from chainlink price feed contracts
+type ChainlinkProvider struct {
+	feeds map[string]*chainlink_feed.Feed
+}
+
+// NewChainlinkProvider initializes the feeds of the given contract addresses keyed by pair
+func NewChainlinkProvider(eClient *ethclient.Client, feedAddrs map[string]string) (*ChainlinkProvider, error) {
+	p := &ChainlinkProvider{feeds: map[string]*chainlink_feed.Feed{}}
+	for pair, addrHex := range feedAddrs {
+		feed, err := chainlink_feed.NewFeed(common.HexToAddress(addrHex), eClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialized chainlink feed for %v (addr: %v): %w", pair, addrHex, err)
+		}
+		p.feeds[pair] = feed
+	}
+	return p, nil
+}
+
+func (p *ChainlinkProvider) Name() string {
+	return "chainlink"
+}
+
+func (p *ChainlinkProvider) Prices(ctx context.Context) (map[string]Quote, error) {
+	quotes := map[string]Quote{}
+	quotesMu := &sync.Mutex{}
+	g, gCtx := errgroup.WithContext(ctx)
+	for pair, feed := range p.feeds {
+		pair := pair
+		feed := feed
+		g.Go(func() error {
+			quote, err := getQuoteFromFeed(gCtx, feed)
+			if err != nil {
+				return fmt.Errorf("error getting price from feed for %v: %w", pair, err)
+			}
+			quotesMu.Lock()
+			defer quotesMu.Unlock()
+			quotes[pair] = quote
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return quotes, nil
+}
+
+func getQuoteFromFeed(ctx context.Context, feed *chainlink_feed.Feed) (Quote, error) {
+	decimals := decimal.NewFromInt(1e8) // 8 decimal places for the Chainlink feeds
+	res, err := feed.LatestRoundData(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return Quote{}, fmt.Errorf("failed to fetch latest chainlink price feed data: %w", err)
+	}
+	return Quote{
+		Price:     decimal.NewFromBigInt(res.Answer, 0).Div(decimals).InexactFloat64(),
+		UpdatedAt: time.Unix(res.UpdatedAt.Int64(), 0),
+	}, nil
+}
@@ -0,0 +1,119 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CoingeckoProvider reads prices from the simple price endpoint of the coingecko api or any api implementing it.
+// Fiat/USD pairs are derived from the prices of the coins in USD and the fiat currency.
+type CoingeckoProvider struct {
+	baseUrl string
+	apiKey  string
+	// coin ids keyed by currency, e.g. ETH: ethereum
+	coins  map[string]string
+	fiats  []string
+	client *http.Client
+}
+
+func NewCoingeckoProvider(baseUrl, apiKey string, coins map[string]string, fiats []string) *CoingeckoProvider {
+	return &CoingeckoProvider{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		apiKey:  apiKey,
+		coins:   coins,
+		fiats:   fiats,
+		client:  &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (p *CoingeckoProvider) Name() string {
+	return "coingecko"
+}
+
+func (p *CoingeckoProvider) Prices(ctx context.Context) (map[string]Quote, error) {
+	ids := make([]string, 0, len(p.coins))
+	for _, id := range p.coins {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	vsCurrencies := []string{"usd"}
+	for _, fiat := range p.fiats {
+		if fiat != "USD" {
+			vsCurrencies = append(vsCurrencies, strings.ToLower(fiat))
+		}
+	}
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("vs_currencies", strings.Join(vsCurrencies, ","))
+	query.Set("include_last_updated_at", "true")
+	data := map[string]map[string]float64{}
+	err := p.get(ctx, p.baseUrl+"/simple/price?"+query.Encode(), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies := make([]string, 0, len(p.coins))
+	for currency := range p.coins {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	quotes := map[string]Quote{}
+	for _, currency := range currencies {
+		coinPrices, exists := data[p.coins[currency]]
+		if !exists {
+			continue
+		}
+		usdPrice := coinPrices["usd"]
+		if usdPrice <= 0 {
+			continue
+		}
+		updatedAt := time.Unix(int64(coinPrices["last_updated_at"]), 0)
+		quotes[currency+"/USD"] = Quote{Price: usdPrice, UpdatedAt: updatedAt}
+		for _, fiat := range p.fiats {
+			pair := fiat + "/USD"
+			fiatPrice := coinPrices[strings.ToLower(fiat)]
+			if fiat == "USD" || fiatPrice <= 0 {
+				continue
+			}
+			if _, exists := quotes[pair]; exists {
+				continue
+			}
+			quotes[pair] = Quote{Price: usdPrice / fiatPrice, UpdatedAt: updatedAt}
+		}
+	}
+	if len(quotes) == 0 {
+		return nil, fmt.Errorf("coingecko returned no prices for %v", strings.Join(ids, ","))
+	}
+	return quotes, nil
+}
+
+func (p *CoingeckoProvider) get(ctx context.Context, url string, data interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("error creating coingecko request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", p.apiKey)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting coingecko prices: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("error requesting coingecko prices: unexpected status code %v", res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(data); err != nil {
+		return fmt.Errorf("error decoding coingecko prices: %w", err)
+	}
+	return nil
+}
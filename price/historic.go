@@ -0,0 +1,202 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+)
+
+// HistoricFiats are the fiat currencies stored in the price table
+var HistoricFiats = []string{"USD", "EUR", "RUB", "CNY", "CAD", "JPY", "GBP", "AUD"}
+
+// HistoricPriceProvider is a source of daily historic prices
+type HistoricPriceProvider interface {
+	Name() string
+	// HistoricPrices returns the prices of the currency at the start of the given day keyed by fiat currency, e.g. USD
+	HistoricPrices(ctx context.Context, currency string, day time.Time) (map[string]float64, error)
+}
+
+// HistoricPrice is the aggregated price of a day including its provenance
+type HistoricPrice struct {
+	// prices keyed by fiat currency
+	Prices map[string]float64
+	// providers the prices are derived from
+	Sources []string
+	// providers whose prices deviated too much from the median and were ignored
+	Outliers []string
+	// share of the providers that agree on the price
+	Confidence float64
+	// usd price reported by each provider
+	Quotes map[string]float64
+}
+
+// NewHistoricPriceProviders returns the historic price providers enabled in the config
+func NewHistoricPriceProviders(chainId uint64, cfg types.PriceProvidersConfig) ([]HistoricPriceProvider, error) {
+	providers := []HistoricPriceProvider{}
+	for _, name := range cfg.Historic.Providers {
+		switch name {
+		case "coingecko":
+			providers = append(providers, NewCoingeckoProvider(cfg.Coingecko.BaseUrl, cfg.Coingecko.ApiKey, getCoingeckoCoins(chainId, cfg), HistoricFiats))
+		case "cryptocompare":
+			providers = append(providers, NewCryptocompareProvider(cfg.Cryptocompare.BaseUrl, cfg.Cryptocompare.ApiKey))
+		case "static":
+			providers = append(providers, NewStaticProvider(cfg.Static))
+		default:
+			return nil, fmt.Errorf("unknown historic price provider %v, must be coingecko, cryptocompare or static", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no historic price providers configured")
+	}
+	return providers, nil
+}
+
+// AggregateHistoricPrices aggregates the prices of multiple providers keyed by provider name.
+// Providers whose usd price deviates more than outlierThreshold (relative) from the median are considered outliers and are ignored,
+// if all providers are outliers the median of all providers is used with a confidence of zero.
+func AggregateHistoricPrices(quotes map[string]map[string]float64, outlierThreshold float64) (*HistoricPrice, error) {
+	providers := make([]string, 0, len(quotes))
+	usdPrices := []float64{}
+	for provider, prices := range quotes {
+		if prices["USD"] > 0 {
+			providers = append(providers, provider)
+			usdPrices = append(usdPrices, prices["USD"])
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no provider returned a usd price")
+	}
+	sort.Strings(providers)
+
+	res := &HistoricPrice{Prices: map[string]float64{}, Sources: []string{}, Outliers: []string{}, Quotes: map[string]float64{}}
+	usdMedian := median(usdPrices)
+	for _, provider := range providers {
+		usdPrice := quotes[provider]["USD"]
+		res.Quotes[provider] = usdPrice
+		if math.Abs(usdPrice-usdMedian)/usdMedian > outlierThreshold {
+			res.Outliers = append(res.Outliers, provider)
+			continue
+		}
+		res.Sources = append(res.Sources, provider)
+	}
+	// providers that failed to return a price are part of quotes and reduce the confidence
+	res.Confidence = float64(len(res.Sources)) / float64(len(quotes))
+	if len(res.Sources) == 0 {
+		res.Sources = providers
+		res.Confidence = 0
+	}
+
+	for _, fiat := range HistoricFiats {
+		fiatPrices := []float64{}
+		for _, provider := range res.Sources {
+			if p := quotes[provider][fiat]; p > 0 {
+				fiatPrices = append(fiatPrices, p)
+			}
+		}
+		if len(fiatPrices) > 0 {
+			res.Prices[fiat] = median(fiatPrices)
+		}
+	}
+	return res, nil
+}
+
+func (p *CoingeckoProvider) HistoricPrices(ctx context.Context, currency string, day time.Time) (map[string]float64, error) {
+	id, exists := p.coins[currency]
+	if !exists {
+		return nil, fmt.Errorf("no coingecko coin id configured for %v", currency)
+	}
+
+	query := url.Values{}
+	query.Set("date", day.Format("02-01-2006"))
+	query.Set("localization", "false")
+	data := struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}{}
+	err := p.get(ctx, fmt.Sprintf("%s/coins/%s/history?%s", p.baseUrl, url.PathEscape(id), query.Encode()), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := map[string]float64{}
+	for _, fiat := range p.fiats {
+		if price := data.MarketData.CurrentPrice[strings.ToLower(fiat)]; price > 0 {
+			prices[fiat] = price
+		}
+	}
+	return prices, nil
+}
+
+func (p *StaticProvider) HistoricPrices(ctx context.Context, currency string, day time.Time) (map[string]float64, error) {
+	prices := map[string]float64{}
+	for pair, price := range p.prices {
+		s := strings.Split(pair, "/")
+		if len(s) == 2 && s[0] == currency {
+			prices[s[1]] = price
+		}
+	}
+	return prices, nil
+}
+
+// CryptocompareProvider reads daily historic prices from the cryptocompare api or any api implementing it
+type CryptocompareProvider struct {
+	baseUrl string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewCryptocompareProvider(baseUrl, apiKey string) *CryptocompareProvider {
+	return &CryptocompareProvider{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+func (p *CryptocompareProvider) Name() string {
+	return "cryptocompare"
+}
+
+func (p *CryptocompareProvider) HistoricPrices(ctx context.Context, currency string, day time.Time) (map[string]float64, error) {
+	query := url.Values{}
+	query.Set("fsym", currency)
+	query.Set("tsyms", strings.Join(HistoricFiats, ","))
+	// cryptocompare returns the close price of the day of the timestamp, which is the price at the start of the following day
+	query.Set("ts", fmt.Sprintf("%d", day.Add(-time.Second).Unix()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseUrl+"/data/pricehistorical?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cryptocompare request: %w", err)
+	}
+	if p.apiKey != "" {
+		req.Header.Set("authorization", "Apikey "+p.apiKey)
+	}
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting cryptocompare prices: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error requesting cryptocompare prices: unexpected status code %v", res.StatusCode)
+	}
+
+	data := map[string]map[string]float64{}
+	if err := json.NewDecoder(res.Body).Decode(&data); err != nil {
+		return nil, fmt.Errorf("error decoding cryptocompare prices: %w", err)
+	}
+	prices := map[string]float64{}
+	for fiat, price := range data[currency] {
+		if price > 0 {
+			prices[fiat] = price
+		}
+	}
+	return prices, nil
+}
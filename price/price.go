@@ -8,12 +8,9 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/gobitfly/eth2-beaconchain-explorer/contracts/chainlink_feed"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
 
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/shopspring/decimal"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
@@ -27,7 +24,8 @@ var runOnceWg sync.WaitGroup
 var prices = map[string]float64{}
 var pricesMu = &sync.Mutex{}
 var didInit = uint64(0)
-var feeds = map[string]*chainlink_feed.Feed{}
+var providers = []PriceProvider{}
+var maxAge = time.Hour
 var calcPairs = map[string]bool{}
 var clCurrency = "ETH"
 var elCurrency = "ETH"
@@ -47,31 +45,28 @@ var currencies = map[string]struct {
 	"GNO":  {"GNO", "Gnosis"},
 	"mGNO": {"mGNO", "mGnosis"},
 	"JPY":  {"¥", "Japanese Yen"},
+	"LYX":  {"LYX", "LUKSO"},
 	"RUB":  {"₽", "Russian Ruble"},
 	"USD":  {"$", "United States Dollar"},
 }
 
+// coingecko coin ids of the currencies of the supported chains
+var coingeckoCoinIds = map[uint64]map[string]string{
+	1:   {"ETH": "ethereum"},
+	100: {"GNO": "gnosis", "DAI": "dai", "ETH": "ethereum"},
+	42:  {"LYX": "lukso-token-2", "ETH": "ethereum"},
+}
+
 func init() {
 	runOnceWg.Add(1)
 }
 
-func Init(chainId uint64, eth1Endpoint, clCurrencyParam, elCurrencyParam string) {
+func Init(chainId uint64, eth1Endpoint, clCurrencyParam, elCurrencyParam string, cfg types.PriceProvidersConfig) {
 	if atomic.AddUint64(&didInit, 1) > 1 {
 		logrus.Warnf("price.Init called multiple times")
 		return
 	}
 
-	switch chainId {
-	case 1, 100:
-	default:
-		setPrice(elCurrency, elCurrency, 1)
-		setPrice(clCurrency, clCurrency, 1)
-		availableCurrencies = []string{clCurrency, elCurrency}
-		logger.Warnf("chainId not supported for fetching prices: %v", chainId)
-		runOnce.Do(func() { runOnceWg.Done() })
-		return
-	}
-
 	clCurrency = clCurrencyParam
 	elCurrency = elCurrencyParam
 	if elCurrency == "xDAI" {
@@ -79,21 +74,16 @@ func Init(chainId uint64, eth1Endpoint, clCurrencyParam, elCurrencyParam string)
 	}
 	calcPairs[elCurrency] = true
 	calcPairs[clCurrency] = true
-
-	eClient, err := ethclient.Dial(eth1Endpoint)
-	if err != nil {
-		logger.Errorf("error dialing pricing eth1 endpoint: %v", err)
-		return
+	maxAge = cfg.MaxAge
+	if maxAge == 0 {
+		maxAge = time.Hour
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
-	defer cancel()
-	clientChainId, err := eClient.ChainID(ctx)
-	if err != nil {
-		logger.WithError(err).Fatalf("failed getting chainID")
-	}
-	if chainId != clientChainId.Uint64() {
-		logger.WithError(err).Fatalf("chainId does not match chainId from client (%v != %v)", chainId, clientChainId.Uint64())
+	// GetPrice blocks until the first prices were fetched, it falls back to the identity prices if no prices can be fetched
+	fallback := func() {
+		setPrice(elCurrency, elCurrency, 1)
+		setPrice(clCurrency, clCurrency, 1)
+		runOnce.Do(func() { runOnceWg.Done() })
 	}
 
 	feedAddrs := map[string]string{}
@@ -138,17 +128,76 @@ func Init(chainId uint64, eth1Endpoint, clCurrencyParam, elCurrencyParam string)
 		calcPairs["GNO"] = true
 
 		availableCurrencies = []string{"GNO", "mGNO", "DAI", "ETH", "USD", "EUR", "JPY"}
+	case 42:
+		// there are no chainlink feeds on lukso, prices are fetched from coingecko by default
+		cfg.Coingecko.Enabled = true
+
+		availableCurrencies = []string{"LYX", "ETH", "USD", "EUR", "GBP", "CNY", "CAD", "AUD", "JPY"}
 	default:
-		logger.Fatalf("unsupported chainId %v", chainId)
+		// there is no price source for other chains, only the configured providers (e.g. static prices) are used
+		availableCurrencies = []string{clCurrency}
+		if elCurrency != clCurrency {
+			availableCurrencies = append(availableCurrencies, elCurrency)
+		}
+		for pair := range cfg.Static {
+			for _, currency := range strings.Split(pair, "/") {
+				if !IsAvailableCurrency(currency) {
+					availableCurrencies = append(availableCurrencies, currency)
+				}
+			}
+		}
 	}
 
-	for pair, addrHex := range feedAddrs {
-		feed, err := chainlink_feed.NewFeed(common.HexToAddress(addrHex), eClient)
+	if len(feedAddrs) > 0 && !cfg.Chainlink.Disabled {
+		eClient, err := ethclient.Dial(eth1Endpoint)
 		if err != nil {
-			logger.Errorf("failed to initialized chainlink feed for %v (addr: %v): %v", pair, addrHex, err)
+			logger.Errorf("error dialing pricing eth1 endpoint: %v", err)
+			fallback()
 			return
 		}
-		feeds[pair] = feed
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+		defer cancel()
+		clientChainId, err := eClient.ChainID(ctx)
+		if err != nil {
+			logger.WithError(err).Fatalf("failed getting chainID")
+		}
+		if chainId != clientChainId.Uint64() {
+			logger.WithError(err).Fatalf("chainId does not match chainId from client (%v != %v)", chainId, clientChainId.Uint64())
+		}
+
+		chainlinkProvider, err := NewChainlinkProvider(eClient, feedAddrs)
+		if err != nil {
+			logger.Error(err)
+			fallback()
+			return
+		}
+		providers = append(providers, chainlinkProvider)
+	}
+
+	if cfg.Coingecko.Enabled {
+		coingeckoCoins := getCoingeckoCoins(chainId, cfg)
+		fiats := []string{}
+		for _, currency := range availableCurrencies {
+			if _, isCoin := coingeckoCoins[currency]; !isCoin && currency != "mGNO" {
+				fiats = append(fiats, currency)
+			}
+		}
+		providers = append(providers, NewCoingeckoProvider(cfg.Coingecko.BaseUrl, cfg.Coingecko.ApiKey, coingeckoCoins, fiats))
+	}
+
+	if len(cfg.Static) > 0 {
+		providers = append(providers, NewStaticProvider(cfg.Static))
+	}
+
+	if len(providers) == 0 {
+		if len(feedAddrs) == 0 && !cfg.Coingecko.Enabled {
+			logger.Warnf("chainId not supported for fetching prices: %v", chainId)
+		} else {
+			logger.Errorf("no price providers configured for chainId %v", chainId)
+		}
+		fallback()
+		return
 	}
 
 	go func() {
@@ -160,33 +209,49 @@ func Init(chainId uint64, eth1Endpoint, clCurrencyParam, elCurrencyParam string)
 }
 
 func updatePrices() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	quotes := make([]map[string]Quote, len(providers))
 	g := &errgroup.Group{}
-	for pair, feed := range feeds {
-		pair := pair
-		feed := feed
+	for i, provider := range providers {
+		i := i
+		provider := provider
 		g.Go(func() error {
-			price, err := getPriceFromFeed(feed)
+			providerQuotes, err := provider.Prices(ctx)
 			if err != nil {
-				return fmt.Errorf("error getting price from feed for %v: %w", pair, err)
-			}
-			pricesMu.Lock()
-			defer pricesMu.Unlock()
-			prices[pair] = price
-			if pair == "GNO/USD" {
-				prices["mGNO/USD"] = price / 32
+				// a failing provider does not prevent the update as long as the other providers deliver prices
+				logger.WithError(err).Errorf("error getting prices from provider %v", provider.Name())
+				return nil
 			}
+			quotes[i] = providerQuotes
 			return nil
 		})
 	}
-	err := g.Wait()
-	if err != nil {
-		logger.WithError(err).Errorf("error upating prices")
+	_ = g.Wait()
+
+	aggregated, stale := aggregateQuotes(quotes, time.Now(), maxAge)
+	if len(stale) > 0 {
+		logger.WithField("pairs", stale).Warnf("prices are stale, keeping the last known prices")
+	}
+	if len(aggregated) == 0 {
+		logger.Errorf("error upating prices: no provider returned current prices")
 		return
 	}
+
+	pricesMu.Lock()
+	for pair, price := range aggregated {
+		prices[pair] = price
+		if pair == "GNO/USD" {
+			prices["mGNO/USD"] = price / 32
+		}
+	}
+	pricesMu.Unlock()
+
 	for p := range calcPairs {
-		if err = calcPricePairs(p); err != nil {
+		// the prices of the other currencies are still set, e.g. if static prices do not cover all currencies
+		if err := calcPricePairs(p); err != nil {
 			logger.WithError(err).Errorf("error calculating price pairs for %v", p)
-			return
 		}
 	}
 	setPrice(elCurrency, elCurrency, 1)
@@ -195,6 +260,23 @@ func updatePrices() {
 	runOnce.Do(func() { runOnceWg.Done() })
 }
 
+// getCoingeckoCoins returns the coingecko coin ids of the chain merged with the configured coin ids
+func getCoingeckoCoins(chainId uint64, cfg types.PriceProvidersConfig) map[string]string {
+	chainCoins, exists := coingeckoCoinIds[chainId]
+	if !exists {
+		// testnets use the prices of mainnet
+		chainCoins = coingeckoCoinIds[1]
+	}
+	coins := map[string]string{}
+	for currency, id := range chainCoins {
+		coins[currency] = id
+	}
+	for currency, id := range cfg.Coingecko.Coins {
+		coins[currency] = id
+	}
+	return coins
+}
+
 func calcPricePairs(currency string) error {
 	pricesMu.Lock()
 	defer pricesMu.Unlock()
@@ -241,15 +323,6 @@ func GetPrice(a, b string) float64 {
 	return price
 }
 
-func getPriceFromFeed(feed *chainlink_feed.Feed) (float64, error) {
-	decimals := decimal.NewFromInt(1e8) // 8 decimal places for the Chainlink feeds
-	res, err := feed.LatestRoundData(&bind.CallOpts{})
-	if err != nil {
-		return 0, fmt.Errorf("failed to fetch latest chainlink eth/usd price feed data: %w", err)
-	}
-	return decimal.NewFromBigInt(res.Answer, 0).Div(decimals).InexactFloat64(), nil
-}
-
 func GetAvailableCurrencies() []string {
 	return availableCurrencies
 }
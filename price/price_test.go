@@ -0,0 +1,146 @@
+package price
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCoingeckoProvider(t *testing.T) {
+	now := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/simple/price" || r.URL.Query().Get("ids") != "ethereum,lukso-token-2" || r.URL.Query().Get("vs_currencies") != "usd,eur" {
+			t.Errorf("unexpected request %v", r.URL.String())
+		}
+		fmt.Fprintf(w, `{"lukso-token-2":{"usd":2,"eur":1.6,"last_updated_at":%[1]d},"ethereum":{"usd":3000,"eur":2500,"last_updated_at":%[1]d}}`, now.Unix())
+	}))
+	defer server.Close()
+
+	provider := NewCoingeckoProvider(server.URL+"/", "", map[string]string{"LYX": "lukso-token-2", "ETH": "ethereum"}, []string{"USD", "EUR"})
+	quotes, err := provider.Prices(context.Background())
+	if err != nil {
+		t.Fatalf("Prices() error = %v", err)
+	}
+	if quotes["LYX/USD"].Price != 2 || quotes["ETH/USD"].Price != 3000 || quotes["EUR/USD"].Price != 1.2 {
+		t.Errorf("Prices() = %+v", quotes)
+	}
+	if quotes["LYX/USD"].UpdatedAt.Unix() != now.Unix() {
+		t.Errorf("Prices() returned LYX/USD updated at %v, want %v", quotes["LYX/USD"].UpdatedAt, now)
+	}
+}
+
+func TestCoingeckoProviderHistoricPrices(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/coins/ethereum/history" || r.URL.Query().Get("date") != "02-01-2024" || r.Header.Get("x-cg-pro-api-key") != "key" {
+			t.Errorf("unexpected request %v", r.URL.String())
+		}
+		fmt.Fprint(w, `{"market_data":{"current_price":{"usd":2350.5,"eur":2150,"gbp":0}}}`)
+	}))
+	defer server.Close()
+
+	provider := NewCoingeckoProvider(server.URL, "key", map[string]string{"ETH": "ethereum"}, []string{"USD", "EUR", "GBP"})
+	prices, err := provider.HistoricPrices(context.Background(), "ETH", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("HistoricPrices() error = %v", err)
+	}
+	// missing and zero prices are not returned
+	if len(prices) != 2 || prices["USD"] != 2350.5 || prices["EUR"] != 2150 {
+		t.Errorf("HistoricPrices() = %v", prices)
+	}
+
+	_, err = provider.HistoricPrices(context.Background(), "LYX", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Errorf("HistoricPrices() expected an error for a currency without coin id")
+	}
+}
+
+func TestCryptocompareProviderHistoricPrices(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		// the close price of the previous day is the price at the start of the day
+		if r.URL.Path != "/data/pricehistorical" || q.Get("fsym") != "ETH" || q.Get("ts") != fmt.Sprintf("%d", day.Unix()-1) || r.Header.Get("authorization") != "Apikey key" {
+			t.Errorf("unexpected request %v", r.URL.String())
+		}
+		fmt.Fprint(w, `{"ETH":{"USD":2350.5,"EUR":2150,"GBP":0}}`)
+	}))
+	defer server.Close()
+
+	provider := NewCryptocompareProvider(server.URL+"/", "key")
+	prices, err := provider.HistoricPrices(context.Background(), "ETH", day)
+	if err != nil {
+		t.Fatalf("HistoricPrices() error = %v", err)
+	}
+	if len(prices) != 2 || prices["USD"] != 2350.5 || prices["EUR"] != 2150 {
+		t.Errorf("HistoricPrices() = %v", prices)
+	}
+}
+
+func TestHistoricPriceProvidersErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/data/pricehistorical" {
+			fmt.Fprint(w, `{"ETH":`)
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	providers := []HistoricPriceProvider{
+		NewCoingeckoProvider(server.URL, "", map[string]string{"ETH": "ethereum"}, HistoricFiats),
+		NewCryptocompareProvider(server.URL, ""),
+	}
+	for _, provider := range providers {
+		if _, err := provider.HistoricPrices(context.Background(), "ETH", day); err == nil {
+			t.Errorf("%v HistoricPrices() expected an error", provider.Name())
+		}
+	}
+}
+
+func TestAggregateQuotes(t *testing.T) {
+	now := time.Now()
+	quotes := []map[string]Quote{
+		{"LYX/USD": {Price: 2, UpdatedAt: now}, "EUR/USD": {Price: 1.1, UpdatedAt: now.Add(-time.Hour * 2)}},
+		{"LYX/USD": {Price: 3, UpdatedAt: now}},
+		nil,
+		{"LYX/USD": {Price: 10, UpdatedAt: now.Add(-time.Minute)}},
+	}
+	aggregated, stale := aggregateQuotes(quotes, now, time.Hour)
+	if aggregated["LYX/USD"] != 3 {
+		t.Errorf("aggregateQuotes() LYX/USD = %v, want median 3", aggregated["LYX/USD"])
+	}
+	if _, exists := aggregated["EUR/USD"]; exists || len(stale) != 1 || stale[0] != "EUR/USD" {
+		t.Errorf("aggregateQuotes() = %v, stale %v, want EUR/USD to be stale", aggregated, stale)
+	}
+}
+
+func TestAggregateHistoricPrices(t *testing.T) {
+	quotes := map[string]map[string]float64{
+		"coingecko":     {"USD": 2000, "EUR": 1800},
+		"cryptocompare": {"USD": 2010, "EUR": 1810},
+		"static":        {"USD": 2500, "EUR": 2200},
+		"failing":       nil,
+	}
+	historicPrice, err := AggregateHistoricPrices(quotes, 0.05)
+	if err != nil {
+		t.Fatalf("AggregateHistoricPrices() error = %v", err)
+	}
+	if historicPrice.Prices["USD"] != 2005 || historicPrice.Prices["EUR"] != 1805 {
+		t.Errorf("AggregateHistoricPrices() prices = %v, want the median without the outlier", historicPrice.Prices)
+	}
+	if len(historicPrice.Outliers) != 1 || historicPrice.Outliers[0] != "static" || historicPrice.Confidence != 0.5 {
+		t.Errorf("AggregateHistoricPrices() outliers = %v, confidence = %v, want [static], 0.5", historicPrice.Outliers, historicPrice.Confidence)
+	}
+
+	historicPrice, err = AggregateHistoricPrices(map[string]map[string]float64{"coingecko": {"USD": 2000}, "static": {"USD": 3000}}, 0.05)
+	if err != nil {
+		t.Fatalf("AggregateHistoricPrices() error = %v", err)
+	}
+	if historicPrice.Prices["USD"] != 2500 || historicPrice.Confidence != 0 {
+		t.Errorf("AggregateHistoricPrices() = %v, confidence %v, want 2500 with zero confidence if all providers disagree", historicPrice.Prices, historicPrice.Confidence)
+	}
+}
@@ -0,0 +1,80 @@
+package price
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Quote is the price of a pair reported by a provider at a point in time
+type Quote struct {
+	Price     float64
+	UpdatedAt time.Time
+}
+
+// PriceProvider is a source of current prices
+type PriceProvider interface {
+	Name() string
+	// Prices returns the quotes of all pairs offered by the provider keyed by pair, e.g. ETH/USD
+	Prices(ctx context.Context) (map[string]Quote, error)
+}
+
+// StaticProvider returns fixed prices, e.g. for chains where no price source is available
+type StaticProvider struct {
+	prices map[string]float64
+}
+
+func NewStaticProvider(prices map[string]float64) *StaticProvider {
+	return &StaticProvider{prices: prices}
+}
+
+func (p *StaticProvider) Name() string {
+	return "static"
+}
+
+func (p *StaticProvider) Prices(ctx context.Context) (map[string]Quote, error) {
+	now := time.Now()
+	quotes := make(map[string]Quote, len(p.prices))
+	for pair, price := range p.prices {
+		quotes[pair] = Quote{Price: price, UpdatedAt: now}
+	}
+	return quotes, nil
+}
+
+// aggregateQuotes returns the median of the quotes of every pair, quotes older than maxAge and non-positive prices are ignored.
+// Pairs that only have unusable quotes are returned as stale.
+func aggregateQuotes(quotes []map[string]Quote, now time.Time, maxAge time.Duration) (map[string]float64, []string) {
+	pricesByPair := map[string][]float64{}
+	for _, providerQuotes := range quotes {
+		for pair, quote := range providerQuotes {
+			if _, exists := pricesByPair[pair]; !exists {
+				pricesByPair[pair] = []float64{}
+			}
+			if quote.Price <= 0 || now.Sub(quote.UpdatedAt) > maxAge {
+				continue
+			}
+			pricesByPair[pair] = append(pricesByPair[pair], quote.Price)
+		}
+	}
+
+	aggregated := make(map[string]float64, len(pricesByPair))
+	stale := []string{}
+	for pair, pairPrices := range pricesByPair {
+		if len(pairPrices) == 0 {
+			stale = append(stale, pair)
+			continue
+		}
+		aggregated[pair] = median(pairPrices)
+	}
+	sort.Strings(stale)
+	return aggregated, stale
+}
+
+func median(values []float64) float64 {
+	sort.Float64s(values)
+	m := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[m-1] + values[m]) / 2
+	}
+	return values[m]
+}
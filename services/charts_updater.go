@@ -17,6 +17,8 @@ import (
 	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
 
 	"github.com/aybabtme/uniplot/histogram"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 )
 
 type chartHandler struct {
@@ -43,6 +45,7 @@ var ChartHandlers = map[string]chartHandler{
 	"performance_distribution_365d":  {12, performanceDistribution365dChartData},
 	"deposits":                       {13, depositsChartData},
 	"withdrawals":                    {17, withdrawalsChartData},
+	"client_diversity":               {18, clientDiversityChartData},
 	"graffiti_wordcloud":             {14, graffitiCloudChartData},
 	"pools_distribution":             {15, poolsDistributionChartData},
 	"historic_pool_performance":      {16, historicPoolPerformanceData},
@@ -872,6 +875,68 @@ func withdrawalsChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
+func clientDiversityChartData() (*types.GenericChartData, error) {
+	if LatestEpoch() == 0 {
+		return nil, fmt.Errorf("chart-data not available pre-genesis")
+	}
+
+	rows := []struct {
+		Day         int64   `db:"day"`
+		Client      string  `db:"client"`
+		BlocksCount float64 `db:"blocks_count"`
+	}{}
+
+	err := db.ReaderDb.Select(&rows, "SELECT day, client, blocks_count FROM client_diversity_days ORDER BY day")
+	if err != nil {
+		return nil, err
+	}
+
+	seriesByClient := make(map[string][][]float64, len(utils.ConsensusClients)+1)
+	for _, row := range rows {
+		seriesByClient[row.Client] = append(seriesByClient[row.Client], []float64{
+			float64(utils.DayToTime(row.Day).UnixMilli()),
+			row.BlocksCount,
+		})
+	}
+
+	series := make([]*types.GenericChartDataSeries, 0, len(seriesByClient))
+	for _, client := range append(utils.ConsensusClients, utils.UnknownConsensusClient) {
+		if data, ok := seriesByClient[client]; ok {
+			series = append(series, &types.GenericChartDataSeries{
+				Name: cases.Title(language.English).String(client),
+				Data: data,
+			})
+		}
+	}
+
+	chartData := &types.GenericChartData{
+		Title:                "Client Diversity",
+		Subtitle:             "Share of daily proposed blocks by consensus client, estimated from graffitis, proposer history and attestation packing.",
+		XAxisTitle:           "",
+		YAxisTitle:           "% of Blocks",
+		Type:                 "column",
+		StackingMode:         "percent",
+		TooltipShared:        true,
+		TooltipFollowPointer: true,
+		TooltipFormatter: `function(tooltip){
+	let header = '<div style="font-weight:bold; text-align:center;">' + Highcharts.dateFormat("%Y-%m-%d", this.x) + '</div><table>'
+	this.points.sort((a, b) => b.y - a.y)
+	return this.points.reduce(function (s, point) {
+		return s +
+			'<tr><td>' +
+			'<span style="color:' + point.series.color + ';">\u25CF </span>' +
+			'<span style="font-weight:bold;">' + point.series.name + ':</span></td><td>' +
+			point.percentage.toFixed(2) + '% (' + point.y + ' blocks)' +
+			'</td></tr>'
+	}, header) + '</table>'
+}`,
+		TooltipUseHTML: true,
+		Series:         series,
+	}
+
+	return chartData, nil
+}
+
 func poolsDistributionChartData() (*types.GenericChartData, error) {
 
 	type seriesDataItem struct {
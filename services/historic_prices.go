@@ -1,15 +1,20 @@
 package services
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
-	"net/http"
+	"math"
 	"time"
 
 	"github.com/gobitfly/eth2-beaconchain-explorer/db"
 	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
-	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/price"
 	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
 )
 
 func StartHistoricPriceService() {
@@ -20,26 +25,107 @@ func StartHistoricPriceService() {
 }
 
 func WriteHistoricPricesForDay(ts time.Time) error {
-	tsFormatted := ts.Format("01-02-2006")
+	providers, err := price.NewHistoricPriceProviders(utils.Config.Chain.ClConfig.DepositChainID, utils.Config.Prices)
+	if err != nil {
+		return err
+	}
+	_, err = writeHistoricPricesForDay(providers, ts, false)
+	return err
+}
+
+// BackfillHistoricPrices writes the aggregated prices of the providers and their provenance for every day from start to end.
+// Days whose usd price changed are corrected in the MARKET_CAP chart series and finished rewards exports covering them are generated again.
+func BackfillHistoricPrices(providers []price.HistoricPriceProvider, start, end time.Time, dryRun bool) error {
+	corrections := 0
+	failures := 0
+	for day := start.UTC().Truncate(utils.Day); !day.After(end); day = day.Add(utils.Day) {
+		timeStart := time.Now()
+		corrected, err := writeHistoricPricesForDay(providers, day, dryRun)
+		if err != nil {
+			utils.LogError(err, "error backfilling historic prices", 0, map[string]interface{}{"day": day.Format("2006-01-02")})
+			failures++
+		} else {
+			logger.Infof("backfilled historic prices for %v, took %v", day.Format("2006-01-02"), time.Since(timeStart))
+		}
+		if corrected {
+			corrections++
+		}
+
+		if day.Before(end) {
+			// Wait to not overload the APIs
+			time.Sleep(5 * time.Second)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{"corrections": corrections, "failures": failures, "dryRun": dryRun}).Infof("historic price backfill completed")
+	if failures > 0 {
+		return fmt.Errorf("failed to backfill historic prices of %v days", failures)
+	}
+	return nil
+}
 
-	historicPrice, err := fetchHistoricPrice(ts)
+// writeHistoricPricesForDay fetches the prices of a day from all providers and saves their aggregate, it returns whether an existing price was corrected
+func writeHistoricPricesForDay(providers []price.HistoricPriceProvider, ts time.Time, dryRun bool) (bool, error) {
+	tsFormatted := ts.Format("2006-01-02")
+	currency := utils.Config.Frontend.MainCurrency
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	quotes := map[string]map[string]float64{}
+	for _, provider := range providers {
+		logger.Infof("fetching historic prices for day %v from %v", tsFormatted, provider.Name())
+		prices, err := provider.HistoricPrices(ctx, currency, ts)
+		if err != nil {
+			// a failing provider reduces the confidence of the day but does not prevent writing the prices of the others
+			utils.LogError(err, "error fetching historic prices", 0, map[string]interface{}{"provider": provider.Name(), "day": tsFormatted})
+		}
+		quotes[provider.Name()] = prices
+	}
+
+	historicPrice, err := price.AggregateHistoricPrices(quotes, utils.Config.Prices.Historic.OutlierThreshold)
 	if err != nil {
-		return fmt.Errorf("error retrieving historic eth prices for %v: %w", tsFormatted, err)
+		return false, fmt.Errorf("error retrieving historic %v prices for %v: %w", currency, tsFormatted, err)
+	}
+	for _, fiat := range price.HistoricFiats {
+		if historicPrice.Prices[fiat] == 0.0 {
+			return false, fmt.Errorf("incomplete historic %v prices for %v: missing %v", currency, tsFormatted, fiat)
+		}
+	}
+	if len(historicPrice.Outliers) > 0 {
+		logger.WithFields(logrus.Fields{"day": tsFormatted, "quotes": historicPrice.Quotes, "outliers": historicPrice.Outliers}).Warnf("historic price providers disagree")
+	}
+
+	quotesJson, err := json.Marshal(historicPrice.Quotes)
+	if err != nil {
+		return false, fmt.Errorf("error marshalling historic price quotes for %v: %w", tsFormatted, err)
+	}
+
+	var previousUsd float64
+	err = db.WriterDb.Get(&previousUsd, `SELECT usd FROM price WHERE ts = $1`, ts)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("error retrieving previous historic prices for %v: %w", tsFormatted, err)
+	}
+	usd := historicPrice.Prices["USD"]
+	corrected := previousUsd > 0 && math.Abs(usd-previousUsd)/previousUsd > 1e-9
+
+	if dryRun {
+		logger.WithFields(logrus.Fields{
+			"day":        tsFormatted,
+			"prices":     historicPrice.Prices,
+			"sources":    historicPrice.Sources,
+			"confidence": historicPrice.Confidence,
+			"previous":   previousUsd,
+		}).Infof("dry run, not saving historic prices")
+		return corrected, nil
 	}
 
-	if historicPrice.MarketData.CurrentPrice.Eth == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Eur == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Usd == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Rub == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Cny == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Cad == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Jpy == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Gbp == 0.0 ||
-		historicPrice.MarketData.CurrentPrice.Aud == 0.0 {
-		return fmt.Errorf("incomplete historic eth prices for %v", tsFormatted)
+	tx, err := db.WriterDb.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("error starting db transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = db.WriterDb.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO price (ts, eur, usd, rub, cny, cad, jpy, gbp, aud)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		ON CONFLICT (ts) DO UPDATE SET
@@ -52,20 +138,59 @@ func WriteHistoricPricesForDay(ts time.Time) error {
 			gbp = excluded.gbp,
 			aud = excluded.aud`,
 		ts,
-		historicPrice.MarketData.CurrentPrice.Eur,
-		historicPrice.MarketData.CurrentPrice.Usd,
-		historicPrice.MarketData.CurrentPrice.Rub,
-		historicPrice.MarketData.CurrentPrice.Cny,
-		historicPrice.MarketData.CurrentPrice.Cad,
-		historicPrice.MarketData.CurrentPrice.Jpy,
-		historicPrice.MarketData.CurrentPrice.Gbp,
-		historicPrice.MarketData.CurrentPrice.Aud,
+		historicPrice.Prices["EUR"],
+		usd,
+		historicPrice.Prices["RUB"],
+		historicPrice.Prices["CNY"],
+		historicPrice.Prices["CAD"],
+		historicPrice.Prices["JPY"],
+		historicPrice.Prices["GBP"],
+		historicPrice.Prices["AUD"],
 	)
+	if err != nil {
+		return false, fmt.Errorf("error saving historic prices for %v: %w", tsFormatted, err)
+	}
 
+	_, err = tx.Exec(`
+		INSERT INTO price_provenance (ts, sources, outliers, confidence, quotes, updated_ts)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (ts) DO UPDATE SET
+			sources = excluded.sources,
+			outliers = excluded.outliers,
+			confidence = excluded.confidence,
+			quotes = excluded.quotes,
+			updated_ts = excluded.updated_ts`,
+		ts, pq.Array(historicPrice.Sources), pq.Array(historicPrice.Outliers), historicPrice.Confidence, quotesJson)
 	if err != nil {
-		return fmt.Errorf("error saving historic eth prices for %v: %w", tsFormatted, err)
+		return false, fmt.Errorf("error saving historic price provenance for %v: %w", tsFormatted, err)
 	}
-	return nil
+
+	if corrected {
+		err = db.UpdateMarketCapChartSeriesPoint(ts, usd, tx)
+		if err != nil {
+			return false, fmt.Errorf("error re-pricing MARKET_CAP chart_series for %v: %w", tsFormatted, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("error committing historic prices for %v: %w", tsFormatted, err)
+	}
+
+	genesisTs := int64(utils.Config.Chain.GenesisTimestamp)
+	if corrected && ts.Add(utils.Day).Unix() > genesisTs {
+		// rewards are aggregated in days since genesis which are not aligned with utc days
+		startDay := uint64(0)
+		if ts.Unix() > genesisTs {
+			startDay = utils.TimeToDay(uint64(ts.Unix()))
+		}
+		requeued, err := db.RequeueRewardsExports(startDay, utils.TimeToDay(uint64(ts.Add(utils.Day).Unix()-1)))
+		if err != nil {
+			return corrected, err
+		}
+		logger.WithFields(logrus.Fields{"day": tsFormatted, "previous": previousUsd, "usd": usd, "requeuedExports": requeued}).Infof("corrected historic prices")
+	}
+	return corrected, nil
 }
 
 func updateHistoricPrices() error {
@@ -103,23 +228,3 @@ func updateHistoricPrices() error {
 	}
 	return nil
 }
-
-func fetchHistoricPrice(ts time.Time) (*types.HistoricEthPrice, error) {
-	logger.Infof("fetching historic prices for day %v", ts)
-	client := &http.Client{Timeout: time.Second * 10}
-
-	chain := "ethereum"
-
-	if utils.Config.Chain.Name == "gnosis" {
-		chain = "gnosis"
-	}
-	resp, err := client.Get(fmt.Sprintf("https://api.coingecko.com/api/v3/coins/%s/history?date=%s", chain, ts.Truncate(utils.Day).Format("02-01-2006")))
-
-	if err != nil {
-		return nil, err
-	}
-
-	priceData := &types.HistoricEthPrice{}
-	err = json.NewDecoder(resp.Body).Decode(&priceData)
-	return priceData, err
-}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	geth_types "github.com/ethereum/go-ethereum/core/types"
+	geth_rpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// newly seen transactions are written in batches every flush interval
+const mempoolTrackerFlushInterval = time.Second
+
+// maximum number of transactions and heads kept for the next flush after saving the transactions failed
+const mempoolTrackerMaxUnsavedTxs = 100000
+const mempoolTrackerMaxUnsavedHeads = 64
+
+// pending transactions that are no longer part of the mempool are marked as dropped every drop check interval,
+// transactions seen within the grace period are skipped as they might not have been propagated to the queried node yet
+const mempoolTrackerDropCheckInterval = time.Minute
+const mempoolTrackerDropGracePeriod = time.Minute
+
+// StartMempoolTracker streams pending transactions and new heads from the configured websocket endpoint
+// and tracks every transaction from the time it was first seen until it is included, replaced or dropped
+func StartMempoolTracker() {
+	logger.Infof("starting mempool tracker")
+	for {
+		err := trackMempool(utils.Config.Mempool.StreamEndpoint)
+		utils.LogError(err, "error tracking mempool, reconnecting", 0)
+		time.Sleep(time.Second * 10)
+	}
+}
+
+func trackMempool(endpoint string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := geth_rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("error dialing mempool stream endpoint: %w", err)
+	}
+	defer client.Close()
+
+	// full transactions are streamed if the node supports it, otherwise the transactions of the streamed hashes are fetched on flush
+	txs := make(chan *types.RawMempoolTransaction, 1024)
+	hashes := make(chan common.Hash, 1024)
+	txSub, err := client.EthSubscribe(ctx, txs, "newPendingTransactions", true)
+	if err != nil {
+		logger.WithError(err).Warnf("error subscribing to full pending transactions, falling back to pending transaction hashes")
+		txSub, err = client.EthSubscribe(ctx, hashes, "newPendingTransactions")
+		if err != nil {
+			return fmt.Errorf("error subscribing to pending transactions: %w", err)
+		}
+	}
+	defer txSub.Unsubscribe()
+
+	heads := make(chan *geth_types.Header, 16)
+	headSub, err := client.EthSubscribe(ctx, heads, "newHeads")
+	if err != nil {
+		return fmt.Errorf("error subscribing to new heads: %w", err)
+	}
+	defer headSub.Unsubscribe()
+
+	flushTicker := time.NewTicker(mempoolTrackerFlushInterval)
+	defer flushTicker.Stop()
+	dropTicker := time.NewTicker(mempoolTrackerDropCheckInterval)
+	defer dropTicker.Stop()
+	retentionTicker := time.NewTicker(time.Hour)
+	defer retentionTicker.Stop()
+
+	seenTxs := []*types.MempoolTransaction{}
+	seenHashes := map[common.Hash]time.Time{}
+	// heads are only tracked once the transactions seen before them were saved, otherwise transactions that are included
+	// before the next flush would be saved as pending afterwards and marked as dropped
+	unsavedHeads := []*geth_types.Header{}
+	flush := func() {
+		if len(seenHashes) > 0 {
+			seenTxs = append(seenTxs, fetchMempoolTransactions(ctx, client, seenHashes)...)
+			seenHashes = map[common.Hash]time.Time{}
+		}
+		// a failed batch is retried on the next flush, only the newest transactions and heads are kept while the db is unavailable
+		err := db.SaveMempoolTransactions(seenTxs)
+		if err != nil {
+			utils.LogError(err, "error saving mempool transactions", 0, map[string]interface{}{"count": len(seenTxs)})
+			if len(seenTxs) > mempoolTrackerMaxUnsavedTxs {
+				seenTxs = append([]*types.MempoolTransaction{}, seenTxs[len(seenTxs)-mempoolTrackerMaxUnsavedTxs:]...)
+			}
+			if len(unsavedHeads) > mempoolTrackerMaxUnsavedHeads {
+				unsavedHeads = append([]*geth_types.Header{}, unsavedHeads[len(unsavedHeads)-mempoolTrackerMaxUnsavedHeads:]...)
+			}
+			return
+		}
+		seenTxs = []*types.MempoolTransaction{}
+
+		for _, head := range unsavedHeads {
+			err := trackMempoolHead(ctx, client, head)
+			if err != nil {
+				utils.LogError(err, "error tracking included mempool transactions", 0, map[string]interface{}{"block": head.Number})
+			}
+		}
+		unsavedHeads = []*geth_types.Header{}
+	}
+
+	for {
+		select {
+		case err := <-txSub.Err():
+			return fmt.Errorf("pending transactions subscription failed: %w", err)
+		case err := <-headSub.Err():
+			return fmt.Errorf("new heads subscription failed: %w", err)
+		case tx := <-txs:
+			seenTxs = append(seenTxs, newMempoolTransaction(tx, time.Now()))
+		case hash := <-hashes:
+			if _, exists := seenHashes[hash]; !exists {
+				seenHashes[hash] = time.Now()
+			}
+		case head := <-heads:
+			unsavedHeads = append(unsavedHeads, head)
+			flush()
+		case <-flushTicker.C:
+			flush()
+		case <-dropTicker.C:
+			err := trackDroppedMempoolTransactions(ctx, client)
+			if err != nil {
+				utils.LogError(err, "error tracking dropped mempool transactions", 0)
+				continue
+			}
+			ReportStatus("mempoolTracker", "Running", nil)
+		case <-retentionTicker.C:
+			deleted, err := db.DeleteOldMempoolTransactions(time.Now().Add(-utils.Day * time.Duration(utils.Config.Mempool.RetentionDays)))
+			if err != nil {
+				utils.LogError(err, "error deleting old mempool transactions", 0)
+				continue
+			}
+			logger.Infof("deleted %v mempool transactions older than %v days", deleted, utils.Config.Mempool.RetentionDays)
+		}
+	}
+}
+
+// trackMempoolHead marks the tracked transactions of a new block as included
+func trackMempoolHead(ctx context.Context, client *geth_rpc.Client, head *geth_types.Header) error {
+	var block struct {
+		Transactions []common.Hash `json:"transactions"`
+	}
+	err := client.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeBig(head.Number), false)
+	if err != nil {
+		return fmt.Errorf("error retrieving block %v: %w", head.Number, err)
+	}
+
+	hashes := make([][]byte, 0, len(block.Transactions))
+	for _, hash := range block.Transactions {
+		hashes = append(hashes, hash.Bytes())
+	}
+	included, err := db.MarkMempoolTransactionsIncluded(head.Number.Uint64(), time.Unix(int64(head.Time), 0), hashes)
+	if err != nil {
+		return err
+	}
+	logger.WithFields(logrus.Fields{"block": head.Number, "txs": len(hashes), "tracked": included}).Debugf("tracked included mempool transactions")
+	return nil
+}
+
+// trackDroppedMempoolTransactions marks tracked pending transactions that are no longer part of the mempool of the node as dropped
+func trackDroppedMempoolTransactions(ctx context.Context, client *geth_rpc.Client) error {
+	now := time.Now()
+	var mempool types.RawMempoolResponse
+	err := client.CallContext(ctx, &mempool, "txpool_content")
+	if err != nil {
+		return fmt.Errorf("error calling txpool_content: %w", err)
+	}
+
+	poolHashes := [][]byte{}
+	for _, pool := range []map[string]map[string]*types.RawMempoolTransaction{mempool.Pending, mempool.Queued, mempool.BaseFee} {
+		for _, txs := range pool {
+			for _, tx := range txs {
+				poolHashes = append(poolHashes, tx.Hash.Bytes())
+			}
+		}
+	}
+
+	dropped, err := db.MarkMempoolTransactionsDropped(poolHashes, now.Add(-mempoolTrackerDropGracePeriod), now)
+	if err != nil {
+		return err
+	}
+	if dropped > 0 {
+		logger.Infof("marked %v mempool transactions as dropped", dropped)
+	}
+	return nil
+}
+
+// fetchMempoolTransactions retrieves the transactions of streamed hashes, transactions that are already gone are skipped
+func fetchMempoolTransactions(ctx context.Context, client *geth_rpc.Client, hashes map[common.Hash]time.Time) []*types.MempoolTransaction {
+	batch := make([]geth_rpc.BatchElem, 0, len(hashes))
+	for hash := range hashes {
+		batch = append(batch, geth_rpc.BatchElem{
+			Method: "eth_getTransactionByHash",
+			Args:   []interface{}{hash},
+			Result: &types.RawMempoolTransaction{},
+		})
+	}
+	err := client.BatchCallContext(ctx, batch)
+	if err != nil {
+		utils.LogError(err, "error retrieving pending transactions", 0, map[string]interface{}{"count": len(batch)})
+		return nil
+	}
+
+	txs := make([]*types.MempoolTransaction, 0, len(batch))
+	for _, elem := range batch {
+		tx := elem.Result.(*types.RawMempoolTransaction)
+		if elem.Error != nil || tx.From == nil {
+			continue
+		}
+		txs = append(txs, newMempoolTransaction(tx, hashes[tx.Hash]))
+	}
+	return txs
+}
+
+func newMempoolTransaction(tx *types.RawMempoolTransaction, firstSeen time.Time) *types.MempoolTransaction {
+	res := &types.MempoolTransaction{
+		Hash:        tx.Hash.Bytes(),
+		FirstSeenTs: firstSeen,
+		Status:      types.MempoolTxStatusPending,
+	}
+	if tx.From != nil {
+		res.From = tx.From.Bytes()
+	}
+	if tx.To != nil {
+		res.To = tx.To.Bytes()
+	}
+	if tx.Input != nil {
+		input, err := hexutil.Decode(*tx.Input)
+		if err == nil && len(input) >= 4 {
+			res.Method = input[:4]
+		}
+	}
+	if tx.Nonce != nil {
+		res.Nonce = tx.Nonce.ToInt().Uint64()
+	}
+	if tx.Value != nil {
+		res.Value = decimal.NewFromBigInt(tx.Value.ToInt(), 0)
+	}
+	if tx.Gas != nil {
+		res.Gas = tx.Gas.ToInt().Uint64()
+	}
+	// the fee cap is used as gas price of dynamic fee transactions
+	if tx.GasFeeCap != nil {
+		res.GasPrice = decimal.NewFromBigInt(tx.GasFeeCap.ToInt(), 0)
+	} else if tx.GasPrice != nil {
+		res.GasPrice = decimal.NewFromBigInt(tx.GasPrice.ToInt(), 0)
+	}
+	if tx.GasTipCap != nil {
+		res.MaxPriorityFeePerGas = decimal.NullDecimal{Decimal: decimal.NewFromBigInt(tx.GasTipCap.ToInt(), 0), Valid: true}
+	}
+	return res
+}
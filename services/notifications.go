@@ -368,6 +368,27 @@ func collectUserDbNotifications(epoch uint64) (map[uint64]map[types.EventName][]
 		return nil, fmt.Errorf("error collecting Eth client memory notifications: %v", err)
 	}
 
+	// Monitoring (premium): execution client out of sync
+	err = collectMonitoringMachineExecutionOutOfSync(notificationsByUserID, epoch)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_collect_monitoring_machine_execution_out_of_sync").Inc()
+		return nil, fmt.Errorf("error collecting Eth client execution out of sync notifications: %v", err)
+	}
+
+	// Monitoring (premium): execution client peer count
+	err = collectMonitoringMachineExecutionPeerCountLow(notificationsByUserID, epoch)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_collect_monitoring_machine_execution_peer_count_low").Inc()
+		return nil, fmt.Errorf("error collecting Eth client execution peer count notifications: %v", err)
+	}
+
+	// Monitoring (premium): custom alerts
+	err = collectMonitoringMachineCustomAlerts(notificationsByUserID, epoch)
+	if err != nil {
+		metrics.Errors.WithLabelValues("notifications_collect_monitoring_machine_custom_alerts").Inc()
+		return nil, fmt.Errorf("error collecting Eth client custom alert notifications: %v", err)
+	}
+
 	// New ETH clients
 	err = collectEthClientNotifications(notificationsByUserID, types.EthClientUpdateEventName)
 	if err != nil {
@@ -2285,6 +2306,202 @@ func collectMonitoringMachineMemoryUsage(notificationsByUserID map[uint64]map[ty
 	)
 }
 
+type machineAlertEvent struct {
+	types.MachineMetricAlert
+	SubscriptionID uint64 `db:"subscription_id"`
+}
+
+// collectMonitoringMachineCustomAlerts evaluates the custom machine metric alerts of all users against the raw metrics within their duration window
+func collectMonitoringMachineCustomAlerts(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
+	var alerts []machineAlertEvent
+	err := db.FrontendWriterDB.Select(&alerts,
+		`SELECT
+			a.id,
+			a.user_id,
+			a.machine,
+			a.process,
+			a.field,
+			a.mode,
+			a.comparator,
+			a.threshold,
+			a.duration_minutes,
+			a.created_ts,
+			us.id AS subscription_id
+		FROM users_machine_alerts a
+		INNER JOIN users_subscriptions us ON us.user_id = a.user_id AND us.event_name = $1 AND us.event_filter = a.id::TEXT
+		WHERE us.created_epoch <= $2`,
+		types.MonitoringMachineCustomAlertEventName, epoch)
+	if err != nil {
+		return err
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	// notifications are sent for a subscription per alert and machine, so an alert of one machine does not suppress the alerts of other machines
+	machineSubscriptions, err := db.GetMachineMetricAlertMachineSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	// read the metrics of all machines of a process once, machine scoped alerts pick their row afterwards
+	now := time.Now()
+	maxWindow := time.Duration(0)
+	prefixes := map[string]bool{}
+	rowRanges := gcp_bigtable.RowRangeList{}
+	for _, alert := range alerts {
+		if window := time.Duration(alert.DurationMinutes) * time.Minute; window > maxWindow {
+			maxWindow = window
+		}
+		prefix := db.BigtableClient.GetMachineRowKey(alert.UserID, alert.Process, "")
+		if !prefixes[prefix] {
+			prefixes[prefix] = true
+			rowRanges = append(rowRanges, gcp_bigtable.PrefixRange(prefix))
+		}
+	}
+
+	// rate alerts need one additional metric before the window starts
+	machineMetrics, err := db.BigtableClient.GetMachineMetricsForAlerts(rowRanges, now.Add(-maxWindow-time.Minute*15))
+	if err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		prefix := db.BigtableClient.GetMachineRowKey(alert.UserID, alert.Process, "")
+		for rowKey, rowMetrics := range machineMetrics {
+			if !strings.HasPrefix(rowKey, prefix) {
+				continue
+			}
+			machine := strings.TrimPrefix(rowKey, prefix)
+			if alert.Machine != "" && alert.Machine != machine {
+				continue
+			}
+
+			triggered, value := utils.EvaluateMachineMetricAlert(&alert.MachineMetricAlert, utils.MachineMetricAlertValues(&alert.MachineMetricAlert, rowMetrics), now)
+			if !triggered {
+				continue
+			}
+
+			subscriptionID := uint64(0)
+			unsubscribeHash := sql.NullString{}
+			if sub, exists := machineSubscriptions[db.MachineMetricAlertMachineFilter(alert.ID, machine)]; exists {
+				if sub.LastEpoch != nil && *sub.LastEpoch+10 >= epoch {
+					continue
+				}
+				subscriptionID = *sub.ID
+				unsubscribeHash = sub.UnsubscribeHash
+			} else {
+				id, hash, err := db.AddMachineMetricAlertMachineSubscription(alert.SubscriptionID, alert.ID, machine, epoch)
+				if err != nil {
+					return err
+				}
+				subscriptionID = id
+				unsubscribeHash = sql.NullString{String: hash, Valid: true}
+			}
+
+			n := &monitorMachineAlertNotification{
+				SubscriptionID:  subscriptionID,
+				MachineName:     machine,
+				UserID:          alert.UserID,
+				Epoch:           epoch,
+				UnsubscribeHash: unsubscribeHash,
+				Alert:           alert.MachineMetricAlert,
+				Value:           value,
+			}
+			if _, exists := notificationsByUserID[alert.UserID]; !exists {
+				notificationsByUserID[alert.UserID] = map[types.EventName][]types.Notification{}
+			}
+			notificationsByUserID[alert.UserID][n.GetEventName()] = append(notificationsByUserID[alert.UserID][n.GetEventName()], n)
+			metrics.NotificationsCollected.WithLabelValues(string(n.GetEventName())).Inc()
+		}
+	}
+
+	return nil
+}
+
+func collectMonitoringMachineExecutionOutOfSync(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
+	return collectMonitoringMachineExecution(notificationsByUserID, types.MonitoringMachineExecutionOutOfSyncEventName,
+		// the execution client is behind the highest known block by more than the threshold for 5 minutes
+		func(subscribeData *MachineEvents) *types.MachineMetricAlert {
+			threshold := subscribeData.EventThreshold
+			if threshold <= 0 {
+				threshold = 10
+			}
+			return &types.MachineMetricAlert{Process: "executionnode", Field: utils.MachineMetricAlertSyncDistanceField, Mode: "value", Comparator: "gt", Threshold: threshold, DurationMinutes: 5}
+		},
+		epoch,
+	)
+}
+
+func collectMonitoringMachineExecutionPeerCountLow(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, epoch uint64) error {
+	return collectMonitoringMachineExecution(notificationsByUserID, types.MonitoringMachineExecutionPeerCountLowEventName,
+		// the execution client is connected to less peers than the threshold for 10 minutes
+		func(subscribeData *MachineEvents) *types.MachineMetricAlert {
+			threshold := subscribeData.EventThreshold
+			if threshold <= 0 {
+				threshold = 5
+			}
+			return &types.MachineMetricAlert{Process: "executionnode", Field: "network_peers_connected", Mode: "value", Comparator: "lt", Threshold: threshold, DurationMinutes: 10}
+		},
+		epoch,
+	)
+}
+
+// collectMonitoringMachineExecution notifies the subscribers of an execution node event whose machine fulfills the alert condition of the subscription
+func collectMonitoringMachineExecution(
+	notificationsByUserID map[uint64]map[types.EventName][]types.Notification,
+	eventName types.EventName,
+	subscriptionAlert func(subscribeData *MachineEvents) *types.MachineMetricAlert,
+	epoch uint64,
+) error {
+	allSubscribed, err := getMachineEventSubscriptions(eventName, 10, epoch)
+	if err != nil {
+		return err
+	}
+	if len(allSubscribed) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	maxWindow := time.Duration(0)
+	alerts := make([]*types.MachineMetricAlert, len(allSubscribed))
+	rowRanges := gcp_bigtable.RowRangeList{}
+	for i, data := range allSubscribed {
+		alerts[i] = subscriptionAlert(&data)
+		if window := time.Duration(alerts[i].DurationMinutes) * time.Minute; window > maxWindow {
+			maxWindow = window
+		}
+		rowRanges = append(rowRanges, gcp_bigtable.PrefixRange(db.BigtableClient.GetMachineRowKey(data.UserID, "executionnode", data.MachineName)))
+	}
+
+	machineMetrics, err := db.BigtableClient.GetMachineMetricsForAlerts(rowRanges, now.Add(-maxWindow-time.Minute*5))
+	if err != nil {
+		return err
+	}
+
+	var result []MachineEvents
+	for i, data := range allSubscribed {
+		rowMetrics, found := machineMetrics[db.BigtableClient.GetMachineRowKey(data.UserID, "executionnode", data.MachineName)]
+		if !found {
+			continue
+		}
+		triggered, _ := utils.EvaluateMachineMetricAlert(alerts[i], utils.MachineMetricAlertValues(alerts[i], rowMetrics), now)
+		if triggered {
+			result = append(result, data)
+		}
+	}
+
+	tooMany, err := tooManyMachineNotifications(eventName, len(result), len(allSubscribed))
+	if err != nil {
+		return err
+	}
+	if tooMany {
+		return nil
+	}
+
+	addMonitorMachineNotifications(notificationsByUserID, eventName, result, epoch)
+	return nil
+}
+
 var isFirstNotificationCheck = true
 
 func collectMonitoringMachine(
@@ -2295,19 +2512,7 @@ func collectMonitoringMachine(
 	epoch uint64,
 ) error {
 
-	var allSubscribed []MachineEvents
-	err := db.FrontendWriterDB.Select(&allSubscribed,
-		`SELECT 
-			us.user_id,
-			max(us.id) AS id,
-			ENCODE((array_agg(us.unsubscribe_hash))[1], 'hex') AS unsubscribe_hash,
-			event_filter AS machine,
-			COALESCE(event_threshold, 0) AS event_threshold
-		FROM users_subscriptions us 
-		WHERE us.event_name = $1 AND us.created_epoch <= $2 
-		AND (us.last_sent_epoch < ($2 - $3) OR us.last_sent_epoch IS NULL)
-		group by us.user_id, machine, event_threshold`,
-		eventName, epoch, epochWaitInBetween)
+	allSubscribed, err := getMachineEventSubscriptions(eventName, epochWaitInBetween, epoch)
 	if err != nil {
 		return err
 	}
@@ -2339,6 +2544,44 @@ func collectMonitoringMachine(
 		}
 	}
 
+	tooMany, err := tooManyMachineNotifications(eventName, len(result), len(allSubscribed))
+	if err != nil {
+		return err
+	}
+	if tooMany {
+		return nil
+	}
+
+	addMonitorMachineNotifications(notificationsByUserID, eventName, result, epoch)
+
+	if eventName == types.MonitoringMachineOfflineEventName {
+		// Notifications will be sent, reset the flag
+		isFirstNotificationCheck = true
+	}
+
+	return nil
+}
+
+// getMachineEventSubscriptions returns the subscriptions of a machine event that were not notified within the last epochWaitInBetween epochs
+func getMachineEventSubscriptions(eventName types.EventName, epochWaitInBetween int, epoch uint64) ([]MachineEvents, error) {
+	var allSubscribed []MachineEvents
+	err := db.FrontendWriterDB.Select(&allSubscribed,
+		`SELECT 
+			us.user_id,
+			max(us.id) AS id,
+			ENCODE((array_agg(us.unsubscribe_hash))[1], 'hex') AS unsubscribe_hash,
+			event_filter AS machine,
+			COALESCE(event_threshold, 0) AS event_threshold
+		FROM users_subscriptions us 
+		WHERE us.event_name = $1 AND us.created_epoch <= $2 
+		AND (us.last_sent_epoch < ($2 - $3) OR us.last_sent_epoch IS NULL)
+		group by us.user_id, machine, event_threshold`,
+		eventName, epoch, epochWaitInBetween)
+	return allSubscribed, err
+}
+
+// tooManyMachineNotifications returns true if the share of subscriptions that would be notified about a machine event is so high that it is likely caused by an issue on our side
+func tooManyMachineNotifications(eventName types.EventName, notifyCount, subscribedCount int) (bool, error) {
 	subThreshold := uint64(10)
 	if utils.Config.Notifications.MachineEventThreshold != 0 {
 		subThreshold = utils.Config.Notifications.MachineEventThreshold
@@ -2355,14 +2598,14 @@ func collectMonitoringMachine(
 	}
 
 	var subScriptionCount uint64
-	err = db.FrontendWriterDB.Get(&subScriptionCount,
+	err := db.FrontendWriterDB.Get(&subScriptionCount,
 		`SELECT 
 			COUNT(DISTINCT user_id)
 			FROM users_subscriptions
 			WHERE event_name = $1`,
 		eventName)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	// If there are too few users subscribed to this event, we always send the notifications
@@ -2373,12 +2616,15 @@ func collectMonitoringMachine(
 			subRatioThreshold = subFirstRatioThreshold
 			isFirstNotificationCheck = false
 		}
-		if float64(len(result))/float64(len(allSubscribed)) >= subRatioThreshold {
+		if float64(notifyCount)/float64(subscribedCount) >= subRatioThreshold {
 			utils.LogError(nil, fmt.Errorf("error too many users would be notified concerning: %v", eventName), 0)
-			return nil
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
+func addMonitorMachineNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName, result []MachineEvents, epoch uint64) {
 	for _, r := range result {
 
 		n := &monitorMachineNotification{
@@ -2399,13 +2645,6 @@ func collectMonitoringMachine(
 		notificationsByUserID[r.UserID][n.GetEventName()] = append(notificationsByUserID[r.UserID][n.GetEventName()], n)
 		metrics.NotificationsCollected.WithLabelValues(string(n.GetEventName())).Inc()
 	}
-
-	if eventName == types.MonitoringMachineOfflineEventName {
-		// Notifications will be sent, reset the flag
-		isFirstNotificationCheck = true
-	}
-
-	return nil
 }
 
 type monitorMachineNotification struct {
@@ -2458,6 +2697,10 @@ func (n *monitorMachineNotification) GetInfo(includeUrl bool) string {
 		return fmt.Sprintf(`Your staking machine "%v" has switched to your configured ETH2 fallback`, n.MachineName)
 	case types.MonitoringMachineMemoryUsageEventName:
 		return fmt.Sprintf(`Your staking machine "%v" has reached your configured RAM threshold.`, n.MachineName)
+	case types.MonitoringMachineExecutionOutOfSyncEventName:
+		return fmt.Sprintf(`The execution client of your staking machine "%v" is out of sync.`, n.MachineName)
+	case types.MonitoringMachineExecutionPeerCountLowEventName:
+		return fmt.Sprintf(`The execution client of your staking machine "%v" is connected to less peers than your configured threshold.`, n.MachineName)
 	}
 	return ""
 }
@@ -2476,6 +2719,10 @@ func (n *monitorMachineNotification) GetTitle() string {
 		return "ETH2 Fallback Active"
 	case types.MonitoringMachineMemoryUsageEventName:
 		return "Memory Warning"
+	case types.MonitoringMachineExecutionOutOfSyncEventName:
+		return "Execution Client Out of Sync"
+	case types.MonitoringMachineExecutionPeerCountLowEventName:
+		return "Execution Client Peer Count Low"
 	}
 	return ""
 }
@@ -2488,57 +2735,92 @@ func (n *monitorMachineNotification) GetInfoMarkdown() string {
 	return n.GetInfo(false)
 }
 
-type taxReportNotification struct {
+type monitorMachineAlertNotification struct {
 	SubscriptionID  uint64
+	MachineName     string
 	UserID          uint64
 	Epoch           uint64
-	EventFilter     string
 	UnsubscribeHash sql.NullString
+	Alert           types.MachineMetricAlert
+	Value           float64
 }
 
-func (n *taxReportNotification) GetLatestState() string {
+func (n *monitorMachineAlertNotification) GetLatestState() string {
 	return ""
 }
 
-func (n *taxReportNotification) GetUnsubscribeHash() string {
+func (n *monitorMachineAlertNotification) GetUnsubscribeHash() string {
 	if n.UnsubscribeHash.Valid {
 		return n.UnsubscribeHash.String
 	}
 	return ""
 }
 
-func (n *taxReportNotification) GetEmailAttachment() *types.EmailAttachment {
-	tNow := time.Now()
-	lastDay := time.Date(tNow.Year(), tNow.Month(), 1, 0, 0, 0, 0, time.UTC)
-	firstDay := lastDay.AddDate(0, -1, 0)
+func (n *monitorMachineAlertNotification) GetEmailAttachment() *types.EmailAttachment {
+	return nil
+}
 
-	q, err := url.ParseQuery(n.EventFilter)
+func (n *monitorMachineAlertNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
 
-	if err != nil {
-		logger.Warn("Failed to parse rewards report eventfilter")
-		return nil
-	}
+func (n *monitorMachineAlertNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
 
-	currency := q.Get("currency")
+func (n *monitorMachineAlertNotification) GetEventName() types.EventName {
+	return types.MonitoringMachineCustomAlertEventName
+}
 
-	validators := []uint64{}
-	valSlice := strings.Split(q.Get("validators"), ",")
-	if len(valSlice) > 0 {
-		for _, val := range valSlice {
-			v, err := strconv.ParseUint(val, 10, 64)
-			if err != nil {
-				continue
-			}
-			validators = append(validators, v)
-		}
-	} else {
-		logger.Warn("Validators Not found in rewards report eventfilter")
-		return nil
+func (n *monitorMachineAlertNotification) GetInfo(includeUrl bool) string {
+	field := n.Alert.Field
+	if n.Alert.Mode == "rate" {
+		field += " per second"
+	}
+	condition := fmt.Sprintf("%v %v %v", field, utils.MachineMetricAlertComparatorSymbol(n.Alert.Comparator), n.Alert.Threshold)
+	if n.Alert.DurationMinutes > 0 {
+		condition += fmt.Sprintf(" for %v minutes", n.Alert.DurationMinutes)
 	}
+	return fmt.Sprintf(`Your %v process on staking machine "%v" has reached your custom alert %v, the current value is %.2f.`, n.Alert.Process, n.MachineName, condition, n.Value)
+}
+
+func (n *monitorMachineAlertNotification) GetTitle() string {
+	return "Custom Machine Alert"
+}
+
+func (n *monitorMachineAlertNotification) GetEventFilter() string {
+	return n.MachineName
+}
+
+func (n *monitorMachineAlertNotification) GetInfoMarkdown() string {
+	return n.GetInfo(false)
+}
+
+type taxReportNotification struct {
+	SubscriptionID  uint64
+	UserID          uint64
+	Epoch           uint64
+	EventFilter     string
+	UnsubscribeHash sql.NullString
+	Report          *types.UserReport
+}
+
+func (n *taxReportNotification) GetLatestState() string {
+	return ""
+}
 
-	pdf := GetPdfReport(validators, currency, uint64(firstDay.Unix()), uint64(lastDay.Unix()))
+func (n *taxReportNotification) GetUnsubscribeHash() string {
+	if n.UnsubscribeHash.Valid {
+		return n.UnsubscribeHash.String
+	}
+	return ""
+}
 
-	return &types.EmailAttachment{Attachment: pdf, Name: fmt.Sprintf("income_history_%v_%v.pdf", firstDay.Format("20060102"), lastDay.Format("20060102"))}
+func (n *taxReportNotification) GetEmailAttachment() *types.EmailAttachment {
+	if n.Report == nil {
+		return nil
+	}
+	return &types.EmailAttachment{Attachment: n.Report.Data, Name: n.Report.Filename}
 }
 
 func (n *taxReportNotification) GetSubscriptionID() uint64 {
@@ -2555,6 +2837,15 @@ func (n *taxReportNotification) GetEventName() types.EventName {
 
 func (n *taxReportNotification) GetInfo(includeUrl bool) string {
 	generalPart := `Please find attached the income history of your selected validators.`
+	if n.Report != nil {
+		generalPart = fmt.Sprintf(`Please find attached the income history of your selected validators from %v to %v.`, n.Report.PeriodStart.Format("2006-01-02"), n.Report.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"))
+		if n.Report.Name != "" {
+			generalPart = fmt.Sprintf(`Please find attached your income report "%v" from %v to %v.`, n.Report.Name, n.Report.PeriodStart.Format("2006-01-02"), n.Report.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"))
+		}
+	}
+	if includeUrl {
+		generalPart += ` All past reports are available at <a href="https://` + utils.Config.Frontend.SiteDomain + `/user/reports">` + utils.Config.Frontend.SiteDomain + `/user/reports</a>.`
+	}
 	return generalPart
 }
 
@@ -2570,24 +2861,22 @@ func (n *taxReportNotification) GetInfoMarkdown() string {
 	return n.GetInfo(false)
 }
 
+// collectTaxReportNotificationNotifications generates the reports of all schedules whose latest period has ended since the last report was sent.
+// The period boundaries are calculated in the timezone of the schedule, a report is only generated once all days of the period are exported.
 func collectTaxReportNotificationNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName) error {
 	lastStatsDay, err := LatestExportedStatisticDay()
 
 	if err != nil {
 		return err
 	}
-	//Check that the last day of the month is already exported
-	tNow := time.Now()
-	firstDayOfMonth := time.Date(tNow.Year(), tNow.Month(), 1, 0, 0, 0, 0, time.UTC)
-	if utils.TimeToDay(uint64(firstDayOfMonth.Unix())) > lastStatsDay {
-		return nil
-	}
 
 	var dbResult []struct {
 		SubscriptionID  uint64         `db:"id"`
 		UserID          uint64         `db:"user_id"`
 		Epoch           uint64         `db:"created_epoch"`
 		EventFilter     string         `db:"event_filter"`
+		LastSent        sql.NullTime   `db:"last_sent_ts"`
+		Created         time.Time      `db:"created_ts"`
 		UnsubscribeHash sql.NullString `db:"unsubscribe_hash"`
 	}
 
@@ -2597,23 +2886,52 @@ func collectTaxReportNotificationNotifications(notificationsByUserID map[uint64]
 	}
 
 	err = db.FrontendWriterDB.Select(&dbResult, `
-			SELECT us.id, us.user_id, us.created_epoch, us.event_filter, ENCODE(us.unsubscribe_hash, 'hex') AS unsubscribe_hash
+			SELECT us.id, us.user_id, us.created_epoch, us.event_filter, us.last_sent_ts, us.created_ts, ENCODE(us.unsubscribe_hash, 'hex') AS unsubscribe_hash
 			FROM users_subscriptions AS us
-			WHERE us.event_name=$1 AND (us.last_sent_ts < $2 OR (us.last_sent_ts IS NULL AND us.created_ts < $2));
+			WHERE us.event_name=$1;
 			`,
-		name, firstDayOfMonth)
+		name)
 
 	if err != nil {
 		return err
 	}
 
+	tNow := time.Now()
 	for _, r := range dbResult {
+		schedule, err := types.ParseTaxReportSchedule(r.EventFilter)
+		if err != nil {
+			logger.Warnf("error parsing tax report schedule of subscription %v: %v", r.SubscriptionID, err)
+			continue
+		}
+
+		start, end := schedule.LastCompletedPeriod(tNow)
+		lastSent := r.Created
+		if r.LastSent.Valid {
+			lastSent = r.LastSent.Time
+		}
+		if !lastSent.Before(end) {
+			continue
+		}
+
+		// check that the last day of the period is already exported
+		_, lastDay, ok := taxReportDayRange(start, end)
+		if !ok || lastDay > lastStatsDay {
+			continue
+		}
+
+		report, err := GenerateTaxReport(r.UserID, r.SubscriptionID, schedule, start, end)
+		if err != nil {
+			utils.LogError(err, "error generating tax report", 0, map[string]interface{}{"subscription_id": r.SubscriptionID, "user_id": r.UserID})
+			continue
+		}
+
 		n := &taxReportNotification{
 			SubscriptionID:  r.SubscriptionID,
 			UserID:          r.UserID,
 			Epoch:           r.Epoch,
 			EventFilter:     r.EventFilter,
 			UnsubscribeHash: r.UnsubscribeHash,
+			Report:          report,
 		}
 		if _, exists := notificationsByUserID[r.UserID]; !exists {
 			notificationsByUserID[r.UserID] = map[types.EventName][]types.Notification{}
@@ -58,27 +58,7 @@ func GetValidatorHist(validatorArr []uint64, currency string, start uint64, end
 	for _, item := range pricesDb {
 		date := fmt.Sprintf("%v", item.TS)
 		date = strings.Split(date, " ")[0]
-		switch currency {
-		case "eur":
-			prices[date] = item.EUR
-		case "usd":
-			prices[date] = item.USD
-		case "gbp":
-			prices[date] = item.GBP
-		case "cad":
-			prices[date] = item.CAD
-		case "cny":
-			prices[date] = item.CNY
-		case "jpy":
-			prices[date] = item.JPY
-		case "rub":
-			prices[date] = item.RUB
-		case "aud":
-			prices[date] = item.AUD
-		default:
-			prices[date] = item.USD
-			currency = "usd"
-		}
+		prices[date], currency = getPriceForCurrency(item, currency)
 	}
 
 	data := make([][]string, len(income))
@@ -109,6 +89,30 @@ func GetValidatorHist(validatorArr []uint64, currency string, start uint64, end
 	}
 }
 
+// getPriceForCurrency returns the price in the given currency, unsupported currencies fall back to usd
+func getPriceForCurrency(item types.Price, currency string) (float64, string) {
+	switch currency {
+	case "eur":
+		return item.EUR, currency
+	case "usd":
+		return item.USD, currency
+	case "gbp":
+		return item.GBP, currency
+	case "cad":
+		return item.CAD, currency
+	case "cny":
+		return item.CNY, currency
+	case "jpy":
+		return item.JPY, currency
+	case "rub":
+		return item.RUB, currency
+	case "aud":
+		return item.AUD, currency
+	default:
+		return item.USD, "usd"
+	}
+}
+
 func addCommas(balance float64, decimals string) string {
 	p := message.NewPrinter(language.English)
 	rb := []rune(p.Sprintf(decimals, balance))
@@ -0,0 +1,283 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/metrics"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+// exports that cover more than this amount of validator days are generated in the background
+const RewardsExportSyncValidatorDayLimit = 31 * 100
+
+// finished background exports are deleted after this amount of days
+const rewardsExportRetentionDays = 7
+
+// GetRewardsLedger returns the rewards ledger of the given validators for the days [startDay, endDay]
+// with the historic price of the respective day attached to every entry.
+// The cost basis of a validator starts at zero at the beginning of the requested range, income and deposits
+// increase it by their fiat value at the time of receipt while withdrawals decrease it by their share of the average cost.
+func GetRewardsLedger(validators []uint64, currency string, startDay, endDay uint64) ([]*types.RewardsLedgerEntry, string, error) {
+	ledger, err := db.GetValidatorRewardsLedger(validators, startDay, endDay)
+	if err != nil {
+		return nil, currency, err
+	}
+
+	var pricesDb []types.Price
+	err = db.ReaderDb.Select(&pricesDb,
+		`select ts, eur, usd, gbp, cad, jpy, cny, rub, aud from price where ts >= $1 and ts <= $2 order by ts`,
+		utils.DayToTime(int64(startDay)).Add(-utils.Day), utils.DayToTime(int64(endDay)).Add(utils.Day))
+	if err != nil {
+		return nil, currency, fmt.Errorf("error getting prices for rewards ledger: %w", err)
+	}
+
+	prices := map[string]float64{}
+	for _, item := range pricesDb {
+		prices[item.TS.Format("2006-01-02")], currency = getPriceForCurrency(item, currency)
+	}
+	if len(pricesDb) == 0 {
+		_, currency = getPriceForCurrency(types.Price{}, currency)
+	}
+
+	type position struct {
+		Holdings  decimal.Decimal
+		CostBasis decimal.Decimal
+	}
+	positions := map[uint64]*position{}
+
+	for _, e := range ledger {
+		e.Currency = strings.ToUpper(currency)
+		e.Price = prices[e.Date.UTC().Format("2006-01-02")]
+		e.Value, _ = e.Amount.Mul(decimal.NewFromFloat(e.Price)).Float64()
+
+		p := positions[e.ValidatorIndex]
+		if p == nil {
+			p = &position{}
+			positions[e.ValidatorIndex] = p
+		}
+		if e.Type == types.RewardsLedgerWithdrawal {
+			if p.Holdings.IsPositive() {
+				share := decimal.Min(e.Amount.Div(p.Holdings), decimal.NewFromInt(1))
+				p.CostBasis = p.CostBasis.Sub(p.CostBasis.Mul(share))
+			}
+			p.Holdings = decimal.Max(p.Holdings.Sub(e.Amount), decimal.Zero)
+		} else {
+			p.Holdings = p.Holdings.Add(e.Amount)
+			p.CostBasis = p.CostBasis.Add(decimal.NewFromFloat(e.Value))
+		}
+		e.CostBasis, _ = p.CostBasis.Float64()
+	}
+
+	return ledger, currency, nil
+}
+
+// GenerateRewardsExport encodes the rewards ledger in the requested format
+func GenerateRewardsExport(ledger []*types.RewardsLedgerEntry, currency string, format types.RewardsExportFormat) ([]byte, error) {
+	asset := utils.Config.Frontend.ClCurrency
+	currency = strings.ToUpper(currency)
+
+	switch format {
+	case types.RewardsExportFormatJSON:
+		return json.Marshal(ledger)
+	case types.RewardsExportFormatCSV:
+		return encodeCsv(rewardsLedgerRows(ledger, asset, currency))
+	case types.RewardsExportFormatXLSX:
+		return utils.GenerateXlsx("Rewards", rewardsLedgerRows(ledger, asset, currency))
+	case types.RewardsExportFormatKoinly:
+		return encodeCsv(koinlyRows(ledger, asset, currency))
+	case types.RewardsExportFormatCoinTracking:
+		return encodeCsv(coinTrackingRows(ledger, asset))
+	default:
+		return nil, fmt.Errorf("unsupported rewards export format %v", format)
+	}
+}
+
+// RewardsExportContentType returns the content type and file extension of a rewards export format
+func RewardsExportContentType(format types.RewardsExportFormat) (string, string) {
+	switch format {
+	case types.RewardsExportFormatJSON:
+		return "application/json", "json"
+	case types.RewardsExportFormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", "xlsx"
+	default:
+		return "text/csv", "csv"
+	}
+}
+
+func IsValidRewardsExportFormat(format types.RewardsExportFormat) bool {
+	switch format {
+	case types.RewardsExportFormatCSV, types.RewardsExportFormatXLSX, types.RewardsExportFormatKoinly, types.RewardsExportFormatCoinTracking, types.RewardsExportFormatJSON:
+		return true
+	}
+	return false
+}
+
+func rewardsLedgerRows(ledger []*types.RewardsLedgerEntry, asset, currency string) [][]string {
+	rows := make([][]string, 0, len(ledger)+1)
+	rows = append(rows, []string{
+		"Date",
+		"Validator",
+		"Type",
+		"Slot",
+		fmt.Sprintf("Amount (%s)", asset),
+		fmt.Sprintf("Price (%s)", currency),
+		fmt.Sprintf("Value (%s)", currency),
+		fmt.Sprintf("Cost Basis (%s)", currency),
+	})
+	for _, e := range ledger {
+		slot := ""
+		if e.Slot > 0 {
+			slot = fmt.Sprintf("%d", e.Slot)
+		}
+		rows = append(rows, []string{
+			e.Date.UTC().Format("2006-01-02 15:04:05"),
+			fmt.Sprintf("%d", e.ValidatorIndex),
+			string(e.Type),
+			slot,
+			e.Amount.String(),
+			fmt.Sprintf("%.6f", e.Price),
+			fmt.Sprintf("%.2f", e.Value),
+			fmt.Sprintf("%.2f", e.CostBasis),
+		})
+	}
+	return rows
+}
+
+// koinlyRows returns the ledger in the Koinly universal import format
+func koinlyRows(ledger []*types.RewardsLedgerEntry, asset, currency string) [][]string {
+	rows := make([][]string, 0, len(ledger)+1)
+	rows = append(rows, []string{"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency", "Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency", "Label", "Description", "TxHash"})
+	for _, e := range ledger {
+		amount := e.Amount.Abs().String()
+		row := make([]string, 12)
+		row[0] = e.Date.UTC().Format("2006-01-02 15:04:05 UTC")
+		switch {
+		case e.Type == types.RewardsLedgerDeposit:
+			row[1] = amount
+			row[2] = asset
+			row[9] = "stake"
+		case e.Type == types.RewardsLedgerWithdrawal:
+			row[3] = amount
+			row[4] = asset
+			row[9] = "unstake"
+		case e.Amount.IsNegative():
+			// days with a net consensus penalty
+			row[1] = amount
+			row[2] = asset
+			row[9] = "cost"
+		default:
+			row[3] = amount
+			row[4] = asset
+			row[9] = "staking"
+		}
+		row[7] = fmt.Sprintf("%.2f", math.Abs(e.Value))
+		row[8] = currency
+		row[10] = fmt.Sprintf("Validator %d %s", e.ValidatorIndex, strings.ReplaceAll(string(e.Type), "_", " "))
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// coinTrackingRows returns the ledger in the CoinTracking CSV import format
+func coinTrackingRows(ledger []*types.RewardsLedgerEntry, asset string) [][]string {
+	rows := make([][]string, 0, len(ledger)+1)
+	rows = append(rows, []string{"Type", "Buy Amount", "Buy Currency", "Sell Amount", "Sell Currency", "Fee", "Fee Currency", "Exchange", "Trade-Group", "Comment", "Date"})
+	for _, e := range ledger {
+		amount := e.Amount.Abs().String()
+		row := make([]string, 11)
+		switch {
+		case e.Type == types.RewardsLedgerDeposit:
+			row[0] = "Deposit"
+			row[1] = amount
+			row[2] = asset
+		case e.Type == types.RewardsLedgerWithdrawal:
+			row[0] = "Withdrawal"
+			row[3] = amount
+			row[4] = asset
+		case e.Amount.IsNegative():
+			row[0] = "Other Fee"
+			row[3] = amount
+			row[4] = asset
+		default:
+			row[0] = "Staking"
+			row[1] = amount
+			row[2] = asset
+		}
+		row[7] = fmt.Sprintf("Validator %d", e.ValidatorIndex)
+		row[8] = string(e.Type)
+		row[10] = e.Date.UTC().Format("2006-01-02 15:04:05")
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func encodeCsv(rows [][]string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	err := w.WriteAll(rows)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func rewardsExportWorker() {
+	for {
+		err := processRewardsExports()
+		if err != nil {
+			utils.LogError(err, "error processing rewards exports", 0)
+		}
+
+		err = db.DeleteExpiredRewardsExports(rewardsExportRetentionDays)
+		if err != nil {
+			utils.LogError(err, "error deleting expired rewards exports", 0)
+		}
+		time.Sleep(time.Second * 10)
+	}
+}
+
+// processRewardsExports generates all pending background exports
+func processRewardsExports() error {
+	for {
+		export, err := db.ClaimPendingRewardsExport()
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		validators := make([]uint64, 0, len(export.Validators))
+		for _, v := range export.Validators {
+			validators = append(validators, uint64(v))
+		}
+
+		var data []byte
+		ledger, currency, err := GetRewardsLedger(validators, export.Currency, uint64(export.StartDay), uint64(export.EndDay))
+		if err == nil {
+			data, err = GenerateRewardsExport(ledger, currency, export.Format)
+		}
+		if err != nil {
+			utils.LogError(err, "error generating rewards export", 0, map[string]interface{}{"id": export.ID})
+		}
+
+		err = db.FinishRewardsExport(export.ID, data, err)
+		if err != nil {
+			return err
+		}
+		metrics.TaskDuration.WithLabelValues("service_rewards_export").Observe(time.Since(start).Seconds())
+		logger.Infof("generated rewards export %v with %v validators, took %v", export.ID, len(validators), time.Since(start))
+	}
+}
@@ -3,13 +3,10 @@ package services
 import (
 	"database/sql"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"html/template"
-	"math"
 	"math/big"
 	"sort"
-	"strings"
 	"sync"
 	"time"
 
@@ -27,7 +24,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/sirupsen/logrus"
 
-	geth_types "github.com/ethereum/go-ethereum/core/types"
 	geth_rpc "github.com/ethereum/go-ethereum/rpc"
 )
 
@@ -72,6 +68,10 @@ func Init() {
 	ready.Add(1)
 	go mempoolUpdater(ready)
 
+	if utils.Config.Mempool.StreamEndpoint != "" {
+		go StartMempoolTracker()
+	}
+
 	ready.Add(1)
 	go burnUpdater(ready)
 
@@ -91,6 +91,10 @@ func Init() {
 		go ratelimit.DBUpdater()
 	}
 
+	if utils.Config.RewardsExportWorker.Enabled {
+		go rewardsExportWorker()
+	}
+
 	ready.Wait()
 }
 
@@ -150,6 +154,16 @@ func getRelaysPageData() (*types.RelaysResp, error) {
 				rb.block_slot > $1 AND 
 				rb.block_root NOT IN (SELECT bt.blockroot FROM blocks_tags bt WHERE bt.tag_id='invalid-relay-reward') 
 			GROUP BY tag_id 
+		),
+		payouts AS (
+			SELECT
+				tag_id AS relay_id,
+				COUNT(*) AS verified_count,
+				COUNT(*) FILTER (WHERE status != 'valid') AS underpaid_count,
+				COALESCE(SUM(claimed_value - paid_value) FILTER (WHERE status != 'valid'), 0) AS underpaid_value
+			FROM relays_blocks_payouts
+			WHERE block_slot > $1 AND status != 'error'
+			GROUP BY tag_id
 		)
 		SELECT 
 			tags.metadata ->> 'name' AS "name",
@@ -163,9 +177,13 @@ func getRelaysPageData() (*types.RelaysResp, error) {
 			stats.avg_value,
 			stats.unique_builders,
 			stats.max_value,
-			stats.max_value_slot
+			stats.max_value_slot,
+			COALESCE(payouts.verified_count, 0) AS verified_count,
+			COALESCE(payouts.underpaid_count, 0) AS underpaid_count,
+			COALESCE(payouts.underpaid_value, 0) AS underpaid_value
 		FROM relays
 		LEFT JOIN stats ON stats.relay_id = relays.tag_id
+		LEFT JOIN payouts ON payouts.relay_id = relays.tag_id
 		LEFT JOIN tags ON tags.id = relays.tag_id 
 		WHERE stats.relay_id = tag_id 
 		ORDER BY stats.block_count DESC`)
@@ -1111,6 +1129,20 @@ func LatestGasNowData() *types.GasNowPageData {
 	return nil
 }
 
+// LatestGasOracleData returns the most recent fee recommendations of the gas price oracle
+func LatestGasOracleData() *types.GasOracleData {
+	wanted := &types.GasOracleData{}
+	cacheKey := fmt.Sprintf("%d:frontend:gasOracle", utils.Config.Chain.ClConfig.DepositChainID)
+
+	if wanted, err := cache.TieredCache.GetWithLocalTimeout(cacheKey, time.Second*5, wanted); err == nil {
+		return wanted.(*types.GasOracleData)
+	} else {
+		logger.Errorf("error retrieving gasOracle from cache: %v", err)
+	}
+
+	return nil
+}
+
 func LatestRelaysPageData() *types.RelaysResp {
 	wanted := &types.RelaysResp{}
 	cacheKey := fmt.Sprintf("%d:frontend:relaysData", utils.Config.Chain.ClConfig.DepositChainID)
@@ -1291,103 +1323,33 @@ func gasNowUpdater(wg *sync.WaitGroup) {
 	}
 }
 
-func getGasNowData() (*types.GasNowPageData, error) {
-	gpoData := &types.GasNowPageData{}
-	gpoData.Code = 200
-	gpoData.Data.Timestamp = time.Now().UnixNano() / 1e6
+// gasOracleBlocks is the sliding window of the most recent indexed blocks used by the gas price oracle, ordered by ascending number
+var gasOracleBlocks = []utils.GasOracleBlock{}
 
-	client, err := geth_rpc.Dial(utils.Config.Eth1GethEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	var raw json.RawMessage
-	err = client.Call(&raw, "eth_getBlockByNumber", "pending", true)
-	if err != nil {
-		return nil, fmt.Errorf("error retrieving pending block data: %.1000s", err) // limit error message to 1000 characters
-	}
-
-	// var res map[string]interface{}
-	// err = json.Unmarshal(raw, &res)
-	// if err != nil {
-	// 	return nil, err
-	// }
+// number of the most recent cached blocks that are fetched again on every update so that blocks reindexed after a reorg replace the cached ones
+const gasOracleReorgDepth = 8
 
-	var header *geth_types.Header
-	var body rpcBlock
-
-	err = json.Unmarshal(raw, &header)
-	if err != nil {
-		return nil, err
-	}
-	err = json.Unmarshal(raw, &body)
+func getGasNowData() (*types.GasNowPageData, error) {
+	oracleData, err := getGasOracleData()
 	if err != nil {
 		return nil, err
 	}
-	txs := body.Transactions
-
-	sort.Slice(txs, func(i, j int) bool {
-		return txs[i].tx.GasPrice().Cmp(txs[j].tx.GasPrice()) > 0
-	})
-	if len(txs) > 1 {
-		medianGasPrice := txs[len(txs)/2].tx.GasPrice()
-		tailGasPrice := txs[len(txs)-1].tx.GasPrice()
-
-		gpoData.Data.Rapid = medianGasPrice
-		gpoData.Data.Fast = tailGasPrice
-	} else {
-		gpoData.Data.Rapid = new(big.Int)
-		gpoData.Data.Fast = new(big.Int)
-	}
 
-	err = client.Call(&raw, "txpool_content")
+	cacheKey := fmt.Sprintf("%d:frontend:gasOracle", utils.Config.Chain.ClConfig.DepositChainID)
+	err = cache.TieredCache.Set(cacheKey, oracleData, utils.Day)
 	if err != nil {
-		return nil, fmt.Errorf("error getting raw json data from txpool_content: %w", err)
-	}
-
-	txPoolContent := &TxPoolContent{}
-	err = json.Unmarshal(raw, txPoolContent)
-	if err != nil {
-		return nil, fmt.Errorf("unmarshal txpoolcontent json error: %w", err)
-	}
-
-	pendingTxs := make([]*TxPoolContentTransaction, 0, len(txPoolContent.Pending))
-
-	for _, account := range txPoolContent.Pending {
-		lowestNonce := 9223372036854775807
-		for n := range account {
-			if n < int(lowestNonce) {
-				lowestNonce = n
-			}
-		}
-
-		pendingTxs = append(pendingTxs, account[lowestNonce])
-	}
-	sort.Slice(pendingTxs, func(i, j int) bool {
-		return pendingTxs[i].GetGasPrice().Cmp(pendingTxs[j].GetGasPrice()) > 0
-	})
-
-	standardIndex := int(math.Max(float64(2*len(txs)), 500))
-
-	slowIndex := int(math.Max(float64(5*len(txs)), 1000))
-	if standardIndex < len(pendingTxs) {
-		gpoData.Data.Standard = pendingTxs[standardIndex].GetGasPrice()
-	} else {
-		gpoData.Data.Standard = header.BaseFee
+		logger.Errorf("error caching gas oracle data: %v", err)
 	}
 
-	if gpoData.Data.Standard.Cmp(header.BaseFee) < 0 {
-		gpoData.Data.Standard = header.BaseFee
-	}
-
-	if slowIndex < len(pendingTxs) {
-		gpoData.Data.Slow = pendingTxs[slowIndex].GetGasPrice()
-	} else {
-		gpoData.Data.Slow = header.BaseFee
-	}
-
-	if gpoData.Data.Slow.Cmp(header.BaseFee) < 0 {
-		gpoData.Data.Slow = header.BaseFee
-	}
+	// the gas now prices are the expected base fee of the next block plus the recommended priority fees
+	nextBaseFee := oracleData.BaseFeeProjection[0]
+	gpoData := &types.GasNowPageData{}
+	gpoData.Code = 200
+	gpoData.Data.Timestamp = oracleData.Timestamp
+	gpoData.Data.Rapid = new(big.Int).Add(nextBaseFee, oracleData.Rapid.MaxPriorityFeePerGas)
+	gpoData.Data.Fast = new(big.Int).Add(nextBaseFee, oracleData.Fast.MaxPriorityFeePerGas)
+	gpoData.Data.Standard = new(big.Int).Add(nextBaseFee, oracleData.Standard.MaxPriorityFeePerGas)
+	gpoData.Data.Slow = new(big.Int).Add(nextBaseFee, oracleData.Slow.MaxPriorityFeePerGas)
 
 	err = db.BigtableClient.SaveGasNowHistory(gpoData.Data.Slow, gpoData.Data.Standard, gpoData.Data.Fast, gpoData.Data.Rapid)
 	if err != nil {
@@ -1397,67 +1359,57 @@ func getGasNowData() (*types.GasNowPageData, error) {
 	gpoData.Data.Price = price.GetPrice(utils.Config.Frontend.ElCurrency, "USD")
 	gpoData.Data.Currency = "USD"
 
-	// gpoData.RapidUSD = gpoData.Rapid * 21000 * params.GWei / params.Ether * usd
-	// gpoData.FastUSD = gpoData.Fast * 21000 * params.GWei / params.Ether * usd
-	// gpoData.StandardUSD = gpoData.Standard * 21000 * params.GWei / params.Ether * usd
-	// gpoData.SlowUSD = gpoData.Slow * 21000 * params.GWei / params.Ether * usd
 	return gpoData, nil
 }
 
-type TxPoolContent struct {
-	Pending map[string]map[int]*TxPoolContentTransaction
-}
+// getGasOracleData fetches the blocks indexed since the last update (and refetches the most recent cached blocks) and computes the fee recommendations of the configured number of recent blocks
+func getGasOracleData() (*types.GasOracleData, error) {
+	lastBlock, err := db.BigtableClient.GetLastBlockInDataTable()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving last indexed block: %w", err)
+	}
 
-type TxPoolContentTransaction struct {
-	GasPrice     string `json:"gasPrice"`
-	MaxFeePerGas string `json:"maxFeePerGas"`
-	Hash         string `json:"hash"`
-}
+	windowSize := utils.Config.GasOracle.Blocks
+	low := uint64(0)
+	if uint64(lastBlock) >= windowSize {
+		low = uint64(lastBlock) - windowSize + 1
+	}
+	// blocks above the last indexed block are no longer part of the canonical chain
+	for len(gasOracleBlocks) > 0 && gasOracleBlocks[len(gasOracleBlocks)-1].Number > uint64(lastBlock) {
+		gasOracleBlocks = gasOracleBlocks[:len(gasOracleBlocks)-1]
+	}
+	if len(gasOracleBlocks) > 0 && gasOracleBlocks[len(gasOracleBlocks)-1].Number+1 > low+gasOracleReorgDepth {
+		low = gasOracleBlocks[len(gasOracleBlocks)-1].Number + 1 - gasOracleReorgDepth
+	}
 
-func (tx *TxPoolContentTransaction) GetGasPrice() *big.Int {
-	if tx.MaxFeePerGas != "" {
-		gasPrice, ok := new(big.Int).SetString(strings.Replace(tx.MaxFeePerGas, "0x", "", 1), 16)
-		if !ok {
-			logger.Warnf("error parsing gas price value of %s in tx %s", tx.MaxFeePerGas, tx.Hash)
-			return big.NewInt(0)
+	if low <= uint64(lastBlock) {
+		stream := make(chan *types.Eth1Block, windowSize)
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- db.BigtableClient.GetFullBlocksDescending(stream, uint64(lastBlock), low)
+			close(stream)
+		}()
+
+		newBlocks := []utils.GasOracleBlock{}
+		for block := range stream {
+			newBlocks = append(newBlocks, utils.NewGasOracleBlock(block))
+		}
+		if err := <-errChan; err != nil {
+			return nil, fmt.Errorf("error retrieving blocks %v to %v: %w", low, lastBlock, err)
 		}
 
-		return gasPrice
-	} else if tx.GasPrice != "" {
-		gasPrice, ok := new(big.Int).SetString(strings.Replace(tx.GasPrice, "0x", "", 1), 16)
-		if !ok {
-			logger.Warnf("error parsing gas price value of %s in tx %s", tx.GasPrice, tx.Hash)
-			return big.NewInt(0)
+		sort.Slice(newBlocks, func(i, j int) bool { return newBlocks[i].Number < newBlocks[j].Number })
+		// the refetched blocks replace the cached ones in case they were reindexed after a reorg
+		for len(gasOracleBlocks) > 0 && len(newBlocks) > 0 && gasOracleBlocks[len(gasOracleBlocks)-1].Number >= newBlocks[0].Number {
+			gasOracleBlocks = gasOracleBlocks[:len(gasOracleBlocks)-1]
+		}
+		gasOracleBlocks = append(gasOracleBlocks, newBlocks...)
+		if uint64(len(gasOracleBlocks)) > windowSize {
+			gasOracleBlocks = gasOracleBlocks[uint64(len(gasOracleBlocks))-windowSize:]
 		}
-		return gasPrice
-	} else {
-		big.NewInt(0)
-		//logger.Warnf("tx %v has neither gasPrice not maxFeePerGas set", tx.Hash)
 	}
 
-	return big.NewInt(0)
-}
-
-type rpcTransaction struct {
-	tx *geth_types.Transaction
-	txExtraInfo
-}
-
-type txExtraInfo struct {
-	BlockNumber *string         `json:"blockNumber,omitempty"`
-	BlockHash   *common.Hash    `json:"blockHash,omitempty"`
-	From        *common.Address `json:"from,omitempty"`
-}
-
-type rpcBlock struct {
-	Transactions []rpcTransaction `json:"transactions"`
-}
-
-func (tx *rpcTransaction) UnmarshalJSON(msg []byte) error {
-	if err := json.Unmarshal(msg, &tx.tx); err != nil {
-		return err
-	}
-	return json.Unmarshal(msg, &tx.txExtraInfo)
+	return utils.ComputeGasOracle(gasOracleBlocks)
 }
 
 func mempoolUpdater(wg *sync.WaitGroup) {
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/db"
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+	"github.com/gobitfly/eth2-beaconchain-explorer/utils"
+)
+
+// taxReportDayRange returns the first and last statistic day that start within [start, end).
+// ok is false if no statistic day starts within the period.
+func taxReportDayRange(start, end time.Time) (firstDay uint64, lastDay uint64, ok bool) {
+	genesis := time.Unix(int64(utils.Config.Chain.GenesisTimestamp), 0)
+	if !end.After(genesis) {
+		return 0, 0, false
+	}
+	if start.After(genesis) {
+		firstDay = utils.TimeToDay(uint64(start.Unix()))
+		if utils.DayToTime(int64(firstDay)).Before(start) {
+			firstDay++
+		}
+	}
+	lastDay = utils.TimeToDay(uint64(end.Unix() - 1))
+	return firstDay, lastDay, firstDay <= lastDay
+}
+
+// getTaxReportValidators returns the validators covered by a schedule, validators tagged with the tag of the schedule are resolved at generation time
+func getTaxReportValidators(userID uint64, schedule *types.TaxReportSchedule) ([]uint64, error) {
+	if schedule.Tag == "" {
+		return schedule.Validators, nil
+	}
+	tagged, err := db.GetUserTaggedValidatorIndices(userID, strings.ToLower(utils.GetNetwork())+":"+schedule.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint64]bool, len(tagged)+len(schedule.Validators))
+	validators := make([]uint64, 0, len(tagged)+len(schedule.Validators))
+	for _, list := range [][]uint64{schedule.Validators, tagged} {
+		for _, v := range list {
+			if !seen[v] {
+				seen[v] = true
+				validators = append(validators, v)
+			}
+		}
+	}
+	return validators, nil
+}
+
+// GenerateTaxReport generates the report of a schedule for the period [start, end) and archives it.
+// If the period was already archived (e.g. a notification run failed after generating it) the archived report is returned.
+func GenerateTaxReport(userID, subscriptionID uint64, schedule *types.TaxReportSchedule, start, end time.Time) (*types.UserReport, error) {
+	if subscriptionID > 0 {
+		archived, err := db.GetUserReportOfPeriod(int64(subscriptionID), start, end)
+		if err == nil {
+			return archived, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("error getting archived report of subscription %v: %w", subscriptionID, err)
+		}
+	}
+
+	validators, err := getTaxReportValidators(userID, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.UserReport{
+		UserID:         userID,
+		SubscriptionID: sql.NullInt64{Int64: int64(subscriptionID), Valid: subscriptionID > 0},
+		Name:           schedule.Name,
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		Currency:       schedule.Currency,
+		Format:         schedule.Format,
+	}
+
+	ext := "pdf"
+	if schedule.Format == types.TaxReportFormatPdf {
+		// GetPdfReport expects the timestamps of the first and the last day of the report
+		report.Data = GetPdfReport(validators, schedule.Currency, uint64(start.Unix()), uint64(end.Unix()-1))
+	} else {
+		format := types.RewardsExportFormat(schedule.Format)
+		if !IsValidRewardsExportFormat(format) {
+			return nil, fmt.Errorf("unsupported tax report format %v", schedule.Format)
+		}
+		_, ext = RewardsExportContentType(format)
+
+		firstDay, lastDay, ok := taxReportDayRange(start, end)
+		if !ok {
+			return nil, fmt.Errorf("tax report period %v - %v does not contain any day", start, end)
+		}
+		ledger, currency, err := GetRewardsLedger(validators, schedule.Currency, firstDay, lastDay)
+		if err != nil {
+			return nil, err
+		}
+		report.Data, err = GenerateRewardsExport(ledger, currency, format)
+		if err != nil {
+			return nil, err
+		}
+	}
+	report.Filename = fmt.Sprintf("income_history_%v_%v.%v", start.Format("20060102"), end.Format("20060102"), ext)
+
+	err = db.InsertUserReport(report)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
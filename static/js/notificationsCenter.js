@@ -3,6 +3,8 @@ var csrfToken = ""
 const VALIDATOR_EVENTS = ["validator_attestation_missed", "validator_proposal_missed", "validator_proposal_submitted", "validator_got_slashed", "validator_synccommittee_soon", "validator_is_offline", "validator_withdrawal"]
 
 // const MONITORING_EVENTS = ['monitoring_machine_offline', 'monitoring_hdd_almostfull', 'monitoring_cpu_load']
+// monitoring events whose threshold is a number of blocks or peers instead of a percentage
+const MONITORING_COUNT_EVENTS = ["monitoring_execution_out_of_sync", "monitoring_execution_peer_count_low"]
 
 function create_typeahead(input_container) {
   var timeWait = 0
@@ -421,7 +423,9 @@ function loadMonitoringData(data) {
           //       ></i>
           //     </span>`
           // }
-          if (data) {
+          if (data && MONITORING_COUNT_EVENTS.includes(row.notification)) {
+            return data.toFixed(0)
+          } else if (data) {
             return (data * 100).toFixed(0) + "%"
           } else {
             return "N/A"
@@ -1095,6 +1099,12 @@ $(function () {
           case "monitoring_hdd_almostfull":
             t = parseFloat($("#hdd-input-range-val").val()) / 100
             break
+          case "monitoring_execution_out_of_sync":
+            t = parseFloat($("#el-sync-input-val").val())
+            break
+          case "monitoring_execution_peer_count_low":
+            t = parseFloat($("#el-peers-input-val").val())
+            break
           default:
             t = 0
         }
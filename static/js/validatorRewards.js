@@ -333,7 +333,7 @@ function updateSubscriptionTable(data, container) {
       $("#subscriptions-table-art").removeClass("d-flex").addClass("d-none")
       $("#subscriptions-table-div").removeClass("invisible")
       $("#subscriptions-div").removeClass("d-none")
-      $("#subs-header").html(`Subscriptions (${data.count}/5)`)
+      $("#subs-header").html(`Subscriptions (${data.count}/${data.limit})`)
       hideSpinner()
     },
     language: {
@@ -383,14 +383,23 @@ function updateSubscriptionTable(data, container) {
       {
         targets: 3,
         data: "3",
+        orderable: true,
+      },
+      {
+        targets: 4,
+        data: "4",
         orderable: false,
         render: function (data, type, row, meta) {
           downloadQueryUrl = `${window.location.origin}/rewards/hist/download?validators=${row[2]}&currency=${row[1]}&days=${moment().subtract(1, "month").startOf("month").unix()}-${moment().subtract(1, "month").endOf("month").unix()}`
+          if (row[2] === "") {
+            // schedules of tagged validators are only available as archived reports
+            downloadQueryUrl = `${window.location.origin}/user/reports`
+          }
           return `
                         <div class="d-flex justify-content-between align-item-center">
                             <i class="far fa-clone mr-2" style="cursor: pointer;" onClick='loadValInForm("${row[2]}")' data-toggle="tooltip" data-placement="top" title="Load validators in the form"></i>
                             <a href="${downloadQueryUrl}" download><i class="fas fa-file-download mr-2" style="cursor: pointer;" data-toggle="tooltip" data-placement="top" title="Download the last month report"></i></a>
-                            <i class="fas fa-times text-danger mr-2" onClick='unSubUser("${data}")' style="cursor: pointer;" data-toggle="tooltip" data-placement="top" title="Unsubscribe"></i>
+                            <i class="fas fa-times text-danger mr-2" onClick='unSubUser("id=${data}")' style="cursor: pointer;" data-toggle="tooltip" data-placement="top" title="Unsubscribe"></i>
                         </div>
                         `
         },
@@ -399,6 +408,26 @@ function updateSubscriptionTable(data, container) {
   })
 }
 
+function pollRewardsExport(id, downloadUrl, done) {
+  fetch(`/rewards/export/${id}`)
+    .then((res) => res.json())
+    .then((data) => {
+      if (data.status === "done") {
+        window.location.href = downloadUrl
+        done()
+      } else if (data.status === "failed") {
+        alert("Error creating the export")
+        done()
+      } else {
+        setTimeout(() => pollRewardsExport(id, downloadUrl, done), 5000)
+      }
+    })
+    .catch((err) => {
+      console.error("error getting rewards export status", err)
+      done()
+    })
+}
+
 function loadValInForm(val) {
   $("#validator-index-view").val(val.replace(/([a-zA-Z ])/g, ""))
 }
@@ -491,7 +520,15 @@ $(document).ready(function () {
                             <span class="sr-only">Loading...</span>
                         </div>`)
 
-    fetch(`/user/rewards/subscribe?validators=${$("#validator-index-view").val()}&currency=${$("#currency").val()}`, {
+    let params = new URLSearchParams({
+      validators: $("#validator-index-view").val(),
+      currency: $("#currency").val(),
+      period: $("#report-period").val(),
+      format: $("#report-format").val(),
+      timezone: Intl.DateTimeFormat().resolvedOptions().timeZone || "UTC",
+      name: $("#report-name").val(),
+    })
+    fetch(`/user/rewards/subscribe?${params.toString()}`, {
       method: "POST",
       headers: { "X-CSRF-Token": csrfToken },
       credentials: "include",
@@ -506,7 +543,11 @@ $(document).ready(function () {
           })
         } else {
           console.error("error subscribing", res)
-          alert("Subscription limit is reached")
+          if (res.status == 409) {
+            alert("Subscription limit is reached")
+          } else {
+            res.text().then((msg) => alert(msg))
+          }
           $(this).html(btn_content)
         }
       })
@@ -516,6 +557,52 @@ $(document).ready(function () {
       })
   })
 
+  $("#export-btn").on("click", function () {
+    var form = document.getElementById("hits-form")
+    if (!form.reportValidity()) {
+      return
+    }
+    let btn = $(this)
+    let btn_content = btn.html()
+    btn.html(`<div class="spinner-border text-dark spinner-border-sm" role="status">
+                            <span class="sr-only">Loading...</span>
+                        </div>`)
+    btn.prop("disabled", true)
+    let resetBtn = function () {
+      btn.html(btn_content)
+      btn.prop("disabled", false)
+    }
+
+    let url = `/rewards/export?validators=${$("#validator-index-view").val()}&currency=${$("#currency").val()}&days=${$("#days").val()}&format=${$("#export-format").val()}`
+    fetch(url)
+      .then((res) => {
+        if (res.status == 200) {
+          // small exports are returned directly
+          let filename = (res.headers.get("Content-Disposition") || "").split("filename=")[1] || "rewards_export"
+          res.blob().then((blob) => {
+            let a = document.createElement("a")
+            a.href = window.URL.createObjectURL(blob)
+            a.download = filename
+            a.click()
+            window.URL.revokeObjectURL(a.href)
+            resetBtn()
+          })
+        } else if (res.status == 202) {
+          res.json().then((data) => {
+            pollRewardsExport(data.id, data.download_url, resetBtn)
+          })
+        } else {
+          console.error("error exporting rewards", res)
+          alert("Error creating the export")
+          resetBtn()
+        }
+      })
+      .catch((err) => {
+        console.error("error exporting rewards", err)
+        resetBtn()
+      })
+  })
+
   if (qry.length > 1) {
     fetch(`/rewards/hist${qry}`, {
       method: "GET",
@@ -75,6 +75,32 @@ type StatsDataStruct struct {
 	Validator interface{} `json:"validator"`
 	Node      interface{} `json:"node"`
 	System    interface{} `json:"system"`
+	Execution interface{} `json:"execution"`
+}
+
+// MachineMetricsQueryResponse contains the machine metrics of a time range at the given resolution (1m, 5m or 1h)
+type MachineMetricsQueryResponse struct {
+	Resolution string                    `json:"resolution"`
+	From       int64                     `json:"from"`
+	To         int64                     `json:"to"`
+	Validator  []*MachineMetricValidator `json:"validator"`
+	Node       []*MachineMetricNode      `json:"node"`
+	System     []*MachineMetricSystem    `json:"system"`
+	Execution  []*MachineMetricExecution `json:"execution"`
+}
+
+// MachineMetricAlert is a user defined alert on a numeric machine metric field, an empty machine applies the alert to all machines of the user
+type MachineMetricAlert struct {
+	ID              uint64    `db:"id" json:"id"`
+	UserID          uint64    `db:"user_id" json:"-"`
+	Machine         string    `db:"machine" json:"machine"`
+	Process         string    `db:"process" json:"process"`
+	Field           string    `db:"field" json:"field"`
+	Mode            string    `db:"mode" json:"mode"`
+	Comparator      string    `db:"comparator" json:"comparator"`
+	Threshold       float64   `db:"threshold" json:"threshold"`
+	DurationMinutes uint64    `db:"duration_minutes" json:"duration_minutes"`
+	CreatedTs       time.Time `db:"created_ts" json:"created_ts"`
 }
 
 type WidgetResponse struct {
@@ -537,6 +563,7 @@ type ApiValidatorDailyStatsResponse struct {
 	ProposerSlashings     uint64    `json:"proposer_slashings"`
 	StartBalance          uint64    `json:"start_balance"`
 	StartEffectiveBalance uint64    `json:"start_effective_balance"`
+	Intraday              bool      `json:"intraday"`
 }
 
 type ApiValidatorEth1Response struct {
@@ -637,6 +664,20 @@ type ApiValidatorDepositsResponse struct {
 	WithdrawalCredentials string `json:"withdrawal_credentials"`
 }
 
+type ApiValidatorTagsResponse struct {
+	Validatorindex uint64   `json:"validatorindex"`
+	Pubkey         string   `json:"pubkey"`
+	Tags           []string `json:"tags"`
+}
+
+// ValidatorTagsFilter selects the validators carrying all tags, optionally limited to the tags assigned by a tag rule
+type ValidatorTagsFilter struct {
+	Tags   []string
+	RuleId uint64
+	Offset uint64
+	Limit  uint64
+}
+
 type ApiValidatorAttestationsResponse struct {
 	AttesterSlot   uint64    `json:"attesterslot"`
 	CommitteeIndex uint64    `json:"committeeindex"`
@@ -700,3 +741,91 @@ type ApiProposalLuckResponse struct {
 	NextProposalEstimateTs  *int64   `json:"next_proposal_estimate_ts"` // The estimated timestamp of the next proposal
 	TimeFrameName           *string  `json:"time_frame_name"`           // The timeframe for which the luck is calculated
 }
+
+type ApiBlobResponse struct {
+	VersionedHash   string `json:"versioned_hash"`
+	Slot            uint64 `json:"slot"`
+	Index           uint64 `json:"index"`
+	BlockRoot       string `json:"block_root"`
+	BlockParentRoot string `json:"block_parent_root,omitempty"`
+	ProposerIndex   string `json:"proposer_index,omitempty"`
+	KzgCommitment   string `json:"kzg_commitment"`
+	KzgProof        string `json:"kzg_proof"`
+	Blob            string `json:"blob"`
+}
+
+type ChartSeriesPoint struct {
+	Time  time.Time `db:"time" json:"time"`
+	Value float64   `db:"value" json:"value"`
+}
+
+type ApiChartSeriesResponse struct {
+	Indicator   string              `json:"indicator"`
+	Resolution  string              `json:"resolution"`
+	Aggregation string              `json:"aggregation"`
+	Data        []*ChartSeriesPoint `json:"data"`
+}
+
+type ChartSeriesEpochPoint struct {
+	Epoch uint64  `db:"epoch" json:"epoch"`
+	Value float64 `db:"value" json:"value"`
+}
+
+type ClientDiversityEntry struct {
+	Period      uint64  `db:"period" json:"period"`
+	Client      string  `db:"client" json:"client"`
+	BlocksCount uint64  `db:"blocks_count" json:"blocks_count"`
+	Proposers   uint64  `db:"proposers" json:"proposers"`
+	Share       float64 `db:"share" json:"share"`
+}
+
+type ValidatorClientEntry struct {
+	ValidatorIndex      uint64  `db:"validatorindex" json:"validatorindex"`
+	Client              string  `db:"client" json:"client"`
+	BlocksCount         uint64  `db:"blocks_count" json:"blocks_count"`
+	GraffitiBlocksCount uint64  `db:"graffiti_blocks_count" json:"graffiti_blocks_count"`
+	LastSlot            uint64  `db:"last_slot" json:"last_slot"`
+	Share               float64 `db:"share" json:"share"`
+}
+
+type RelayBidCompetition struct {
+	Slot            uint64    `db:"slot" json:"slot"`
+	BidsCount       uint64    `db:"bids_count" json:"bids_count"`
+	RelaysCount     uint64    `db:"relays_count" json:"relays_count"`
+	BuildersCount   uint64    `db:"builders_count" json:"builders_count"`
+	TopBidValue     WeiString `db:"top_bid_value" json:"top_bid_value"`
+	TopBidBuilder   string    `db:"top_bid_builder" json:"top_bid_builder"`
+	DeliveredValue  WeiString `db:"delivered_value" json:"delivered_value"`
+	WinningBuilder  string    `db:"winning_builder" json:"winning_builder"`
+	RunnerUpValue   WeiString `db:"runner_up_value" json:"runner_up_value"`
+	WinningMargin   WeiString `db:"winning_margin" json:"winning_margin"`
+	DeliveredIsBest bool      `db:"delivered_is_best" json:"delivered_is_best"`
+}
+
+type RelayBuilderBidStats struct {
+	BuilderPubkey string    `db:"builder_pubkey" json:"builder_pubkey"`
+	BidsCount     uint64    `db:"bids_count" json:"bids_count"`
+	SlotsBid      uint64    `db:"slots_bid" json:"slots_bid"`
+	SlotsWon      uint64    `db:"slots_won" json:"slots_won"`
+	MaxBidValue   WeiString `db:"max_bid_value" json:"max_bid_value"`
+}
+
+// SearchResult is a single typed result of the search api, Score is between 0 and 1 with exact matches scoring highest
+type SearchResult struct {
+	Type    string  `json:"type"`
+	Value   string  `json:"value"`
+	Label   string  `json:"label,omitempty"`
+	Match   string  `json:"match"`
+	Score   float64 `json:"score"`
+	URL     string  `json:"url,omitempty"`
+	Count   uint64  `json:"count,omitempty"`
+	Details string  `json:"details,omitempty"`
+}
+
+type ApiSearchResponse struct {
+	Query   string          `json:"query"`
+	Total   int             `json:"total"`
+	Offset  int             `json:"offset"`
+	Limit   int             `json:"limit"`
+	Results []*SearchResult `json:"results"`
+}
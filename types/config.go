@@ -38,12 +38,22 @@ type Config struct {
 		V2SchemaCutOffEpoch uint64 `yaml:"v2SchemaCutOffEpoch" envconfig:"BIGTABLE_V2_SCHEMA_CUTT_OFF_EPOCH"`
 	} `yaml:"bigtable"`
 	BlobIndexer struct {
-		S3 struct {
+		Store           string `yaml:"store" envconfig:"BLOB_INDEXER_STORE"`
+		RetentionEpochs uint64 `yaml:"retentionEpochs" envconfig:"BLOB_INDEXER_RETENTION_EPOCHS"`
+		S3              struct {
 			Endpoint        string `yaml:"endpoint" envconfig:"BLOB_INDEXER_S3_ENDPOINT"`
 			Bucket          string `yaml:"bucket" envconfig:"BLOB_INDEXER_S3_BUCKET"`
 			AccessKeyId     string `yaml:"accessKeyId" envconfig:"BLOB_INDEXER_S3_ACCESS_KEY_ID"`
 			AccessKeySecret string `yaml:"accessKeySecret" envconfig:"BLOB_INDEXER_S3_ACCESS_KEY_SECRET"`
 		} `yaml:"s3"`
+		Filesystem struct {
+			Path string `yaml:"path" envconfig:"BLOB_INDEXER_FILESYSTEM_PATH"`
+		} `yaml:"filesystem"`
+		Gcs struct {
+			Endpoint        string `yaml:"endpoint" envconfig:"BLOB_INDEXER_GCS_ENDPOINT"`
+			Bucket          string `yaml:"bucket" envconfig:"BLOB_INDEXER_GCS_BUCKET"`
+			CredentialsFile string `yaml:"credentialsFile" envconfig:"BLOB_INDEXER_GCS_CREDENTIALS_FILE"`
+		} `yaml:"gcs"`
 	} `yaml:"blobIndexer"`
 	Chain struct {
 		Name                       string `yaml:"name" envconfig:"CHAIN_NAME"`
@@ -239,6 +249,10 @@ type Config struct {
 		Enabled        bool          `yaml:"enabled" envconfig:"RATELIMIT_UPDATER_ENABLED"`
 		UpdateInterval time.Duration `yaml:"updateInterval" envconfig:"RATELIMIT_UPDATER_UPDATE_INTERVAL"`
 	} `yaml:"ratelimitUpdater"`
+	RewardsExportWorker struct {
+		// generates the queued background rewards exports
+		Enabled bool `yaml:"enabled" envconfig:"REWARDS_EXPORT_WORKER_ENABLED"`
+	} `yaml:"rewardsExportWorker"`
 	SSVExporter struct {
 		Enabled bool   `yaml:"enabled" envconfig:"SSV_EXPORTER_ENABLED"`
 		Address string `yaml:"address" envconfig:"SSV_EXPORTER_ADDRESS"`
@@ -247,21 +261,84 @@ type Config struct {
 		Enabled bool `yaml:"enabled" envconfig:"ROCKETPOOL_EXPORTER_ENABLED"`
 	} `yaml:"rocketpoolExporter"`
 	MevBoostRelayExporter struct {
-		Enabled bool `yaml:"enabled" envconfig:"MEVBOOSTRELAY_EXPORTER_ENABLED"`
+		Enabled       bool `yaml:"enabled" envconfig:"MEVBOOSTRELAY_EXPORTER_ENABLED"`
+		VerifyPayouts bool `yaml:"verifyPayouts" envconfig:"MEVBOOSTRELAY_EXPORTER_VERIFY_PAYOUTS"`
+		ReceivedBids  struct {
+			Enabled        bool   `yaml:"enabled" envconfig:"MEVBOOSTRELAY_EXPORTER_RECEIVED_BIDS_ENABLED"`
+			RetentionDays  uint64 `yaml:"retentionDays" envconfig:"MEVBOOSTRELAY_EXPORTER_RECEIVED_BIDS_RETENTION_DAYS"`
+			MaxBidsPerSlot int    `yaml:"maxBidsPerSlot" envconfig:"MEVBOOSTRELAY_EXPORTER_RECEIVED_BIDS_MAX_PER_SLOT"`
+		} `yaml:"receivedBids"`
 	} `yaml:"mevBoostRelayExporter"`
+	MachineMetrics struct {
+		// retention of the raw (1m), 5m and 1h machine metric tiers, applied as gc policies of their bigtable column families
+		Retention struct {
+			RawDays         uint64 `yaml:"rawDays" envconfig:"MACHINE_METRICS_RETENTION_RAW_DAYS"`
+			FiveMinutesDays uint64 `yaml:"fiveMinutesDays" envconfig:"MACHINE_METRICS_RETENTION_FIVE_MINUTES_DAYS"`
+			HourDays        uint64 `yaml:"hourDays" envconfig:"MACHINE_METRICS_RETENTION_HOUR_DAYS"`
+		} `yaml:"retention"`
+	} `yaml:"machineMetrics"`
 	Pprof struct {
 		Enabled bool   `yaml:"enabled" envconfig:"PPROF_ENABLED"`
 		Port    string `yaml:"port" envconfig:"PPROF_PORT"`
 	} `yaml:"pprof"`
 	NodeJobsProcessor struct {
-		ElEndpoint string `yaml:"elEndpoint" envconfig:"NODE_JOBS_PROCESSOR_EL_ENDPOINT"`
-		ClEndpoint string `yaml:"clEndpoint" envconfig:"NODE_JOBS_PROCESSOR_CL_ENDPOINT"`
+		ElEndpoint     string   `yaml:"elEndpoint" envconfig:"NODE_JOBS_PROCESSOR_EL_ENDPOINT"`
+		ElEndpoints    []string `yaml:"elEndpoints" envconfig:"NODE_JOBS_PROCESSOR_EL_ENDPOINTS"`
+		ClEndpoint     string   `yaml:"clEndpoint" envconfig:"NODE_JOBS_PROCESSOR_CL_ENDPOINT"`
+		ClEndpoints    []string `yaml:"clEndpoints" envconfig:"NODE_JOBS_PROCESSOR_CL_ENDPOINTS"`
+		ResubmitEpochs uint64   `yaml:"resubmitEpochs" envconfig:"NODE_JOBS_PROCESSOR_RESUBMIT_EPOCHS"`
+		MaxSubmissions int      `yaml:"maxSubmissions" envconfig:"NODE_JOBS_PROCESSOR_MAX_SUBMISSIONS"`
 	} `yaml:"nodeJobsProcessor"`
 	Monitoring struct {
 		ApiKey                          string                           `yaml:"apiKey" envconfig:"MONITORING_API_KEY"`
 		ServiceMonitoringConfigurations []ServiceMonitoringConfiguration `yaml:"serviceMonitoringConfigurations" envconfig:"SERVICE_MONITORING_CONFIGURATIONS"`
 	} `yaml:"monitoring"`
 	GithubApiHost string `yaml:"githubApiHost" envconfig:"GITHUB_API_HOST"`
+	ChartSeries   struct {
+		Indicators []ChartSeriesIndicator `yaml:"indicators"`
+		// number of epochs the epoch-resolution chart series are kept for (default: 30 days)
+		EpochRetention uint64 `yaml:"epochRetention" envconfig:"CHART_SERIES_EPOCH_RETENTION"`
+	} `yaml:"chartSeries"`
+	Prices  PriceProvidersConfig `yaml:"prices"`
+	Mempool struct {
+		// websocket endpoint used to stream pending transactions, the mempool tracker is disabled if empty
+		StreamEndpoint string `yaml:"streamEndpoint" envconfig:"MEMPOOL_STREAM_ENDPOINT"`
+		// number of days tracked mempool transactions are kept (default: 7)
+		RetentionDays uint64 `yaml:"retentionDays" envconfig:"MEMPOOL_RETENTION_DAYS"`
+	} `yaml:"mempool"`
+	GasOracle struct {
+		// number of recent indexed blocks the fee recommendations are derived from (default: 100)
+		Blocks uint64 `yaml:"blocks" envconfig:"GAS_ORACLE_BLOCKS"`
+	} `yaml:"gasOracle"`
+}
+
+// PriceProvidersConfig configures the sources of the current prices, prices offered by multiple providers are aggregated using their median
+type PriceProvidersConfig struct {
+	// quotes older than this are considered stale and are not used (default: 1h)
+	MaxAge    time.Duration `yaml:"maxAge" envconfig:"PRICES_MAX_AGE"`
+	Chainlink struct {
+		Disabled bool `yaml:"disabled" envconfig:"PRICES_CHAINLINK_DISABLED"`
+	} `yaml:"chainlink"`
+	Coingecko struct {
+		// enabled by default on chains without chainlink feeds
+		Enabled bool   `yaml:"enabled" envconfig:"PRICES_COINGECKO_ENABLED"`
+		BaseUrl string `yaml:"baseUrl" envconfig:"PRICES_COINGECKO_BASE_URL"`
+		ApiKey  string `yaml:"apiKey" envconfig:"PRICES_COINGECKO_API_KEY"`
+		// coingecko coin ids keyed by currency, e.g. LYX: lukso-token-2, merged with the defaults of the chain
+		Coins map[string]string `yaml:"coins" envconfig:"PRICES_COINGECKO_COINS"`
+	} `yaml:"coingecko"`
+	Cryptocompare struct {
+		BaseUrl string `yaml:"baseUrl" envconfig:"PRICES_CRYPTOCOMPARE_BASE_URL"`
+		ApiKey  string `yaml:"apiKey" envconfig:"PRICES_CRYPTOCOMPARE_API_KEY"`
+	} `yaml:"cryptocompare"`
+	// fixed prices keyed by pair, e.g. LYX/USD: 2.5
+	Static   map[string]float64 `yaml:"static" envconfig:"PRICES_STATIC"`
+	Historic struct {
+		// providers used to backfill the daily historic prices, available: coingecko, cryptocompare, static (default: coingecko)
+		Providers []string `yaml:"providers" envconfig:"PRICES_HISTORIC_PROVIDERS"`
+		// relative deviation from the median above which the price of a provider is considered an outlier (default: 0.05)
+		OutlierThreshold float64 `yaml:"outlierThreshold" envconfig:"PRICES_HISTORIC_OUTLIER_THRESHOLD"`
+	} `yaml:"historic"`
 }
 
 type DatabaseConfig struct {
@@ -280,6 +357,19 @@ type ServiceMonitoringConfiguration struct {
 	Duration time.Duration `yaml:"duration" envconfig:"DURATION"`
 }
 
+// ChartSeriesIndicator describes an indicator of the chart_series table.
+// Indicators defined in the config are computed per day by Query, which has to return a single numeric value and can use the named parameters
+// :day_start (start of the day), :first_epoch, :last_epoch, :first_slot and :last_slot (last_epoch and last_slot are exclusive).
+// Other names starting with a colon are left untouched, so postgres casts like value::numeric or :first_slot::bigint can be used.
+// Aggregation is the default function used to aggregate the daily values to weeks or months (sum, avg, min, max, first or last).
+type ChartSeriesIndicator struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Query       string `yaml:"query" json:"-"`
+	Aggregation string `yaml:"aggregation" json:"aggregation"`
+	Custom      bool   `yaml:"-" json:"custom"`
+}
+
 type ConfigJsonResponse struct {
 	Data struct {
 		ConfigName                              string `json:"CONFIG_NAME"`
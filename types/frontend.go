@@ -4,8 +4,11 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"math/big"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +17,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/lib/pq"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 )
@@ -43,6 +47,9 @@ const (
 	MonitoringMachineMemoryUsageEventName            EventName = "monitoring_memory_usage"
 	MonitoringMachineSwitchedToETH2FallbackEventName EventName = "monitoring_fallback_eth2inuse"
 	MonitoringMachineSwitchedToETH1FallbackEventName EventName = "monitoring_fallback_eth1inuse"
+	MonitoringMachineCustomAlertEventName            EventName = "monitoring_custom_alert"
+	MonitoringMachineExecutionOutOfSyncEventName     EventName = "monitoring_execution_out_of_sync"
+	MonitoringMachineExecutionPeerCountLowEventName  EventName = "monitoring_execution_peer_count_low"
 	TaxReportEventName                               EventName = "user_tax_report"
 	RocketpoolCommissionThresholdEventName           EventName = "rocketpool_commision_threshold"
 	RocketpoolNewClaimRoundStartedEventName          EventName = "rocketpool_new_claimround"
@@ -59,6 +66,8 @@ var MachineEvents = []EventName{
 	MonitoringMachineMemoryUsageEventName,
 	MonitoringMachineSwitchedToETH2FallbackEventName,
 	MonitoringMachineSwitchedToETH1FallbackEventName,
+	MonitoringMachineExecutionOutOfSyncEventName,
+	MonitoringMachineExecutionPeerCountLowEventName,
 }
 
 var UserIndexEvents = []EventName{
@@ -71,6 +80,9 @@ var UserIndexEvents = []EventName{
 	MonitoringMachineMemoryUsageEventName,
 	MonitoringMachineSwitchedToETH2FallbackEventName,
 	MonitoringMachineSwitchedToETH1FallbackEventName,
+	MonitoringMachineCustomAlertEventName,
+	MonitoringMachineExecutionOutOfSyncEventName,
+	MonitoringMachineExecutionPeerCountLowEventName,
 }
 
 var EventLabel map[EventName]string = map[EventName]string{
@@ -96,6 +108,9 @@ var EventLabel map[EventName]string = map[EventName]string{
 	MonitoringMachineMemoryUsageEventName:            "Your machine(s) has a high memory load",
 	MonitoringMachineSwitchedToETH2FallbackEventName: "Your machine(s) is using its consensus client fallback",
 	MonitoringMachineSwitchedToETH1FallbackEventName: "Your machine(s) is using its execution client fallback",
+	MonitoringMachineCustomAlertEventName:            "Your machine(s) reached a custom alert threshold",
+	MonitoringMachineExecutionOutOfSyncEventName:     "Your machine(s) execution client is out of sync",
+	MonitoringMachineExecutionPeerCountLowEventName:  "Your machine(s) execution client has a low peer count",
 	TaxReportEventName:                               "You have an available tax report",
 	RocketpoolCommissionThresholdEventName:           "Your configured Rocket Pool commission threshold is reached",
 	RocketpoolNewClaimRoundStartedEventName:          "Your Rocket Pool claim from last round is available",
@@ -145,6 +160,9 @@ var EventNames = []EventName{
 	MonitoringMachineSwitchedToETH2FallbackEventName,
 	MonitoringMachineSwitchedToETH1FallbackEventName,
 	MonitoringMachineMemoryUsageEventName,
+	MonitoringMachineCustomAlertEventName,
+	MonitoringMachineExecutionOutOfSyncEventName,
+	MonitoringMachineExecutionPeerCountLowEventName,
 	TaxReportEventName,
 	RocketpoolCommissionThresholdEventName,
 	RocketpoolNewClaimRoundStartedEventName,
@@ -265,6 +283,152 @@ type Subscription struct {
 	State           sql.NullString `db:"internal_state" swaggertype:"string"`
 }
 
+type TaxReportPeriod string
+
+const (
+	TaxReportPeriodWeekly    TaxReportPeriod = "weekly"
+	TaxReportPeriodMonthly   TaxReportPeriod = "monthly"
+	TaxReportPeriodQuarterly TaxReportPeriod = "quarterly"
+	TaxReportPeriodYearly    TaxReportPeriod = "yearly"
+)
+
+// TaxReportFormatPdf is the default format of scheduled tax reports, all other formats are rewards export formats
+const TaxReportFormatPdf = "pdf"
+
+// TaxReportSchedule describes a scheduled tax report, it is stored as the event filter of a tax report subscription.
+// Legacy filters (validators=...&days=30&currency=...) are treated as monthly pdf reports in UTC.
+type TaxReportSchedule struct {
+	Name       string
+	Period     TaxReportPeriod
+	Currency   string
+	Timezone   string
+	Format     string
+	Validators []uint64
+	Tag        string
+	location   *time.Location
+}
+
+// ParseTaxReportSchedule parses the event filter of a tax report subscription
+func ParseTaxReportSchedule(filter string) (*TaxReportSchedule, error) {
+	q, err := url.ParseQuery(filter)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing tax report event filter: %w", err)
+	}
+
+	s := &TaxReportSchedule{
+		Name:     q.Get("name"),
+		Period:   TaxReportPeriod(q.Get("period")),
+		Currency: q.Get("currency"),
+		Timezone: q.Get("timezone"),
+		Format:   q.Get("format"),
+		Tag:      q.Get("tag"),
+	}
+	if s.Period == "" {
+		s.Period = TaxReportPeriodMonthly
+	}
+	if s.Timezone == "" {
+		s.Timezone = "UTC"
+	}
+	if s.Format == "" {
+		s.Format = TaxReportFormatPdf
+	}
+
+	switch s.Period {
+	case TaxReportPeriodWeekly, TaxReportPeriodMonthly, TaxReportPeriodQuarterly, TaxReportPeriodYearly:
+	default:
+		return nil, fmt.Errorf("invalid tax report period %v", s.Period)
+	}
+
+	s.location, err = time.LoadLocation(s.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tax report timezone %v: %w", s.Timezone, err)
+	}
+
+	if validators := q.Get("validators"); validators != "" {
+		for _, v := range strings.Split(validators, ",") {
+			index, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validator index %v in tax report event filter", v)
+			}
+			s.Validators = append(s.Validators, index)
+		}
+	}
+	if len(s.Validators) == 0 && s.Tag == "" {
+		return nil, fmt.Errorf("tax report event filter contains neither validators nor a tag")
+	}
+
+	return s, nil
+}
+
+// EventFilter encodes the schedule as event filter of a tax report subscription
+func (s *TaxReportSchedule) EventFilter() string {
+	q := url.Values{}
+	if len(s.Validators) > 0 {
+		validators := make([]string, 0, len(s.Validators))
+		for _, v := range s.Validators {
+			validators = append(validators, strconv.FormatUint(v, 10))
+		}
+		q.Set("validators", strings.Join(validators, ","))
+	}
+	if s.Tag != "" {
+		q.Set("tag", s.Tag)
+	}
+	if s.Name != "" {
+		q.Set("name", s.Name)
+	}
+	q.Set("currency", s.Currency)
+	q.Set("period", string(s.Period))
+	q.Set("timezone", s.Timezone)
+	q.Set("format", s.Format)
+	return q.Encode()
+}
+
+// Location returns the timezone used for the day boundaries of the schedule
+func (s *TaxReportSchedule) Location() *time.Location {
+	if s.location == nil {
+		return time.UTC
+	}
+	return s.location
+}
+
+// LastCompletedPeriod returns the start (inclusive) and end (exclusive) of the latest report period that ended before now.
+// Weeks start on monday, quarters on the first of january, april, july and october.
+func (s *TaxReportSchedule) LastCompletedPeriod(now time.Time) (time.Time, time.Time) {
+	now = now.In(s.Location())
+	var end, start time.Time
+	switch s.Period {
+	case TaxReportPeriodWeekly:
+		offset := (int(now.Weekday()) + 6) % 7
+		end = time.Date(now.Year(), now.Month(), now.Day()-offset, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(0, 0, -7)
+	case TaxReportPeriodQuarterly:
+		end = time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(0, -3, 0)
+	case TaxReportPeriodYearly:
+		end = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(-1, 0, 0)
+	default:
+		end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		start = end.AddDate(0, -1, 0)
+	}
+	return start, end
+}
+
+// UserReport is an archived scheduled report
+type UserReport struct {
+	ID             uint64        `db:"id"`
+	UserID         uint64        `db:"user_id"`
+	SubscriptionID sql.NullInt64 `db:"subscription_id"`
+	Name           string        `db:"name"`
+	PeriodStart    time.Time     `db:"period_start"`
+	PeriodEnd      time.Time     `db:"period_end"`
+	Currency       string        `db:"currency"`
+	Format         string        `db:"format"`
+	Filename       string        `db:"filename"`
+	Data           []byte        `db:"data"`
+	CreatedTs      time.Time     `db:"created_ts"`
+}
+
 type TaggedValidators struct {
 	UserID             uint64 `db:"user_id"`
 	Tag                string `db:"tag"`
@@ -544,6 +708,29 @@ type GasNowPageData struct {
 	} `json:"data"`
 }
 
+// GasOracleRecommendation is an EIP-1559 fee recommendation for a transaction to be included within TargetBlocks blocks.
+// Confidence is the share of recent windows of TargetBlocks blocks in which a transaction paying MaxPriorityFeePerGas would have been included.
+type GasOracleRecommendation struct {
+	MaxFeePerGas         *big.Int `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *big.Int `json:"maxPriorityFeePerGas"`
+	TargetBlocks         uint64   `json:"targetBlocks"`
+	Confidence           float64  `json:"confidence"`
+}
+
+// GasOracleData contains the fee recommendations derived from the effective priority fees of the last Blocks indexed blocks
+type GasOracleData struct {
+	BlockNumber uint64   `json:"blockNumber"`
+	Blocks      uint64   `json:"blocks"`
+	BaseFee     *big.Int `json:"baseFee"`
+	// expected base fees of the next blocks based on the average gas usage of the recent blocks, starting with the next block
+	BaseFeeProjection []*big.Int              `json:"baseFeeProjection"`
+	Rapid             GasOracleRecommendation `json:"rapid"`
+	Fast              GasOracleRecommendation `json:"fast"`
+	Standard          GasOracleRecommendation `json:"standard"`
+	Slow              GasOracleRecommendation `json:"slow"`
+	Timestamp         int64                   `json:"timestamp"`
+}
+
 type Eth1AddressSearchItem struct {
 	Address string `json:"address"`
 	Name    string `json:"name"`
@@ -576,6 +763,74 @@ type RawMempoolTransaction struct {
 	TransactionIndex *hexutil.Big    `json:"transactionIndex"`
 }
 
+const (
+	MempoolTxStatusPending  = "pending"
+	MempoolTxStatusIncluded = "included"
+	MempoolTxStatusReplaced = "replaced"
+	MempoolTxStatusDropped  = "dropped"
+)
+
+// MempoolTransaction is a transaction tracked from the time it was first seen in the mempool until it was included, replaced or dropped
+type MempoolTransaction struct {
+	Hash                 []byte              `db:"hash"`
+	From                 []byte              `db:"from_address"`
+	To                   []byte              `db:"to_address"`
+	Method               []byte              `db:"method"`
+	Nonce                uint64              `db:"nonce"`
+	Value                decimal.Decimal     `db:"value"`
+	Gas                  uint64              `db:"gas"`
+	GasPrice             decimal.Decimal     `db:"gas_price"`
+	MaxPriorityFeePerGas decimal.NullDecimal `db:"max_priority_fee_per_gas"`
+	FirstSeenTs          time.Time           `db:"first_seen_ts"`
+	Status               string              `db:"status"`
+	BlockNumber          sql.NullInt64       `db:"block_number"`
+	IncludedTs           sql.NullTime        `db:"included_ts"`
+	RemovedTs            sql.NullTime        `db:"removed_ts"`
+	ReplacedBy           []byte              `db:"replaced_by"`
+}
+
+// MempoolTransactionsFilter restricts the tracked mempool transactions returned by a query, empty fields are ignored
+type MempoolTransactionsFilter struct {
+	From   []byte
+	To     []byte
+	Method []byte
+	// minimum gas price or max fee per gas in wei
+	MinFee *big.Int
+	Status string
+	Limit  uint64
+	Offset uint64
+}
+
+type MempoolTransactionApiResponse struct {
+	Hash                 string   `json:"hash"`
+	From                 string   `json:"from"`
+	To                   string   `json:"to,omitempty"`
+	Method               string   `json:"method,omitempty"`
+	Nonce                uint64   `json:"nonce"`
+	Value                string   `json:"value"`
+	Gas                  uint64   `json:"gas"`
+	GasPrice             string   `json:"gasPrice"`
+	MaxPriorityFeePerGas string   `json:"maxPriorityFeePerGas,omitempty"`
+	FirstSeen            int64    `json:"firstSeen"`
+	Status               string   `json:"status"`
+	BlockNumber          *int64   `json:"blockNumber,omitempty"`
+	IncludedAt           *int64   `json:"includedAt,omitempty"`
+	TimeToInclusion      *float64 `json:"timeToInclusion,omitempty"`
+	RemovedAt            *int64   `json:"removedAt,omitempty"`
+	ReplacedBy           string   `json:"replacedBy,omitempty"`
+}
+
+// MempoolStats summarizes the mempool transactions tracked within a time window, times to inclusion are in seconds
+type MempoolStats struct {
+	Pending               uint64  `db:"pending" json:"pending"`
+	Included              uint64  `db:"included" json:"included"`
+	Replaced              uint64  `db:"replaced" json:"replaced"`
+	Dropped               uint64  `db:"dropped" json:"dropped"`
+	MedianTimeToInclusion float64 `db:"median_time_to_inclusion" json:"medianTimeToInclusion"`
+	P90TimeToInclusion    float64 `db:"p90_time_to_inclusion" json:"p90TimeToInclusion"`
+	WindowSeconds         uint64  `db:"-" json:"windowSeconds"`
+}
+
 type MempoolTxPageData struct {
 	RawMempoolTransaction
 	TargetIsContract   bool
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 type NodeJobStatus string
@@ -22,11 +25,15 @@ type NodeJobType string
 
 const BLSToExecutionChangesNodeJobType NodeJobType = "BLS_TO_EXECUTION_CHANGES"
 const VoluntaryExitsNodeJobType NodeJobType = "VOLUNTARY_EXITS"
+const ConsolidationRequestsNodeJobType NodeJobType = "CONSOLIDATION_REQUESTS" // EIP-7251
+const WithdrawalRequestsNodeJobType NodeJobType = "WITHDRAWAL_REQUESTS"       // EIP-7002
 const UnknownNodeJobType NodeJobType = "UNKNOWN"
 
 var NodeJobTypes = []NodeJobType{
 	BLSToExecutionChangesNodeJobType,
 	VoluntaryExitsNodeJobType,
+	ConsolidationRequestsNodeJobType,
+	WithdrawalRequestsNodeJobType,
 }
 
 func NewNodeJob(data []byte) (*NodeJob, error) {
@@ -59,11 +66,71 @@ func (e CreateNodeJobUserError) Error() string {
 }
 
 type NodeJobValidatorInfo struct {
-	ValidatorIndex      uint64 `db:"validatorindex"`
-	PublicKey           []byte `db:"pubkey"`
-	WithdrawCredentials []byte `db:"withdrawalcredentials"`
-	ExitEpoch           uint64 `db:"exitepoch"`
-	Status              string
+	ValidatorIndex      uint64         `db:"validatorindex"`
+	PublicKey           []byte         `db:"pubkey"`
+	WithdrawCredentials []byte         `db:"withdrawalcredentials"`
+	ExitEpoch           uint64         `db:"exitepoch"`
+	Status              string         `db:"status"`
+	MessageStatus       sql.NullString `db:"message_status"`
+	SubmitCount         int            `db:"submit_count"`
+	SubmittedNodes      int            `db:"submitted_nodes"`
+	IncludedSlot        sql.NullInt64  `db:"included_slot"`
+}
+
+// NodeJobMessage tracks the submission and inclusion of a single operation of a node job
+type NodeJobMessage struct {
+	NodeJobID         string        `db:"node_job_id"`
+	ValidatorIndex    uint64        `db:"validatorindex"`
+	Status            NodeJobStatus `db:"status"`
+	SubmitCount       int           `db:"submit_count"`
+	SubmittedNodes    int           `db:"submitted_nodes"`
+	LastSubmittedTime sql.NullTime  `db:"last_submitted_time"`
+	IncludedSlot      sql.NullInt64 `db:"included_slot"`
+}
+
+// ConsolidationRequestsNodeJobData holds signed raw transactions calling the EIP-7251 consolidation request contract
+type ConsolidationRequestsNodeJobData struct {
+	ConsolidationRequests []hexutil.Bytes `json:"consolidation_requests"`
+}
+
+// WithdrawalRequestsNodeJobData holds signed raw transactions calling the EIP-7002 withdrawal request contract
+type WithdrawalRequestsNodeJobData struct {
+	WithdrawalRequests []hexutil.Bytes `json:"withdrawal_requests"`
+}
+
+// ExecutionLayerRequest is the decoded content of a signed consolidation- or withdrawal-request transaction
+type ExecutionLayerRequest struct {
+	TxHash       common.Hash
+	Sender       common.Address
+	SourcePubkey []byte
+	TargetPubkey []byte // only set for consolidation requests
+	Amount       uint64 // only set for withdrawal requests, 0 means full exit
+}
+
+// NodeJobDryRunReport is the result of checking a node job without queueing it
+type NodeJobDryRunReport struct {
+	Type          NodeJobType             `json:"type"`
+	Valid         bool                    `json:"valid"`
+	BatchVerified bool                    `json:"batch_verified"` // true if all signatures have been verified by a single batch verification
+	Messages      []*NodeJobDryRunMessage `json:"messages"`
+}
+
+// NodeJobDryRunMessage holds the checks of a single message of a node job, Position is its position in the submitted data
+type NodeJobDryRunMessage struct {
+	Position       int           `json:"position"`
+	ValidatorIndex uint64        `json:"validator_index"`
+	Pubkey         hexutil.Bytes `json:"pubkey,omitempty"`
+	Valid          bool          `json:"valid"`
+	Errors         []string      `json:"errors,omitempty"`
+	Warnings       []string      `json:"warnings,omitempty"`
+}
+
+func (m *NodeJobDryRunMessage) AddError(format string, args ...interface{}) {
+	m.Errors = append(m.Errors, fmt.Sprintf(format, args...))
+}
+
+func (m *NodeJobDryRunMessage) AddWarning(format string, args ...interface{}) {
+	m.Warnings = append(m.Warnings, fmt.Sprintf(format, args...))
 }
 
 // ParseData will try to unmarshal NodeJob.RawData into NodeJob.Data and determine NodeJob.Type by doing so. If it is not able to unmarshal any type it will return an error. It will sanitize NodeJob.RawData on success.
@@ -86,6 +153,30 @@ func (nj *NodeJob) ParseData() error {
 			return nj.SanitizeRawData()
 		}
 	}
+	{
+		d := &ConsolidationRequestsNodeJobData{}
+		err := unmarshalStrict(nj.RawData, d)
+		if err == nil && len(d.ConsolidationRequests) > 0 {
+			if nj.Type != "" && nj.Type != UnknownNodeJobType && nj.Type != ConsolidationRequestsNodeJobType {
+				return fmt.Errorf("nodejob.RawData mismatches nodejob.Type (%v)", nj.Type)
+			}
+			nj.Type = ConsolidationRequestsNodeJobType
+			nj.Data = d
+			return nj.SanitizeRawData()
+		}
+	}
+	{
+		d := &WithdrawalRequestsNodeJobData{}
+		err := unmarshalStrict(nj.RawData, d)
+		if err == nil && len(d.WithdrawalRequests) > 0 {
+			if nj.Type != "" && nj.Type != UnknownNodeJobType && nj.Type != WithdrawalRequestsNodeJobType {
+				return fmt.Errorf("nodejob.RawData mismatches nodejob.Type (%v)", nj.Type)
+			}
+			nj.Type = WithdrawalRequestsNodeJobType
+			nj.Data = d
+			return nj.SanitizeRawData()
+		}
+	}
 	{
 		//var d *VoluntaryExitsNodeJobData
 		var d *phase0.SignedVoluntaryExit
@@ -102,6 +193,12 @@ func (nj *NodeJob) ParseData() error {
 	return CreateNodeJobUserError{Message: "can not unmarshal data: invalid json"}
 }
 
+func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
 func (nj *NodeJob) SanitizeRawData() error {
 	d, err := json.Marshal(nj.Data)
 	if err != nil {
@@ -120,3 +217,13 @@ func (nj NodeJob) GetVoluntaryExitsNodeJobData() (*phase0.SignedVoluntaryExit, b
 	d, ok := nj.Data.(*phase0.SignedVoluntaryExit)
 	return d, ok
 }
+
+func (nj NodeJob) GetConsolidationRequestsNodeJobData() (*ConsolidationRequestsNodeJobData, bool) {
+	d, ok := nj.Data.(*ConsolidationRequestsNodeJobData)
+	return d, ok
+}
+
+func (nj NodeJob) GetWithdrawalRequestsNodeJobData() (*WithdrawalRequestsNodeJobData, bool) {
+	d, ok := nj.Data.(*WithdrawalRequestsNodeJobData)
+	return d, ok
+}
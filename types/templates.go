@@ -511,6 +511,69 @@ type ValidatorIncomeHistory struct {
 	WithdrawalAmount sql.NullInt64 `db:"withdrawals_amount"`
 }
 
+type RewardsLedgerEntryType string
+
+const (
+	RewardsLedgerConsensusReward RewardsLedgerEntryType = "consensus_reward"
+	RewardsLedgerExecutionFee    RewardsLedgerEntryType = "execution_fee"
+	RewardsLedgerMevReward       RewardsLedgerEntryType = "mev_reward"
+	RewardsLedgerWithdrawal      RewardsLedgerEntryType = "withdrawal"
+	RewardsLedgerDeposit         RewardsLedgerEntryType = "deposit"
+)
+
+// IsIncome returns true if the entry represents staking income (as opposed to a movement of principal)
+func (t RewardsLedgerEntryType) IsIncome() bool {
+	return t == RewardsLedgerConsensusReward || t == RewardsLedgerExecutionFee || t == RewardsLedgerMevReward
+}
+
+// RewardsLedgerEntry is a single row of an accounting export. Rewards are aggregated per validator and day,
+// deposits and withdrawals are listed individually with the slot they were included in.
+type RewardsLedgerEntry struct {
+	Day            int64                  `db:"day" json:"day"`
+	Date           time.Time              `db:"-" json:"date"`
+	ValidatorIndex uint64                 `db:"validatorindex" json:"validator_index"`
+	Type           RewardsLedgerEntryType `db:"type" json:"type"`
+	Slot           uint64                 `db:"slot" json:"slot,omitempty"`
+	Amount         decimal.Decimal        `db:"amount" json:"amount"` // denominated in the main currency of the chain
+	Currency       string                 `db:"-" json:"currency"`
+	Price          float64                `db:"-" json:"price"`
+	Value          float64                `db:"-" json:"value"`      // fiat value at the time of receipt
+	CostBasis      float64                `db:"-" json:"cost_basis"` // running fiat cost basis of the validator after this entry
+}
+
+type RewardsExportFormat string
+
+const (
+	RewardsExportFormatCSV          RewardsExportFormat = "csv"
+	RewardsExportFormatXLSX         RewardsExportFormat = "xlsx"
+	RewardsExportFormatKoinly       RewardsExportFormat = "koinly"
+	RewardsExportFormatCoinTracking RewardsExportFormat = "cointracking"
+	RewardsExportFormatJSON         RewardsExportFormat = "json"
+)
+
+const (
+	RewardsExportStatusPending = "pending"
+	RewardsExportStatusRunning = "running"
+	RewardsExportStatusDone    = "done"
+	RewardsExportStatusFailed  = "failed"
+)
+
+// RewardsExport is a background rewards export job, Data is only populated once the job is finished
+type RewardsExport struct {
+	ID         string              `db:"id" json:"id"`
+	UserID     sql.NullInt64       `db:"user_id" json:"-"`
+	Validators pq.Int64Array       `db:"validators" json:"validators"`
+	Currency   string              `db:"currency" json:"currency"`
+	Format     RewardsExportFormat `db:"format" json:"format"`
+	StartDay   int64               `db:"start_day" json:"start_day"`
+	EndDay     int64               `db:"end_day" json:"end_day"`
+	Status     string              `db:"status" json:"status"`
+	Error      sql.NullString      `db:"error" json:"-"`
+	CreatedTs  time.Time           `db:"created_ts" json:"created_ts"`
+	FinishedTs sql.NullTime        `db:"finished_ts" json:"-"`
+	Data       []byte              `db:"data" json:"-"`
+}
+
 type ValidatorBalanceHistoryChartData struct {
 	Epoch   uint64
 	Balance uint64
@@ -671,8 +734,9 @@ type BlockPageData struct {
 	SyncCommittee     []uint64 // TODO: Setting it to contain the validator index
 	BlobSidecars      []*BlockPageBlobSidecar
 
-	Tags       TagMetadataSlice `db:"tags"`
-	IsValidMev bool             `db:"is_valid_mev"`
+	Tags           TagMetadataSlice `db:"tags"`
+	IsValidMev     bool             `db:"is_valid_mev"`
+	BidCompetition *RelayBidCompetition
 	ValidatorProposalInfo
 }
 
@@ -1476,6 +1540,14 @@ type AdConfigurationPageData struct {
 	TemplateNames  []string
 }
 
+type ValidatorTagRulesPageData struct {
+	Rules     []*ValidatorTagRule
+	CsrfField template.HTML
+	New       ValidatorTagRule
+	RuleTypes []string
+	Error     string
+}
+
 type ExplorerConfigurationPageData struct {
 	Configurations ExplorerConfigurationMap
 	CsrfField      template.HTML
@@ -1498,6 +1570,22 @@ type WebhookPageData struct {
 	Flashes      []interface{}
 }
 
+type UserReportsPageData struct {
+	Schedules     []UserReportScheduleRow
+	Reports       []*UserReport
+	ScheduleLimit uint64
+	CsrfField     template.HTML
+	Flashes       []interface{}
+}
+
+type UserReportScheduleRow struct {
+	ID          uint64
+	Description string
+	Currency    string
+	Validators  string
+	LastSent    *time.Time
+}
+
 type EventNameCheckbox struct {
 	EventLabel string
 	EventName
@@ -1960,6 +2048,17 @@ type RelayInfo struct {
 	AverageValue   WeiString      `db:"avg_value"`
 	MaxValue       WeiString      `db:"max_value"`
 	MaxValueSlot   uint64         `db:"max_value_slot"`
+	VerifiedCount  uint64         `db:"verified_count"`
+	UnderpaidCount uint64         `db:"underpaid_count"`
+	UnderpaidValue WeiString      `db:"underpaid_value"`
+}
+
+// PayoutReliability returns the share of verified blocks whose proposer payment matched the value reported by the relay
+func (r *RelayInfo) PayoutReliability() float64 {
+	if r.VerifiedCount == 0 {
+		return 0
+	}
+	return float64(r.VerifiedCount-r.UnderpaidCount) / float64(r.VerifiedCount)
 }
 
 type BurnPageDataBlock struct {
@@ -2045,6 +2144,39 @@ type AdConfig struct {
 	HtmlContent     string `db:"html_content"`
 }
 
+// validator tag rule types, a rule tags the validators whose deposits, blocks or registry entries match its pattern
+const (
+	ValidatorTagRuleDepositAddress        = "deposit_address"
+	ValidatorTagRuleWithdrawalCredentials = "withdrawal_credentials"
+	ValidatorTagRuleFeeRecipient          = "fee_recipient"
+	ValidatorTagRuleGraffiti              = "graffiti"
+	ValidatorTagRuleRegistry              = "registry"
+)
+
+var ValidatorTagRuleTypes = []string{
+	ValidatorTagRuleDepositAddress,
+	ValidatorTagRuleWithdrawalCredentials,
+	ValidatorTagRuleFeeRecipient,
+	ValidatorTagRuleGraffiti,
+	ValidatorTagRuleRegistry,
+}
+
+// ValidatorTagRule assigns a tag to all validators matching the pattern of the rule type.
+// The cursors are the exclusive upper bounds of the deposit blocks, slots and validator indices the rule was evaluated for.
+type ValidatorTagRule struct {
+	Id                   uint64    `db:"id"`
+	Tag                  string    `db:"tag"`
+	RuleType             string    `db:"rule_type"`
+	Pattern              string    `db:"pattern"`
+	Enabled              bool      `db:"enabled"`
+	Source               string    `db:"source"`
+	DepositBlockCursor   uint64    `db:"deposit_block_cursor"`
+	SlotCursor           uint64    `db:"slot_cursor"`
+	ValidatorIndexCursor uint64    `db:"validator_index_cursor"`
+	UpdatedTs            time.Time `db:"updated_ts"`
+	TaggedValidators     uint64    `db:"tagged_validators"`
+}
+
 type ExplorerConfigurationCategory string
 type ExplorerConfigurationKey string
 type ExplorerConfigValue struct {
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const UnknownConsensusClient = "unknown"
+
+// ConsensusClients are the consensus clients proposed blocks can be attributed to
+var ConsensusClients = []string{"lighthouse", "prysm", "teku", "nimbus", "lodestar", "grandine"}
+
+// consensusClientCodes are the two letter client codes used in client version graffitis (e.g. GE1a2bLH3c4d)
+var consensusClientCodes = map[string]string{
+	"LH": "lighthouse",
+	"PM": "prysm",
+	"TK": "teku",
+	"NB": "nimbus",
+	"LS": "lodestar",
+	"GR": "grandine",
+}
+
+var executionClientCodes = map[string]bool{
+	"BU": true, "EJ": true, "EG": true, "GE": true, "NM": true, "RH": true, "TE": true,
+}
+
+var clientVersionGraffitiRE = regexp.MustCompile(`([A-Z]{2})([0-9a-fA-F]{2,4})([A-Z]{2})([0-9a-fA-F]{2,4})`)
+
+// default graffitis of the clients contain the client name followed by its version, e.g. Lighthouse/v4.5.0-441fc16
+var clientDefaultGraffitiRE = regexp.MustCompile(`(?i)\b(lighthouse|prysm|teku|nimbus|lodestar|grandine)[/\- ]v?[0-9]+\.[0-9]+`)
+
+var clientNameGraffitiRE = regexp.MustCompile(`(?i)\b(lighthouse|prysm|prysmatic|teku|nimbus|lodestar|grandine)\b`)
+
+// ClassifyGraffiti returns the consensus client a graffiti identifies together with the confidence of the classification.
+// Client version graffitis and default graffitis are trusted more than graffitis that merely mention a client.
+func ClassifyGraffiti(graffiti string) (string, float64) {
+	for _, match := range clientVersionGraffitiRE.FindAllStringSubmatch(graffiti, -1) {
+		if !executionClientCodes[match[1]] {
+			continue
+		}
+		if client, ok := consensusClientCodes[match[3]]; ok {
+			return client, 0.95
+		}
+	}
+	if match := clientDefaultGraffitiRE.FindStringSubmatch(graffiti); match != nil {
+		return strings.ToLower(match[1]), 0.9
+	}
+	if match := clientNameGraffitiRE.FindStringSubmatch(graffiti); match != nil {
+		client := strings.ToLower(match[1])
+		if client == "prysmatic" {
+			client = "prysm"
+		}
+		return client, 0.6
+	}
+	return UnknownConsensusClient, 0
+}
+
+// AttestationPackingFingerprint describes how a block packs its attestations: the order of the attestation slots,
+// whether the block is full and the distance of the first attestation to the block.
+// Clients pack attestations differently, so blocks with the same fingerprint are likely proposed by the same client.
+func AttestationPackingFingerprint(blockSlot uint64, attestationSlots []int64, maxAttestations int) string {
+	if len(attestationSlots) == 0 {
+		return "empty"
+	}
+
+	ascending, descending := true, true
+	for i := 1; i < len(attestationSlots); i++ {
+		if attestationSlots[i] < attestationSlots[i-1] {
+			ascending = false
+		}
+		if attestationSlots[i] > attestationSlots[i-1] {
+			descending = false
+		}
+	}
+	order := "mixed"
+	if ascending && !descending {
+		order = "asc"
+	} else if descending && !ascending {
+		order = "desc"
+	} else if ascending && descending {
+		order = "same"
+	}
+
+	fill := "partial"
+	if len(attestationSlots) >= maxAttestations {
+		fill = "full"
+	}
+
+	distance := int64(blockSlot) - attestationSlots[0]
+	if distance > 2 {
+		distance = 2
+	}
+	return fmt.Sprintf("%s/%s/%d", order, fill, distance)
+}
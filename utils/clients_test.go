@@ -0,0 +1,46 @@
+package utils
+
+import "testing"
+
+func TestClassifyGraffiti(t *testing.T) {
+	tests := []struct {
+		graffiti string
+		client   string
+	}{
+		{"GE1a2bLH3c4d", "lighthouse"},
+		{"NMe2a1PMf3b2 solo staker", "prysm"},
+		{"Lighthouse/v4.5.0-441fc16", "lighthouse"},
+		{"teku/v23.10.0", "teku"},
+		{"running nimbus at home", "nimbus"},
+		{"Prysmatic Labs", "prysm"},
+		{"XX1a2bLH3c4d", UnknownConsensusClient},
+		{"hello world", UnknownConsensusClient},
+		{"", UnknownConsensusClient},
+	}
+
+	for _, tt := range tests {
+		client, _ := ClassifyGraffiti(tt.graffiti)
+		if client != tt.client {
+			t.Errorf("ClassifyGraffiti(%q) = %v, want %v", tt.graffiti, client, tt.client)
+		}
+	}
+}
+
+func TestAttestationPackingFingerprint(t *testing.T) {
+	tests := []struct {
+		attestationSlots []int64
+		fingerprint      string
+	}{
+		{[]int64{}, "empty"},
+		{[]int64{99, 98, 97}, "desc/partial/1"},
+		{[]int64{90, 95, 99, 99}, "asc/full/2"},
+		{[]int64{99, 97, 98}, "mixed/partial/1"},
+	}
+
+	for _, tt := range tests {
+		fingerprint := AttestationPackingFingerprint(100, tt.attestationSlots, 4)
+		if fingerprint != tt.fingerprint {
+			t.Errorf("AttestationPackingFingerprint(%v) = %v, want %v", tt.attestationSlots, fingerprint, tt.fingerprint)
+		}
+	}
+}
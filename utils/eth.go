@@ -2,11 +2,17 @@ package utils
 
 import (
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"math/big"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
 
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/ethereum/go-ethereum/common"
+	gethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/herumi/bls-eth-go-binary/bls"
 	"github.com/prysmaticlabs/go-ssz"
 	"github.com/sirupsen/logrus"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
@@ -23,48 +29,75 @@ func init() {
 // see: https://github.com/wealdtech/ethdo/blob/master/cmd/validator/credentials/set/process.go
 // see: https://github.com/prysmaticlabs/prysm/blob/76ed634f7386609f0d1ee47b703eb0143c995464/beacon-chain/core/blocks/withdrawals.go
 func VerifyBlsToExecutionChangeSignature(op *capella.SignedBLSToExecutionChange) error {
-	genesisForkVersion := phase0.Version{}
-	genesisValidatorsRoot := phase0.Root{}
-	copy(genesisForkVersion[:], MustParseHex(Config.Chain.ClConfig.GenesisForkVersion))
-	copy(genesisValidatorsRoot[:], MustParseHex(Config.Chain.GenesisValidatorsRoot))
+	signingRoot, err := BlsToExecutionChangeSigningRoot(op, MustParseHex(Config.Chain.ClConfig.GenesisForkVersion))
+	if err != nil {
+		return err
+	}
+	return verifyBlsSignature(op.Signature[:], op.Message.FromBLSPubkey[:], signingRoot)
+}
 
-	forkDataRoot, err := (&phase0.ForkData{
-		CurrentVersion:        genesisForkVersion,
-		GenesisValidatorsRoot: genesisValidatorsRoot,
-	}).HashTreeRoot()
+// VerifyVoluntaryExitSignature verifies the signature of an voluntary_exit message
+func VerifyVoluntaryExitSignature(op *phase0.SignedVoluntaryExit, forkVersion, pubkeyBytes []byte) error {
+	signingRoot, err := VoluntaryExitSigningRoot(op, forkVersion)
 	if err != nil {
-		return fmt.Errorf("failed hashing hashtreeroot: %w", err)
+		return err
 	}
+	return verifyBlsSignature(op.Signature[:], pubkeyBytes, signingRoot)
+}
 
-	domain := phase0.Domain{}
-	domainBLSToExecutionChange := MustParseHex(Config.Chain.DomainBLSToExecutionChange)
-	copy(domain[:], domainBLSToExecutionChange[:])
-	copy(domain[4:], forkDataRoot[:])
+// BlsToExecutionChangeSigningRoot returns the signing root of an bls_to_execution_change message,
+// the fork version has to be the genesis fork version for the signature to be valid
+func BlsToExecutionChangeSigningRoot(op *capella.SignedBLSToExecutionChange, forkVersion []byte) (phase0.Root, error) {
+	root, err := op.Message.HashTreeRoot()
+	if err != nil {
+		return phase0.Root{}, fmt.Errorf("failed to generate message root: %w", err)
+	}
+	return computeSigningRoot(root, MustParseHex(Config.Chain.DomainBLSToExecutionChange), forkVersion)
+}
 
+// VoluntaryExitSigningRoot returns the signing root of an voluntary_exit message for the given fork version
+func VoluntaryExitSigningRoot(op *phase0.SignedVoluntaryExit, forkVersion []byte) (phase0.Root, error) {
 	root, err := op.Message.HashTreeRoot()
 	if err != nil {
-		return fmt.Errorf("failed to generate message root: %w", err)
+		return phase0.Root{}, fmt.Errorf("failed to generate message root: %w", err)
 	}
+	return computeSigningRoot(root, MustParseHex(Config.Chain.DomainVoluntaryExit), forkVersion)
+}
 
-	sigBytes := make([]byte, len(op.Signature))
-	copy(sigBytes, op.Signature[:])
+func computeSigningRoot(objectRoot phase0.Root, domainType, forkVersion []byte) (phase0.Root, error) {
+	currentVersion := phase0.Version{}
+	genesisValidatorsRoot := phase0.Root{}
+	copy(currentVersion[:], forkVersion)
+	copy(genesisValidatorsRoot[:], MustParseHex(Config.Chain.GenesisValidatorsRoot))
 
-	sig, err := e2types.BLSSignatureFromBytes(sigBytes)
+	forkDataRoot, err := (&phase0.ForkData{
+		CurrentVersion:        currentVersion,
+		GenesisValidatorsRoot: genesisValidatorsRoot,
+	}).HashTreeRoot()
 	if err != nil {
-		return fmt.Errorf("invalid signature: %w", err)
+		return phase0.Root{}, fmt.Errorf("failed hashing hashtreeroot: %w", err)
 	}
 
+	domain := phase0.Domain{}
+	copy(domain[:], domainType[:])
+	copy(domain[4:], forkDataRoot[:])
+
 	container := &phase0.SigningData{
-		ObjectRoot: root,
+		ObjectRoot: objectRoot,
 		Domain:     domain,
 	}
 	signingRoot, err := ssz.HashTreeRoot(container)
 	if err != nil {
-		return fmt.Errorf("failed to generate signing root: %w", err)
+		return phase0.Root{}, fmt.Errorf("failed to generate signing root: %w", err)
 	}
+	return signingRoot, nil
+}
 
-	pubkeyBytes := make([]byte, len(op.Message.FromBLSPubkey))
-	copy(pubkeyBytes, op.Message.FromBLSPubkey[:])
+func verifyBlsSignature(sigBytes, pubkeyBytes []byte, signingRoot phase0.Root) error {
+	sig, err := e2types.BLSSignatureFromBytes(sigBytes)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
 	pubkey, err := e2types.BLSPublicKeyFromBytes(pubkeyBytes)
 	if err != nil {
 		return fmt.Errorf("invalid public key: %w", err)
@@ -72,61 +105,123 @@ func VerifyBlsToExecutionChangeSignature(op *capella.SignedBLSToExecutionChange)
 	if !sig.Verify(signingRoot[:], pubkey) {
 		return fmt.Errorf("signature does not verify")
 	}
-
 	return nil
 }
 
-// VerifyVoluntaryExitSignature verifies the signature of an voluntary_exit message
-func VerifyVoluntaryExitSignature(op *phase0.SignedVoluntaryExit, forkVersion, pubkeyBytes []byte) error {
-	currentVersion := phase0.Version{}
-	genesisValidatorsRoot := phase0.Root{}
-	copy(currentVersion[:], forkVersion)
-	copy(genesisValidatorsRoot[:], MustParseHex(Config.Chain.GenesisValidatorsRoot))
+// BlsSignatureSet is a single signature to be checked by BatchVerifyBlsSignatures
+type BlsSignatureSet struct {
+	Signature   []byte
+	Pubkey      []byte
+	SigningRoot phase0.Root
+}
 
-	forkDataRoot, err := (&phase0.ForkData{
-		CurrentVersion:        currentVersion,
-		GenesisValidatorsRoot: genesisValidatorsRoot,
-	}).HashTreeRoot()
-	if err != nil {
-		return fmt.Errorf("failed hashing hashtreeroot: %w", err)
+// BatchVerifyBlsSignatures verifies all signature sets at once using randomized batch verification,
+// which is considerably faster than verifying each signature on its own. It returns false if any
+// signature is invalid, in that case the sets have to be verified one by one to find the invalid ones.
+func BatchVerifyBlsSignatures(sets []BlsSignatureSet) (bool, error) {
+	if len(sets) == 0 {
+		return true, nil
 	}
+	sigs := make([]bls.Sign, len(sets))
+	pubs := make([]bls.PublicKey, len(sets))
+	msgs := make([]byte, 0, len(sets)*32)
+	for i, set := range sets {
+		err := sigs[i].Deserialize(set.Signature)
+		if err != nil {
+			return false, fmt.Errorf("invalid signature at position %v: %w", i, err)
+		}
+		err = pubs[i].Deserialize(set.Pubkey)
+		if err != nil {
+			return false, fmt.Errorf("invalid public key at position %v: %w", i, err)
+		}
+		msgs = append(msgs, set.SigningRoot[:]...)
+	}
+	return bls.MultiVerify(sigs, pubs, msgs), nil
+}
 
-	domain := phase0.Domain{}
-	domainVoluntaryExit := MustParseHex(Config.Chain.DomainVoluntaryExit)
-	copy(domain[:], domainVoluntaryExit[:])
-	copy(domain[4:], forkDataRoot[:])
-
-	root, err := op.Message.HashTreeRoot()
-	if err != nil {
-		return fmt.Errorf("failed to generate message root: %w", err)
+// ForkVersions returns all configured fork versions of the chain by fork name
+func ForkVersions() map[string][]byte {
+	cfg := Config.Chain.ClConfig
+	forkVersions := map[string][]byte{}
+	for name, version := range map[string]string{
+		"genesis":   cfg.GenesisForkVersion,
+		"altair":    cfg.AltairForkVersion,
+		"bellatrix": cfg.BellatrixForkVersion,
+		"capella":   cfg.CappellaForkVersion,
+		"deneb":     cfg.DenebForkVersion,
+	} {
+		if version != "" {
+			forkVersions[name] = MustParseHex(version)
+		}
 	}
+	return forkVersions
+}
 
-	sigBytes := make([]byte, len(op.Signature))
-	copy(sigBytes, op.Signature[:])
+// system contracts receiving execution layer triggered requests
+// see: https://eips.ethereum.org/EIPS/eip-7251 and https://eips.ethereum.org/EIPS/eip-7002
+var ConsolidationRequestContractAddress = common.HexToAddress("0x0000BBdDc7CE488642fb579F8B00f3a590007251")
+var WithdrawalRequestContractAddress = common.HexToAddress("0x00000961Ef480Eb55e80D19ad83579A64c007002")
 
-	sig, err := e2types.BLSSignatureFromBytes(sigBytes)
+// VerifyConsolidationRequestTransaction decodes a signed consolidation request transaction (source pubkey ++ target pubkey)
+// and verifies that it is signed for the configured chain and targets the consolidation request contract
+func VerifyConsolidationRequestTransaction(rawTx []byte) (*types.ExecutionLayerRequest, error) {
+	tx, sender, err := decodeRequestTransaction(rawTx, ConsolidationRequestContractAddress)
 	if err != nil {
-		return fmt.Errorf("invalid signature: %w", err)
+		return nil, err
 	}
-
-	container := &phase0.SigningData{
-		ObjectRoot: root,
-		Domain:     domain,
+	data := tx.Data()
+	if len(data) != 96 {
+		return nil, fmt.Errorf("invalid calldata length %v, expected 96", len(data))
 	}
-	signingRoot, err := ssz.HashTreeRoot(container)
+	return &types.ExecutionLayerRequest{
+		TxHash:       tx.Hash(),
+		Sender:       sender,
+		SourcePubkey: data[:48],
+		TargetPubkey: data[48:96],
+	}, nil
+}
+
+// VerifyWithdrawalRequestTransaction decodes a signed withdrawal request transaction (pubkey ++ uint64 amount in gwei)
+// and verifies that it is signed for the configured chain and targets the withdrawal request contract
+func VerifyWithdrawalRequestTransaction(rawTx []byte) (*types.ExecutionLayerRequest, error) {
+	tx, sender, err := decodeRequestTransaction(rawTx, WithdrawalRequestContractAddress)
 	if err != nil {
-		return fmt.Errorf("failed to generate signing root: %w", err)
+		return nil, err
+	}
+	data := tx.Data()
+	if len(data) != 56 {
+		return nil, fmt.Errorf("invalid calldata length %v, expected 56", len(data))
 	}
+	return &types.ExecutionLayerRequest{
+		TxHash:       tx.Hash(),
+		Sender:       sender,
+		SourcePubkey: data[:48],
+		Amount:       binary.BigEndian.Uint64(data[48:56]),
+	}, nil
+}
 
-	pubkey, err := e2types.BLSPublicKeyFromBytes(pubkeyBytes)
+func decodeRequestTransaction(rawTx []byte, contract common.Address) (*gethtypes.Transaction, common.Address, error) {
+	tx := &gethtypes.Transaction{}
+	err := tx.UnmarshalBinary(rawTx)
 	if err != nil {
-		return fmt.Errorf("invalid public key: %w", err)
+		return nil, common.Address{}, fmt.Errorf("invalid transaction: %w", err)
 	}
-	if !sig.Verify(signingRoot[:], pubkey) {
-		return fmt.Errorf("signature does not verify")
+	chainID := new(big.Int).SetUint64(Config.Chain.Id)
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return nil, common.Address{}, fmt.Errorf("transaction is signed for chain %v, expected %v", tx.ChainId(), chainID)
 	}
-
-	return nil
+	if tx.To() == nil || *tx.To() != contract {
+		return nil, common.Address{}, fmt.Errorf("transaction is not sent to %v", contract.Hex())
+	}
+	// the contracts charge a dynamic fee of at least 1 wei, excess is not refunded
+	if tx.Value().Sign() <= 0 {
+		return nil, common.Address{}, fmt.Errorf("transaction does not pay the request fee")
+	}
+	sender, err := gethtypes.Sender(gethtypes.LatestSignerForChainID(chainID), tx)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("signature does not verify: %w", err)
+	}
+	return tx, sender, nil
 }
 
 func FixAddressCasing(add string) string {
@@ -0,0 +1,195 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+)
+
+// GasOracleProjectionBlocks is the number of projected base fees, it is also the longest horizon used for the worst case base fee of the max fee recommendations
+const GasOracleProjectionBlocks = 6
+
+// EIP-1559 base fee parameters
+const gasOracleBaseFeeChangeDenominator = 8
+const gasOracleElasticityMultiplier = 2
+
+// percentile of the effective priority fees of a block a transaction has to pay to be considered included,
+// the lowest fees are ignored as blocks commonly contain zero tip transactions of their builder
+const gasOracleInclusionPercentile = 5
+
+// recommendation levels, the target blocks correspond to the inclusion speeds of the gas now page (15 seconds, 1 minute, 3 minutes and 10 minutes)
+var gasOracleLevels = []struct {
+	Percentile   float64
+	TargetBlocks uint64
+}{
+	{90, 1},
+	{60, 5},
+	{30, 15},
+	{10, 50},
+}
+
+// GasOracleBlock contains the data of an indexed block used by the gas price oracle
+type GasOracleBlock struct {
+	Number   uint64
+	BaseFee  *big.Int
+	GasUsed  uint64
+	GasLimit uint64
+	// effective priority fees paid by the transactions of the block, sorted ascending
+	PriorityFees []*big.Int
+}
+
+// NewGasOracleBlock extracts the base fee, gas usage and effective priority fees of an indexed block
+func NewGasOracleBlock(block *types.Eth1Block) GasOracleBlock {
+	b := GasOracleBlock{
+		Number:       block.GetNumber(),
+		BaseFee:      new(big.Int).SetBytes(block.GetBaseFee()),
+		GasUsed:      block.GetGasUsed(),
+		GasLimit:     block.GetGasLimit(),
+		PriorityFees: make([]*big.Int, 0, len(block.GetTransactions())),
+	}
+	for _, tx := range block.GetTransactions() {
+		b.PriorityFees = append(b.PriorityFees, EffectivePriorityFee(tx, b.BaseFee))
+	}
+	sort.Slice(b.PriorityFees, func(i, j int) bool { return b.PriorityFees[i].Cmp(b.PriorityFees[j]) < 0 })
+	return b
+}
+
+// EffectivePriorityFee returns the priority fee per gas a transaction paid to the fee recipient of a block with the given base fee
+func EffectivePriorityFee(tx *types.Eth1Transaction, baseFee *big.Int) *big.Int {
+	fee := new(big.Int)
+	if len(tx.GetMaxFeePerGas()) > 0 {
+		fee.SetBytes(tx.GetMaxFeePerGas()).Sub(fee, baseFee)
+		if maxPriorityFee := new(big.Int).SetBytes(tx.GetMaxPriorityFeePerGas()); maxPriorityFee.Cmp(fee) < 0 {
+			fee = maxPriorityFee
+		}
+	} else {
+		fee.SetBytes(tx.GetGasPrice()).Sub(fee, baseFee)
+	}
+	if fee.Sign() < 0 {
+		fee.SetUint64(0)
+	}
+	return fee
+}
+
+// NextBaseFee returns the base fee of the block following a block with the given base fee and gas usage as defined by EIP-1559
+func NextBaseFee(baseFee *big.Int, gasUsed, gasLimit uint64) *big.Int {
+	gasTarget := gasLimit / gasOracleElasticityMultiplier
+	if gasTarget == 0 || gasUsed == gasTarget {
+		return new(big.Int).Set(baseFee)
+	}
+
+	if gasUsed > gasTarget {
+		delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasUsed-gasTarget))
+		delta.Div(delta, new(big.Int).SetUint64(gasTarget))
+		delta.Div(delta, big.NewInt(gasOracleBaseFeeChangeDenominator))
+		if delta.Sign() == 0 {
+			delta.SetUint64(1)
+		}
+		return delta.Add(baseFee, delta)
+	}
+
+	delta := new(big.Int).Mul(baseFee, new(big.Int).SetUint64(gasTarget-gasUsed))
+	delta.Div(delta, new(big.Int).SetUint64(gasTarget))
+	delta.Div(delta, big.NewInt(gasOracleBaseFeeChangeDenominator))
+	return delta.Sub(baseFee, delta)
+}
+
+// ComputeGasOracle derives fee recommendations from blocks ordered by ascending number.
+// The recommended priority fee of a level is the median of the level percentile of the effective priority fees of every block,
+// the max fee covers the worst case base fee (full blocks) until the target block of the level, limited to GasOracleProjectionBlocks blocks.
+func ComputeGasOracle(blocks []GasOracleBlock) (*types.GasOracleData, error) {
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no blocks to compute gas oracle data")
+	}
+	latest := blocks[len(blocks)-1]
+
+	// the expected base fees assume the average gas utilization of the blocks
+	utilization := 0.0
+	for _, b := range blocks {
+		if b.GasLimit > 0 {
+			utilization += float64(b.GasUsed) / float64(b.GasLimit)
+		}
+	}
+	expectedGasUsed := uint64(utilization / float64(len(blocks)) * float64(latest.GasLimit))
+
+	projection := make([]*big.Int, GasOracleProjectionBlocks)
+	worstCase := make([]*big.Int, GasOracleProjectionBlocks)
+	projection[0] = NextBaseFee(latest.BaseFee, latest.GasUsed, latest.GasLimit)
+	worstCase[0] = projection[0]
+	for i := 1; i < GasOracleProjectionBlocks; i++ {
+		projection[i] = NextBaseFee(projection[i-1], expectedGasUsed, latest.GasLimit)
+		worstCase[i] = NextBaseFee(worstCase[i-1], latest.GasLimit, latest.GasLimit)
+	}
+
+	inclusionThresholds := make([]*big.Int, len(blocks))
+	for i, b := range blocks {
+		inclusionThresholds[i] = gasOraclePercentile(b.PriorityFees, gasOracleInclusionPercentile)
+	}
+
+	recommendations := make([]types.GasOracleRecommendation, len(gasOracleLevels))
+	for i, level := range gasOracleLevels {
+		percentiles := []*big.Int{}
+		for _, b := range blocks {
+			if len(b.PriorityFees) > 0 {
+				percentiles = append(percentiles, gasOraclePercentile(b.PriorityFees, level.Percentile))
+			}
+		}
+		sort.Slice(percentiles, func(i, j int) bool { return percentiles[i].Cmp(percentiles[j]) < 0 })
+		priorityFee := gasOraclePercentile(percentiles, 50)
+
+		horizon := int(math.Min(float64(level.TargetBlocks), GasOracleProjectionBlocks))
+		recommendations[i] = types.GasOracleRecommendation{
+			MaxFeePerGas:         new(big.Int).Add(worstCase[horizon-1], priorityFee),
+			MaxPriorityFeePerGas: priorityFee,
+			TargetBlocks:         level.TargetBlocks,
+			Confidence:           gasOracleConfidence(inclusionThresholds, priorityFee, level.TargetBlocks),
+		}
+	}
+
+	return &types.GasOracleData{
+		BlockNumber:       latest.Number,
+		Blocks:            uint64(len(blocks)),
+		BaseFee:           latest.BaseFee,
+		BaseFeeProjection: projection,
+		Rapid:             recommendations[0],
+		Fast:              recommendations[1],
+		Standard:          recommendations[2],
+		Slow:              recommendations[3],
+		Timestamp:         time.Now().UnixMilli(),
+	}, nil
+}
+
+// gasOracleConfidence returns the share of windows of targetBlocks consecutive blocks in which at least one block included transactions paying priorityFee
+func gasOracleConfidence(inclusionThresholds []*big.Int, priorityFee *big.Int, targetBlocks uint64) float64 {
+	windowSize := int(targetBlocks)
+	if windowSize > len(inclusionThresholds) {
+		windowSize = len(inclusionThresholds)
+	}
+	windows := len(inclusionThresholds) - windowSize + 1
+	included := 0
+	for i := 0; i < windows; i++ {
+		for _, threshold := range inclusionThresholds[i : i+windowSize] {
+			if threshold.Cmp(priorityFee) <= 0 {
+				included++
+				break
+			}
+		}
+	}
+	return float64(included) / float64(windows)
+}
+
+// gasOraclePercentile returns the nearest rank percentile of ascending values, zero if there are no values
+func gasOraclePercentile(values []*big.Int, percentile float64) *big.Int {
+	if len(values) == 0 {
+		return new(big.Int)
+	}
+	rank := int(math.Ceil(percentile/100*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return new(big.Int).Set(values[rank])
+}
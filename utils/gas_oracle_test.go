@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+)
+
+func TestNextBaseFee(t *testing.T) {
+	tests := []struct {
+		baseFee  int64
+		gasUsed  uint64
+		gasLimit uint64
+		want     int64
+	}{
+		{1000, 15_000_000, 30_000_000, 1000},
+		{1000, 30_000_000, 30_000_000, 1125},
+		{1000, 0, 30_000_000, 875},
+		{1000, 22_500_000, 30_000_000, 1062},
+		// an increase is at least 1 wei
+		{7, 15_000_001, 30_000_000, 8},
+	}
+
+	for _, tt := range tests {
+		got := NextBaseFee(big.NewInt(tt.baseFee), tt.gasUsed, tt.gasLimit)
+		if got.Int64() != tt.want {
+			t.Errorf("NextBaseFee(%v, %v, %v) = %v, want %v", tt.baseFee, tt.gasUsed, tt.gasLimit, got, tt.want)
+		}
+	}
+}
+
+func TestEffectivePriorityFee(t *testing.T) {
+	baseFee := big.NewInt(100)
+	tests := []struct {
+		name string
+		tx   *types.Eth1Transaction
+		want int64
+	}{
+		{"legacy", &types.Eth1Transaction{GasPrice: big.NewInt(130).Bytes()}, 30},
+		{"dynamic fee capped by priority fee", &types.Eth1Transaction{MaxFeePerGas: big.NewInt(200).Bytes(), MaxPriorityFeePerGas: big.NewInt(10).Bytes()}, 10},
+		{"dynamic fee capped by max fee", &types.Eth1Transaction{MaxFeePerGas: big.NewInt(105).Bytes(), MaxPriorityFeePerGas: big.NewInt(10).Bytes()}, 5},
+		{"below base fee", &types.Eth1Transaction{GasPrice: big.NewInt(90).Bytes()}, 0},
+	}
+
+	for _, tt := range tests {
+		got := EffectivePriorityFee(tt.tx, baseFee)
+		if got.Int64() != tt.want {
+			t.Errorf("EffectivePriorityFee() %v = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestComputeGasOracle(t *testing.T) {
+	blocks := []GasOracleBlock{}
+	for i := uint64(0); i < 20; i++ {
+		fees := []*big.Int{}
+		for fee := int64(1); fee <= 10; fee++ {
+			fees = append(fees, big.NewInt(fee))
+		}
+		blocks = append(blocks, GasOracleBlock{Number: 100 + i, BaseFee: big.NewInt(1000), GasUsed: 30_000_000, GasLimit: 30_000_000, PriorityFees: fees})
+	}
+	// the latest block is at the gas target, the other blocks are full
+	blocks[len(blocks)-1].GasUsed = 15_000_000
+
+	data, err := ComputeGasOracle(blocks)
+	if err != nil {
+		t.Fatalf("ComputeGasOracle() error = %v", err)
+	}
+	if data.BlockNumber != 119 || data.Blocks != 20 || len(data.BaseFeeProjection) != GasOracleProjectionBlocks {
+		t.Fatalf("ComputeGasOracle() = %+v", data)
+	}
+	// the following blocks are projected with the average utilization of 97.5%
+	if data.BaseFeeProjection[0].Int64() != 1000 || data.BaseFeeProjection[1].Int64() != 1118 {
+		t.Errorf("ComputeGasOracle() base fee projection = %v", data.BaseFeeProjection)
+	}
+
+	if data.Rapid.MaxPriorityFeePerGas.Int64() != 9 || data.Slow.MaxPriorityFeePerGas.Int64() != 1 {
+		t.Errorf("ComputeGasOracle() priority fees rapid = %v, slow = %v, want 9, 1", data.Rapid.MaxPriorityFeePerGas, data.Slow.MaxPriorityFeePerGas)
+	}
+	// the rapid max fee covers the next block only, the fast max fee 5 full blocks
+	if data.Rapid.MaxFeePerGas.Int64() != 1009 || data.Fast.MaxFeePerGas.Int64() != 1600+6 {
+		t.Errorf("ComputeGasOracle() max fees rapid = %v, fast = %v", data.Rapid.MaxFeePerGas, data.Fast.MaxFeePerGas)
+	}
+	if data.Rapid.Confidence != 1 || data.Slow.TargetBlocks != 50 {
+		t.Errorf("ComputeGasOracle() rapid = %+v, slow = %+v", data.Rapid, data.Slow)
+	}
+
+	// blocks only containing high tips lower the confidence of low recommendations
+	for i := range blocks[:10] {
+		blocks[i].PriorityFees = []*big.Int{big.NewInt(50)}
+	}
+	data, err = ComputeGasOracle(blocks)
+	if err != nil {
+		t.Fatalf("ComputeGasOracle() error = %v", err)
+	}
+	if data.Rapid.Confidence != 0.5 {
+		t.Errorf("ComputeGasOracle() rapid confidence = %v, want 0.5", data.Rapid.Confidence)
+	}
+
+	if _, err := ComputeGasOracle(nil); err == nil {
+		t.Errorf("ComputeGasOracle(nil) expected an error")
+	}
+}
@@ -0,0 +1,189 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MachineMetricAlertSyncDistanceField is a virtual field containing the number of slots the head of a beacon node is behind the wall clock slot
+// or the number of blocks the head of an execution node is behind the highest known block
+const MachineMetricAlertSyncDistanceField = "sync_distance"
+
+// MachineMetricAlertMaxDuration is the longest window a custom machine alert condition can be required to hold for
+const MachineMetricAlertMaxDuration = time.Hour * 24
+
+// metrics older than this are not used to trigger alerts, offline machines are covered by the machine offline notification
+const machineMetricAlertMaxAge = time.Minute * 10
+
+// machine metrics are stored at most once per minute, the window of an alert is considered covered if the oldest metric is at most this much younger than the window start
+const machineMetricAlertWindowTolerance = time.Minute * 2
+
+var machineMetricAlertComparators = map[string]struct {
+	Symbol  string
+	Compare func(value, threshold float64) bool
+}{
+	"gt":  {">", func(value, threshold float64) bool { return value > threshold }},
+	"gte": {">=", func(value, threshold float64) bool { return value >= threshold }},
+	"lt":  {"<", func(value, threshold float64) bool { return value < threshold }},
+	"lte": {"<=", func(value, threshold float64) bool { return value <= threshold }},
+	"eq":  {"=", func(value, threshold float64) bool { return value == threshold }},
+	"neq": {"!=", func(value, threshold float64) bool { return value != threshold }},
+}
+
+// MachineMetricAlertValue is the value of the field of a custom machine alert at a point in time
+type MachineMetricAlertValue struct {
+	Timestamp time.Time
+	Value     float64
+}
+
+// MachineMetricAlertComparatorSymbol returns the mathematical symbol of an alert comparator (e.g. > for gt)
+func MachineMetricAlertComparatorSymbol(comparator string) string {
+	return machineMetricAlertComparators[comparator].Symbol
+}
+
+// ValidateMachineMetricAlert checks that the alert references an existing numeric field of its process and uses a supported mode, comparator and duration
+func ValidateMachineMetricAlert(alert *types.MachineMetricAlert) error {
+	metric := NewMachineMetric(alert.Process)
+	if metric == nil {
+		return fmt.Errorf("invalid process %v, must be system, beaconnode, validator or executionnode", alert.Process)
+	}
+	if alert.Field == MachineMetricAlertSyncDistanceField {
+		if alert.Process != "beaconnode" && alert.Process != "executionnode" {
+			return fmt.Errorf("field %v is only available for the beaconnode and executionnode processes", alert.Field)
+		}
+	} else {
+		field := metric.ProtoReflect().Descriptor().Fields().ByName(protoreflect.Name(alert.Field))
+		if field == nil || alert.Field == "timestamp" || machineMetricAlertFieldValue(metric.ProtoReflect(), field) == nil {
+			return fmt.Errorf("invalid field %v, must be a numeric field of the %v metrics", alert.Field, alert.Process)
+		}
+	}
+	if alert.Mode != "value" && alert.Mode != "rate" {
+		return fmt.Errorf("invalid mode %v, must be value or rate", alert.Mode)
+	}
+	if _, ok := machineMetricAlertComparators[alert.Comparator]; !ok {
+		return fmt.Errorf("invalid comparator %v, must be gt, gte, lt, lte, eq or neq", alert.Comparator)
+	}
+	if time.Duration(alert.DurationMinutes)*time.Minute > MachineMetricAlertMaxDuration {
+		return fmt.Errorf("duration must be at most %v minutes", MachineMetricAlertMaxDuration.Minutes())
+	}
+	return nil
+}
+
+// MachineMetricAlertValues returns the values of the alert field of the metrics, which must be ordered by time.
+// Rate alerts return the per second rate between consecutive metrics, counter resets are skipped.
+func MachineMetricAlertValues(alert *types.MachineMetricAlert, metrics []proto.Message) []MachineMetricAlertValue {
+	values := make([]MachineMetricAlertValue, 0, len(metrics))
+	for _, metric := range metrics {
+		m := metric.ProtoReflect()
+		fields := m.Descriptor().Fields()
+		timestampField := fields.ByName("timestamp")
+		if timestampField == nil {
+			continue
+		}
+		ts := time.UnixMilli(int64(m.Get(timestampField).Uint()))
+
+		var value *float64
+		if alert.Field == MachineMetricAlertSyncDistanceField {
+			value = machineMetricAlertSyncDistance(m, ts)
+		} else if field := fields.ByName(protoreflect.Name(alert.Field)); field != nil {
+			value = machineMetricAlertFieldValue(m, field)
+		}
+		if value == nil {
+			continue
+		}
+		values = append(values, MachineMetricAlertValue{Timestamp: ts, Value: *value})
+	}
+
+	if alert.Mode != "rate" {
+		return values
+	}
+	rates := make([]MachineMetricAlertValue, 0, len(values))
+	for i := 1; i < len(values); i++ {
+		seconds := values[i].Timestamp.Sub(values[i-1].Timestamp).Seconds()
+		delta := values[i].Value - values[i-1].Value
+		if seconds <= 0 || delta < 0 {
+			continue
+		}
+		rates = append(rates, MachineMetricAlertValue{Timestamp: values[i].Timestamp, Value: delta / seconds})
+	}
+	return rates
+}
+
+// EvaluateMachineMetricAlert returns whether the alert condition held for all values within the duration window of the alert and the latest value.
+// Alerts only trigger if the latest value is recent and the values cover the whole window.
+func EvaluateMachineMetricAlert(alert *types.MachineMetricAlert, values []MachineMetricAlertValue, now time.Time) (bool, float64) {
+	if len(values) == 0 {
+		return false, 0
+	}
+	comparator, ok := machineMetricAlertComparators[alert.Comparator]
+	if !ok {
+		return false, 0
+	}
+
+	latest := values[len(values)-1]
+	if now.Sub(latest.Timestamp) > machineMetricAlertMaxAge {
+		return false, latest.Value
+	}
+
+	windowStart := latest.Timestamp.Add(-time.Duration(alert.DurationMinutes) * time.Minute)
+	if values[0].Timestamp.After(windowStart.Add(machineMetricAlertWindowTolerance)) {
+		return false, latest.Value
+	}
+
+	for i := len(values) - 1; i >= 0 && !values[i].Timestamp.Before(windowStart); i-- {
+		if !comparator.Compare(values[i].Value, alert.Threshold) {
+			return false, latest.Value
+		}
+	}
+	return true, latest.Value
+}
+
+// machineMetricAlertSyncDistance returns the sync distance of a beacon or execution node metric, nil for all other metrics
+func machineMetricAlertSyncDistance(m protoreflect.Message, ts time.Time) *float64 {
+	fields := m.Descriptor().Fields()
+	distance := 0.0
+	if headBlockField := fields.ByName("sync_execution_head_block"); headBlockField != nil {
+		headBlock, highestBlock := m.Get(headBlockField).Uint(), m.Get(fields.ByName("sync_execution_highest_block")).Uint()
+		if highestBlock > headBlock {
+			distance = float64(highestBlock - headBlock)
+		}
+		return &distance
+	}
+	if headSlotField := fields.ByName("sync_beacon_head_slot"); headSlotField != nil {
+		headSlot := m.Get(headSlotField).Uint()
+		if slot := TimeToSlot(uint64(ts.Unix())); slot > headSlot {
+			distance = float64(slot - headSlot)
+		}
+		return &distance
+	}
+	return nil
+}
+
+// machineMetricAlertFieldValue returns the value of a numeric or boolean field as float, nil for all other field kinds
+func machineMetricAlertFieldValue(m protoreflect.Message, field protoreflect.FieldDescriptor) *float64 {
+	if field.IsList() || field.IsMap() {
+		return nil
+	}
+	var value float64
+	v := m.Get(field)
+	switch field.Kind() {
+	case protoreflect.Uint64Kind, protoreflect.Uint32Kind, protoreflect.Fixed64Kind, protoreflect.Fixed32Kind:
+		value = float64(v.Uint())
+	case protoreflect.Int64Kind, protoreflect.Int32Kind, protoreflect.Sint64Kind, protoreflect.Sint32Kind, protoreflect.Sfixed64Kind, protoreflect.Sfixed32Kind:
+		value = float64(v.Int())
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		value = v.Float()
+	case protoreflect.BoolKind:
+		if v.Bool() {
+			value = 1
+		}
+	default:
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEvaluateMachineMetricAlert(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	metrics := []proto.Message{}
+	for i := 10; i >= 0; i-- {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		// 1 MB/s received until the last 3 minutes, 10 MB/s since then
+		received := uint64(60_000_000 * (10 - i))
+		if i < 3 {
+			received = 60_000_000*7 + 600_000_000*uint64(3-i)
+		}
+		metrics = append(metrics, &types.MachineMetricSystem{Timestamp: uint64(ts.UnixMilli()), NetworkNodeBytesTotalReceive: received})
+	}
+
+	alert := &types.MachineMetricAlert{Process: "system", Field: "network_node_bytes_total_receive", Mode: "rate", Comparator: "gt", Threshold: 5_000_000, DurationMinutes: 2}
+	if err := ValidateMachineMetricAlert(alert); err != nil {
+		t.Fatalf("ValidateMachineMetricAlert() error = %v", err)
+	}
+
+	values := MachineMetricAlertValues(alert, metrics)
+	if len(values) != 10 {
+		t.Fatalf("MachineMetricAlertValues() returned %v values, want 10", len(values))
+	}
+
+	if triggered, value := EvaluateMachineMetricAlert(alert, values, now); !triggered || value != 10_000_000 {
+		t.Errorf("EvaluateMachineMetricAlert() = %v, %v, want true, 10000000", triggered, value)
+	}
+
+	alert.DurationMinutes = 5
+	if triggered, _ := EvaluateMachineMetricAlert(alert, values, now); triggered {
+		t.Errorf("EvaluateMachineMetricAlert() triggered although the condition did not hold for the whole window")
+	}
+
+	alert.DurationMinutes = 60
+	alert.Comparator = "gte"
+	alert.Threshold = 1_000_000
+	if triggered, _ := EvaluateMachineMetricAlert(alert, values, now); triggered {
+		t.Errorf("EvaluateMachineMetricAlert() triggered although the values do not cover the window")
+	}
+
+	if triggered, _ := EvaluateMachineMetricAlert(&types.MachineMetricAlert{Comparator: "gt", Threshold: 5_000_000}, values, now.Add(time.Hour)); triggered {
+		t.Errorf("EvaluateMachineMetricAlert() triggered on outdated values")
+	}
+
+	if err := ValidateMachineMetricAlert(&types.MachineMetricAlert{Process: "system", Field: "misc_os", Mode: "value", Comparator: "gt"}); err == nil {
+		t.Errorf("ValidateMachineMetricAlert() accepted a string field")
+	}
+}
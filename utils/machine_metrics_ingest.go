@@ -0,0 +1,560 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// MetricSample is the latest value of a single prometheus or opentelemetry time series
+type MetricSample struct {
+	Name        string
+	Labels      map[string]string
+	Value       float64
+	TimestampMs int64
+}
+
+// MachineMetric is a machine metric of a single process derived from metric samples
+type MachineMetric struct {
+	Machine string
+	Process string
+	Metric  proto.Message
+}
+
+// machineMetricMapping maps the sum of all samples of Metric accepted by Filter onto the protobuf field Field.
+// If Count is set the number of matching samples is used instead of their sum.
+type machineMetricMapping struct {
+	Field  string
+	Metric string
+	Filter func(labels map[string]string) bool
+	Count  bool
+}
+
+func labelEquals(name, value string) func(labels map[string]string) bool {
+	return func(labels map[string]string) bool {
+		return labels[name] == value
+	}
+}
+
+func notLoopbackDevice(labels map[string]string) bool {
+	return labels["device"] != "lo"
+}
+
+var processMachineMetricMappings = []machineMetricMapping{
+	{Field: "cpu_process_seconds_total", Metric: "process_cpu_seconds_total"},
+	{Field: "memory_process_bytes", Metric: "process_resident_memory_bytes"},
+}
+
+// machineMetricMappings maps well known node exporter, beacon node and validator client metrics onto the machine metric protobuf fields,
+// the first mapping of a field with matching samples is used
+var machineMetricMappings = map[string][]machineMetricMapping{
+	"system": {
+		{Field: "cpu_cores", Metric: "node_cpu_seconds_total", Filter: labelEquals("mode", "idle"), Count: true},
+		{Field: "cpu_node_system_seconds_total", Metric: "node_cpu_seconds_total", Filter: labelEquals("mode", "system")},
+		{Field: "cpu_node_user_seconds_total", Metric: "node_cpu_seconds_total", Filter: labelEquals("mode", "user")},
+		{Field: "cpu_node_iowait_seconds_total", Metric: "node_cpu_seconds_total", Filter: labelEquals("mode", "iowait")},
+		{Field: "cpu_node_idle_seconds_total", Metric: "node_cpu_seconds_total", Filter: labelEquals("mode", "idle")},
+		{Field: "memory_node_bytes_total", Metric: "node_memory_MemTotal_bytes"},
+		{Field: "memory_node_bytes_free", Metric: "node_memory_MemFree_bytes"},
+		{Field: "memory_node_bytes_cached", Metric: "node_memory_Cached_bytes"},
+		{Field: "memory_node_bytes_buffers", Metric: "node_memory_Buffers_bytes"},
+		{Field: "disk_node_bytes_total", Metric: "node_filesystem_size_bytes", Filter: labelEquals("mountpoint", "/")},
+		{Field: "disk_node_bytes_free", Metric: "node_filesystem_avail_bytes", Filter: labelEquals("mountpoint", "/")},
+		{Field: "disk_node_io_seconds", Metric: "node_disk_io_time_seconds_total"},
+		{Field: "disk_node_reads_total", Metric: "node_disk_reads_completed_total"},
+		{Field: "disk_node_writes_total", Metric: "node_disk_writes_completed_total"},
+		{Field: "network_node_bytes_total_receive", Metric: "node_network_receive_bytes_total", Filter: notLoopbackDevice},
+		{Field: "network_node_bytes_total_transmit", Metric: "node_network_transmit_bytes_total", Filter: notLoopbackDevice},
+		{Field: "misc_node_boot_ts_seconds", Metric: "node_boot_time_seconds"},
+	},
+	"beaconnode": append([]machineMetricMapping{
+		{Field: "sync_beacon_head_slot", Metric: "beacon_head_slot"},
+		{Field: "network_peers_connected", Metric: "libp2p_peers"},
+		{Field: "network_peers_connected", Metric: "p2p_peer_count", Filter: labelEquals("state", "Connected")},
+		{Field: "network_peers_connected", Metric: "connected_libp2p_peers"},
+	}, processMachineMetricMappings...),
+	"validator": append([]machineMetricMapping{
+		{Field: "validator_total", Metric: "vc_validators_total_count"},
+	}, processMachineMetricMappings...),
+	"executionnode": append([]machineMetricMapping{
+		{Field: "sync_execution_head_block", Metric: "chain_head_block"},
+		{Field: "sync_execution_head_block", Metric: "ethereum_blockchain_height"},
+		{Field: "sync_execution_highest_block", Metric: "ethereum_best_known_block_number"},
+		// geth and erigon import headers ahead of blocks while syncing, the head header is the highest known block
+		{Field: "sync_execution_highest_block", Metric: "chain_head_header"},
+		{Field: "sync_execution_highest_block", Metric: "sync", Filter: labelEquals("stage", "headers")},
+		{Field: "network_peers_connected", Metric: "p2p_peers"},
+		{Field: "network_peers_connected", Metric: "ethereum_peer_count"},
+		{Field: "txpool_transactions_pending", Metric: "txpool_pending"},
+		{Field: "txpool_transactions_queued", Metric: "txpool_queued"},
+		{Field: "disk_executionchain_bytes_total", Metric: "eth_db_chaindata_disk_size"},
+	}, processMachineMetricMappings...),
+}
+
+// executionClientJobs are job label fragments identifying the metrics of an execution client
+var executionClientJobs = []string{"execution", "geth", "nethermind", "besu", "erigon", "reth"}
+
+// machineMetricProcess returns the process (system, beaconnode, validator or executionnode) a sample belongs to or an empty string if it is unknown.
+// An explicit process label takes precedence, otherwise the process is derived from the metric name and the job label.
+func machineMetricProcess(sample *MetricSample) string {
+	switch sample.Labels["process"] {
+	case "system", "beaconnode", "validator", "executionnode":
+		return sample.Labels["process"]
+	}
+	if strings.HasPrefix(sample.Name, "node_") {
+		return "system"
+	}
+	job := strings.ToLower(sample.Labels["job"])
+	for _, executionJob := range executionClientJobs {
+		if strings.Contains(job, executionJob) {
+			return "executionnode"
+		}
+	}
+	switch {
+	case strings.Contains(job, "validator"):
+		return "validator"
+	case strings.Contains(job, "beacon"):
+		return "beaconnode"
+	case strings.HasPrefix(sample.Name, "beacon_") || strings.HasPrefix(sample.Name, "libp2p_"):
+		return "beaconnode"
+	case strings.HasPrefix(sample.Name, "validator_") || strings.HasPrefix(sample.Name, "vc_"):
+		return "validator"
+	case strings.HasPrefix(sample.Name, "chain_") || strings.HasPrefix(sample.Name, "txpool_") || strings.HasPrefix(sample.Name, "eth_db_") || strings.HasPrefix(sample.Name, "ethereum_"):
+		return "executionnode"
+	}
+	return ""
+}
+
+// machineMetricMachine returns the machine name of a sample, the machine label takes precedence over the default machine and the host of the instance label
+func machineMetricMachine(sample *MetricSample, defaultMachine string) string {
+	if sample.Labels["machine"] != "" {
+		return sample.Labels["machine"]
+	}
+	if defaultMachine != "" {
+		return defaultMachine
+	}
+	host, _, err := net.SplitHostPort(sample.Labels["instance"])
+	if err != nil {
+		return sample.Labels["instance"]
+	}
+	return host
+}
+
+// NewMachineMetric returns an empty machine metric protobuf of the process (system, beaconnode, validator or executionnode)
+func NewMachineMetric(process string) proto.Message {
+	switch process {
+	case "system":
+		return &types.MachineMetricSystem{}
+	case "beaconnode":
+		return &types.MachineMetricNode{}
+	case "validator":
+		return &types.MachineMetricValidator{}
+	case "executionnode":
+		return &types.MachineMetricExecution{}
+	}
+	return nil
+}
+
+// MachineMetricsFromSamples maps the samples onto the machine metric protobufs, one per machine and process.
+// Samples named like a protobuf field (e.g. sync_eth2_synced) are mapped directly, string fields are read from labels of the same name (e.g. client_name).
+func MachineMetricsFromSamples(samples []*MetricSample, defaultMachine, exporterVersion string) []*MachineMetric {
+	type key struct{ machine, process string }
+	grouped := make(map[key][]*MetricSample)
+	for _, sample := range samples {
+		process := machineMetricProcess(sample)
+		if process == "" {
+			continue
+		}
+		k := key{machineMetricMachine(sample, defaultMachine), process}
+		grouped[k] = append(grouped[k], sample)
+	}
+
+	metrics := make([]*MachineMetric, 0, len(grouped))
+	for k, group := range grouped {
+		msg := NewMachineMetric(k.process)
+		m := msg.ProtoReflect()
+		fields := m.Descriptor().Fields()
+
+		timestamp := int64(0)
+		byName := make(map[string][]*MetricSample)
+		for _, sample := range group {
+			byName[sample.Name] = append(byName[sample.Name], sample)
+			if sample.TimestampMs > timestamp {
+				timestamp = sample.TimestampMs
+			}
+			for i := 0; i < fields.Len(); i++ {
+				field := fields.Get(i)
+				if field.Kind() == protoreflect.StringKind && field.Name() != "machine" && sample.Labels[string(field.Name())] != "" {
+					m.Set(field, protoreflect.ValueOfString(sample.Labels[string(field.Name())]))
+				}
+			}
+		}
+
+		mapped := make(map[string]bool)
+		for _, mapping := range machineMetricMappings[k.process] {
+			if mapped[mapping.Field] {
+				continue
+			}
+			value, count := 0.0, 0
+			for _, sample := range byName[mapping.Metric] {
+				if mapping.Filter != nil && !mapping.Filter(sample.Labels) {
+					continue
+				}
+				value += sample.Value
+				count++
+			}
+			if count == 0 {
+				continue
+			}
+			if mapping.Count {
+				value = float64(count)
+			}
+			setMachineMetricField(m, fields.ByName(protoreflect.Name(mapping.Field)), value)
+			mapped[mapping.Field] = true
+		}
+		for name, named := range byName {
+			field := fields.ByName(protoreflect.Name(name))
+			if field == nil || mapped[name] || name == "timestamp" {
+				continue
+			}
+			value := 0.0
+			for _, sample := range named {
+				value += sample.Value
+			}
+			setMachineMetricField(m, field, value)
+		}
+
+		if k.process == "system" {
+			setMachineMetricOs(m, byName["node_uname_info"])
+		}
+		if execution, ok := msg.(*types.MachineMetricExecution); ok && !mapped["sync_execution_syncing"] && byName["sync_execution_syncing"] == nil {
+			execution.SyncExecutionSyncing = execution.SyncExecutionHighestBlock > execution.SyncExecutionHeadBlock
+		}
+		m.Set(fields.ByName("timestamp"), protoreflect.ValueOfUint64(uint64(timestamp)))
+		m.Set(fields.ByName("exporter_version"), protoreflect.ValueOfString(exporterVersion))
+
+		metrics = append(metrics, &MachineMetric{Machine: k.machine, Process: k.process, Metric: msg})
+	}
+
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Machine != metrics[j].Machine {
+			return metrics[i].Machine < metrics[j].Machine
+		}
+		return metrics[i].Process < metrics[j].Process
+	})
+	return metrics
+}
+
+func setMachineMetricField(m protoreflect.Message, field protoreflect.FieldDescriptor, value float64) {
+	if field == nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	switch field.Kind() {
+	case protoreflect.Uint64Kind:
+		if value < 0 {
+			value = 0
+		}
+		m.Set(field, protoreflect.ValueOfUint64(uint64(value)))
+	case protoreflect.BoolKind:
+		m.Set(field, protoreflect.ValueOfBool(value != 0))
+	}
+}
+
+// setMachineMetricOs sets the misc_os field (lin, mac, win or unk) from the sysname label of node_uname_info
+func setMachineMetricOs(m protoreflect.Message, samples []*MetricSample) {
+	if len(samples) == 0 {
+		return
+	}
+	os := "unk"
+	switch strings.ToLower(samples[0].Labels["sysname"]) {
+	case "linux":
+		os = "lin"
+	case "darwin":
+		os = "mac"
+	case "windows":
+		os = "win"
+	}
+	m.Set(m.Descriptor().Fields().ByName("misc_os"), protoreflect.ValueOfString(os))
+}
+
+// forEachProtoField calls fn for every field of the protobuf encoded message b, value is set for length delimited fields and scalar for all others
+func forEachProtoField(b []byte, fn func(num protowire.Number, value []byte, scalar uint64) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		var value []byte
+		var scalar uint64
+		switch typ {
+		case protowire.VarintType:
+			scalar, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			scalar, n = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			scalar = uint64(v)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		err := fn(num, value, scalar)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeRemoteWriteRequest decodes an uncompressed prometheus remote-write WriteRequest and returns the latest sample of every time series
+func DecodeRemoteWriteRequest(b []byte) ([]*MetricSample, error) {
+	samples := []*MetricSample{}
+	err := forEachProtoField(b, func(num protowire.Number, timeSeries []byte, _ uint64) error {
+		if num != 1 { // timeseries
+			return nil
+		}
+		sample := &MetricSample{Labels: make(map[string]string), TimestampMs: math.MinInt64}
+		err := forEachProtoField(timeSeries, func(num protowire.Number, value []byte, _ uint64) error {
+			switch num {
+			case 1: // labels
+				var name, labelValue string
+				err := forEachProtoField(value, func(num protowire.Number, value []byte, _ uint64) error {
+					switch num {
+					case 1:
+						name = string(value)
+					case 2:
+						labelValue = string(value)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				sample.Labels[name] = labelValue
+			case 2: // samples
+				var sampleValue float64
+				var timestamp int64
+				err := forEachProtoField(value, func(num protowire.Number, _ []byte, scalar uint64) error {
+					switch num {
+					case 1:
+						sampleValue = math.Float64frombits(scalar)
+					case 2:
+						timestamp = int64(scalar)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+				if timestamp >= sample.TimestampMs {
+					sample.Value = sampleValue
+					sample.TimestampMs = timestamp
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error decoding time series: %w", err)
+		}
+		sample.Name = sample.Labels["__name__"]
+		delete(sample.Labels, "__name__")
+		if sample.Name != "" && sample.TimestampMs != math.MinInt64 {
+			samples = append(samples, sample)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decoding remote-write request: %w", err)
+	}
+	return samples, nil
+}
+
+// DecodeOTLPMetricsRequest decodes a protobuf encoded OTLP ExportMetricsServiceRequest and returns the latest data point of every gauge and cumulative sum.
+// Metric and attribute names are converted to prometheus names (dots are replaced by underscores), resource attributes are added as labels
+// with service.name and service.instance.id becoming the job and instance labels.
+func DecodeOTLPMetricsRequest(b []byte) ([]*MetricSample, error) {
+	samples := []*MetricSample{}
+	err := forEachProtoField(b, func(num protowire.Number, resourceMetrics []byte, _ uint64) error {
+		if num != 1 { // resource_metrics
+			return nil
+		}
+		resourceLabels := make(map[string]string)
+		scopeMetrics := [][]byte{}
+		err := forEachProtoField(resourceMetrics, func(num protowire.Number, value []byte, _ uint64) error {
+			switch num {
+			case 1: // resource
+				return forEachProtoField(value, func(num protowire.Number, value []byte, _ uint64) error {
+					if num != 1 { // attributes
+						return nil
+					}
+					return decodeOTLPAttribute(value, resourceLabels)
+				})
+			case 2: // scope_metrics
+				scopeMetrics = append(scopeMetrics, value)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if resourceLabels["service_name"] != "" {
+			resourceLabels["job"] = resourceLabels["service_name"]
+		}
+		if resourceLabels["service_instance_id"] != "" {
+			resourceLabels["instance"] = resourceLabels["service_instance_id"]
+		}
+
+		for _, scope := range scopeMetrics {
+			err := forEachProtoField(scope, func(num protowire.Number, metric []byte, _ uint64) error {
+				if num != 2 { // metrics
+					return nil
+				}
+				metricSamples, err := decodeOTLPMetric(metric, resourceLabels)
+				if err != nil {
+					return err
+				}
+				samples = append(samples, metricSamples...)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error decoding otlp metrics request: %w", err)
+	}
+	return samples, nil
+}
+
+func otlpPrometheusName(name string) string {
+	return strings.NewReplacer(".", "_", "-", "_", "/", "_").Replace(name)
+}
+
+func decodeOTLPMetric(metric []byte, resourceLabels map[string]string) ([]*MetricSample, error) {
+	var name string
+	dataPoints := [][]byte{}
+	err := forEachProtoField(metric, func(num protowire.Number, value []byte, _ uint64) error {
+		switch num {
+		case 1: // name
+			name = otlpPrometheusName(string(value))
+		case 5, 7: // gauge, sum
+			isDelta := false
+			points := [][]byte{}
+			err := forEachProtoField(value, func(num protowire.Number, value []byte, scalar uint64) error {
+				switch num {
+				case 1: // data_points
+					points = append(points, value)
+				case 2: // aggregation_temporality
+					isDelta = scalar == 1
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			// machine metrics are totals, delta sums can not be mapped
+			if !isDelta {
+				dataPoints = append(dataPoints, points...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, nil
+	}
+
+	latest := make(map[string]*MetricSample)
+	for _, point := range dataPoints {
+		sample := &MetricSample{Name: name, Labels: make(map[string]string, len(resourceLabels))}
+		for k, v := range resourceLabels {
+			sample.Labels[k] = v
+		}
+		err := forEachProtoField(point, func(num protowire.Number, value []byte, scalar uint64) error {
+			switch num {
+			case 3: // time_unix_nano
+				sample.TimestampMs = int64(scalar / 1e6)
+			case 4: // as_double
+				sample.Value = math.Float64frombits(scalar)
+			case 6: // as_int
+				sample.Value = float64(int64(scalar))
+			case 7: // attributes
+				return decodeOTLPAttribute(value, sample.Labels)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		labelNames := make([]string, 0, len(sample.Labels))
+		for k, v := range sample.Labels {
+			labelNames = append(labelNames, k+"="+v)
+		}
+		sort.Strings(labelNames)
+		seriesKey := strings.Join(labelNames, ",")
+		if existing, ok := latest[seriesKey]; !ok || sample.TimestampMs >= existing.TimestampMs {
+			latest[seriesKey] = sample
+		}
+	}
+
+	samples := make([]*MetricSample, 0, len(latest))
+	for _, sample := range latest {
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// decodeOTLPAttribute decodes a KeyValue attribute with a scalar value into labels
+func decodeOTLPAttribute(b []byte, labels map[string]string) error {
+	var key, value string
+	err := forEachProtoField(b, func(num protowire.Number, anyValue []byte, _ uint64) error {
+		switch num {
+		case 1: // key
+			key = otlpPrometheusName(string(anyValue))
+		case 2: // value
+			return forEachProtoField(anyValue, func(num protowire.Number, v []byte, scalar uint64) error {
+				switch num {
+				case 1: // string_value
+					value = string(v)
+				case 2: // bool_value
+					value = fmt.Sprintf("%v", scalar != 0)
+				case 3: // int_value
+					value = fmt.Sprintf("%d", int64(scalar))
+				case 4: // double_value
+					value = fmt.Sprintf("%v", math.Float64frombits(scalar))
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if key != "" {
+		labels[key] = value
+	}
+	return nil
+}
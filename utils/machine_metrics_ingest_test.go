@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func encodeRemoteWriteTimeSeries(labels map[string]string, value float64, timestamp int64) []byte {
+	var ts []byte
+	for name, labelValue := range labels {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, name)
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, labelValue)
+		ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, label)
+	}
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(timestamp))
+	ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+	ts = protowire.AppendBytes(ts, sample)
+
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	return protowire.AppendBytes(req, ts)
+}
+
+func TestMachineMetricsFromRemoteWrite(t *testing.T) {
+	var req []byte
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "node_cpu_seconds_total", "cpu": "0", "mode": "idle", "instance": "host1:9100"}, 100, 1000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "node_cpu_seconds_total", "cpu": "1", "mode": "idle", "instance": "host1:9100"}, 50, 1000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "node_cpu_seconds_total", "cpu": "0", "mode": "system", "instance": "host1:9100"}, 7, 1000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "node_network_receive_bytes_total", "device": "lo", "instance": "host1:9100"}, 1000, 1000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "node_network_receive_bytes_total", "device": "eth0", "instance": "host1:9100"}, 42, 1000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "node_uname_info", "sysname": "Linux", "instance": "host1:9100"}, 1, 1000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "beacon_head_slot", "job": "beacon", "instance": "host1:5054"}, 1234, 2000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "process_cpu_seconds_total", "job": "beacon", "instance": "host1:5054", "client_name": "lighthouse"}, 12.5, 2000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "sync_eth2_synced", "job": "beacon", "instance": "host1:5054"}, 1, 2000)...)
+	req = append(req, encodeRemoteWriteTimeSeries(map[string]string{"__name__": "process_cpu_seconds_total", "job": "node", "instance": "host1:9100"}, 3, 2000)...)
+
+	samples, err := DecodeRemoteWriteRequest(req)
+	if err != nil {
+		t.Fatalf("DecodeRemoteWriteRequest() error = %v", err)
+	}
+	if len(samples) != 10 {
+		t.Fatalf("DecodeRemoteWriteRequest() returned %v samples, want 10", len(samples))
+	}
+
+	metrics := MachineMetricsFromSamples(samples, "", "test")
+	if len(metrics) != 2 {
+		t.Fatalf("MachineMetricsFromSamples() returned %v metrics, want 2", len(metrics))
+	}
+	if metrics[0].Machine != "host1" || metrics[0].Process != "beaconnode" || metrics[1].Process != "system" {
+		t.Fatalf("MachineMetricsFromSamples() returned unexpected machines or processes: %+v", metrics)
+	}
+
+	node := metrics[0].Metric.(*types.MachineMetricNode)
+	if node.SyncBeaconHeadSlot != 1234 || node.CpuProcessSecondsTotal != 12 || !node.SyncEth2Synced || node.ClientName != "lighthouse" || node.Timestamp != 2000 {
+		t.Errorf("unexpected beaconnode metric: %+v", node)
+	}
+
+	system := metrics[1].Metric.(*types.MachineMetricSystem)
+	if system.CpuCores != 2 || system.CpuNodeIdleSecondsTotal != 150 || system.CpuNodeSystemSecondsTotal != 7 || system.NetworkNodeBytesTotalReceive != 42 || system.MiscOs != "lin" {
+		t.Errorf("unexpected system metric: %+v", system)
+	}
+}
+
+func TestMachineMetricsFromSamplesExecution(t *testing.T) {
+	samples := []*MetricSample{
+		{Name: "chain_head_block", Labels: map[string]string{"job": "geth", "instance": "host1:6060"}, Value: 100, TimestampMs: 1000},
+		{Name: "sync_execution_highest_block", Labels: map[string]string{"job": "geth", "instance": "host1:6060"}, Value: 150, TimestampMs: 1000},
+		{Name: "p2p_peers", Labels: map[string]string{"job": "geth", "instance": "host1:6060"}, Value: 25, TimestampMs: 1000},
+		{Name: "txpool_pending", Labels: map[string]string{"instance": "host1:6060"}, Value: 4000, TimestampMs: 1000},
+	}
+
+	metrics := MachineMetricsFromSamples(samples, "", "test")
+	if len(metrics) != 1 || metrics[0].Process != "executionnode" {
+		t.Fatalf("MachineMetricsFromSamples() returned unexpected processes: %+v", metrics)
+	}
+	execution := metrics[0].Metric.(*types.MachineMetricExecution)
+	if execution.SyncExecutionHeadBlock != 100 || execution.SyncExecutionHighestBlock != 150 || !execution.SyncExecutionSyncing || execution.NetworkPeersConnected != 25 || execution.TxpoolTransactionsPending != 4000 {
+		t.Errorf("unexpected executionnode metric: %+v", execution)
+	}
+}
+
+func TestMachineMetricsFromSamplesExecutionHighestBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []*MetricSample
+	}{
+		{"geth", []*MetricSample{
+			{Name: "chain_head_block", Labels: map[string]string{"job": "geth"}, Value: 100},
+			{Name: "chain_head_header", Labels: map[string]string{"job": "geth"}, Value: 150},
+		}},
+		{"erigon", []*MetricSample{
+			{Name: "chain_head_block", Labels: map[string]string{"job": "erigon"}, Value: 100},
+			{Name: "sync", Labels: map[string]string{"job": "erigon", "stage": "headers"}, Value: 150},
+			{Name: "sync", Labels: map[string]string{"job": "erigon", "stage": "bodies"}, Value: 120},
+		}},
+	}
+
+	for _, tt := range tests {
+		metrics := MachineMetricsFromSamples(tt.samples, "host1", "test")
+		if len(metrics) != 1 || metrics[0].Process != "executionnode" {
+			t.Fatalf("%v: MachineMetricsFromSamples() returned unexpected processes: %+v", tt.name, metrics)
+		}
+		execution := metrics[0].Metric.(*types.MachineMetricExecution)
+		if execution.SyncExecutionHeadBlock != 100 || execution.SyncExecutionHighestBlock != 150 || !execution.SyncExecutionSyncing {
+			t.Errorf("%v: unexpected executionnode metric: %+v", tt.name, execution)
+		}
+	}
+}
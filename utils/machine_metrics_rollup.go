@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// machineMetricLastValueFields are cumulative or monotonic machine metric fields, rollups keep their latest value while all other numeric fields are averaged
+var machineMetricLastValueFields = map[protoreflect.Name]bool{
+	"timestamp":                           true,
+	"cpu_node_system_seconds_total":       true,
+	"cpu_node_user_seconds_total":         true,
+	"cpu_node_iowait_seconds_total":       true,
+	"cpu_node_idle_seconds_total":         true,
+	"disk_node_io_seconds":                true,
+	"disk_node_reads_total":               true,
+	"disk_node_writes_total":              true,
+	"network_node_bytes_total_receive":    true,
+	"network_node_bytes_total_transmit":   true,
+	"misc_node_boot_ts_seconds":           true,
+	"cpu_process_seconds_total":           true,
+	"client_build":                        true,
+	"network_libp2p_bytes_total_receive":  true,
+	"network_libp2p_bytes_total_transmit": true,
+	"sync_beacon_head_slot":               true,
+	"sync_execution_head_block":           true,
+	"sync_execution_highest_block":        true,
+}
+
+// RollupMachineMetrics aggregates machine metrics of the same process ordered by timestamp into a single metric.
+// Counters, booleans and strings keep their latest value, gauges (e.g. free memory or connected peers) are averaged.
+func RollupMachineMetrics(metrics []proto.Message) proto.Message {
+	if len(metrics) == 0 {
+		return nil
+	}
+	result := proto.Clone(metrics[len(metrics)-1])
+	m := result.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		if field.Kind() != protoreflect.Uint64Kind || machineMetricLastValueFields[field.Name()] {
+			continue
+		}
+		sum := 0.0
+		for _, metric := range metrics {
+			sum += float64(metric.ProtoReflect().Get(field).Uint())
+		}
+		m.Set(field, protoreflect.ValueOfUint64(uint64(sum/float64(len(metrics))+0.5)))
+	}
+	return result
+}
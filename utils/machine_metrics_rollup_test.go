@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRollupMachineMetrics(t *testing.T) {
+	metrics := []proto.Message{
+		&types.MachineMetricNode{Timestamp: 1000, CpuProcessSecondsTotal: 10, NetworkPeersConnected: 40, SyncEth2Synced: false, ClientVersion: "v1"},
+		&types.MachineMetricNode{Timestamp: 2000, CpuProcessSecondsTotal: 12, NetworkPeersConnected: 50, SyncEth2Synced: true, ClientVersion: "v1"},
+		&types.MachineMetricNode{Timestamp: 3000, CpuProcessSecondsTotal: 15, NetworkPeersConnected: 61, SyncEth2Synced: true, ClientVersion: "v2"},
+	}
+
+	rollup := RollupMachineMetrics(metrics).(*types.MachineMetricNode)
+	if rollup.Timestamp != 3000 || rollup.CpuProcessSecondsTotal != 15 || rollup.NetworkPeersConnected != 50 || !rollup.SyncEth2Synced || rollup.ClientVersion != "v2" {
+		t.Errorf("unexpected rollup: %+v", rollup)
+	}
+
+	if RollupMachineMetrics(nil) != nil {
+		t.Errorf("RollupMachineMetrics(nil) should return nil")
+	}
+}
@@ -717,6 +717,11 @@ func ReadConfig(cfg *types.Config, path string) error {
 			cfg.Frontend.ClCurrency = "mGNO"
 			cfg.Frontend.ClCurrencyDecimals = 18
 			cfg.Frontend.ClCurrencyDivisor = 1e9
+		case "lukso":
+			cfg.Frontend.MainCurrency = "LYX"
+			cfg.Frontend.ClCurrency = "LYX"
+			cfg.Frontend.ClCurrencyDecimals = 18
+			cfg.Frontend.ClCurrencyDivisor = 1e9
 		default:
 			cfg.Frontend.MainCurrency = "ETH"
 			cfg.Frontend.ClCurrency = "ETH"
@@ -731,6 +736,10 @@ func ReadConfig(cfg *types.Config, path string) error {
 			cfg.Frontend.ElCurrency = "xDAI"
 			cfg.Frontend.ElCurrencyDecimals = 18
 			cfg.Frontend.ElCurrencyDivisor = 1e18
+		case "lukso":
+			cfg.Frontend.ElCurrency = "LYX"
+			cfg.Frontend.ElCurrencyDecimals = 18
+			cfg.Frontend.ElCurrencyDivisor = 1e18
 		default:
 			cfg.Frontend.ElCurrency = "ETH"
 			cfg.Frontend.ElCurrencyDecimals = 18
@@ -738,6 +747,30 @@ func ReadConfig(cfg *types.Config, path string) error {
 		}
 	}
 
+	if cfg.Prices.MaxAge == 0 {
+		cfg.Prices.MaxAge = time.Hour
+	}
+	if cfg.Prices.Coingecko.BaseUrl == "" {
+		cfg.Prices.Coingecko.BaseUrl = "https://api.coingecko.com/api/v3"
+	}
+	if cfg.Prices.Cryptocompare.BaseUrl == "" {
+		cfg.Prices.Cryptocompare.BaseUrl = "https://min-api.cryptocompare.com"
+	}
+	if len(cfg.Prices.Historic.Providers) == 0 {
+		cfg.Prices.Historic.Providers = []string{"coingecko"}
+	}
+	if cfg.Prices.Historic.OutlierThreshold == 0 {
+		cfg.Prices.Historic.OutlierThreshold = 0.05
+	}
+
+	if cfg.Mempool.RetentionDays == 0 {
+		cfg.Mempool.RetentionDays = 7
+	}
+
+	if cfg.GasOracle.Blocks == 0 {
+		cfg.GasOracle.Blocks = 100
+	}
+
 	if cfg.Frontend.SiteTitle == "" {
 		cfg.Frontend.SiteTitle = "Open Source Ethereum Explorer"
 	}
@@ -758,6 +791,8 @@ func ReadConfig(cfg *types.Config, path string) error {
 			cfg.Chain.Id = 11155111
 		case "gnosis":
 			cfg.Chain.Id = 100
+		case "lukso":
+			cfg.Chain.Id = 42
 		}
 	}
 
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// registry methods are view functions taking the validator pubkey and returning whether it is registered, e.g. isRegistered(bytes)
+var validatorTagRegistryMethodRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*\(bytes\)$`)
+
+// graffiti patterns are evaluated by postgres (ARE) which does not support the named groups and flag groups of go regular expressions,
+// only non-capturing groups and a case insensitive flag at the start of the pattern are supported by both
+var validatorTagGraffitiUnsupportedRE = regexp.MustCompile(`\(\?[^:]`)
+
+// NormalizeValidatorTagRule validates the tag and pattern of a rule and brings the pattern into the form used during evaluation:
+// addresses and withdrawal credentials are lower case 0x prefixed hex, addresses used as withdrawal credentials are converted to 0x01 credentials,
+// graffiti patterns are regular expressions using the syntax supported by both go and postgres and registry patterns are a contract address and a method separated by a colon
+func NormalizeValidatorTagRule(rule *types.ValidatorTagRule) error {
+	rule.Tag = strings.TrimSpace(rule.Tag)
+	if rule.Tag == "" || len(rule.Tag) > 100 {
+		return fmt.Errorf("invalid tag, must be between 1 and 100 characters")
+	}
+	pattern := strings.TrimSpace(rule.Pattern)
+
+	switch rule.RuleType {
+	case types.ValidatorTagRuleDepositAddress, types.ValidatorTagRuleFeeRecipient:
+		if !IsValidEth1Address(pattern) {
+			return fmt.Errorf("invalid pattern, must be an address")
+		}
+		rule.Pattern = fmt.Sprintf("%#x", common.FromHex(pattern))
+	case types.ValidatorTagRuleWithdrawalCredentials:
+		if IsValidEth1Address(pattern) {
+			credentials, err := AddressToWithdrawalCredentials(common.FromHex(pattern))
+			if err != nil {
+				return err
+			}
+			rule.Pattern = fmt.Sprintf("%#x", credentials)
+		} else if IsValidWithdrawalCredentials(pattern) {
+			rule.Pattern = fmt.Sprintf("%#x", common.FromHex(pattern))
+		} else {
+			return fmt.Errorf("invalid pattern, must be withdrawal credentials or an address")
+		}
+	case types.ValidatorTagRuleGraffiti:
+		if pattern == "" {
+			return fmt.Errorf("invalid pattern, must be a regular expression")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern, must be a regular expression: %w", err)
+		}
+		if validatorTagGraffitiUnsupportedRE.MatchString(strings.TrimPrefix(pattern, "(?i)")) {
+			return fmt.Errorf("invalid pattern, named groups and flags (except a leading (?i)) are not supported")
+		}
+		rule.Pattern = pattern
+	case types.ValidatorTagRuleRegistry:
+		address, method, err := ParseValidatorTagRegistryPattern(pattern)
+		if err != nil {
+			return err
+		}
+		rule.Pattern = fmt.Sprintf("%#x:%s", address, method)
+	default:
+		return fmt.Errorf("invalid rule type %v", rule.RuleType)
+	}
+	return nil
+}
+
+// ParseValidatorTagRegistryPattern splits a registry pattern into the registry contract address and its method signature
+func ParseValidatorTagRegistryPattern(pattern string) ([]byte, string, error) {
+	address, method, found := strings.Cut(pattern, ":")
+	if !found || !IsValidEth1Address(address) {
+		return nil, "", fmt.Errorf("invalid pattern, must be a registry contract address followed by a colon and the method, e.g. 0x...:isRegistered(bytes)")
+	}
+	method = strings.ReplaceAll(method, " ", "")
+	if !validatorTagRegistryMethodRE.MatchString(method) {
+		return nil, "", fmt.Errorf("invalid registry method %v, must take the validator pubkey as only argument, e.g. isRegistered(bytes)", method)
+	}
+	return common.FromHex(address), method, nil
+}
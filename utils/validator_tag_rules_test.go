@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gobitfly/eth2-beaconchain-explorer/types"
+)
+
+func TestNormalizeValidatorTagRule(t *testing.T) {
+	tests := []struct {
+		ruleType string
+		pattern  string
+		want     string
+		wantErr  bool
+	}{
+		{types.ValidatorTagRuleDepositAddress, "0xAE7AB96520DE3A18E5E111B5EAAB095312D7FE84", "0xae7ab96520de3a18e5e111b5eaab095312d7fe84", false},
+		{types.ValidatorTagRuleDepositAddress, "not an address", "", true},
+		{types.ValidatorTagRuleFeeRecipient, " 388c818ca8b9251b393131c08a736a67ccb19297 ", "0x388c818ca8b9251b393131c08a736a67ccb19297", false},
+		{types.ValidatorTagRuleWithdrawalCredentials, "0xb9d7934878b5fb9610b3fe8a5e441e8fad7e293f", "0x010000000000000000000000b9d7934878b5fb9610b3fe8a5e441e8fad7e293f", false},
+		{types.ValidatorTagRuleWithdrawalCredentials, "0x00f50428677c60f997aadeab24aabf7fceaef491c96a52b463a6e3fcd3d2dd11", "0x00f50428677c60f997aadeab24aabf7fceaef491c96a52b463a6e3fcd3d2dd11", false},
+		{types.ValidatorTagRuleGraffiti, "^(Lido|lido)", "^(Lido|lido)", false},
+		{types.ValidatorTagRuleGraffiti, "(unclosed", "", true},
+		{types.ValidatorTagRuleGraffiti, "(?i)^lido", "(?i)^lido", false},
+		{types.ValidatorTagRuleGraffiti, "^(?:Lido|lido)", "^(?:Lido|lido)", false},
+		// accepted by go but not by postgres which evaluates the pattern
+		{types.ValidatorTagRuleGraffiti, "(?P<pool>lido)", "", true},
+		{types.ValidatorTagRuleGraffiti, "^lido(?i)node", "", true},
+		{types.ValidatorTagRuleGraffiti, "(?i:lido)", "", true},
+		{types.ValidatorTagRuleGraffiti, "(?U)lido.*", "", true},
+		{types.ValidatorTagRuleRegistry, "0xFFE9Ec0FF2E8D0ad1C8eF5a6C1e0E28D1A55b8DA:isRegistered (bytes)", "0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered(bytes)", false},
+		{types.ValidatorTagRuleRegistry, "0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered(uint256)", "", true},
+		{"unknown", "0xae7ab96520de3a18e5e111b5eaab095312d7fe84", "", true},
+	}
+
+	for _, tt := range tests {
+		rule := &types.ValidatorTagRule{Tag: " pool:test ", RuleType: tt.ruleType, Pattern: tt.pattern}
+		err := NormalizeValidatorTagRule(rule)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeValidatorTagRule(%v, %q) error = %v, wantErr %v", tt.ruleType, tt.pattern, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (rule.Pattern != tt.want || rule.Tag != "pool:test") {
+			t.Errorf("NormalizeValidatorTagRule(%v, %q) = %q, %q, want %q", tt.ruleType, tt.pattern, rule.Tag, rule.Pattern, tt.want)
+		}
+	}
+
+	if err := NormalizeValidatorTagRule(&types.ValidatorTagRule{Tag: "", RuleType: types.ValidatorTagRuleGraffiti, Pattern: "lido"}); err == nil {
+		t.Errorf("NormalizeValidatorTagRule() expected an error for an empty tag")
+	}
+}
+
+func TestParseValidatorTagRegistryPattern(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		wantMethod string
+		wantErr    bool
+	}{
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered(bytes)", "isRegistered(bytes)", false},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da: is_registered ( bytes ) ", "is_registered(bytes)", false},
+		{"ffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered(bytes)", "isRegistered(bytes)", false},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da", "", true},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:", "", true},
+		{":isRegistered(bytes)", "", true},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8:isRegistered(bytes)", "", true},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered", "", true},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered(bytes,uint256)", "", true},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:1isRegistered(bytes)", "", true},
+		{"0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da:isRegistered(bytes):extra", "", true},
+	}
+
+	for _, tt := range tests {
+		address, method, err := ParseValidatorTagRegistryPattern(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseValidatorTagRegistryPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if method != tt.wantMethod || fmt.Sprintf("%#x", address) != "0xffe9ec0ff2e8d0ad1c8ef5a6c1e0e28d1a55b8da" {
+			t.Errorf("ParseValidatorTagRegistryPattern(%q) = %#x, %q, want %q", tt.pattern, address, method, tt.wantMethod)
+		}
+	}
+}
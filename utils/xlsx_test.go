@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestXlsxColumnName(t *testing.T) {
+	tests := []struct {
+		col  int
+		name string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+	for _, tt := range tests {
+		if n := XlsxColumnName(tt.col); n != tt.name {
+			t.Errorf("wrong column name for column %v: got %v, expected %v", tt.col, n, tt.name)
+		}
+	}
+}
+
+func TestGenerateXlsx(t *testing.T) {
+	data, err := GenerateXlsx("Rewards", [][]string{{"Date", "Amount"}, {"2024-01-01", "0.0123"}, {"<tag>", "NaN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sheet, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{`<c r="B2"><v>0.0123</v></c>`, `&lt;tag&gt;`, `<c r="B3" t="inlineStr"><is><t>NaN</t></is></c>`} {
+			if !strings.Contains(string(sheet), expected) {
+				t.Errorf("sheet does not contain %v", expected)
+			}
+		}
+		return
+	}
+	t.Errorf("sheet1.xml not found in workbook")
+}